@@ -0,0 +1,255 @@
+package attestation
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/config"
+	"golang.org/x/exp/slices"
+	"os"
+	"time"
+)
+
+// Type is the attestation type as defined in https://www.w3.org/TR/webauthn/#sctn-attestation-types.
+type Type string
+
+const (
+	TypeNone   Type = "none"
+	TypeSelf   Type = "self"
+	TypeBasic  Type = "basic"
+	TypeAttCA  Type = "attca"
+	TypeAnonCA Type = "anonca"
+)
+
+// Trust is the result of the evaluation of the attestation certificate chain.
+type Trust string
+
+const (
+	// TrustNone means that the authenticator did not convey an attestation.
+	TrustNone Trust = "none"
+	// TrustSelf means that the attestation is signed with the credential key itself.
+	TrustSelf Trust = "self"
+	// TrustUntrusted means that the attestation certificate does not chain to one of the trust anchors.
+	TrustUntrusted Trust = "untrusted"
+	// TrustTrusted means that the attestation certificate chains to one of the trust anchors.
+	TrustTrusted Trust = "trusted"
+)
+
+// ErrNotAllowed is returned when an authenticator is rejected by the attestation policy.
+var ErrNotAllowed = errors.New("authenticator not allowed")
+
+// Result describes the attestation of a registered credential.
+type Result struct {
+	Format string
+	Type   Type
+	Trust  Trust
+	AAGUID uuid.UUID
+}
+
+// RootsProvider returns additional trust anchors for authenticators with the given AAGUID.
+type RootsProvider func(aaguid uuid.UUID) []*x509.Certificate
+
+// Verifier evaluates the trust of attestation statements, which have already been verified cryptographically by the
+// webauthn library, and applies the configured attestation policy.
+type Verifier struct {
+	cfg            config.WebauthnAttestation
+	allowedAAGUIDs []uuid.UUID
+	deniedAAGUIDs  []uuid.UUID
+	roots          *x509.CertPool
+	rootsProvider  RootsProvider
+}
+
+// NewVerifier loads the configured trust anchors. rootsProvider may be nil.
+func NewVerifier(cfg config.WebauthnAttestation, rootsProvider RootsProvider) (*Verifier, error) {
+	roots := x509.NewCertPool()
+	for _, path := range cfg.TrustAnchors {
+		certificates, err := loadCertificates(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, certificate := range certificates {
+			roots.AddCert(certificate)
+		}
+	}
+
+	allowedAAGUIDs, err := parseAAGUIDs(cfg.AllowedAAGUIDs)
+	if err != nil {
+		return nil, err
+	}
+	deniedAAGUIDs, err := parseAAGUIDs(cfg.DeniedAAGUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		cfg:            cfg,
+		allowedAAGUIDs: allowedAAGUIDs,
+		deniedAAGUIDs:  deniedAAGUIDs,
+		roots:          roots,
+		rootsProvider:  rootsProvider,
+	}, nil
+}
+
+// ConveyancePreference returns the configured attestation conveyance preference.
+func (v *Verifier) ConveyancePreference() protocol.ConveyancePreference {
+	if v.cfg.ConveyancePreference == "" {
+		return protocol.PreferNoAttestation
+	}
+	return protocol.ConveyancePreference(v.cfg.ConveyancePreference)
+}
+
+// Evaluate determines the type and the trust of the attestation.
+func (v *Verifier) Evaluate(attestationObject protocol.AttestationObject) *Result {
+	aaguid, _ := uuid.FromBytes(attestationObject.AuthData.AttData.AAGUID)
+	result := &Result{
+		Format: attestationObject.Format,
+		AAGUID: aaguid,
+	}
+
+	chain := parseChain(attestationObject.AttStatement)
+	switch attestationObject.Format {
+	case "none":
+		result.Type = TypeNone
+	case "packed":
+		if len(chain) == 0 {
+			result.Type = TypeSelf
+		} else {
+			result.Type = TypeBasic
+		}
+	case "tpm":
+		result.Type = TypeAttCA
+	case "apple":
+		result.Type = TypeAnonCA
+	default:
+		// fido-u2f, android-key and android-safetynet only support basic attestation. The certificates of
+		// android-safetynet attestations are part of the JWS response and are not evaluated here.
+		result.Type = TypeBasic
+	}
+
+	switch {
+	case result.Type == TypeNone:
+		result.Trust = TrustNone
+	case result.Type == TypeSelf:
+		result.Trust = TrustSelf
+	case v.verifyChain(chain, aaguid):
+		result.Trust = TrustTrusted
+	default:
+		result.Trust = TrustUntrusted
+	}
+
+	return result
+}
+
+// Check applies the attestation policy to the result. It returns an error wrapping ErrNotAllowed if the
+// authenticator must be rejected.
+func (v *Verifier) Check(result *Result) error {
+	if slices.Contains(v.deniedAAGUIDs, result.AAGUID) {
+		return fmt.Errorf("%w: aaguid %s is denied", ErrNotAllowed, result.AAGUID)
+	}
+	if len(v.allowedAAGUIDs) > 0 && !slices.Contains(v.allowedAAGUIDs, result.AAGUID) {
+		return fmt.Errorf("%w: aaguid %s is not allowed", ErrNotAllowed, result.AAGUID)
+	}
+	if len(v.cfg.AllowedTypes) > 0 && !slices.Contains(v.cfg.AllowedTypes, string(result.Type)) {
+		return fmt.Errorf("%w: attestation type %s is not allowed", ErrNotAllowed, result.Type)
+	}
+	if v.cfg.RequireTrusted && result.Trust != TrustTrusted {
+		return fmt.Errorf("%w: attestation is not trusted", ErrNotAllowed)
+	}
+	return nil
+}
+
+func (v *Verifier) verifyChain(chain []*x509.Certificate, aaguid uuid.UUID) bool {
+	if len(chain) == 0 {
+		return false
+	}
+
+	roots := v.roots
+	if v.rootsProvider != nil {
+		if additionalRoots := v.rootsProvider(aaguid); len(additionalRoots) > 0 {
+			roots = v.roots.Clone()
+			for _, root := range additionalRoots {
+				roots.AddCert(root)
+			}
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		// the attestation certificate must be valid at the time of the registration
+		CurrentTime: time.Now(),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// parseChain returns the certificates of the x5c entry of the attestation statement, attestation certificate first.
+func parseChain(attStatement map[string]interface{}) []*x509.Certificate {
+	x5c, ok := attStatement["x5c"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var chain []*x509.Certificate
+	for _, entry := range x5c {
+		raw, ok := entry.([]byte)
+		if !ok {
+			return nil
+		}
+		certificate, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil
+		}
+		chain = append(chain, certificate)
+	}
+	return chain
+}
+
+func parseAAGUIDs(values []string) ([]uuid.UUID, error) {
+	aaguids := make([]uuid.UUID, len(values))
+	for i, value := range values {
+		aaguid, err := uuid.FromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid aaguid: %w", value, err)
+		}
+		aaguids[i] = aaguid
+	}
+	return aaguids, nil
+}
+
+func loadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust anchor %s: %w", path, err)
+	}
+
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trust anchor %s: %w", path, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("trust anchor %s does not contain any certificates", path)
+	}
+	return certificates, nil
+}
@@ -0,0 +1,233 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testAAGUID = uuid.FromStringOrNil("ee882879-721c-4913-9775-3dfcce97072a")
+
+func TestVerifier_Evaluate(t *testing.T) {
+	root, rootKey := createCertificate(t, nil, nil, true)
+	leaf, _ := createCertificate(t, root, rootKey, false)
+	otherRoot, otherRootKey := createCertificate(t, nil, nil, true)
+	otherLeaf, _ := createCertificate(t, otherRoot, otherRootKey, false)
+
+	verifier, err := NewVerifier(config.WebauthnAttestation{TrustAnchors: []string{writePEM(t, root)}}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		format        string
+		attStatement  map[string]interface{}
+		expectedType  Type
+		expectedTrust Trust
+	}{
+		{
+			name:          "none",
+			format:        "none",
+			attStatement:  map[string]interface{}{},
+			expectedType:  TypeNone,
+			expectedTrust: TrustNone,
+		},
+		{
+			name:          "packed self attestation",
+			format:        "packed",
+			attStatement:  map[string]interface{}{"alg": int64(-7), "sig": []byte{}},
+			expectedType:  TypeSelf,
+			expectedTrust: TrustSelf,
+		},
+		{
+			name:          "packed basic attestation with trusted chain",
+			format:        "packed",
+			attStatement:  map[string]interface{}{"x5c": []interface{}{leaf.Raw}},
+			expectedType:  TypeBasic,
+			expectedTrust: TrustTrusted,
+		},
+		{
+			name:          "packed basic attestation with untrusted chain",
+			format:        "packed",
+			attStatement:  map[string]interface{}{"x5c": []interface{}{otherLeaf.Raw}},
+			expectedType:  TypeBasic,
+			expectedTrust: TrustUntrusted,
+		},
+		{
+			name:          "tpm",
+			format:        "tpm",
+			attStatement:  map[string]interface{}{"x5c": []interface{}{leaf.Raw}},
+			expectedType:  TypeAttCA,
+			expectedTrust: TrustTrusted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := verifier.Evaluate(attestationObject(tt.format, tt.attStatement))
+			assert.Equal(t, tt.format, result.Format)
+			assert.Equal(t, tt.expectedType, result.Type)
+			assert.Equal(t, tt.expectedTrust, result.Trust)
+			assert.Equal(t, testAAGUID, result.AAGUID)
+		})
+	}
+}
+
+func TestVerifier_EvaluateWithRootsProvider(t *testing.T) {
+	root, rootKey := createCertificate(t, nil, nil, true)
+	leaf, _ := createCertificate(t, root, rootKey, false)
+
+	verifier, err := NewVerifier(config.WebauthnAttestation{}, func(aaguid uuid.UUID) []*x509.Certificate {
+		if aaguid == testAAGUID {
+			return []*x509.Certificate{root}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	result := verifier.Evaluate(attestationObject("packed", map[string]interface{}{"x5c": []interface{}{leaf.Raw}}))
+	assert.Equal(t, TrustTrusted, result.Trust)
+}
+
+func TestVerifier_Check(t *testing.T) {
+	otherAAGUID := uuid.FromStringOrNil("08987058-cadc-4b81-b6e1-30de50dcbe96")
+
+	tests := []struct {
+		name    string
+		cfg     config.WebauthnAttestation
+		result  Result
+		wantErr bool
+	}{
+		{
+			name:   "no policy",
+			cfg:    config.WebauthnAttestation{},
+			result: Result{Type: TypeNone, Trust: TrustNone, AAGUID: testAAGUID},
+		},
+		{
+			name:    "denied aaguid",
+			cfg:     config.WebauthnAttestation{DeniedAAGUIDs: []string{testAAGUID.String()}},
+			result:  Result{Type: TypeBasic, Trust: TrustTrusted, AAGUID: testAAGUID},
+			wantErr: true,
+		},
+		{
+			name:   "allowed aaguid",
+			cfg:    config.WebauthnAttestation{AllowedAAGUIDs: []string{testAAGUID.String()}},
+			result: Result{Type: TypeBasic, Trust: TrustTrusted, AAGUID: testAAGUID},
+		},
+		{
+			name:    "aaguid not allowed",
+			cfg:     config.WebauthnAttestation{AllowedAAGUIDs: []string{otherAAGUID.String()}},
+			result:  Result{Type: TypeBasic, Trust: TrustTrusted, AAGUID: testAAGUID},
+			wantErr: true,
+		},
+		{
+			name:    "type not allowed",
+			cfg:     config.WebauthnAttestation{AllowedTypes: []string{"basic", "attca"}},
+			result:  Result{Type: TypeSelf, Trust: TrustSelf, AAGUID: testAAGUID},
+			wantErr: true,
+		},
+		{
+			name:   "type allowed",
+			cfg:    config.WebauthnAttestation{AllowedTypes: []string{"basic", "attca"}},
+			result: Result{Type: TypeAttCA, Trust: TrustUntrusted, AAGUID: testAAGUID},
+		},
+		{
+			name:    "untrusted",
+			cfg:     config.WebauthnAttestation{RequireTrusted: true},
+			result:  Result{Type: TypeBasic, Trust: TrustUntrusted, AAGUID: testAAGUID},
+			wantErr: true,
+		},
+		{
+			name:   "trusted",
+			cfg:    config.WebauthnAttestation{RequireTrusted: true},
+			result: Result{Type: TypeBasic, Trust: TrustTrusted, AAGUID: testAAGUID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewVerifier(tt.cfg, nil)
+			require.NoError(t, err)
+
+			err = verifier.Check(&tt.result)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrNotAllowed))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewVerifier_InvalidTrustAnchor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anchor.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0600))
+
+	_, err := NewVerifier(config.WebauthnAttestation{TrustAnchors: []string{path}}, nil)
+	assert.Error(t, err)
+}
+
+func attestationObject(format string, attStatement map[string]interface{}) protocol.AttestationObject {
+	return protocol.AttestationObject{
+		Format:       format,
+		AttStatement: attStatement,
+		AuthData: protocol.AuthenticatorData{
+			AttData: protocol.AttestedCredentialData{
+				AAGUID: testAAGUID.Bytes(),
+			},
+		},
+	}
+}
+
+// createCertificate creates a self-signed CA certificate if parent is nil, otherwise a certificate signed by parent.
+func createCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Hanko Test", Organization: []string{"Hanko"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return certificate, key
+}
+
+func writePEM(t *testing.T, certificate *x509.Certificate) string {
+	path := filepath.Join(t.TempDir(), "anchor.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
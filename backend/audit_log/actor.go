@@ -0,0 +1,28 @@
+package auditlog
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+const actorTypeContextKey = "audit_log_actor_type"
+
+// AdminActor is a middleware which marks all audit logs created while handling a request as performed by an admin.
+func AdminActor() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(actorTypeContextKey, models.AuditLogActorTypeAdmin)
+			return next(c)
+		}
+	}
+}
+
+func actorType(context echo.Context) models.AuditLogActorType {
+	if context == nil {
+		return models.AuditLogActorTypeSystem
+	}
+	if actorType, ok := context.Get(actorTypeContextKey).(models.AuditLogActorType); ok {
+		return actorType
+	}
+	return models.AuditLogActorTypeUser
+}
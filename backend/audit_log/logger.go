@@ -15,6 +15,8 @@ import (
 	"time"
 )
 
+// Logger creates audit logs. The context is nil for actions which are not triggered by a request, e.g. by
+// maintenance jobs. These are logged with the "system" actor type and without request metadata.
 type Logger interface {
 	Create(echo.Context, models.AuditLogType, *models.User, error) error
 	CreateWithConnection(*pop.Connection, echo.Context, models.AuditLogType, *models.User, error) error
@@ -72,14 +74,18 @@ func (l *logger) store(tx *pop.Connection, context echo.Context, auditLogType mo
 	}
 
 	al := models.AuditLog{
-		ID:                id,
-		Type:              auditLogType,
-		Error:             nil,
-		MetaHttpRequestId: context.Response().Header().Get(echo.HeaderXRequestID),
-		MetaUserAgent:     context.Request().UserAgent(),
-		MetaSourceIp:      context.RealIP(),
-		ActorUserId:       nil,
-		ActorEmail:        nil,
+		ID:          id,
+		Type:        auditLogType,
+		Error:       nil,
+		ActorUserId: nil,
+		ActorEmail:  nil,
+		ActorType:   actorType(context),
+	}
+
+	if context != nil {
+		al.MetaHttpRequestId = context.Response().Header().Get(echo.HeaderXRequestID)
+		al.MetaUserAgent = context.Request().UserAgent()
+		al.MetaSourceIp = context.RealIP()
 	}
 
 	if user != nil {
@@ -102,13 +108,18 @@ func (l *logger) logToConsole(context echo.Context, auditLogType models.AuditLog
 	loggerEvent := zeroLogger.Log().
 		Str("audience", "audit").
 		Str("type", string(auditLogType)).
+		Str("actor_type", string(actorType(context))).
 		AnErr("error", logError).
-		Str("http_request_id", context.Response().Header().Get(echo.HeaderXRequestID)).
-		Str("source_ip", context.RealIP()).
-		Str("user_agent", context.Request().UserAgent()).
 		Str("time", now.Format(time.RFC3339Nano)).
 		Str("time_unix", strconv.FormatInt(now.Unix(), 10))
 
+	if context != nil {
+		loggerEvent.
+			Str("http_request_id", context.Response().Header().Get(echo.HeaderXRequestID)).
+			Str("source_ip", context.RealIP()).
+			Str("user_agent", context.Request().UserAgent())
+	}
+
 	if user != nil {
 		loggerEvent.Str("user_id", user.ID.String())
 		if e := user.Emails.GetPrimary(); e != nil {
@@ -0,0 +1,50 @@
+package cleanup
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/maintenance"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"log"
+)
+
+func NewCleanupCommand() *cobra.Command {
+	var (
+		configFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Purge expired records",
+		Long: `Runs every maintenance job once, e.g. from a cron job when the jobs are not run by "hanko serve".
+Jobs which another instance has run within their interval are skipped.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			purged, err := maintenance.NewScheduler(cfg, persister).RunAll()
+			for job, count := range purged {
+				log.Printf("%s: purged %d rows", job, count)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+
+	return cmd
+}
+
+func RegisterCommands(parent *cobra.Command) {
+	cmd := NewCleanupCommand()
+	parent.AddCommand(cmd)
+}
@@ -7,7 +7,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/metadata"
+	"github.com/teamhanko/hanko/backend/organization"
 	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/rbac"
 	"github.com/teamhanko/hanko/backend/session"
 	"log"
 )
@@ -46,7 +49,11 @@ func NewCreateCommand() *cobra.Command {
 				return
 			}
 
-			sessionManager, err := session.NewManager(jwkManager, *cfg, persister.GetSessionPersister())
+			sessionManager, err := session.NewManager(jwkManager, *cfg, persister.GetSessionPersister(),
+				metadata.NewClaimsProvider(cfg.UserMetadata, persister),
+				rbac.NewClaimsProvider(cfg.Rbac, persister),
+				organization.NewClaimsProvider(persister),
+			)
 			if err != nil {
 				fmt.Printf("failed to create session generator: %s", err)
 				return
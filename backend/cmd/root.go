@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/cmd/cleanup"
 	"github.com/teamhanko/hanko/backend/cmd/isready"
 	"github.com/teamhanko/hanko/backend/cmd/jwk"
 	"github.com/teamhanko/hanko/backend/cmd/jwt"
@@ -29,6 +30,7 @@ func NewRootCmd() *cobra.Command {
 	version.RegisterCommands(cmd)
 	user.RegisterCommands(cmd)
 	siwa.RegisterCommands(cmd)
+	cleanup.RegisterCommands(cmd)
 
 	return cmd
 }
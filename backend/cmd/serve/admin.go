@@ -36,6 +36,8 @@ func NewServeAdminCommand() *cobra.Command {
 
 			go server.StartAdmin(cfg, &wg, persister, nil)
 
+			server.StartMaintenance(cfg, persister)
+
 			wg.Wait()
 		},
 	}
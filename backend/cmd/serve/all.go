@@ -40,6 +40,8 @@ func NewServeAllCommand() *cobra.Command {
 			go server.StartPublic(cfg, &wg, persister, prometheus)
 			go server.StartAdmin(cfg, &wg, persister, prometheus)
 
+			server.StartMaintenance(cfg, persister)
+
 			wg.Wait()
 		},
 	}
@@ -36,6 +36,8 @@ func NewServePublicCommand() *cobra.Command {
 
 			go server.StartPublic(cfg, &wg, persister, nil)
 
+			server.StartMaintenance(cfg, persister)
+
 			wg.Wait()
 		},
 	}
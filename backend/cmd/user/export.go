@@ -0,0 +1,133 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"log"
+	"os"
+)
+
+const exportPageSize = 1000
+
+func NewExportCommand() *cobra.Command {
+	var (
+		configFile            string
+		outputFile            string
+		includePasswordHashes bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export users from the database into a Json file",
+		Long:  `Export users in the format of the import command, so that they can be imported into another instance.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.Load(&configFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			persister, err := persistence.New(cfg.Database)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			entries, err := export(persister, includePasswordHashes)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			bytes, err := json.Marshal(entries)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = os.WriteFile(outputFile, bytes, 0600)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Println(fmt.Sprintf("Successfully exported %v users.", len(entries)))
+		},
+	}
+
+	cmd.Flags().StringVar(&configFile, "config", config.DefaultConfigFilePath, "config file")
+	cmd.Flags().StringVarP(&outputFile, "outputFile", "o", "", "The path of the output file.")
+	cmd.Flags().BoolVar(&includePasswordHashes, "includePasswordHashes", false, "Whether to export the password hashes of the users.")
+	err := cmd.MarkFlagRequired("outputFile")
+	if err != nil {
+		log.Println(err)
+	}
+	return cmd
+}
+
+// export reads all users page by page and converts them to ImportEntries.
+func export(persister persistence.Persister, includePasswordHashes bool) (ImportList, error) {
+	entries := ImportList{}
+	tx := persister.GetConnection()
+	for page := 1; ; page++ {
+		var users []models.User
+		err := tx.Q().
+			EagerPreload("Emails", "Emails.PrimaryEmail").
+			Order("created_at asc, id asc").
+			Paginate(page, exportPageSize).
+			All(&users)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get users: %w", err)
+		}
+
+		passwordHashes := make(map[uuid.UUID]string)
+		if includePasswordHashes && len(users) > 0 {
+			userIds := make([]interface{}, len(users))
+			for i, user := range users {
+				userIds[i] = user.ID
+			}
+			var credentials []models.PasswordCredential
+			err = tx.Where("user_id in (?)", userIds...).All(&credentials)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get password credentials: %w", err)
+			}
+			for _, credential := range credentials {
+				passwordHashes[credential.UserId] = credential.Password
+			}
+		}
+
+		for _, user := range users {
+			entries = append(entries, toImportEntry(user, passwordHashes[user.ID]))
+		}
+
+		if len(users) < exportPageSize {
+			return entries, nil
+		}
+	}
+}
+
+func toImportEntry(user models.User, passwordHash string) ImportEntry {
+	emails := make(Emails, len(user.Emails))
+	for i, email := range user.Emails {
+		emails[i] = ImportEmail{
+			Address:    email.Address,
+			IsPrimary:  email.IsPrimary(),
+			IsVerified: email.Verified,
+		}
+	}
+
+	entry := ImportEntry{
+		UserID:          user.ID.String(),
+		Emails:          emails,
+		CreatedAt:       &user.CreatedAt,
+		UpdatedAt:       &user.UpdatedAt,
+		PublicMetadata:  user.PublicMetadata,
+		PrivateMetadata: user.PrivateMetadata,
+		UnsafeMetadata:  user.UnsafeMetadata,
+		PasswordHash:    passwordHash,
+	}
+	if user.Username != nil {
+		entry.Username = *user.Username
+	}
+
+	return entry
+}
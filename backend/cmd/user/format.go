@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/crypto/password_hash"
+	"strings"
 	"time"
 )
 
@@ -24,20 +26,31 @@ type Emails []ImportEmail
 type ImportEntry struct {
 	// UserID optional uuid.v4. If not provided a new one will be generated for the user
 	UserID string `json:"user_id" yaml:"user_id"`
-	// Emails List of emails
+	// Emails List of emails. Can be empty if a Username is provided.
 	Emails Emails `json:"emails" yaml:"emails"`
+	// Username optional username. It is normalized to lower case and must fulfil the configured username rules.
+	Username string `json:"username,omitempty" yaml:"username"`
 	// CreatedAt optional timestamp of the users' creation. Will be set to the import date if not provided.
 	CreatedAt *time.Time `json:"created_at" yaml:"created_at"`
 	// UpdatedAt optional timestamp of the last update to the user. Will be set to the import date if not provided.
 	UpdatedAt *time.Time `json:"updated_at" yaml:"updated_at"`
+	// PublicMetadata optional metadata readable by the user.
+	PublicMetadata map[string]interface{} `json:"public_metadata,omitempty" yaml:"public_metadata"`
+	// PrivateMetadata optional metadata only readable by admins.
+	PrivateMetadata map[string]interface{} `json:"private_metadata,omitempty" yaml:"private_metadata"`
+	// UnsafeMetadata optional metadata readable and writable by the user.
+	UnsafeMetadata map[string]interface{} `json:"unsafe_metadata,omitempty" yaml:"unsafe_metadata"`
+	// PasswordHash optional hash of the users' password. Supported are bcrypt hashes, PHC strings of argon2id,
+	// argon2i, scrypt, firebase-scrypt and pbkdf2, and PBKDF2 hashes in the format of Django.
+	PasswordHash string `json:"password_hash,omitempty" yaml:"password_hash"`
 }
 
 // ImportList a list of ImportEntries
 type ImportList []ImportEntry
 
 func (entry *ImportEntry) validate() error {
-	if len(entry.Emails) == 0 {
-		return errors.New(fmt.Sprintf("Entry with id: %v has got no Emails.", entry.UserID))
+	if len(entry.Emails) == 0 && strings.TrimSpace(entry.Username) == "" {
+		return errors.New(fmt.Sprintf("Entry with id: %v has got neither Emails nor a Username.", entry.UserID))
 	}
 	primaryMails := 0
 	for _, email := range entry.Emails {
@@ -47,7 +60,7 @@ func (entry *ImportEntry) validate() error {
 		}
 	}
 
-	if primaryMails != 1 {
+	if len(entry.Emails) > 0 && primaryMails != 1 {
 		return errors.New(fmt.Sprintf("Need exactly one primary email, got %v", primaryMails))
 	}
 	if entry.UserID != "" {
@@ -56,5 +69,11 @@ func (entry *ImportEntry) validate() error {
 			return errors.New(fmt.Sprintf("Provided uuid is not valid: %v", entry.UserID))
 		}
 	}
+	if entry.PasswordHash != "" {
+		_, err := password_hash.Normalize(entry.PasswordHash)
+		if err != nil {
+			return fmt.Errorf("Provided password hash is not valid: %w", err)
+		}
+	}
 	return nil
 }
@@ -12,10 +12,12 @@ const invalidUUID = "notvalid"
 
 func TestImportEntry_validate(t *testing.T) {
 	type fields struct {
-		UserID    string
-		Emails    Emails
-		CreatedAt *time.Time
-		UpdatedAt *time.Time
+		UserID       string
+		Emails       Emails
+		CreatedAt    *time.Time
+		UpdatedAt    *time.Time
+		Username     string
+		PasswordHash string
 	}
 	tests := []struct {
 		name    string
@@ -80,6 +82,13 @@ func TestImportEntry_validate(t *testing.T) {
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "User with username and no email must validate",
+			fields: fields{
+				Username: "jane.doe",
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name: "User with no primary email must not validate",
 			fields: fields{
@@ -117,14 +126,42 @@ func TestImportEntry_validate(t *testing.T) {
 			},
 			wantErr: assert.Error,
 		},
+		{
+			name: "User with django password hash must validate",
+			fields: fields{
+				Emails: Emails{
+					ImportEmail{
+						Address:   "primary@hanko.io",
+						IsPrimary: true,
+					},
+				},
+				PasswordHash: "pbkdf2_sha256$600000$seasalt$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI=",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "User with unsupported password hash must not validate",
+			fields: fields{
+				Emails: Emails{
+					ImportEmail{
+						Address:   "primary@hanko.io",
+						IsPrimary: true,
+					},
+				},
+				PasswordHash: "5f4dcc3b5aa765d61d8327deb882cf99",
+			},
+			wantErr: assert.Error,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			entry := &ImportEntry{
-				UserID:    tt.fields.UserID,
-				Emails:    tt.fields.Emails,
-				CreatedAt: tt.fields.CreatedAt,
-				UpdatedAt: tt.fields.UpdatedAt,
+				UserID:       tt.fields.UserID,
+				Emails:       tt.fields.Emails,
+				Username:     tt.fields.Username,
+				CreatedAt:    tt.fields.CreatedAt,
+				UpdatedAt:    tt.fields.UpdatedAt,
+				PasswordHash: tt.fields.PasswordHash,
 			}
 			tt.wantErr(t, entry.validate(), fmt.Sprintf("validate()"))
 		})
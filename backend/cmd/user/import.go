@@ -8,8 +8,11 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/spf13/cobra"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/password_hash"
+	"github.com/teamhanko/hanko/backend/metadata"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/username"
 	"io"
 	"log"
 	"net/http"
@@ -81,6 +84,16 @@ func NewImportCommand() *cobra.Command {
 			if err != nil {
 				log.Fatal(err)
 			}
+			if cfg.Username.Enabled {
+				err = validateUsernames(users, username.NewPolicy(cfg.Username))
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			err = validateMetadata(users, cfg.UserMetadata)
+			if err != nil {
+				log.Fatal(err)
+			}
 			//Import Users
 			persister, err := persistence.New(cfg.Database)
 			if err != nil {
@@ -151,6 +164,49 @@ func loadAndValidate(input io.Reader) ([]ImportEntry, error) {
 	return users, nil
 }
 
+// validateUsernames checks the usernames of all entries against the username rules and prints every violation.
+func validateUsernames(entries []ImportEntry, policy *username.Policy) error {
+	numErrors := 0
+	for i, entry := range entries {
+		if entry.Username == "" {
+			continue
+		}
+		violations := policy.Check(username.Normalize(entry.Username))
+		if len(violations) > 0 {
+			log.Println(fmt.Sprintf("Error at entry %v : %v", i+1, policy.Describe(violations[0])))
+			numErrors++
+		}
+	}
+
+	if numErrors > 0 {
+		return errors.New(fmt.Sprintf("Found %v invalid usernames.", numErrors))
+	}
+
+	return nil
+}
+
+// validateMetadata checks the size of the metadata of all entries and prints every violation.
+func validateMetadata(entries []ImportEntry, cfg config.UserMetadata) error {
+	numErrors := 0
+	for i, entry := range entries {
+		u := models.User{
+			PublicMetadata:  entry.PublicMetadata,
+			PrivateMetadata: entry.PrivateMetadata,
+			UnsafeMetadata:  entry.UnsafeMetadata,
+		}
+		if err := metadata.Validate(&u, cfg); err != nil {
+			log.Println(fmt.Sprintf("Error at entry %v : %v", i+1, err))
+			numErrors++
+		}
+	}
+
+	if numErrors > 0 {
+		return errors.New(fmt.Sprintf("Found %v entries with invalid metadata.", numErrors))
+	}
+
+	return nil
+}
+
 // commits the list of ImportEntries to the database. Wrapped in a transaction so if something fails no new users are added.
 func addToDatabase(entries []ImportEntry, persister persistence.Persister) error {
 	tx := persister.GetConnection()
@@ -177,9 +233,16 @@ func addToDatabase(entries []ImportEntry, persister persistence.Persister) error
 			}
 
 			u := models.User{
-				ID:        userId,
-				CreatedAt: createdAt,
-				UpdatedAt: updatedAt,
+				ID:              userId,
+				PublicMetadata:  v.PublicMetadata,
+				PrivateMetadata: v.PrivateMetadata,
+				UnsafeMetadata:  v.UnsafeMetadata,
+				CreatedAt:       createdAt,
+				UpdatedAt:       updatedAt,
+			}
+			if v.Username != "" {
+				name := username.Normalize(v.Username)
+				u.Username = &name
 			}
 
 			err := tx.Create(&u)
@@ -214,6 +277,25 @@ func addToDatabase(entries []ImportEntry, persister persistence.Persister) error
 					}
 				}
 			}
+
+			if v.PasswordHash != "" {
+				passwordHash, err := password_hash.Normalize(v.PasswordHash)
+				if err != nil {
+					return fmt.Errorf("Failed to import password hash for user %v : %w", userId.String(), err)
+				}
+				passwordId, _ := uuid.NewV4()
+				password := models.PasswordCredential{
+					ID:        passwordId,
+					UserId:    userId,
+					Password:  passwordHash,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				err = tx.Create(&password)
+				if err != nil {
+					return fmt.Errorf("Failed to create password for user %v : %w", userId.String(), err)
+				}
+			}
 		}
 		return nil
 	})
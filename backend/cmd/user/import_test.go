@@ -212,7 +212,7 @@ func (s *importSuite) Test_addToDatabase() {
 
 			s.SetupTest()
 			tt.wantErr(t, addToDatabase(tt.args.entries, tt.args.persister), fmt.Sprintf("addToDatabase(%v, %v)", tt.args.entries, tt.args.persister))
-			users, err := tt.args.persister.GetUserPersister().List(0, 100, uuid.Nil, "", "")
+			users, err := tt.args.persister.GetUserPersister().List(0, 100, uuid.Nil, "", nil, "", "")
 			log.Println(users)
 			s.NoError(err)
 			s.Equal(tt.wantNumUsers, len(users))
@@ -221,3 +221,60 @@ func (s *importSuite) Test_addToDatabase() {
 		})
 	}
 }
+
+func (s *importSuite) Test_export() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	entries := []ImportEntry{
+		{
+			UserID: validUUID,
+			Emails: Emails{
+				ImportEmail{
+					Address:    "primary@hanko.io",
+					IsPrimary:  true,
+					IsVerified: true,
+				},
+			},
+			PublicMetadata:  map[string]interface{}{"plan": "pro"},
+			PrivateMetadata: map[string]interface{}{"stripe_id": "cus_123"},
+			PasswordHash:    "$2a$12$Cf7k.dG6pznTUJ5u2u1pgu6I4VXH5.9O0NZsDk8TwWwyBkZovYVli",
+		},
+		{
+			UserID:   validUUID2,
+			Username: "jane.doe",
+		},
+	}
+
+	s.SetupTest()
+	defer s.TearDownTest()
+	s.Require().NoError(addToDatabase(entries, s.Storage))
+
+	exported, err := export(s.Storage, false)
+	s.Require().NoError(err)
+	s.Require().Len(exported, 2)
+
+	for _, entry := range exported {
+		switch entry.UserID {
+		case validUUID:
+			s.Equal(entries[0].Emails, entry.Emails)
+			s.Equal(entries[0].PublicMetadata, entry.PublicMetadata)
+			s.Equal(entries[0].PrivateMetadata, entry.PrivateMetadata)
+			s.Empty(entry.PasswordHash)
+		case validUUID2:
+			s.Equal("jane.doe", entry.Username)
+			s.Empty(entry.Emails)
+		default:
+			s.Failf("unexpected user", "user id: %s", entry.UserID)
+		}
+	}
+
+	exported, err = export(s.Storage, true)
+	s.Require().NoError(err)
+	for _, entry := range exported {
+		if entry.UserID == validUUID {
+			s.Equal(entries[0].PasswordHash, entry.PasswordHash)
+		}
+	}
+}
@@ -7,8 +7,8 @@ import (
 func NewUserCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "user",
-		Short: "User import/export(TODO) tools",
-		Long:  `Add the ability to import users into the hanko database.`,
+		Short: "User import/export tools",
+		Long:  `Add the ability to import users into and export users from the hanko database.`,
 	}
 }
 
@@ -16,5 +16,6 @@ func RegisterCommands(parent *cobra.Command) {
 	command := NewUserCommand()
 	parent.AddCommand(command)
 	command.AddCommand(NewImportCommand())
+	command.AddCommand(NewExportCommand())
 	command.AddCommand(NewGenerateCommand())
 }
@@ -5,32 +5,39 @@ import (
 	"fmt"
 	"github.com/fatih/structs"
 	"github.com/gobwas/glob"
+	"github.com/gofrs/uuid"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/file"
 	"golang.org/x/exp/slices"
 	"log"
+	"net/url"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Config is the central configuration type
 type Config struct {
-	Server      Server           `yaml:"server" json:"server,omitempty" koanf:"server"`
-	Webauthn    WebauthnSettings `yaml:"webauthn" json:"webauthn,omitempty" koanf:"webauthn"`
-	Passcode    Passcode         `yaml:"passcode" json:"passcode" koanf:"passcode"`
-	Password    Password         `yaml:"password" json:"password,omitempty" koanf:"password"`
-	Database    Database         `yaml:"database" json:"database" koanf:"database"`
-	Secrets     Secrets          `yaml:"secrets" json:"secrets" koanf:"secrets"`
-	Service     Service          `yaml:"service" json:"service" koanf:"service"`
-	Session     Session          `yaml:"session" json:"session,omitempty" koanf:"session"`
-	AuditLog    AuditLog         `yaml:"audit_log" json:"audit_log,omitempty" koanf:"audit_log" split_words:"true"`
-	Emails      Emails           `yaml:"emails" json:"emails,omitempty" koanf:"emails"`
-	RateLimiter RateLimiter      `yaml:"rate_limiter" json:"rate_limiter,omitempty" koanf:"rate_limiter" split_words:"true"`
-	ThirdParty  ThirdParty       `yaml:"third_party" json:"third_party,omitempty" koanf:"third_party" split_words:"true"`
-	Log         LoggerConfig     `yaml:"log" json:"log,omitempty" koanf:"log"`
-	Account     Account          `yaml:"account" json:"account,omitempty" koanf:"account"`
+	Server       Server           `yaml:"server" json:"server,omitempty" koanf:"server"`
+	Webauthn     WebauthnSettings `yaml:"webauthn" json:"webauthn,omitempty" koanf:"webauthn"`
+	Passcode     Passcode         `yaml:"passcode" json:"passcode" koanf:"passcode"`
+	Password     Password         `yaml:"password" json:"password,omitempty" koanf:"password"`
+	Database     Database         `yaml:"database" json:"database" koanf:"database"`
+	Secrets      Secrets          `yaml:"secrets" json:"secrets" koanf:"secrets"`
+	Service      Service          `yaml:"service" json:"service" koanf:"service"`
+	Session      Session          `yaml:"session" json:"session,omitempty" koanf:"session"`
+	AuditLog     AuditLog         `yaml:"audit_log" json:"audit_log,omitempty" koanf:"audit_log" split_words:"true"`
+	Emails       Emails           `yaml:"emails" json:"emails,omitempty" koanf:"emails"`
+	RateLimiter  RateLimiter      `yaml:"rate_limiter" json:"rate_limiter,omitempty" koanf:"rate_limiter" split_words:"true"`
+	ThirdParty   ThirdParty       `yaml:"third_party" json:"third_party,omitempty" koanf:"third_party" split_words:"true"`
+	Log          LoggerConfig     `yaml:"log" json:"log,omitempty" koanf:"log"`
+	Account      Account          `yaml:"account" json:"account,omitempty" koanf:"account"`
+	Maintenance  Maintenance      `yaml:"maintenance" json:"maintenance,omitempty" koanf:"maintenance"`
+	Username     Username         `yaml:"username" json:"username,omitempty" koanf:"username"`
+	UserMetadata UserMetadata     `yaml:"user_metadata" json:"user_metadata,omitempty" koanf:"user_metadata" split_words:"true"`
+	Rbac         Rbac             `yaml:"rbac" json:"rbac,omitempty" koanf:"rbac"`
 }
 
 var (
@@ -89,6 +96,15 @@ func DefaultConfig() *Config {
 			},
 			UserVerification: "preferred",
 			Timeout:          60000,
+			Attestation: WebauthnAttestation{
+				ConveyancePreference: "none",
+			},
+			MetadataService: WebauthnMetadataService{
+				ReloadInterval: 24 * time.Hour,
+			},
+			CloneDetection: WebauthnCloneDetection{
+				Mode: CloneDetectionModeLog,
+			},
 		},
 		Passcode: Passcode{
 			Smtp: SMTP{
@@ -99,9 +115,36 @@ func DefaultConfig() *Config {
 				FromAddress: "passcode@hanko.io",
 				FromName:    "Hanko",
 			},
+			Policy: PasscodePolicy{
+				Length:               6,
+				Alphabet:             PasscodeAlphabetNumeric,
+				MaxAttempts:          3,
+				ResendCooldown:       30 * time.Second,
+				MaxFailedAttempts:    10,
+				FailedAttemptsWindow: 1 * time.Hour,
+			},
 		},
 		Password: Password{
 			MinPasswordLength: 8,
+			Hashing: PasswordHashing{
+				Algorithm: PasswordHashAlgorithmBcrypt,
+				Argon2id: Argon2idParameters{
+					Memory:      64 * 1024,
+					Iterations:  3,
+					Parallelism: 4,
+					SaltLength:  16,
+					KeyLength:   32,
+				},
+				Bcrypt: BcryptParameters{
+					Cost: 12,
+				},
+			},
+			Policy: PasswordPolicy{
+				BreachCheck: PasswordBreachCheck{
+					Timeout:        5 * time.Second,
+					MinOccurrences: 1,
+				},
+			},
 		},
 		Database: Database{
 			Database: "hanko",
@@ -123,6 +166,9 @@ func DefaultConfig() *Config {
 		Emails: Emails{
 			RequireVerification: true,
 			MaxNumOfAddresses:   5,
+			Change: EmailChange{
+				RevertLinkTTL: 72 * time.Hour,
+			},
 		},
 		RateLimiter: RateLimiter{
 			Enabled: true,
@@ -143,6 +189,72 @@ func DefaultConfig() *Config {
 		Account: Account{
 			AllowDeletion: false,
 			AllowSignup:   true,
+			Lockout: AccountLockout{
+				MaxFailedAttempts: 10,
+				Window:            15 * time.Minute,
+				Duration:          5 * time.Minute,
+				MaxDuration:       24 * time.Hour,
+				UnlockLinkTTL:     time.Hour,
+			},
+			SoftDelete: AccountSoftDelete{
+				GracePeriod: 30 * 24 * time.Hour,
+			},
+			Invitations: AccountInvitations{
+				Lifespan: 7 * 24 * time.Hour,
+			},
+		},
+		Maintenance: Maintenance{
+			WebauthnSessionData: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: time.Hour,
+			},
+			Passcodes: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: time.Hour,
+			},
+			Tokens: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: time.Hour,
+			},
+			Sessions: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: 7 * 24 * time.Hour,
+			},
+			UnassignedEmails: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: 24 * time.Hour,
+			},
+			DeletedUsers: MaintenanceJob{
+				Interval: time.Hour,
+			},
+			FailedPasscodeAttempts: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: time.Hour,
+			},
+			UserLockouts: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: time.Hour,
+			},
+			Invitations: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: 7 * 24 * time.Hour,
+			},
+			EmailChanges: MaintenanceJob{
+				Interval:  time.Hour,
+				Retention: time.Hour,
+			},
+		},
+		ThirdParty: ThirdParty{
+			ProfileRefresh: ThirdPartyProfileRefreshMissing,
+		},
+		Username: Username{
+			MinLength:         3,
+			MaxLength:         32,
+			AllowedCharacters: "_-.",
+		},
+		UserMetadata: UserMetadata{
+			MaxSize:       16384,
+			UnsafeMaxSize: 2048,
 		},
 	}
 }
@@ -176,6 +288,10 @@ func (c *Config) Validate() error {
 	if err != nil {
 		return fmt.Errorf("failed to validate session settings: %w", err)
 	}
+	err = c.Password.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate password settings: %w", err)
+	}
 	err = c.RateLimiter.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate rate-limiter settings: %w", err)
@@ -184,6 +300,30 @@ func (c *Config) Validate() error {
 	if err != nil {
 		return fmt.Errorf("failed to validate third_party settings: %w", err)
 	}
+	err = c.Account.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate account settings: %w", err)
+	}
+	err = c.Emails.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate email settings: %w", err)
+	}
+	err = c.Maintenance.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate maintenance settings: %w", err)
+	}
+	err = c.Username.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate username settings: %w", err)
+	}
+	err = c.UserMetadata.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate user_metadata settings: %w", err)
+	}
+	err = c.Rbac.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate rbac settings: %w", err)
+	}
 	return nil
 }
 
@@ -219,6 +359,174 @@ func (s *Service) Validate() error {
 type Password struct {
 	Enabled           bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
 	MinPasswordLength int  `yaml:"min_password_length" json:"min_password_length,omitempty" koanf:"min_password_length" split_words:"true" jsonschema:"default=8"`
+	// RevokeSessionsOnReset invalidates all refresh sessions of a user when their password is reset.
+	RevokeSessionsOnReset bool            `yaml:"revoke_sessions_on_reset" json:"revoke_sessions_on_reset,omitempty" koanf:"revoke_sessions_on_reset" split_words:"true" jsonschema:"default=false"`
+	Policy                PasswordPolicy  `yaml:"policy" json:"policy,omitempty" koanf:"policy"`
+	Hashing               PasswordHashing `yaml:"hashing" json:"hashing,omitempty" koanf:"hashing"`
+}
+
+func (p *Password) Validate() error {
+	if p.Policy.MaxPasswordLength > 0 && p.Policy.MaxPasswordLength < p.MinPasswordLength {
+		return fmt.Errorf("policy.max_password_length must not be less than min_password_length (%d)", p.MinPasswordLength)
+	}
+	err := p.Policy.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate policy settings: %w", err)
+	}
+	err = p.Hashing.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate hashing settings: %w", err)
+	}
+	return nil
+}
+
+type PasswordHashAlgorithm string
+
+const (
+	PasswordHashAlgorithmArgon2id PasswordHashAlgorithm = "argon2id"
+	PasswordHashAlgorithmBcrypt   PasswordHashAlgorithm = "bcrypt"
+)
+
+// PasswordHashing configures how new passwords are hashed. Existing hashes of any supported algorithm can still be
+// verified and are replaced with a hash of the configured algorithm on the next successful login.
+type PasswordHashing struct {
+	Algorithm PasswordHashAlgorithm `yaml:"algorithm" json:"algorithm,omitempty" koanf:"algorithm" jsonschema:"default=bcrypt,enum=argon2id,enum=bcrypt"`
+	Argon2id  Argon2idParameters    `yaml:"argon2id" json:"argon2id,omitempty" koanf:"argon2id"`
+	Bcrypt    BcryptParameters      `yaml:"bcrypt" json:"bcrypt,omitempty" koanf:"bcrypt"`
+	// FirebaseScrypt contains the project-wide parameters needed to verify imported Firebase password hashes.
+	FirebaseScrypt FirebaseScryptParameters `yaml:"firebase_scrypt" json:"firebase_scrypt,omitempty" koanf:"firebase_scrypt" split_words:"true"`
+}
+
+func (h *PasswordHashing) Validate() error {
+	switch h.Algorithm {
+	case PasswordHashAlgorithmArgon2id:
+		if h.Argon2id.Memory < 8*uint32(h.Argon2id.Parallelism) {
+			return errors.New("argon2id.memory must be at least 8 KiB per thread")
+		}
+		if h.Argon2id.Iterations < 1 {
+			return errors.New("argon2id.iterations must be at least 1")
+		}
+		if h.Argon2id.Parallelism < 1 {
+			return errors.New("argon2id.parallelism must be at least 1")
+		}
+		if h.Argon2id.SaltLength < 8 {
+			return errors.New("argon2id.salt_length must be at least 8")
+		}
+		if h.Argon2id.KeyLength < 16 {
+			return errors.New("argon2id.key_length must be at least 16")
+		}
+	case PasswordHashAlgorithmBcrypt:
+		if h.Bcrypt.Cost < 10 || h.Bcrypt.Cost > 31 {
+			return fmt.Errorf("bcrypt.cost must be between 10 and 31, got: %d", h.Bcrypt.Cost)
+		}
+	default:
+		return fmt.Errorf("expected algorithm to be one of [%s, %s], got: '%s'", PasswordHashAlgorithmArgon2id, PasswordHashAlgorithmBcrypt, h.Algorithm)
+	}
+	return nil
+}
+
+type Argon2idParameters struct {
+	// Memory is the amount of memory in KiB.
+	Memory      uint32 `yaml:"memory" json:"memory,omitempty" koanf:"memory" jsonschema:"default=65536"`
+	Iterations  uint32 `yaml:"iterations" json:"iterations,omitempty" koanf:"iterations" jsonschema:"default=3"`
+	Parallelism uint8  `yaml:"parallelism" json:"parallelism,omitempty" koanf:"parallelism" jsonschema:"default=4"`
+	SaltLength  uint32 `yaml:"salt_length" json:"salt_length,omitempty" koanf:"salt_length" split_words:"true" jsonschema:"default=16"`
+	KeyLength   uint32 `yaml:"key_length" json:"key_length,omitempty" koanf:"key_length" split_words:"true" jsonschema:"default=32"`
+}
+
+type BcryptParameters struct {
+	Cost int `yaml:"cost" json:"cost,omitempty" koanf:"cost" jsonschema:"default=12"`
+}
+
+type FirebaseScryptParameters struct {
+	// SignerKey is the base64 encoded "base64_signer_key" of the Firebase project.
+	SignerKey string `yaml:"signer_key" json:"signer_key,omitempty" koanf:"signer_key" split_words:"true"`
+	// SaltSeparator is the base64 encoded "base64_salt_separator" of the Firebase project.
+	SaltSeparator string `yaml:"salt_separator" json:"salt_separator,omitempty" koanf:"salt_separator" split_words:"true"`
+}
+
+// PasswordPolicy contains the requirements a new password must fulfil in addition to the minimum length.
+type PasswordPolicy struct {
+	// MaxPasswordLength is the maximum number of characters of a password. Regardless of this setting, passwords
+	// must not be longer than 72 bytes. Set to 0 to only apply the byte limit.
+	MaxPasswordLength int `yaml:"max_password_length" json:"max_password_length,omitempty" koanf:"max_password_length" split_words:"true" jsonschema:"default=0"`
+	// RequireLowercase requires at least one lower case letter.
+	RequireLowercase bool `yaml:"require_lowercase" json:"require_lowercase,omitempty" koanf:"require_lowercase" split_words:"true" jsonschema:"default=false"`
+	// RequireUppercase requires at least one upper case letter.
+	RequireUppercase bool `yaml:"require_uppercase" json:"require_uppercase,omitempty" koanf:"require_uppercase" split_words:"true" jsonschema:"default=false"`
+	// RequireDigit requires at least one digit.
+	RequireDigit bool `yaml:"require_digit" json:"require_digit,omitempty" koanf:"require_digit" split_words:"true" jsonschema:"default=false"`
+	// RequireSymbol requires at least one character that is neither a letter nor a digit.
+	RequireSymbol bool `yaml:"require_symbol" json:"require_symbol,omitempty" koanf:"require_symbol" split_words:"true" jsonschema:"default=false"`
+	// DisallowEmailLocalPart rejects passwords that contain the local part of one of the user's email addresses.
+	DisallowEmailLocalPart bool `yaml:"disallow_email_local_part" json:"disallow_email_local_part,omitempty" koanf:"disallow_email_local_part" split_words:"true" jsonschema:"default=false"`
+	// HistorySize is the number of most recent passwords that must not be reused. Set to 0 to allow reuse.
+	HistorySize int `yaml:"history_size" json:"history_size,omitempty" koanf:"history_size" split_words:"true" jsonschema:"default=0"`
+	// BreachCheck rejects passwords that appear in a breached password corpus.
+	BreachCheck PasswordBreachCheck `yaml:"breach_check" json:"breach_check,omitempty" koanf:"breach_check" split_words:"true"`
+}
+
+func (p *PasswordPolicy) Validate() error {
+	if p.MaxPasswordLength < 0 {
+		return errors.New("max_password_length must not be negative")
+	}
+	if p.HistorySize < 0 {
+		return errors.New("history_size must not be negative")
+	}
+	err := p.BreachCheck.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate breach_check settings: %w", err)
+	}
+	return nil
+}
+
+type PasswordBreachCheckSource string
+
+const (
+	// PasswordBreachCheckSourceRangeFile looks up hashes in a directory of k-anonymity range files in the format
+	// of the Have I Been Pwned downloader, i.e. one file named "<PREFIX>.txt" per five character SHA-1 prefix.
+	PasswordBreachCheckSourceRangeFile PasswordBreachCheckSource = "range_file"
+	// PasswordBreachCheckSourceHTTP queries an endpoint that implements the Have I Been Pwned range API.
+	PasswordBreachCheckSourceHTTP PasswordBreachCheckSource = "http"
+)
+
+type PasswordBreachCheck struct {
+	Enabled bool                      `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	Source  PasswordBreachCheckSource `yaml:"source" json:"source,omitempty" koanf:"source" jsonschema:"enum=range_file,enum=http"`
+	// RangeDirectory is the directory containing the range files. Required for the "range_file" source.
+	RangeDirectory string `yaml:"range_directory" json:"range_directory,omitempty" koanf:"range_directory" split_words:"true"`
+	// Endpoint is the base URL of the range API. The hash prefix is appended to it, e.g.
+	// "https://api.pwnedpasswords.com/range/". Required for the "http" source.
+	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty" koanf:"endpoint"`
+	// Timeout limits the duration of a request to the range API.
+	Timeout time.Duration `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"type=string,default=5s"`
+	// MinOccurrences is the number of times a password must appear in the corpus to be rejected.
+	MinOccurrences int `yaml:"min_occurrences" json:"min_occurrences,omitempty" koanf:"min_occurrences" split_words:"true" jsonschema:"default=1"`
+}
+
+func (b *PasswordBreachCheck) Validate() error {
+	if !b.Enabled {
+		return nil
+	}
+	switch b.Source {
+	case PasswordBreachCheckSourceRangeFile:
+		if len(strings.TrimSpace(b.RangeDirectory)) == 0 {
+			return errors.New("range_directory must not be empty when using the range_file source")
+		}
+	case PasswordBreachCheckSourceHTTP:
+		if _, err := url.ParseRequestURI(b.Endpoint); err != nil {
+			return fmt.Errorf("endpoint must be a valid url: %w", err)
+		}
+		if b.Timeout <= 0 {
+			return errors.New("timeout must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("expected source to be one of [%s, %s], got: '%s'", PasswordBreachCheckSourceRangeFile, PasswordBreachCheckSourceHTTP, b.Source)
+	}
+	if b.MinOccurrences < 1 {
+		return errors.New("min_occurrences must be at least 1")
+	}
+	return nil
 }
 
 type Cookie struct {
@@ -284,9 +592,22 @@ func (s *ServerSettings) Validate() error {
 
 // WebauthnSettings defines the settings for the webauthn authentication mechanism
 type WebauthnSettings struct {
-	RelyingParty     RelyingParty `yaml:"relying_party" json:"relying_party,omitempty" koanf:"relying_party" split_words:"true"`
-	Timeout          int          `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"default=60000"`
-	UserVerification string       `yaml:"user_verification" json:"user_verification,omitempty" koanf:"user_verification" split_words:"true" jsonschema:"default=preferred,enum=required,enum=preferred,enum=discouraged"`
+	RelyingParty     RelyingParty            `yaml:"relying_party" json:"relying_party,omitempty" koanf:"relying_party" split_words:"true"`
+	Timeout          int                     `yaml:"timeout" json:"timeout,omitempty" koanf:"timeout" jsonschema:"default=60000"`
+	UserVerification string                  `yaml:"user_verification" json:"user_verification,omitempty" koanf:"user_verification" split_words:"true" jsonschema:"default=preferred,enum=required,enum=preferred,enum=discouraged"`
+	Attestation      WebauthnAttestation     `yaml:"attestation" json:"attestation,omitempty" koanf:"attestation"`
+	MetadataService  WebauthnMetadataService `yaml:"metadata_service" json:"metadata_service,omitempty" koanf:"metadata_service" split_words:"true"`
+	CloneDetection   WebauthnCloneDetection  `yaml:"clone_detection" json:"clone_detection,omitempty" koanf:"clone_detection" split_words:"true"`
+	Prf              WebauthnPrf             `yaml:"prf" json:"prf,omitempty" koanf:"prf"`
+	// AdditionalRelyingParties allow to use passkeys on several registrable domains. For every request the first
+	// relying party (starting with RelyingParty) is used whose origins or related origins contain the origin of the
+	// request. RelyingParty is used if none matches.
+	AdditionalRelyingParties []RelyingParty `yaml:"additional_relying_parties" json:"additional_relying_parties,omitempty" koanf:"additional_relying_parties" split_words:"true"`
+}
+
+// RelyingParties returns RelyingParty followed by the AdditionalRelyingParties.
+func (r *WebauthnSettings) RelyingParties() []RelyingParty {
+	return append([]RelyingParty{r.RelyingParty}, r.AdditionalRelyingParties...)
 }
 
 // Validate does not need to validate the config, because the library does this already
@@ -295,6 +616,127 @@ func (r *WebauthnSettings) Validate() error {
 	if !slices.Contains(validUv, r.UserVerification) {
 		return fmt.Errorf("expected user_verification to be one of [%s], got: '%s'", strings.Join(validUv, ", "), r.UserVerification)
 	}
+	ids := make(map[string]bool)
+	for _, relyingParty := range r.RelyingParties() {
+		err := relyingParty.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate relying party settings: %w", err)
+		}
+		if ids[relyingParty.Id] {
+			return fmt.Errorf("relying party %s is configured more than once", relyingParty.Id)
+		}
+		ids[relyingParty.Id] = true
+	}
+	err := r.Attestation.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate attestation settings: %w", err)
+	}
+	err = r.MetadataService.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate metadata service settings: %w", err)
+	}
+	err = r.CloneDetection.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate clone detection settings: %w", err)
+	}
+	return nil
+}
+
+type CloneDetectionMode string
+
+const (
+	// CloneDetectionModeLog only marks the credential as suspected clone and creates an audit log.
+	CloneDetectionModeLog CloneDetectionMode = "log"
+	// CloneDetectionModeReject additionally rejects the assertion.
+	CloneDetectionModeReject CloneDetectionMode = "reject"
+	// CloneDetectionModeRejectAndDisable additionally disables the credential, so it can not be used anymore.
+	CloneDetectionModeRejectAndDisable CloneDetectionMode = "reject_and_disable"
+)
+
+// WebauthnPrf configures the PRF extension, which allows the frontend to derive secrets, e.g. encryption keys, from
+// credentials. The server manages a salt per user and returns it as evaluation input in the creation and assertion
+// options. The evaluation results are never sent to the server.
+type WebauthnPrf struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+}
+
+// WebauthnCloneDetection configures how assertions are handled whose signature counter did not increase compared to
+// the previous assertion, which indicates that the authenticator may have been cloned.
+type WebauthnCloneDetection struct {
+	Mode CloneDetectionMode `yaml:"mode" json:"mode,omitempty" koanf:"mode" jsonschema:"default=log,enum=log,enum=reject,enum=reject_and_disable"`
+}
+
+func (d *WebauthnCloneDetection) Validate() error {
+	switch d.Mode {
+	case CloneDetectionModeLog, CloneDetectionModeReject, CloneDetectionModeRejectAndDisable:
+		return nil
+	default:
+		return fmt.Errorf("expected mode to be one of [%s, %s, %s], got: '%s'", CloneDetectionModeLog, CloneDetectionModeReject, CloneDetectionModeRejectAndDisable, d.Mode)
+	}
+}
+
+// WebauthnAttestation configures which attestation is requested from authenticators on registration and which
+// authenticators are accepted. Note that browsers remove the AAGUID when no attestation is conveyed, so AAGUID based
+// rules should be combined with RequireTrusted.
+type WebauthnAttestation struct {
+	ConveyancePreference string `yaml:"conveyance_preference" json:"conveyance_preference,omitempty" koanf:"conveyance_preference" split_words:"true" jsonschema:"default=none,enum=none,enum=indirect,enum=direct,enum=enterprise"`
+	// AllowedAAGUIDs accepts only authenticators with one of the AAGUIDs if not empty.
+	AllowedAAGUIDs []string `yaml:"allowed_aaguids" json:"allowed_aaguids,omitempty" koanf:"allowed_aaguids" split_words:"true"`
+	// DeniedAAGUIDs rejects authenticators with one of the AAGUIDs.
+	DeniedAAGUIDs []string `yaml:"denied_aaguids" json:"denied_aaguids,omitempty" koanf:"denied_aaguids" split_words:"true"`
+	// AllowedTypes accepts only attestations of one of the types if not empty.
+	AllowedTypes []string `yaml:"allowed_types" json:"allowed_types,omitempty" koanf:"allowed_types" split_words:"true" jsonschema:"enum=none,enum=self,enum=basic,enum=attca,enum=anonca"`
+	// RequireTrusted accepts only attestations with a certificate chain leading to one of the trust anchors.
+	RequireTrusted bool `yaml:"require_trusted" json:"require_trusted,omitempty" koanf:"require_trusted" split_words:"true" jsonschema:"default=false"`
+	// TrustAnchors are paths to PEM files containing the root certificates of trusted authenticator vendors.
+	TrustAnchors []string `yaml:"trust_anchors" json:"trust_anchors,omitempty" koanf:"trust_anchors" split_words:"true"`
+}
+
+// WebauthnMetadataService configures the use of a locally stored BLOB of the FIDO Metadata Service (MDS3). The BLOB must
+// be downloaded from https://mds3.fidoalliance.org periodically by other means, e.g. a cron job.
+type WebauthnMetadataService struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// BlobPath is the path to the MDS3 BLOB (a JWS in compact serialization).
+	BlobPath string `yaml:"blob_path" json:"blob_path,omitempty" koanf:"blob_path" split_words:"true"`
+	// RootCertificatePath is the path to a PEM file containing the root certificate the BLOB signing certificate must
+	// chain to. Defaults to the FIDO Alliance MDS3 root certificate.
+	RootCertificatePath string `yaml:"root_certificate_path" json:"root_certificate_path,omitempty" koanf:"root_certificate_path" split_words:"true"`
+	// ReloadInterval determines how often the BLOB is read from BlobPath again.
+	ReloadInterval time.Duration `yaml:"reload_interval" json:"reload_interval,omitempty" koanf:"reload_interval" split_words:"true" jsonschema:"type=string,default=24h"`
+}
+
+func (m *WebauthnMetadataService) Validate() error {
+	if !m.Enabled {
+		return nil
+	}
+	if len(strings.TrimSpace(m.BlobPath)) == 0 {
+		return errors.New("blob_path must be set")
+	}
+	if m.ReloadInterval <= 0 {
+		return errors.New("reload_interval must be greater than 0")
+	}
+	return nil
+}
+
+func (a *WebauthnAttestation) Validate() error {
+	validPreferences := []string{"none", "indirect", "direct", "enterprise"}
+	if !slices.Contains(validPreferences, a.ConveyancePreference) {
+		return fmt.Errorf("expected conveyance_preference to be one of [%s], got: '%s'", strings.Join(validPreferences, ", "), a.ConveyancePreference)
+	}
+	validTypes := []string{"none", "self", "basic", "attca", "anonca"}
+	for _, attestationType := range a.AllowedTypes {
+		if !slices.Contains(validTypes, attestationType) {
+			return fmt.Errorf("expected allowed_types to contain only [%s], got: '%s'", strings.Join(validTypes, ", "), attestationType)
+		}
+	}
+	for _, aaguid := range append(append([]string{}, a.AllowedAAGUIDs...), a.DeniedAAGUIDs...) {
+		if _, err := uuid.FromString(aaguid); err != nil {
+			return fmt.Errorf("'%s' is not a valid aaguid: %w", aaguid, err)
+		}
+	}
+	if a.RequireTrusted && a.ConveyancePreference == "none" {
+		return errors.New("require_trusted needs a conveyance_preference other than none")
+	}
 	return nil
 }
 
@@ -304,6 +746,31 @@ type RelyingParty struct {
 	DisplayName string   `yaml:"display_name" json:"display_name,omitempty" koanf:"display_name" split_words:"true" jsonschema:"default=Hanko Authentication Service"`
 	Icon        string   `yaml:"icon" json:"icon,omitempty" koanf:"icon"`
 	Origins     []string `yaml:"origins" json:"origins,omitempty" koanf:"origins" jsonschema:"minItems=1,default=http://localhost:8888"`
+	// RelatedOrigins are origins on other registrable domains which may use the RP ID, see
+	// https://w3c.github.io/webauthn/#sctn-related-origins. They are served at /.well-known/webauthn and accepted in
+	// addition to Origins.
+	RelatedOrigins []string `yaml:"related_origins" json:"related_origins,omitempty" koanf:"related_origins" split_words:"true"`
+}
+
+// AllOrigins returns the origins and the related origins of the relying party.
+func (r *RelyingParty) AllOrigins() []string {
+	return append(append([]string{}, r.Origins...), r.RelatedOrigins...)
+}
+
+func (r *RelyingParty) Validate() error {
+	if len(strings.TrimSpace(r.Id)) == 0 {
+		return errors.New("id must not be empty")
+	}
+	if len(r.Origins) == 0 {
+		return fmt.Errorf("origins of relying party %s must not be empty", r.Id)
+	}
+	for _, origin := range r.RelatedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("related origin '%s' of relying party %s must be an origin, e.g. https://example.com", origin, r.Id)
+		}
+	}
+	return nil
 }
 
 // SMTP Server Settings for sending passcodes
@@ -337,11 +804,12 @@ func (e *Email) Validate() error {
 }
 
 type Passcode struct {
-	Email          Email  `yaml:"email" json:"email,omitempty" koanf:"email"`
-	Smtp           SMTP   `yaml:"smtp" json:"smtp" koanf:"smtp"`
-	TTL            int    `yaml:"ttl" json:"ttl,omitempty" koanf:"ttl" jsonschema:"default=300"`
-	ExclusionEmail string `yaml:"exclusion_email" json:"exclusion_email,omitempty" koanf:"exclusion_email"`
-	ExclusionCode  string `yaml:"exclusion_code" json:"exclusion_code,omitempty" koanf:"exclusion_code"`
+	Email          Email          `yaml:"email" json:"email,omitempty" koanf:"email"`
+	Smtp           SMTP           `yaml:"smtp" json:"smtp" koanf:"smtp"`
+	TTL            int            `yaml:"ttl" json:"ttl,omitempty" koanf:"ttl" jsonschema:"default=300"`
+	ExclusionEmail string         `yaml:"exclusion_email" json:"exclusion_email,omitempty" koanf:"exclusion_email"`
+	ExclusionCode  string         `yaml:"exclusion_code" json:"exclusion_code,omitempty" koanf:"exclusion_code"`
+	Policy         PasscodePolicy `yaml:"policy" json:"policy,omitempty" koanf:"policy"`
 }
 
 func (p *Passcode) Validate() error {
@@ -353,6 +821,63 @@ func (p *Passcode) Validate() error {
 	if err != nil {
 		return fmt.Errorf("failed to validate smtp settings: %w", err)
 	}
+	err = p.Policy.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate policy settings: %w", err)
+	}
+	return nil
+}
+
+type PasscodeAlphabet string
+
+const (
+	PasscodeAlphabetNumeric      PasscodeAlphabet = "numeric"
+	PasscodeAlphabetAlphanumeric PasscodeAlphabet = "alphanumeric"
+)
+
+// PasscodePolicy controls how passcodes are generated and how often they can be tried and requested.
+type PasscodePolicy struct {
+	// Length is the number of characters of a generated passcode.
+	Length int `yaml:"length" json:"length,omitempty" koanf:"length" jsonschema:"default=6,minimum=4,maximum=16"`
+	// Alphabet determines the characters a passcode consists of. "alphanumeric" passcodes consist of upper case
+	// letters and digits, excluding the easily confused characters 0, 1, I and O. Entered passcodes are matched
+	// case-insensitively.
+	Alphabet PasscodeAlphabet `yaml:"alphabet" json:"alphabet,omitempty" koanf:"alphabet" jsonschema:"default=numeric,enum=numeric,enum=alphanumeric"`
+	// MaxAttempts is the number of times a single passcode can be tried before it is invalidated.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts,omitempty" koanf:"max_attempts" split_words:"true" jsonschema:"default=3"`
+	// ResendCooldown is the minimum duration between two passcode requests for the same email address. Requesting a
+	// new passcode invalidates all passcodes previously sent to the same email address. Set to 0 to disable the
+	// cooldown.
+	ResendCooldown time.Duration `yaml:"resend_cooldown" json:"resend_cooldown,omitempty" koanf:"resend_cooldown" split_words:"true" jsonschema:"type=string,default=30s"`
+	// MaxFailedAttempts is the number of failed attempts a user can make across all of their passcodes within the
+	// FailedAttemptsWindow. When the budget is used up, no new passcodes are sent and no passcodes are accepted for
+	// the user until the window has passed. Set to 0 to disable the budget.
+	MaxFailedAttempts int `yaml:"max_failed_attempts" json:"max_failed_attempts,omitempty" koanf:"max_failed_attempts" split_words:"true" jsonschema:"default=10"`
+	// FailedAttemptsWindow is the duration for which failed attempts count against the MaxFailedAttempts budget.
+	FailedAttemptsWindow time.Duration `yaml:"failed_attempts_window" json:"failed_attempts_window,omitempty" koanf:"failed_attempts_window" split_words:"true" jsonschema:"type=string,default=1h"`
+}
+
+func (p *PasscodePolicy) Validate() error {
+	if p.Length < 4 || p.Length > 16 {
+		return fmt.Errorf("length must be between 4 and 16, got: %d", p.Length)
+	}
+	switch p.Alphabet {
+	case PasscodeAlphabetNumeric, PasscodeAlphabetAlphanumeric:
+	default:
+		return fmt.Errorf("expected alphabet to be one of [%s, %s], got: '%s'", PasscodeAlphabetNumeric, PasscodeAlphabetAlphanumeric, p.Alphabet)
+	}
+	if p.MaxAttempts < 1 {
+		return errors.New("max_attempts must be at least 1")
+	}
+	if p.ResendCooldown < 0 {
+		return errors.New("resend_cooldown must not be negative")
+	}
+	if p.MaxFailedAttempts < 0 {
+		return errors.New("max_failed_attempts must not be negative")
+	}
+	if p.MaxFailedAttempts > 0 && p.FailedAttemptsWindow <= 0 {
+		return errors.New("failed_attempts_window must be set when max_failed_attempts is enabled")
+	}
 	return nil
 }
 
@@ -452,6 +977,84 @@ type AuditLogConsole struct {
 type Emails struct {
 	RequireVerification bool `yaml:"require_verification" json:"require_verification,omitempty" koanf:"require_verification" split_words:"true" jsonschema:"default=true"`
 	MaxNumOfAddresses   int  `yaml:"max_num_of_addresses" json:"max_num_of_addresses,omitempty" koanf:"max_num_of_addresses" split_words:"true" jsonschema:"default=5"`
+	// Domains restricts the domains of email addresses used for signups and added by users.
+	Domains EmailDomains `yaml:"domains" json:"domains,omitempty" koanf:"domains"`
+	// Change configures the flow which replaces the primary email address of a user with a verified new one.
+	Change EmailChange `yaml:"change" json:"change,omitempty" koanf:"change"`
+}
+
+func (e *Emails) Validate() error {
+	err := e.Change.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate change settings: %w", err)
+	}
+	return nil
+}
+
+// EmailChange configures the email change flow. After the primary email address has been changed, a notification with
+// a link to RevertUrl is sent to the old address. The link allows to revert the change within RevertLinkTTL.
+type EmailChange struct {
+	Enabled       bool          `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	RevertUrl     string        `yaml:"revert_url" json:"revert_url,omitempty" koanf:"revert_url" split_words:"true"`
+	RevertLinkTTL time.Duration `yaml:"revert_link_ttl" json:"revert_link_ttl,omitempty" koanf:"revert_link_ttl" split_words:"true" jsonschema:"type=string,default=72h"`
+}
+
+func (e *EmailChange) Validate() error {
+	if !e.Enabled {
+		return nil
+	}
+	if _, err := url.ParseRequestURI(e.RevertUrl); err != nil {
+		return fmt.Errorf("revert_url is not a valid url: %w", err)
+	}
+	if e.RevertLinkTTL <= 0 {
+		return errors.New("revert_link_ttl must be greater than 0")
+	}
+	return nil
+}
+
+// EmailDomains restricts email addresses by their domain. Entries of Allowlist and Denylist are globs, e.g.
+// "example.com", "*.example.com" (one subdomain level) or "**.example.com" (any number of subdomain levels). An address
+// is rejected if its domain matches the Denylist, does not match a non-empty Allowlist or, with BlockDisposable, is a
+// disposable email domain.
+type EmailDomains struct {
+	Allowlist       []string `yaml:"allowlist" json:"allowlist,omitempty" koanf:"allowlist"`
+	Denylist        []string `yaml:"denylist" json:"denylist,omitempty" koanf:"denylist"`
+	BlockDisposable bool     `yaml:"block_disposable" json:"block_disposable,omitempty" koanf:"block_disposable" split_words:"true" jsonschema:"default=false"`
+	// DisposableListFile is the path of a file which replaces the bundled list of disposable email domains. The file
+	// contains one domain per line, empty lines and lines starting with "#" are ignored.
+	DisposableListFile string `yaml:"disposable_list_file" json:"disposable_list_file,omitempty" koanf:"disposable_list_file" split_words:"true"`
+	// ApplyToAdmin applies the restrictions to users and invitations created via the admin API as well.
+	ApplyToAdmin   bool        `yaml:"apply_to_admin" json:"apply_to_admin,omitempty" koanf:"apply_to_admin" split_words:"true" jsonschema:"default=false"`
+	AllowlistGlobs []glob.Glob `jsonschema:"-"`
+	DenylistGlobs  []glob.Glob `jsonschema:"-"`
+}
+
+func (d *EmailDomains) PostProcess() error {
+	var err error
+	d.AllowlistGlobs, err = compileDomainGlobs(d.Allowlist)
+	if err != nil {
+		return fmt.Errorf("failed to compile allowlist: %w", err)
+	}
+
+	d.DenylistGlobs, err = compileDomainGlobs(d.Denylist)
+	if err != nil {
+		return fmt.Errorf("failed to compile denylist: %w", err)
+	}
+
+	return nil
+}
+
+func compileDomainGlobs(domains []string) ([]glob.Glob, error) {
+	globs := make([]glob.Glob, 0, len(domains))
+	for _, domain := range domains {
+		g, err := glob.Compile(strings.ToLower(strings.TrimSpace(domain)), '.')
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile email domain glob '%s': %w", domain, err)
+		}
+		globs = append(globs, g)
+	}
+
+	return globs, nil
 }
 
 type OutputStream string
@@ -513,8 +1116,22 @@ type ThirdParty struct {
 	ErrorRedirectURL      string               `yaml:"error_redirect_url" json:"error_redirect_url,omitempty" koanf:"error_redirect_url" split_words:"true"`
 	AllowedRedirectURLS   []string             `yaml:"allowed_redirect_urls" json:"allowed_redirect_urls,omitempty" koanf:"allowed_redirect_urls" split_words:"true"`
 	AllowedRedirectURLMap map[string]glob.Glob `jsonschema:"-"`
+	// ProfileRefresh determines how the profile of a user (display name, given and family name, avatar URL and
+	// locale) is updated with the data of the provider on sign in. The profile is always filled on sign up.
+	ProfileRefresh ThirdPartyProfileRefresh `yaml:"profile_refresh" json:"profile_refresh,omitempty" koanf:"profile_refresh" split_words:"true" jsonschema:"default=missing,enum=never,enum=missing,enum=always"`
 }
 
+type ThirdPartyProfileRefresh string
+
+const (
+	// ThirdPartyProfileRefreshNever keeps the profile as it is.
+	ThirdPartyProfileRefreshNever ThirdPartyProfileRefresh = "never"
+	// ThirdPartyProfileRefreshMissing only sets profile fields without a value.
+	ThirdPartyProfileRefreshMissing ThirdPartyProfileRefresh = "missing"
+	// ThirdPartyProfileRefreshAlways overwrites all profile fields the provider has a value for.
+	ThirdPartyProfileRefreshAlways ThirdPartyProfileRefresh = "always"
+)
+
 func (t *ThirdParty) Validate() error {
 	if t.Providers.HasEnabled() {
 		if t.RedirectURL == "" {
@@ -537,6 +1154,12 @@ func (t *ThirdParty) Validate() error {
 		}
 	}
 
+	switch t.ProfileRefresh {
+	case ThirdPartyProfileRefreshNever, ThirdPartyProfileRefreshMissing, ThirdPartyProfileRefreshAlways:
+	default:
+		return fmt.Errorf("profile_refresh must be one of 'never', 'missing' or 'always', got '%s'", t.ProfileRefresh)
+	}
+
 	err := t.Providers.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate third party providers: %w", err)
@@ -625,6 +1248,11 @@ func (c *Config) PostProcess() error {
 		return fmt.Errorf("failed to post process third party settings: %w", err)
 	}
 
+	err = c.Emails.Domains.PostProcess()
+	if err != nil {
+		return fmt.Errorf("failed to post process email domain settings: %w", err)
+	}
+
 	return nil
 
 }
@@ -636,5 +1264,232 @@ type LoggerConfig struct {
 type Account struct {
 	// Allow Deletion indicates if a user can perform self-service deletion
 	AllowDeletion bool `yaml:"allow_deletion" json:"allow_deletion,omitempty" koanf:"allow_deletion" jsonschema:"default=false"`
-	AllowSignup   bool `yaml:"allow_signup" json:"allow_signup,omitempty" koanf:"allow_signup" jsonschema:"default=true"`
+	// Allow Signup indicates if a user can sign up with service
+	AllowSignup bool `yaml:"allow_signup" json:"allow_signup,omitempty" koanf:"allow_signup" jsonschema:"default=true"`
+	// AllowPasskeySignup indicates if a user can sign up with a passkey only. An email address can be added later.
+	// Requires AllowSignup.
+	AllowPasskeySignup bool           `yaml:"allow_passkey_signup" json:"allow_passkey_signup,omitempty" koanf:"allow_passkey_signup" split_words:"true" jsonschema:"default=false"`
+	Lockout            AccountLockout `yaml:"lockout" json:"lockout,omitempty" koanf:"lockout"`
+	// SoftDelete keeps deleted users for a grace period, in which they can be restored via the admin API.
+	SoftDelete AccountSoftDelete `yaml:"soft_delete" json:"soft_delete,omitempty" koanf:"soft_delete" split_words:"true"`
+	// Invitations lets admins invite users by email. Invited users can sign up even when AllowSignup is false.
+	Invitations AccountInvitations `yaml:"invitations" json:"invitations,omitempty" koanf:"invitations"`
+}
+
+func (a *Account) Validate() error {
+	err := a.Lockout.Validate()
+	if err != nil {
+		return err
+	}
+	err = a.SoftDelete.Validate()
+	if err != nil {
+		return err
+	}
+	return a.Invitations.Validate()
+}
+
+// AccountInvitations configures invitations created via the admin API. The invitation token is appended to AcceptUrl
+// as "invitation_token" query parameter and must be sent to the accept endpoint.
+type AccountInvitations struct {
+	Enabled   bool          `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	AcceptUrl string        `yaml:"accept_url" json:"accept_url,omitempty" koanf:"accept_url" split_words:"true"`
+	Lifespan  time.Duration `yaml:"lifespan" json:"lifespan,omitempty" koanf:"lifespan" jsonschema:"type=string,default=168h"`
+}
+
+func (i *AccountInvitations) Validate() error {
+	if !i.Enabled {
+		return nil
+	}
+	if _, err := url.ParseRequestURI(i.AcceptUrl); err != nil {
+		return fmt.Errorf("accept_url is not a valid url: %w", err)
+	}
+	if i.Lifespan <= 0 {
+		return errors.New("lifespan must be greater than 0")
+	}
+	return nil
+}
+
+// AccountSoftDelete configures the soft deletion of users. A soft deleted user cannot log in and their email addresses
+// stay reserved until the user is purged by the "deleted_users" maintenance job after the grace period.
+type AccountSoftDelete struct {
+	Enabled     bool          `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	GracePeriod time.Duration `yaml:"grace_period" json:"grace_period,omitempty" koanf:"grace_period" split_words:"true" jsonschema:"type=string,default=720h"`
+}
+
+func (s *AccountSoftDelete) Validate() error {
+	if s.Enabled && s.GracePeriod <= 0 {
+		return errors.New("grace_period must be greater than 0")
+	}
+	return nil
+}
+
+// AccountLockout configures the temporary lockout of accounts after repeated failed password, passcode and WebAuthn
+// login attempts. Every consecutive lockout doubles the lockout duration up to MaxDuration.
+type AccountLockout struct {
+	Enabled           bool          `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	MaxFailedAttempts int           `yaml:"max_failed_attempts" json:"max_failed_attempts,omitempty" koanf:"max_failed_attempts" split_words:"true" jsonschema:"default=10"`
+	Window            time.Duration `yaml:"window" json:"window,omitempty" koanf:"window" jsonschema:"type=string,default=15m"`
+	Duration          time.Duration `yaml:"duration" json:"duration,omitempty" koanf:"duration" jsonschema:"type=string,default=5m"`
+	MaxDuration       time.Duration `yaml:"max_duration" json:"max_duration,omitempty" koanf:"max_duration" split_words:"true" jsonschema:"type=string,default=24h"`
+	// UnlockLinkUrl is the URL sent to the primary email address of a locked user. The unlock token is appended as
+	// "unlock_token" query parameter and must be sent to the unlock endpoint. No email is sent when it is empty.
+	UnlockLinkUrl string        `yaml:"unlock_link_url" json:"unlock_link_url,omitempty" koanf:"unlock_link_url" split_words:"true"`
+	UnlockLinkTTL time.Duration `yaml:"unlock_link_ttl" json:"unlock_link_ttl,omitempty" koanf:"unlock_link_ttl" split_words:"true" jsonschema:"type=string,default=1h"`
+}
+
+func (l *AccountLockout) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.MaxFailedAttempts < 1 {
+		return errors.New("max_failed_attempts must be at least 1")
+	}
+	if l.Window <= 0 {
+		return errors.New("window must be greater than 0")
+	}
+	if l.Duration <= 0 {
+		return errors.New("duration must be greater than 0")
+	}
+	if l.MaxDuration < l.Duration {
+		return errors.New("max_duration must not be less than duration")
+	}
+	if l.UnlockLinkUrl != "" {
+		if _, err := url.ParseRequestURI(l.UnlockLinkUrl); err != nil {
+			return fmt.Errorf("unlock_link_url is not a valid url: %w", err)
+		}
+		if l.UnlockLinkTTL <= 0 {
+			return errors.New("unlock_link_ttl must be greater than 0")
+		}
+	}
+	return nil
+}
+
+// Maintenance configures the jobs which purge expired records. The jobs run periodically as part of "hanko serve" if
+// enabled, or once with "hanko cleanup". Only one instance runs a job per interval, even with several replicas.
+type Maintenance struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	// WebauthnSessionData purges the session data of webauthn ceremonies which expired more than Retention ago.
+	WebauthnSessionData MaintenanceJob `yaml:"webauthn_session_data" json:"webauthn_session_data,omitempty" koanf:"webauthn_session_data" split_words:"true"`
+	// Passcodes purges passcodes which expired more than Retention ago.
+	Passcodes MaintenanceJob `yaml:"passcodes" json:"passcodes,omitempty" koanf:"passcodes"`
+	// Tokens purges tokens which expired more than Retention ago.
+	Tokens MaintenanceJob `yaml:"tokens" json:"tokens,omitempty" koanf:"tokens"`
+	// Sessions purges refresh sessions which have been used more than Retention ago. Used sessions are kept for a
+	// while to detect the reuse of refresh tokens.
+	Sessions MaintenanceJob `yaml:"sessions" json:"sessions,omitempty" koanf:"sessions"`
+	// UnassignedEmails purges email addresses which have not been assigned to a user within Retention after their
+	// creation, e.g. because the user never verified them.
+	UnassignedEmails MaintenanceJob `yaml:"unassigned_emails" json:"unassigned_emails,omitempty" koanf:"unassigned_emails" split_words:"true"`
+	// DeletedUsers purges soft deleted users whose grace period (see AccountSoftDelete) ended more than Retention ago.
+	DeletedUsers MaintenanceJob `yaml:"deleted_users" json:"deleted_users,omitempty" koanf:"deleted_users" split_words:"true"`
+	// FailedPasscodeAttempts purges failed passcode attempts which left the FailedAttemptsWindow of the passcode policy
+	// more than Retention ago.
+	FailedPasscodeAttempts MaintenanceJob `yaml:"failed_passcode_attempts" json:"failed_passcode_attempts,omitempty" koanf:"failed_passcode_attempts" split_words:"true"`
+	// UserLockouts purges account lockouts which no longer affect future lockouts (see AccountLockout) since more than
+	// Retention.
+	UserLockouts MaintenanceJob `yaml:"user_lockouts" json:"user_lockouts,omitempty" koanf:"user_lockouts" split_words:"true"`
+	// Invitations purges invitations which have not been accepted and expired more than Retention ago. Revoked
+	// invitations are deleted immediately.
+	Invitations MaintenanceJob `yaml:"invitations" json:"invitations,omitempty" koanf:"invitations"`
+	// EmailChanges purges email changes whose revert link expired more than Retention ago.
+	EmailChanges MaintenanceJob `yaml:"email_changes" json:"email_changes,omitempty" koanf:"email_changes" split_words:"true"`
+}
+
+func (m *Maintenance) Validate() error {
+	jobs := map[string]MaintenanceJob{
+		"webauthn_session_data":    m.WebauthnSessionData,
+		"passcodes":                m.Passcodes,
+		"tokens":                   m.Tokens,
+		"sessions":                 m.Sessions,
+		"unassigned_emails":        m.UnassignedEmails,
+		"deleted_users":            m.DeletedUsers,
+		"failed_passcode_attempts": m.FailedPasscodeAttempts,
+		"user_lockouts":            m.UserLockouts,
+		"invitations":              m.Invitations,
+		"email_changes":            m.EmailChanges,
+	}
+	for name, job := range jobs {
+		if job.Interval <= 0 {
+			return fmt.Errorf("interval of %s must be greater than 0", name)
+		}
+		if job.Retention < 0 {
+			return fmt.Errorf("retention of %s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+type MaintenanceJob struct {
+	Interval  time.Duration `yaml:"interval" json:"interval,omitempty" koanf:"interval" jsonschema:"type=string,default=1h"`
+	Retention time.Duration `yaml:"retention" json:"retention,omitempty" koanf:"retention" jsonschema:"type=string"`
+}
+
+// Username configures usernames, which can be used instead of an email address to identify a user on login.
+// Usernames are unique and normalized to lower case.
+type Username struct {
+	Enabled   bool `yaml:"enabled" json:"enabled,omitempty" koanf:"enabled" jsonschema:"default=false"`
+	MinLength int  `yaml:"min_length" json:"min_length,omitempty" koanf:"min_length" split_words:"true" jsonschema:"default=3"`
+	MaxLength int  `yaml:"max_length" json:"max_length,omitempty" koanf:"max_length" split_words:"true" jsonschema:"default=32"`
+	// AllowedCharacters are the characters allowed in addition to the letters a-z and digits. A username must start
+	// with a letter or a digit.
+	AllowedCharacters string `yaml:"allowed_characters" json:"allowed_characters,omitempty" koanf:"allowed_characters" split_words:"true" jsonschema:"default=_-."`
+}
+
+func (u *Username) Validate() error {
+	if u.MinLength < 1 {
+		return errors.New("min_length must be at least 1")
+	}
+	if u.MaxLength < u.MinLength {
+		return fmt.Errorf("max_length must not be less than min_length (%d)", u.MinLength)
+	}
+	for _, r := range u.AllowedCharacters {
+		// usernames must not be mistaken for email addresses
+		if r == '@' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("allowed_characters must not contain %q", r)
+		}
+	}
+	return nil
+}
+
+// UserMetadata configures the custom JSON metadata of users. Public metadata is readable by the user, private metadata
+// only by admins and unsafe metadata is readable and writable by the user.
+type UserMetadata struct {
+	// MaxSize is the maximum size in bytes of the public and the private metadata of a user, each serialized as JSON.
+	MaxSize int `yaml:"max_size" json:"max_size,omitempty" koanf:"max_size" split_words:"true" jsonschema:"default=16384"`
+	// UnsafeMaxSize is the maximum size in bytes of the unsafe metadata of a user serialized as JSON.
+	UnsafeMaxSize int `yaml:"unsafe_max_size" json:"unsafe_max_size,omitempty" koanf:"unsafe_max_size" split_words:"true" jsonschema:"default=2048"`
+	// JwtClaims are the scopes of metadata added to session JWTs, e.g. "public" adds a "public_metadata" claim.
+	// Private metadata must not be added, because users can read their JWTs.
+	JwtClaims []string `yaml:"jwt_claims" json:"jwt_claims,omitempty" koanf:"jwt_claims" split_words:"true" jsonschema:"enum=public,enum=unsafe"`
+}
+
+func (m *UserMetadata) Validate() error {
+	if m.MaxSize < 1 {
+		return errors.New("max_size must be at least 1")
+	}
+	if m.UnsafeMaxSize < 1 {
+		return errors.New("unsafe_max_size must be at least 1")
+	}
+	for _, scope := range m.JwtClaims {
+		if scope != "public" && scope != "unsafe" {
+			return fmt.Errorf("jwt_claims must only contain 'public' or 'unsafe', got '%s'", scope)
+		}
+	}
+	return nil
+}
+
+// Rbac configures the roles and permissions of users. Roles and permissions are managed via the admin API.
+type Rbac struct {
+	// JwtClaims adds the "roles" and "permissions" claims to session JWTs.
+	JwtClaims bool `yaml:"jwt_claims" json:"jwt_claims,omitempty" koanf:"jwt_claims" split_words:"true" jsonschema:"default=false"`
+	// DefaultRole is the name of a role which is assigned to users on sign up. No role is assigned if it is empty or
+	// the role does not exist.
+	DefaultRole string `yaml:"default_role" json:"default_role,omitempty" koanf:"default_role" split_words:"true"`
+}
+
+func (r *Rbac) Validate() error {
+	if r.DefaultRole != "" && strings.TrimSpace(r.DefaultRole) != r.DefaultRole {
+		return errors.New("default_role must not contain leading or trailing whitespace")
+	}
+	return nil
 }
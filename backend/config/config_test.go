@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfigNotEnoughForValidation(t *testing.T) {
@@ -19,6 +20,7 @@ func TestDefaultConfigAccountParameters(t *testing.T) {
 	cfg := DefaultConfig()
 	assert.Equal(t, cfg.Account.AllowDeletion, false)
 	assert.Equal(t, cfg.Account.AllowSignup, true)
+	assert.Equal(t, cfg.Account.AllowPasskeySignup, false)
 }
 
 func TestParseValidConfig(t *testing.T) {
@@ -75,6 +77,405 @@ func TestRateLimiterConfig(t *testing.T) {
 	}
 }
 
+func TestPasscodePolicyConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Passcode.Policy.Alphabet = PasscodeAlphabetAlphanumeric
+	cfg.Passcode.Policy.Length = 8
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Passcode.Policy.Length = 2
+	if err := cfg.Validate(); err == nil {
+		t.Error("a passcode length of 2 should not be valid")
+	}
+	cfg.Passcode.Policy.Length = 6
+
+	cfg.Passcode.Policy.Alphabet = "notvalid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid alphabet")
+	}
+	cfg.Passcode.Policy.Alphabet = PasscodeAlphabetNumeric
+
+	cfg.Passcode.Policy.MaxAttempts = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("at least one attempt per passcode must be allowed")
+	}
+	cfg.Passcode.Policy.MaxAttempts = 3
+
+	cfg.Passcode.Policy.FailedAttemptsWindow = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("a failed attempts window must be set when the failed attempts budget is enabled")
+	}
+	cfg.Passcode.Policy.MaxFailedAttempts = 0
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPasswordPolicyConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Password.Policy.MaxPasswordLength = cfg.Password.MinPasswordLength - 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("max_password_length must not be less than min_password_length")
+	}
+	cfg.Password.Policy.MaxPasswordLength = 0
+
+	cfg.Password.Policy.BreachCheck.Enabled = true
+	cfg.Password.Policy.BreachCheck.Source = PasswordBreachCheckSourceRangeFile
+	if err := cfg.Validate(); err == nil {
+		t.Error("when using the range_file source, the range_directory should also be specified")
+	}
+	cfg.Password.Policy.BreachCheck.RangeDirectory = "/var/lib/hibp"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Password.Policy.BreachCheck.Source = PasswordBreachCheckSourceHTTP
+	if err := cfg.Validate(); err == nil {
+		t.Error("when using the http source, the endpoint should also be specified")
+	}
+	cfg.Password.Policy.BreachCheck.Endpoint = "https://api.pwnedpasswords.com/range/"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Password.Policy.BreachCheck.Source = "notvalid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid breach check source")
+	}
+}
+
+func TestPasswordHashingConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Password.Hashing.Bcrypt.Cost = 4
+	if err := cfg.Validate(); err == nil {
+		t.Error("bcrypt cost must be at least 10")
+	}
+
+	cfg.Password.Hashing.Algorithm = PasswordHashAlgorithmArgon2id
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Password.Hashing.Argon2id.Iterations = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("argon2id iterations must be at least 1")
+	}
+
+	cfg.Password.Hashing.Algorithm = "notvalid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid hashing algorithm")
+	}
+}
+
+func TestAccountLockoutConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Account.Lockout.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Account.Lockout.MaxDuration = cfg.Account.Lockout.Duration - time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("max_duration must not be less than duration")
+	}
+	cfg.Account.Lockout.MaxDuration = 24 * time.Hour
+
+	cfg.Account.Lockout.UnlockLinkUrl = "not a url"
+	if err := cfg.Validate(); err == nil {
+		t.Error("unlock_link_url must be a valid url")
+	}
+	cfg.Account.Lockout.UnlockLinkUrl = "https://app.example.com/unlock"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccountSoftDeleteConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Account.SoftDelete.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Account.SoftDelete.GracePeriod = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("grace_period must be greater than 0")
+	}
+}
+
+func TestAccountInvitationsConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Account.Invitations.Enabled = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("accept_url must be set")
+	}
+
+	cfg.Account.Invitations.AcceptUrl = "https://app.example.com/invitation"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Account.Invitations.Lifespan = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("lifespan must be greater than 0")
+	}
+}
+
+func TestEmailDomainsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Emails.Domains.Allowlist = []string{"example.com", "*.example.org"}
+	cfg.Emails.Domains.Denylist = []string{"spam.example.org"}
+	if err := cfg.PostProcess(); err != nil {
+		t.Error(err)
+	}
+	if len(cfg.Emails.Domains.AllowlistGlobs) != 2 || len(cfg.Emails.Domains.DenylistGlobs) != 1 {
+		t.Error("email domain globs must be compiled")
+	}
+
+	cfg.Emails.Domains.Denylist = []string{"[example.com"}
+	if err := cfg.PostProcess(); err == nil {
+		t.Error("invalid email domain globs must be rejected")
+	}
+}
+
+func TestWebauthnAttestationConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.Attestation.ConveyancePreference = "notvalid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid conveyance preference")
+	}
+	cfg.Webauthn.Attestation.ConveyancePreference = "none"
+
+	cfg.Webauthn.Attestation.RequireTrusted = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("require_trusted must not be used without requesting an attestation")
+	}
+	cfg.Webauthn.Attestation.ConveyancePreference = "direct"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.Attestation.AllowedTypes = []string{"basic", "notvalid"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid attestation type")
+	}
+	cfg.Webauthn.Attestation.AllowedTypes = []string{"basic", "attca"}
+
+	cfg.Webauthn.Attestation.DeniedAAGUIDs = []string{"notvalid"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid aaguid")
+	}
+	cfg.Webauthn.Attestation.DeniedAAGUIDs = []string{"ee882879-721c-4913-9775-3dfcce97072a"}
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebauthnMetadataServiceConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.MetadataService.Enabled = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("when enabling the metadata service, the blob_path should also be specified")
+	}
+	cfg.Webauthn.MetadataService.BlobPath = "/var/lib/hanko/mds.jwt"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.MetadataService.ReloadInterval = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("reload_interval must be greater than 0")
+	}
+}
+
+func TestWebauthnCloneDetectionConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.CloneDetection.Mode = CloneDetectionModeRejectAndDisable
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.CloneDetection.Mode = "notvalid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("notvalid is not a valid clone detection mode")
+	}
+}
+
+func TestWebauthnRelyingPartiesConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.RelyingParty.RelatedOrigins = []string{"https://example.de"}
+	cfg.Webauthn.AdditionalRelyingParties = []RelyingParty{
+		{Id: "example.fr", Origins: []string{"https://example.fr"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Webauthn.RelyingParty.RelatedOrigins = []string{"example.de"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("related origins must be origins")
+	}
+	cfg.Webauthn.RelyingParty.RelatedOrigins = nil
+
+	cfg.Webauthn.AdditionalRelyingParties[0].Origins = nil
+	if err := cfg.Validate(); err == nil {
+		t.Error("additional relying parties must have origins")
+	}
+	cfg.Webauthn.AdditionalRelyingParties[0].Origins = []string{"https://example.fr"}
+
+	cfg.Webauthn.AdditionalRelyingParties[0].Id = cfg.Webauthn.RelyingParty.Id
+	if err := cfg.Validate(); err == nil {
+		t.Error("relying parties must not be configured more than once")
+	}
+}
+
+func TestMaintenanceConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg.Maintenance.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Maintenance.Sessions.Interval = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("interval must be greater than 0")
+	}
+	cfg.Maintenance.Sessions.Interval = time.Hour
+
+	cfg.Maintenance.Tokens.Retention = -time.Hour
+	if err := cfg.Validate(); err == nil {
+		t.Error("retention must not be negative")
+	}
+}
+
+func TestUsernameConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+	require.NoError(t, err)
+
+	cfg.Username.Enabled = true
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Username.MaxLength = 2
+	assert.Error(t, cfg.Validate())
+	cfg.Username.MaxLength = 32
+
+	cfg.Username.AllowedCharacters = "_@"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestUserMetadataConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+	require.NoError(t, err)
+
+	cfg.UserMetadata.JwtClaims = []string{"public", "unsafe"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.UserMetadata.JwtClaims = []string{"private"}
+	assert.Error(t, cfg.Validate())
+	cfg.UserMetadata.JwtClaims = nil
+
+	cfg.UserMetadata.UnsafeMaxSize = 0
+	assert.Error(t, cfg.Validate())
+}
+
+func TestThirdPartyProfileRefreshConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, ThirdPartyProfileRefreshMissing, cfg.ThirdParty.ProfileRefresh)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ThirdParty.ProfileRefresh = "sometimes"
+	assert.Error(t, cfg.Validate())
+}
+
 func TestEnvironmentVariables(t *testing.T) {
 	err := os.Setenv("PASSCODE_SMTP_HOST", "valueFromEnvVars")
 	require.NoError(t, err)
@@ -89,3 +490,31 @@ func TestEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "valueFromEnvVars", cfg.Passcode.Smtp.Host)
 	assert.True(t, reflect.DeepEqual([]string{"https://hanko.io", "https://auth.hanko.io"}, cfg.Webauthn.RelyingParty.Origins))
 }
+
+func TestEmailChangeConfig(t *testing.T) {
+	configPath := "./minimal-config.yaml"
+	cfg, err := Load(&configPath)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if cfg.Emails.Change.RevertLinkTTL != 72*time.Hour {
+		t.Errorf("expected default revert_link_ttl of 72h, got %s", cfg.Emails.Change.RevertLinkTTL)
+	}
+
+	cfg.Emails.Change.Enabled = true
+	if err := cfg.Validate(); err == nil {
+		t.Error("revert_url must be set")
+	}
+
+	cfg.Emails.Change.RevertUrl = "https://app.example.com/email/revert"
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Emails.Change.RevertLinkTTL = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("revert_link_ttl must be greater than 0")
+	}
+}
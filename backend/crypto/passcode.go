@@ -2,8 +2,18 @@ package crypto
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+)
+
+const (
+	// PasscodeAlphabetNumeric contains the digits 0-9.
+	PasscodeAlphabetNumeric = "0123456789"
+	// PasscodeAlphabetAlphanumeric contains upper case letters and digits without the easily confused characters
+	// 0, 1, I and O.
+	PasscodeAlphabetAlphanumeric = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
 )
 
 type PasscodeGenerator interface {
@@ -11,17 +21,43 @@ type PasscodeGenerator interface {
 }
 
 type passcodeGenerator struct {
+	length   int
+	alphabet string
 }
 
+// NewPasscodeGenerator returns a generator for six digit numeric passcodes.
 func NewPasscodeGenerator() PasscodeGenerator {
-	return &passcodeGenerator{}
+	return &passcodeGenerator{
+		length:   6,
+		alphabet: PasscodeAlphabetNumeric,
+	}
+}
+
+// NewCustomPasscodeGenerator returns a generator for passcodes of the given length, where every character is drawn
+// uniformly from the given alphabet.
+func NewCustomPasscodeGenerator(length int, alphabet string) (PasscodeGenerator, error) {
+	if length <= 0 {
+		return nil, errors.New("passcode length must be greater than zero")
+	}
+	if len(alphabet) < 2 {
+		return nil, errors.New("passcode alphabet must contain at least two characters")
+	}
+	return &passcodeGenerator{
+		length:   length,
+		alphabet: alphabet,
+	}, nil
 }
 
 func (g *passcodeGenerator) Generate() (string, error) {
-	max := big.NewInt(999999)
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate random number: %w", err)
+	max := big.NewInt(int64(len(g.alphabet)))
+	var sb strings.Builder
+	sb.Grow(g.length)
+	for i := 0; i < g.length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		sb.WriteByte(g.alphabet[n.Int64()])
 	}
-	return fmt.Sprintf("%06d", n), nil
+	return sb.String(), nil
 }
@@ -27,3 +27,51 @@ func TestPasscodeGenerator_Generate_Different_Codes(t *testing.T) {
 
 	assert.NotEqual(t, passcode1, passcode2)
 }
+
+func TestCustomPasscodeGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		alphabet string
+	}{
+		{name: "numeric", length: 8, alphabet: PasscodeAlphabetNumeric},
+		{name: "alphanumeric", length: 10, alphabet: PasscodeAlphabetAlphanumeric},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pg, err := NewCustomPasscodeGenerator(test.length, test.alphabet)
+			assert.NoError(t, err)
+
+			passcode, err := pg.Generate()
+			assert.NoError(t, err)
+			assert.Equal(t, test.length, len(passcode))
+			for _, c := range passcode {
+				assert.Contains(t, test.alphabet, string(c))
+			}
+		})
+	}
+}
+
+func TestCustomPasscodeGenerator_Generate_Covers_Alphabet(t *testing.T) {
+	pg, err := NewCustomPasscodeGenerator(1, PasscodeAlphabetNumeric)
+	assert.NoError(t, err)
+
+	seen := map[string]bool{}
+	for i := 0; i < 1000 && len(seen) < len(PasscodeAlphabetNumeric); i++ {
+		passcode, err := pg.Generate()
+		assert.NoError(t, err)
+		seen[passcode] = true
+	}
+
+	// every character of the alphabet must be reachable
+	assert.Len(t, seen, len(PasscodeAlphabetNumeric))
+}
+
+func TestNewCustomPasscodeGenerator_Invalid(t *testing.T) {
+	_, err := NewCustomPasscodeGenerator(0, PasscodeAlphabetNumeric)
+	assert.Error(t, err)
+
+	_, err = NewCustomPasscodeGenerator(6, "a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,344 @@
+package password_hash
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+const (
+	idArgon2id       = "argon2id"
+	idArgon2i        = "argon2i"
+	idScrypt         = "scrypt"
+	idFirebaseScrypt = "firebase-scrypt"
+	idPbkdf2Sha256   = "pbkdf2-sha256"
+	idPbkdf2Sha1     = "pbkdf2-sha1"
+)
+
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Upper bounds of the cost parameters of imported hashes. They are well above the values recommended for the
+// supported algorithms, but keep a single verification from exhausting the memory or CPU of the server.
+const (
+	maxMemoryKiB         = 1024 * 1024 // 1 GiB, used for argon2 and scrypt
+	maxArgon2Iterations  = 64
+	maxScryptLogN        = 20
+	maxScryptR           = 32
+	maxScryptP           = 16
+	maxPbkdf2Iterations  = 10_000_000
+	maxArgon2Parallelism = 255
+)
+
+// Hasher creates password hashes with the configured algorithm and verifies hashes of all supported algorithms:
+//   - argon2id and argon2i: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//   - bcrypt: $2a$12$... (the modular crypt format, which bcrypt hashes have always been stored in)
+//   - scrypt: $scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//   - Firebase scrypt: $firebase-scrypt$ln=14,r=8$<salt>$<hash>, requires the signer key and salt separator of the project
+//   - PBKDF2: $pbkdf2-sha256$i=260000$<salt>$<hash> and $pbkdf2-sha1$i=...
+//
+// Salts and hashes are base64 encoded.
+type Hasher struct {
+	cfg config.PasswordHashing
+}
+
+// defaultBcryptCost is used when no bcrypt cost is configured. It is the cost hashes have been created with before
+// the cost became configurable.
+const defaultBcryptCost = 12
+
+func NewHasher(cfg config.PasswordHashing) *Hasher {
+	if cfg.Bcrypt.Cost == 0 {
+		cfg.Bcrypt.Cost = defaultBcryptCost
+	}
+	return &Hasher{cfg: cfg}
+}
+
+// LimitsPasswordLength reports whether the configured algorithm only considers the first 72 bytes of a password.
+func (h *Hasher) LimitsPasswordLength() bool {
+	return h.cfg.Algorithm != config.PasswordHashAlgorithmArgon2id
+}
+
+// Hash returns the hash of the password using the configured algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == config.PasswordHashAlgorithmArgon2id {
+		params := h.cfg.Argon2id
+		salt := make([]byte, params.SaltLength)
+		_, err := rand.Read(salt)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+
+		phc := &phcString{
+			id:      idArgon2id,
+			version: argon2.Version,
+			params: map[string]string{
+				"m": strconv.FormatUint(uint64(params.Memory), 10),
+				"t": strconv.FormatUint(uint64(params.Iterations), 10),
+				"p": strconv.FormatUint(uint64(params.Parallelism), 10),
+			},
+			salt: salt,
+			hash: argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength),
+		}
+		return phc.String(), nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.Bcrypt.Cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// Verify reports whether the password matches the encoded hash. An error is returned if the hash can not be
+// processed.
+func (h *Hasher) Verify(password string, encoded string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err != nil && !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) && !errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return false, fmt.Errorf("failed to compare bcrypt hash: %w", err)
+		}
+		return err == nil, nil
+	}
+
+	phc, err := parsePHC(encoded)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrUnsupportedHash, err)
+	}
+
+	if len(phc.hash) == 0 {
+		return false, fmt.Errorf("%w: empty hash", ErrUnsupportedHash)
+	}
+
+	computed, err := h.compute(phc, password)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, phc.hash) == 1, nil
+}
+
+// NeedsRehash reports whether the hash was created with a different algorithm or different parameters than the
+// configured ones.
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if h.cfg.Algorithm == config.PasswordHashAlgorithmArgon2id {
+		phc, err := parsePHC(encoded)
+		if err != nil || phc.id != idArgon2id || phc.version != argon2.Version {
+			return true
+		}
+		params := h.cfg.Argon2id
+		return phc.params["m"] != strconv.FormatUint(uint64(params.Memory), 10) ||
+			phc.params["t"] != strconv.FormatUint(uint64(params.Iterations), 10) ||
+			phc.params["p"] != strconv.FormatUint(uint64(params.Parallelism), 10) ||
+			len(phc.salt) != int(params.SaltLength) ||
+			len(phc.hash) != int(params.KeyLength)
+	}
+
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cfg.Bcrypt.Cost
+}
+
+// Normalize checks that the hash uses a supported format and converts hashes in the native format of Django
+// (pbkdf2_sha256$<iterations>$<salt>$<hash>) to the PHC string format. It is meant to be used when importing hashes.
+func Normalize(encoded string) (string, error) {
+	if isBcrypt(encoded) {
+		_, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedHash, err)
+		}
+		return encoded, nil
+	}
+
+	if strings.HasPrefix(encoded, "pbkdf2_") {
+		return normalizeDjango(encoded)
+	}
+
+	phc, err := parsePHC(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedHash, err)
+	}
+
+	switch phc.id {
+	case idArgon2id, idArgon2i:
+		_, _, _, err = argon2Params(phc)
+	case idScrypt, idFirebaseScrypt:
+		_, _, _, err = scryptParams(phc)
+	case idPbkdf2Sha256, idPbkdf2Sha1:
+		_, err = phc.intParam("i", 1, maxPbkdf2Iterations)
+	default:
+		err = fmt.Errorf("unknown algorithm: %s", phc.id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedHash, err)
+	}
+
+	return phc.String(), nil
+}
+
+func (h *Hasher) compute(phc *phcString, password string) ([]byte, error) {
+	keyLength := len(phc.hash)
+	switch phc.id {
+	case idArgon2id, idArgon2i:
+		memory, iterations, parallelism, err := argon2Params(phc)
+		if err != nil {
+			return nil, err
+		}
+		if phc.id == idArgon2i {
+			return argon2.Key([]byte(password), phc.salt, iterations, memory, parallelism, uint32(keyLength)), nil
+		}
+		return argon2.IDKey([]byte(password), phc.salt, iterations, memory, parallelism, uint32(keyLength)), nil
+	case idScrypt:
+		n, r, p, err := scryptParams(phc)
+		if err != nil {
+			return nil, err
+		}
+		return scrypt.Key([]byte(password), phc.salt, n, r, p, keyLength)
+	case idFirebaseScrypt:
+		return h.computeFirebaseScrypt(phc, password)
+	case idPbkdf2Sha256, idPbkdf2Sha1:
+		iterations, err := phc.intParam("i", 1, maxPbkdf2Iterations)
+		if err != nil {
+			return nil, err
+		}
+		hashFunc := sha256.New
+		if phc.id == idPbkdf2Sha1 {
+			hashFunc = func() hash.Hash { return sha1.New() }
+		}
+		return pbkdf2.Key([]byte(password), phc.salt, iterations, keyLength, hashFunc), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm: %s", ErrUnsupportedHash, phc.id)
+	}
+}
+
+// computeFirebaseScrypt implements the modified scrypt of Firebase: the signer key of the project is encrypted with
+// AES-256-CTR, using the scrypt key derived from the password and the salt followed by the salt separator.
+func (h *Hasher) computeFirebaseScrypt(phc *phcString, password string) ([]byte, error) {
+	signerKey, err := decodeBase64(h.cfg.FirebaseScrypt.SignerKey)
+	if err != nil || len(signerKey) == 0 {
+		return nil, errors.New("firebase_scrypt.signer_key must be configured to verify firebase-scrypt hashes")
+	}
+	saltSeparator, err := decodeBase64(h.cfg.FirebaseScrypt.SaltSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("invalid firebase_scrypt.salt_separator: %w", err)
+	}
+
+	n, r, p, err := scryptParams(phc)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := append(append([]byte{}, phc.salt...), saltSeparator...)
+	derivedKey, err := scrypt.Key([]byte(password), salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	computed := make([]byte, len(signerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(computed, signerKey)
+
+	return computed, nil
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func argon2Params(phc *phcString) (uint32, uint32, uint8, error) {
+	if phc.version != argon2.Version {
+		return 0, 0, 0, fmt.Errorf("unsupported argon2 version: %d", phc.version)
+	}
+	parallelism, err := phc.intParam("p", 1, maxArgon2Parallelism)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	// argon2 requires at least 8 KiB per lane
+	memory, err := phc.intParam("m", 8*parallelism, maxMemoryKiB)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	iterations, err := phc.intParam("t", 1, maxArgon2Iterations)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return uint32(memory), uint32(iterations), uint8(parallelism), nil
+}
+
+// scryptParams returns N, r and p. N is encoded as its binary logarithm "ln", p defaults to 1.
+func scryptParams(phc *phcString) (int, int, int, error) {
+	ln, err := phc.intParam("ln", 1, maxScryptLogN)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	r, err := phc.intParam("r", 1, maxScryptR)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	p := 1
+	if _, ok := phc.params["p"]; ok {
+		p, err = phc.intParam("p", 1, maxScryptP)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	// scrypt needs 128 * r * N bytes of memory
+	if 128*r*(1<<ln)/1024 > maxMemoryKiB {
+		return 0, 0, 0, fmt.Errorf("parameters ln=%d and r=%d require too much memory", ln, r)
+	}
+	return 1 << ln, r, p, nil
+}
+
+func normalizeDjango(encoded string) (string, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("%w: invalid django hash", ErrUnsupportedHash)
+	}
+
+	var id string
+	switch parts[0] {
+	case "pbkdf2_sha256":
+		id = idPbkdf2Sha256
+	case "pbkdf2_sha1":
+		id = idPbkdf2Sha1
+	default:
+		return "", fmt.Errorf("%w: unknown django algorithm: %s", ErrUnsupportedHash, parts[0])
+	}
+
+	if iterations, err := strconv.Atoi(parts[1]); err != nil || iterations < 1 || iterations > maxPbkdf2Iterations {
+		return "", fmt.Errorf("%w: invalid iterations: %s", ErrUnsupportedHash, parts[1])
+	}
+	hashed, err := decodeBase64(parts[3])
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid hash: %s", ErrUnsupportedHash, err)
+	}
+	// Django uses the salt string as is, not its decoded bytes
+	err = checkSaltAndHash([]byte(parts[2]), hashed)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedHash, err)
+	}
+
+	phc := &phcString{
+		id:     id,
+		params: map[string]string{"i": parts[1]},
+		salt:   []byte(parts[2]),
+		hash:   hashed,
+	}
+	return phc.String(), nil
+}
@@ -0,0 +1,242 @@
+package password_hash
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	"strings"
+	"testing"
+)
+
+var testConfig = config.PasswordHashing{
+	Algorithm: config.PasswordHashAlgorithmArgon2id,
+	Argon2id: config.Argon2idParameters{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	},
+	Bcrypt: config.BcryptParameters{
+		Cost: 10,
+	},
+	// test parameters from https://github.com/firebase/scrypt
+	FirebaseScrypt: config.FirebaseScryptParameters{
+		SignerKey:     "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==",
+		SaltSeparator: "Bw==",
+	},
+}
+
+func TestHasher_Hash(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm config.PasswordHashAlgorithm
+		prefix    string
+	}{
+		{name: "argon2id", algorithm: config.PasswordHashAlgorithmArgon2id, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{name: "bcrypt", algorithm: config.PasswordHashAlgorithmBcrypt, prefix: "$2a$10$"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig
+			cfg.Algorithm = test.algorithm
+			hasher := NewHasher(cfg)
+
+			encoded, err := hasher.Hash("hanko-password")
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encoded, test.prefix), encoded)
+			assert.False(t, hasher.NeedsRehash(encoded))
+
+			valid, err := hasher.Verify("hanko-password", encoded)
+			assert.NoError(t, err)
+			assert.True(t, valid)
+
+			valid, err = hasher.Verify("wrong-password", encoded)
+			assert.NoError(t, err)
+			assert.False(t, valid)
+		})
+	}
+}
+
+func TestHasher_Verify(t *testing.T) {
+	hasher := NewHasher(testConfig)
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+	}{
+		{
+			name:     "bcrypt",
+			password: "hanko-password",
+			encoded:  "$2a$10$40I64gqZnOpucuWiwsyv0OiosOh0zPFHU9yLUJME4dxHjHypXKYVq",
+		},
+		{
+			name:     "scrypt",
+			password: "hanko-password",
+			encoded:  "$scrypt$ln=14,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$h3xrE4tf8jb3m4ZqCQnf+T8ba699OzQ1nFv0OFe2EwI",
+		},
+		{
+			name:     "firebase scrypt",
+			password: "user1password",
+			encoded:  "$firebase-scrypt$ln=14,r=8$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==",
+		},
+		{
+			name:     "pbkdf2",
+			password: "hanko-password",
+			encoded:  "$pbkdf2-sha256$i=600000$c2Vhc2FsdA$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			valid, err := hasher.Verify(test.password, test.encoded)
+			assert.NoError(t, err)
+			assert.True(t, valid)
+
+			valid, err = hasher.Verify("wrong-password", test.encoded)
+			assert.NoError(t, err)
+			assert.False(t, valid)
+
+			assert.True(t, hasher.NeedsRehash(test.encoded))
+		})
+	}
+}
+
+func TestHasher_Verify_Unsupported(t *testing.T) {
+	hasher := NewHasher(testConfig)
+
+	_, err := hasher.Verify("hanko-password", "$md5$c2FsdA$aGFzaA")
+	assert.ErrorIs(t, err, ErrUnsupportedHash)
+
+	_, err = hasher.Verify("hanko-password", "plaintext")
+	assert.ErrorIs(t, err, ErrUnsupportedHash)
+}
+
+func TestHasher_Verify_EmptyHash(t *testing.T) {
+	hasher := NewHasher(testConfig)
+
+	for _, encoded := range []string{
+		"$pbkdf2-sha256$i=1000$c2FsdA$",
+		"$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHQ$",
+		"$scrypt$ln=14,r=8,p=1$c2FsdHNhbHQ$",
+	} {
+		valid, err := hasher.Verify("any-password", encoded)
+		assert.ErrorIs(t, err, ErrUnsupportedHash, encoded)
+		assert.False(t, valid, encoded)
+	}
+}
+
+func TestHasher_NeedsRehash_ChangedParameters(t *testing.T) {
+	hasher := NewHasher(testConfig)
+	encoded, err := hasher.Hash("hanko-password")
+	assert.NoError(t, err)
+
+	cfg := testConfig
+	cfg.Argon2id.Iterations = 2
+	assert.True(t, NewHasher(cfg).NeedsRehash(encoded))
+
+	cfg = testConfig
+	cfg.Algorithm = config.PasswordHashAlgorithmBcrypt
+	bcryptHash, err := NewHasher(cfg).Hash("hanko-password")
+	assert.NoError(t, err)
+	cfg.Bcrypt.Cost = 11
+	assert.True(t, NewHasher(cfg).NeedsRehash(bcryptHash))
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoded  string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "django pbkdf2",
+			encoded:  "pbkdf2_sha256$600000$seasalt$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI=",
+			expected: "$pbkdf2-sha256$i=600000$c2Vhc2FsdA$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+		},
+		{
+			name:     "padded firebase scrypt",
+			encoded:  "$firebase-scrypt$ln=14,r=8$42xEC+ixf3L2lw==$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==",
+			expected: "$firebase-scrypt$ln=14,r=8$42xEC+ixf3L2lw$lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ",
+		},
+		{
+			name:     "bcrypt",
+			encoded:  "$2a$10$40I64gqZnOpucuWiwsyv0OiosOh0zPFHU9yLUJME4dxHjHypXKYVq",
+			expected: "$2a$10$40I64gqZnOpucuWiwsyv0OiosOh0zPFHU9yLUJME4dxHjHypXKYVq",
+		},
+		{
+			name:    "argon2id without parameters",
+			encoded: "$argon2id$v=19$c2FsdA$aGFzaA",
+			wantErr: true,
+		},
+		{
+			name:    "unknown algorithm",
+			encoded: "$md5$c2FsdA$aGFzaA",
+			wantErr: true,
+		},
+		{
+			name:    "pbkdf2 with empty hash",
+			encoded: "$pbkdf2-sha256$i=1000$c2FsdA$",
+			wantErr: true,
+		},
+		{
+			name:    "django pbkdf2 with empty hash",
+			encoded: "pbkdf2_sha256$1000$salt$",
+			wantErr: true,
+		},
+		{
+			name:    "django pbkdf2 with empty salt",
+			encoded: "pbkdf2_sha256$1000$$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI=",
+			wantErr: true,
+		},
+		{
+			name:    "argon2id with empty hash",
+			encoded: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHQ$",
+			wantErr: true,
+		},
+		{
+			name:    "pbkdf2 with empty salt",
+			encoded: "$pbkdf2-sha256$i=1000$$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+			wantErr: true,
+		},
+		{
+			name:    "pbkdf2 with too many iterations",
+			encoded: "$pbkdf2-sha256$i=2000000000$c2Vhc2FsdA$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+			wantErr: true,
+		},
+		{
+			name:    "django pbkdf2 with too many iterations",
+			encoded: "pbkdf2_sha256$2000000000$seasalt$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI=",
+			wantErr: true,
+		},
+		{
+			name:    "argon2id with too much memory",
+			encoded: "$argon2id$v=19$m=4294967295,t=3,p=4$c2Vhc2FsdA$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+			wantErr: true,
+		},
+		{
+			name:    "argon2id without iterations",
+			encoded: "$argon2id$v=19$m=65536,t=0,p=4$c2Vhc2FsdA$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+			wantErr: true,
+		},
+		{
+			name:    "scrypt with too much memory",
+			encoded: "$scrypt$ln=20,r=32,p=1$c2Vhc2FsdA$Sn9Ni+0o2SruO1F5M9c089PFSqKGPdhkT3aUR4DrHaI",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := Normalize(test.encoded)
+			if test.wantErr {
+				assert.ErrorIs(t, err, ErrUnsupportedHash)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, normalized)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package password_hash
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// phcString is a decoded hash in the PHC string format: $<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+// See https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md
+type phcString struct {
+	id      string
+	version int
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+func parsePHC(encoded string) (*phcString, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 2 || parts[0] != "" || parts[1] == "" {
+		return nil, errors.New("not a PHC string")
+	}
+
+	phc := &phcString{id: parts[1], params: map[string]string{}}
+	fields := parts[2:]
+
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "v=") {
+		version, err := strconv.Atoi(strings.TrimPrefix(fields[0], "v="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %w", err)
+		}
+		phc.version = version
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 && strings.Contains(fields[0], "=") {
+		for _, param := range strings.Split(fields[0], ",") {
+			name, value, found := strings.Cut(param, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid parameter: %s", param)
+			}
+			phc.params[name] = value
+		}
+		fields = fields[1:]
+	}
+
+	if len(fields) != 2 {
+		return nil, errors.New("salt and hash are required")
+	}
+
+	var err error
+	phc.salt, err = decodeBase64(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	phc.hash, err = decodeBase64(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	err = checkSaltAndHash(phc.salt, phc.hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return phc, nil
+}
+
+const (
+	// minSaltLength rejects empty and trivially short salts. It is kept low, because exports of some systems contain
+	// short salts, e.g. Django salts of 7 characters.
+	minSaltLength = 4
+	// minHashLength is the length of the shortest hash of the supported algorithms that are in use, e.g. 20 bytes for
+	// PBKDF2-SHA1, with some margin. An empty hash would match every password.
+	minHashLength = 16
+)
+
+func checkSaltAndHash(salt []byte, hash []byte) error {
+	if len(salt) < minSaltLength {
+		return fmt.Errorf("salt must be at least %d bytes long", minSaltLength)
+	}
+	if len(hash) < minHashLength {
+		return fmt.Errorf("hash must be at least %d bytes long", minHashLength)
+	}
+	return nil
+}
+
+func (p *phcString) String() string {
+	var sb strings.Builder
+	sb.WriteString("$" + p.id)
+	if p.version != 0 {
+		sb.WriteString(fmt.Sprintf("$v=%d", p.version))
+	}
+	if len(p.params) > 0 {
+		sb.WriteString("$" + p.encodeParams())
+	}
+	sb.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.salt))
+	sb.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.hash))
+	return sb.String()
+}
+
+// paramOrder keeps the parameters of the encoded strings in the order commonly used by other implementations.
+var paramOrder = []string{"m", "t", "p", "ln", "r", "i"}
+
+func (p *phcString) encodeParams() string {
+	var params []string
+	for _, name := range paramOrder {
+		if value, ok := p.params[name]; ok {
+			params = append(params, name+"="+value)
+		}
+	}
+	return strings.Join(params, ",")
+}
+
+// intParam returns the parameter, which must be within min and max. The bounds keep imported hashes from making the
+// verification of a password fail or take an unreasonable amount of time or memory.
+func (p *phcString) intParam(name string, min int, max int) (int, error) {
+	value, ok := p.params[name]
+	if !ok {
+		return 0, fmt.Errorf("missing parameter: %s", name)
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < min || i > max {
+		return 0, fmt.Errorf("invalid parameter %s: %s", name, value)
+	}
+	return i, nil
+}
+
+// decodeBase64 accepts standard base64 with and without padding, as the PHC format omits the padding but many
+// exports contain it.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -2,7 +2,9 @@ package crypto
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 )
 
 // GenerateRandomBytes returns securely generated random bytes.
@@ -29,3 +31,11 @@ func GenerateRandomStringURLSafe(n int) (string, error) {
 	b, err := GenerateRandomBytes(n)
 	return base64.URLEncoding.EncodeToString(b), err
 }
+
+// HashToken returns the hex encoded SHA-256 hash of a random token sent to a user, e.g. as part of a link. Only the
+// hash is stored, so that a leaked database does not allow using the tokens. Unlike passwords, the tokens have enough
+// entropy that a fast hash suffices.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// Block describes why and until when a user is blocked. A block without ExpiresAt lasts until the user is unblocked.
+type Block struct {
+	Reason    *string    `json:"reason,omitempty"`
+	BlockedAt time.Time  `json:"blocked_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// FromUserBlockModel Converts the block of the DB model to a DTO object. Returns nil if the user is not blocked.
+func FromUserBlockModel(model models.User) *Block {
+	if !model.IsBlocked(time.Now().UTC()) {
+		return nil
+	}
+
+	return &Block{
+		Reason:    model.BlockedReason,
+		BlockedAt: *model.BlockedAt,
+		ExpiresAt: model.BlockedUntil,
+	}
+}
+
+type BlockUser struct {
+	Reason    *string    `json:"reason" validate:"omitempty,max=1024"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
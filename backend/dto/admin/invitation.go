@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// Invitation is an invitation of an email address. Role is the name of the role the invited user gets within the
+// organization.
+type Invitation struct {
+	ID             uuid.UUID  `json:"id"`
+	Email          string     `json:"email"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Role           *string    `json:"role,omitempty"`
+	Metadata       slices.Map `json:"metadata,omitempty"`
+	Language       *string    `json:"language,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// FromInvitationModel Converts the DB model to a DTO object
+func FromInvitationModel(model models.Invitation, role *string) Invitation {
+	return Invitation{
+		ID:             model.ID,
+		Email:          model.Email,
+		OrganizationID: model.OrganizationID,
+		Role:           role,
+		Metadata:       model.Metadata,
+		Language:       model.Language,
+		ExpiresAt:      model.ExpiresAt,
+		AcceptedAt:     model.AcceptedAt,
+		UserID:         model.UserID,
+		CreatedAt:      model.CreatedAt,
+		UpdatedAt:      model.UpdatedAt,
+	}
+}
+
+type InvitationListRequest struct {
+	PerPage int `query:"per_page"`
+	Page    int `query:"page"`
+}
+
+// CreateInvitation invites an email address. The invited user becomes a member of the organization with the role, if
+// set. Metadata is a merge patch with "public_metadata" and "private_metadata" keys, which is applied to the user.
+// Language is the language of the invitation email.
+type CreateInvitation struct {
+	Email          string                 `json:"email" validate:"required,email"`
+	OrganizationID *uuid.UUID             `json:"organization_id"`
+	Role           *string                `json:"role"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Language       *string                `json:"language" validate:"omitempty,bcp47_language_tag,max=35"`
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type Lockout struct {
+	IsLocked       bool       `json:"is_locked"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockoutCount   int        `json:"lockout_count"`
+}
+
+// FromUserLockoutModel Converts the DB model to a DTO object. Returns nil if the user never failed to log in.
+func FromUserLockoutModel(lockout *models.UserLockout) *Lockout {
+	if lockout == nil {
+		return nil
+	}
+
+	isLocked := lockout.IsLocked(time.Now().UTC())
+	var lockedUntil *time.Time
+	if isLocked {
+		lockedUntil = lockout.LockedUntil
+	}
+
+	return &Lockout{
+		IsLocked:       isLocked,
+		LockedUntil:    lockedUntil,
+		FailedAttempts: lockout.FailedAttempts,
+		LockoutCount:   lockout.LockoutCount,
+	}
+}
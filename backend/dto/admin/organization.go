@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FromOrganizationModel Converts the DB model to a DTO object
+func FromOrganizationModel(model models.Organization) Organization {
+	return Organization{
+		ID:        model.ID,
+		Name:      model.Name,
+		Slug:      model.Slug,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
+
+type OrganizationListRequest struct {
+	PerPage int `query:"per_page"`
+	Page    int `query:"page"`
+}
+
+type CreateOrganization struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Slug string `json:"slug" validate:"required,max=100"`
+}
+
+// UpdateOrganization changes the fields which are not nil.
+type UpdateOrganization struct {
+	Name *string `json:"name" validate:"omitempty,max=255"`
+	Slug *string `json:"slug" validate:"omitempty,max=100"`
+}
+
+// OrganizationMember is a membership of a user. Role is the name of the role of the user within the organization.
+type OrganizationMember struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Role      *string   `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AddOrganizationMember adds an existing user to an organization. Role is the name of a role.
+type AddOrganizationMember struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+	Role   *string   `json:"role"`
+}
+
+// UpdateOrganizationMember changes the role of a member. An empty or missing role removes the role.
+type UpdateOrganizationMember struct {
+	Role *string `json:"role"`
+}
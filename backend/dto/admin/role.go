@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type Permission struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// FromPermissionModel Converts the DB model to a DTO object
+func FromPermissionModel(model models.Permission) Permission {
+	return Permission{
+		ID:          model.ID,
+		Name:        model.Name,
+		Description: model.Description,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+}
+
+type Role struct {
+	ID          uuid.UUID    `json:"id"`
+	Name        string       `json:"name"`
+	Description *string      `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// FromRoleModel Converts the DB model to a DTO object
+func FromRoleModel(model models.Role) Role {
+	permissions := make([]Permission, len(model.Permissions))
+	for i := range model.Permissions {
+		permissions[i] = FromPermissionModel(model.Permissions[i])
+	}
+
+	return Role{
+		ID:          model.ID,
+		Name:        model.Name,
+		Description: model.Description,
+		Permissions: permissions,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+}
+
+type CreatePermission struct {
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=1024"`
+}
+
+// UpdatePermission changes the fields which are not nil. An empty description removes the description.
+type UpdatePermission struct {
+	Name        *string `json:"name" validate:"omitempty,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=1024"`
+}
+
+type CreateRole struct {
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=1024"`
+	// Permissions are the names of the permissions granted by the role.
+	Permissions []string `json:"permissions" validate:"unique"`
+}
+
+// UpdateRole changes the fields which are not nil. An empty description removes the description and Permissions
+// replaces all permissions of the role.
+type UpdateRole struct {
+	Name        *string   `json:"name" validate:"omitempty,max=100"`
+	Description *string   `json:"description" validate:"omitempty,max=1024"`
+	Permissions *[]string `json:"permissions" validate:"omitempty,unique"`
+}
@@ -1,25 +1,37 @@
 package admin
 
 import (
+	"github.com/gobuffalo/pop/v6/slices"
 	"github.com/gofrs/uuid"
-	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"time"
 )
 
 type User struct {
-	ID                  uuid.UUID                        `json:"id"`
-	WebauthnCredentials []dto.WebauthnCredentialResponse `json:"webauthn_credentials,omitempty"`
-	Emails              []Email                          `json:"emails,omitempty"`
-	CreatedAt           time.Time                        `json:"created_at"`
-	UpdatedAt           time.Time                        `json:"updated_at"`
+	ID                  uuid.UUID            `json:"id"`
+	Username            *string              `json:"username,omitempty"`
+	DisplayName         *string              `json:"display_name,omitempty"`
+	GivenName           *string              `json:"given_name,omitempty"`
+	FamilyName          *string              `json:"family_name,omitempty"`
+	AvatarURL           *string              `json:"avatar_url,omitempty"`
+	Locale              *string              `json:"locale,omitempty"`
+	WebauthnCredentials []WebauthnCredential `json:"webauthn_credentials,omitempty"`
+	Emails              []Email              `json:"emails,omitempty"`
+	Lockout             *Lockout             `json:"lockout,omitempty"`
+	Blocked             *Block               `json:"blocked,omitempty"`
+	DeletedAt           *time.Time           `json:"deleted_at,omitempty"`
+	PublicMetadata      slices.Map           `json:"public_metadata,omitempty"`
+	PrivateMetadata     slices.Map           `json:"private_metadata,omitempty"`
+	UnsafeMetadata      slices.Map           `json:"unsafe_metadata,omitempty"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
 }
 
 // FromUserModel Converts the DB model to a DTO object
 func FromUserModel(model models.User) User {
-	credentials := make([]dto.WebauthnCredentialResponse, len(model.WebauthnCredentials))
+	credentials := make([]WebauthnCredential, len(model.WebauthnCredentials))
 	for i := range model.WebauthnCredentials {
-		credentials[i] = *dto.FromWebauthnCredentialModel(&model.WebauthnCredentials[i])
+		credentials[i] = *FromWebauthnCredentialModel(&model.WebauthnCredentials[i])
 	}
 	emails := make([]Email, len(model.Emails))
 	for i := range model.Emails {
@@ -27,8 +39,20 @@ func FromUserModel(model models.User) User {
 	}
 	return User{
 		ID:                  model.ID,
+		Username:            model.Username,
+		DisplayName:         model.DisplayName,
+		GivenName:           model.GivenName,
+		FamilyName:          model.FamilyName,
+		AvatarURL:           model.AvatarURL,
+		Locale:              model.Locale,
 		WebauthnCredentials: credentials,
 		Emails:              emails,
+		Lockout:             FromUserLockoutModel(model.Lockout),
+		Blocked:             FromUserBlockModel(model),
+		DeletedAt:           model.DeletedAt,
+		PublicMetadata:      model.PublicMetadata,
+		PrivateMetadata:     model.PrivateMetadata,
+		UnsafeMetadata:      model.UnsafeMetadata,
 		CreatedAt:           model.CreatedAt,
 		UpdatedAt:           model.UpdatedAt,
 	}
@@ -39,3 +63,17 @@ type CreateUser struct {
 	Emails    []CreateEmail `json:"emails" validate:"required,gte=1,unique=Address,dive"`
 	CreatedAt time.Time     `json:"created_at"`
 }
+
+type UserMetadata struct {
+	PublicMetadata  slices.Map `json:"public_metadata"`
+	PrivateMetadata slices.Map `json:"private_metadata"`
+	UnsafeMetadata  slices.Map `json:"unsafe_metadata"`
+}
+
+func FromUserMetadataModel(model models.User) UserMetadata {
+	return UserMetadata{
+		PublicMetadata:  model.PublicMetadata,
+		PrivateMetadata: model.PrivateMetadata,
+		UnsafeMetadata:  model.UnsafeMetadata,
+	}
+}
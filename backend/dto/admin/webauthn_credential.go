@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type WebauthnCredential struct {
+	dto.WebauthnCredentialResponse
+	// SuspectedClone is true if the signature counter of an assertion did not increase, which indicates that the
+	// authenticator may have been cloned.
+	SuspectedClone bool       `json:"suspected_clone"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+}
+
+// FromWebauthnCredentialModel Converts the DB model to a DTO object
+func FromWebauthnCredentialModel(model *models.WebauthnCredential) *WebauthnCredential {
+	return &WebauthnCredential{
+		WebauthnCredentialResponse: *dto.FromWebauthnCredentialModel(model),
+		SuspectedClone:             model.SuspectedClone,
+		DisabledAt:                 model.DisabledAt,
+	}
+}
@@ -11,6 +11,7 @@ type PublicConfig struct {
 	Emails    config.Emails   `json:"emails"`
 	Providers []string        `json:"providers"`
 	Account   config.Account  `json:"account"`
+	Username  config.Username `json:"username"`
 }
 
 // FromConfig Returns a PublicConfig from the Application configuration
@@ -20,6 +21,7 @@ func FromConfig(config config.Config) PublicConfig {
 		Emails:    config.Emails,
 		Providers: GetEnabledProviders(config.ThirdParty.Providers),
 		Account:   config.Account,
+		Username:  config.Username,
 	}
 }
 
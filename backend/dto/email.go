@@ -21,6 +21,20 @@ type EmailUpdateRequest struct {
 	IsPrimary *bool `json:"is_primary"`
 }
 
+type EmailChangeInitRequest struct {
+	Address string `json:"address" validate:"required,email"`
+}
+
+type EmailChangeFinishRequest struct {
+	Id             string `json:"id" validate:"required,uuid4"`
+	Code           string `json:"code" validate:"required"`
+	RemoveOldEmail bool   `json:"remove_old_email"`
+}
+
+type EmailChangeRevertRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 // FromEmailModel Converts the DB model to a DTO object
 func FromEmailModel(email *models.Email) *EmailResponse {
 	return &EmailResponse{
@@ -5,11 +5,12 @@ import (
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/attestation"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"time"
 )
 
-func WebauthnCredentialToModel(credential *webauthn.Credential, userId uuid.UUID, backupEligible bool, backupState bool) *models.WebauthnCredential {
+func WebauthnCredentialToModel(credential *webauthn.Credential, userId uuid.UUID, backupEligible bool, backupState bool, attestationResult *attestation.Result) *models.WebauthnCredential {
 	now := time.Now().UTC()
 	aaguid, _ := uuid.FromBytes(credential.Authenticator.AAGUID)
 	credentialID := base64.RawURLEncoding.EncodeToString(credential.ID)
@@ -28,6 +29,13 @@ func WebauthnCredentialToModel(credential *webauthn.Credential, userId uuid.UUID
 		BackupState:     backupState,
 	}
 
+	if attestationResult != nil {
+		format := attestationResult.Format
+		trust := string(attestationResult.Trust)
+		c.AttestationFormat = &format
+		c.AttestationTrust = &trust
+	}
+
 	for _, name := range credential.Transport {
 		if string(name) != "" {
 			id, _ := uuid.NewV4()
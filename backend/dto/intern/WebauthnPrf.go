@@ -0,0 +1,38 @@
+package intern
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+const extensionPrf = "prf"
+
+// PrfExtension returns the input of the PRF extension which evaluates the salt. Without a salt the extension is only
+// requested, which tells whether a new credential supports it.
+func PrfExtension(salt *models.WebauthnPrfSalt) (protocol.AuthenticationExtensions, error) {
+	input := map[string]interface{}{}
+	if salt != nil {
+		first, err := base64.RawURLEncoding.DecodeString(salt.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode prf salt: %w", err)
+		}
+		input["eval"] = map[string]interface{}{
+			"first": protocol.URLEncodedBase64(first),
+		}
+	}
+
+	return protocol.AuthenticationExtensions{extensionPrf: input}, nil
+}
+
+// PrfEnabled returns true if the client extension outputs of a registration state that the credential supports the
+// PRF extension.
+func PrfEnabled(outputs protocol.AuthenticationExtensionsClientOutputs) bool {
+	prf, ok := outputs[extensionPrf].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	enabled, _ := prf["enabled"].(bool)
+	return enabled
+}
@@ -0,0 +1,49 @@
+package intern
+
+import (
+	"encoding/json"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"testing"
+)
+
+func TestPrfExtension(t *testing.T) {
+	extensions, err := PrfExtension(nil)
+	require.NoError(t, err)
+	data, err := json.Marshal(extensions)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"prf":{}}`, string(data))
+
+	salt, err := models.NewWebauthnPrfSalt(uuid.Must(uuid.NewV4()))
+	require.NoError(t, err)
+	extensions, err = PrfExtension(salt)
+	require.NoError(t, err)
+	data, err = json.Marshal(extensions)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"prf":{"eval":{"first":"`+salt.Salt+`"}}}`, string(data))
+}
+
+func TestPrfEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs string
+		want    bool
+	}{
+		{name: "enabled", outputs: `{"prf":{"enabled":true}}`, want: true},
+		{name: "not enabled", outputs: `{"prf":{"enabled":false}}`, want: false},
+		{name: "no prf output", outputs: `{"credProps":{"rk":true}}`, want: false},
+		{name: "invalid prf output", outputs: `{"prf":true}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outputs protocol.AuthenticationExtensionsClientOutputs
+			require.NoError(t, json.Unmarshal([]byte(tt.outputs), &outputs))
+			assert.Equal(t, tt.want, PrfEnabled(outputs))
+		})
+	}
+	assert.False(t, PrfEnabled(nil))
+}
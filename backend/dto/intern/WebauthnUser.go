@@ -1,28 +1,33 @@
 package intern
 
 import (
-	"errors"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 )
 
-func NewWebauthnUser(user models.User, credentials []models.WebauthnCredential) (*WebauthnUser, error) {
-	email := user.Emails.GetPrimary()
-	if email == nil {
-		return nil, errors.New("primary email unavailable")
-	}
-
-	return &WebauthnUser{
+// NewWebauthnUser creates a WebauthnUser. Users who signed up with a passkey do not necessarily have an email address.
+func NewWebauthnUser(user models.User, credentials []models.WebauthnCredential) *WebauthnUser {
+	webauthnUser := &WebauthnUser{
 		UserId:              user.ID,
-		Email:               email.Address,
 		WebauthnCredentials: credentials,
-	}, nil
+	}
+
+	if email := user.Emails.GetPrimary(); email != nil {
+		webauthnUser.Email = email.Address
+	}
+
+	if user.DisplayName != nil {
+		webauthnUser.DisplayName = *user.DisplayName
+	}
+
+	return webauthnUser
 }
 
 type WebauthnUser struct {
 	UserId              uuid.UUID
 	Email               string
+	DisplayName         string
 	WebauthnCredentials []models.WebauthnCredential
 }
 
@@ -30,12 +35,22 @@ func (u *WebauthnUser) WebAuthnID() []byte {
 	return u.UserId.Bytes()
 }
 
+// WebAuthnName returns the email address, the display name or the user id, whichever is available first.
 func (u *WebauthnUser) WebAuthnName() string {
-	return u.Email
+	if u.Email != "" {
+		return u.Email
+	}
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.UserId.String()
 }
 
 func (u *WebauthnUser) WebAuthnDisplayName() string {
-	return u.Email
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.WebAuthnName()
 }
 
 func (u *WebauthnUser) WebAuthnIcon() string {
@@ -45,6 +60,9 @@ func (u *WebauthnUser) WebAuthnIcon() string {
 func (u *WebauthnUser) WebAuthnCredentials() []webauthn.Credential {
 	var credentials []webauthn.Credential
 	for _, credential := range u.WebauthnCredentials {
+		if credential.IsDisabled() {
+			continue
+		}
 		cred := credential
 		c := WebauthnCredentialFromModel(&cred)
 		credentials = append(credentials, *c)
@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/gofrs/uuid"
+
+// InvitationAcceptRequest contains the token from the invitation link.
+type InvitationAcceptRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type InvitationAcceptResponse struct {
+	UserID uuid.UUID `json:"user_id"`
+}
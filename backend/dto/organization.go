@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"github.com/gofrs/uuid"
+)
+
+// OrganizationResponse is an organization the current user is a member of. Role is the name of the role of the user
+// within the organization and Active is true for the active organization of the session.
+type OrganizationResponse struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Slug   string    `json:"slug"`
+	Role   *string   `json:"role,omitempty"`
+	Active bool      `json:"active"`
+}
+
+// OrganizationSwitchRequest selects the active organization of the session. A missing or null OrganizationID leaves
+// the session without an active organization.
+type OrganizationSwitchRequest struct {
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
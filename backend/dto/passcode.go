@@ -5,11 +5,19 @@ import "time"
 type PasscodeFinishRequest struct {
 	Id   string `json:"id" validate:"required,uuid4"`
 	Code string `json:"code" validate:"required"`
+	// Purpose is optional. The expected purpose is derived from the session and the verification state of the email,
+	// when set, it must match as well.
+	Purpose *string `json:"purpose" validate:"omitempty,oneof=login email_verification signup"`
 }
 
 type PasscodeInitRequest struct {
-	UserId  string  `json:"user_id" validate:"required,uuid4"`
-	EmailId *string `json:"email_id"`
+	UserId string `json:"user_id" validate:"required_without=Username,omitempty,uuid4"`
+	// Username can be used instead of UserId if usernames are enabled.
+	Username *string `json:"username"`
+	EmailId  *string `json:"email_id"`
+	// Purpose is optional. The purpose is derived from the session and the verification state of the email, when set,
+	// it must match the derived purpose.
+	Purpose *string `json:"purpose" validate:"omitempty,oneof=login email_verification signup"`
 }
 
 type PasscodeReturn struct {
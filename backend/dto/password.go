@@ -0,0 +1,9 @@
+package dto
+
+// PasswordPolicyErrorResponse is returned when a new password does not satisfy the password policy. Violations
+// contains a machine-readable code for every requirement that is not met, e.g. "password_too_short".
+type PasswordPolicyErrorResponse struct {
+	Code       int      `json:"code"`
+	Message    string   `json:"message"`
+	Violations []string `json:"violations"`
+}
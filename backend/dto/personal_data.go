@@ -0,0 +1,276 @@
+package dto
+
+import (
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// PersonalDataExportFormatVersion is increased on incompatible changes of the PersonalDataExport format.
+const PersonalDataExportFormatVersion = 2
+
+// PersonalDataExport is the machine-readable archive of all data stored about a user, e.g. to answer requests
+// according to Articles 15 and 20 GDPR. Lists are empty instead of null if there is no data. Secrets like password
+// hashes, passkey public keys and refresh tokens are not part of the export.
+type PersonalDataExport struct {
+	FormatVersion       int                              `json:"format_version"`
+	ExportedAt          time.Time                        `json:"exported_at"`
+	User                PersonalDataUser                 `json:"user"`
+	Emails              []PersonalDataEmail              `json:"emails"`
+	Identities          []PersonalDataIdentity           `json:"identities"`
+	WebauthnCredentials []PersonalDataWebauthnCredential `json:"webauthn_credentials"`
+	// Password is null if the user has no password.
+	Password *PersonalDataPassword `json:"password"`
+	// Sessions are the refresh sessions of the user.
+	Sessions                []PersonalDataSession                `json:"sessions"`
+	Roles                   []PersonalDataRole                   `json:"roles"`
+	OrganizationMemberships []PersonalDataOrganizationMembership `json:"organization_memberships"`
+	EmailChanges            []PersonalDataEmailChange            `json:"email_changes"`
+	AuditLogs               []PersonalDataAuditLog               `json:"audit_logs"`
+}
+
+type PersonalDataUser struct {
+	ID       uuid.UUID `json:"id"`
+	Username *string   `json:"username,omitempty"`
+	UserProfile
+	PublicMetadata slices.Map `json:"public_metadata,omitempty"`
+	// PrivateMetadata is only contained in exports created via the admin API.
+	PrivateMetadata slices.Map `json:"private_metadata,omitempty"`
+	UnsafeMetadata  slices.Map `json:"unsafe_metadata,omitempty"`
+	BlockedAt       *time.Time `json:"blocked_at,omitempty"`
+	BlockedUntil    *time.Time `json:"blocked_until,omitempty"`
+	BlockedReason   *string    `json:"blocked_reason,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type PersonalDataEmail struct {
+	ID         uuid.UUID `json:"id"`
+	Address    string    `json:"address"`
+	IsVerified bool      `json:"is_verified"`
+	IsPrimary  bool      `json:"is_primary"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PersonalDataIdentity is an account of the user at a third party provider. Data contains the user data as received
+// from the provider on the last login.
+type PersonalDataIdentity struct {
+	ID           uuid.UUID  `json:"id"`
+	ProviderID   string     `json:"provider_id"`
+	ProviderName string     `json:"provider_name"`
+	EmailID      uuid.UUID  `json:"email_id"`
+	Data         slices.Map `json:"data"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type PersonalDataWebauthnCredential struct {
+	ID                string     `json:"id"`
+	Name              *string    `json:"name,omitempty"`
+	AttestationType   string     `json:"attestation_type"`
+	AttestationFormat *string    `json:"attestation_format,omitempty"`
+	AAGUID            uuid.UUID  `json:"aaguid"`
+	Transports        []string   `json:"transports"`
+	BackupEligible    bool       `json:"backup_eligible"`
+	BackupState       bool       `json:"backup_state"`
+	SuspectedClone    bool       `json:"suspected_clone"`
+	DisabledAt        *time.Time `json:"disabled_at,omitempty"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type PersonalDataPassword struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PersonalDataSession is a refresh session. UpdatedAt is the time of the last exchange of its refresh token.
+type PersonalDataSession struct {
+	Used      bool      `json:"used"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PersonalDataRole is a role assigned to the user.
+type PersonalDataRole struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// PersonalDataOrganizationMembership is a membership of the user in an organization. Role is the name of the role of
+// the user within the organization.
+type PersonalDataOrganizationMembership struct {
+	OrganizationID   uuid.UUID `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	OrganizationSlug string    `json:"organization_slug"`
+	Role             *string   `json:"role,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PersonalDataEmailChange is a change of the primary email address. The revert token is not part of the export.
+type PersonalDataEmailChange struct {
+	ID              uuid.UUID  `json:"id"`
+	OldAddress      string     `json:"old_address"`
+	NewEmailID      *uuid.UUID `json:"new_email_id,omitempty"`
+	RevertExpiresAt time.Time  `json:"revert_expires_at"`
+	RevertedAt      *time.Time `json:"reverted_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// PersonalDataAuditLog is an audit log entry of an action performed by or on the user.
+type PersonalDataAuditLog struct {
+	ID                uuid.UUID `json:"id"`
+	Type              string    `json:"type"`
+	Error             *string   `json:"error,omitempty"`
+	ActorType         string    `json:"actor_type"`
+	MetaHttpRequestId string    `json:"meta_http_request_id"`
+	MetaSourceIp      string    `json:"meta_source_ip"`
+	MetaUserAgent     string    `json:"meta_user_agent"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// FromPersonalDataModels converts the DB models to a PersonalDataExport. The memberships must contain their
+// organization, roleNames maps the IDs of the roles within the organizations to their names. Private metadata is only
+// included if includePrivateMetadata is set.
+func FromPersonalDataModels(user models.User, credentials []models.WebauthnCredential, password *models.PasswordCredential, sessions []models.Session, roles models.Roles, memberships []models.OrganizationMembership, roleNames map[uuid.UUID]string, emailChanges []models.EmailChange, auditLogs []models.AuditLog, includePrivateMetadata bool) PersonalDataExport {
+	export := PersonalDataExport{
+		FormatVersion: PersonalDataExportFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		User: PersonalDataUser{
+			ID:             user.ID,
+			Username:       user.Username,
+			UserProfile:    FromUserProfileModel(user),
+			PublicMetadata: user.PublicMetadata,
+			UnsafeMetadata: user.UnsafeMetadata,
+			BlockedAt:      user.BlockedAt,
+			BlockedUntil:   user.BlockedUntil,
+			BlockedReason:  user.BlockedReason,
+			DeletedAt:      user.DeletedAt,
+			CreatedAt:      user.CreatedAt,
+			UpdatedAt:      user.UpdatedAt,
+		},
+		Emails:                  make([]PersonalDataEmail, len(user.Emails)),
+		Identities:              []PersonalDataIdentity{},
+		WebauthnCredentials:     make([]PersonalDataWebauthnCredential, len(credentials)),
+		Sessions:                make([]PersonalDataSession, len(sessions)),
+		Roles:                   make([]PersonalDataRole, len(roles)),
+		OrganizationMemberships: []PersonalDataOrganizationMembership{},
+		EmailChanges:            make([]PersonalDataEmailChange, len(emailChanges)),
+		AuditLogs:               make([]PersonalDataAuditLog, len(auditLogs)),
+	}
+	if includePrivateMetadata {
+		export.User.PrivateMetadata = user.PrivateMetadata
+	}
+
+	for i, email := range user.Emails {
+		export.Emails[i] = PersonalDataEmail{
+			ID:         email.ID,
+			Address:    email.Address,
+			IsVerified: email.Verified,
+			IsPrimary:  email.IsPrimary(),
+			CreatedAt:  email.CreatedAt,
+			UpdatedAt:  email.UpdatedAt,
+		}
+		if identity := email.Identity; identity != nil {
+			export.Identities = append(export.Identities, PersonalDataIdentity{
+				ID:           identity.ID,
+				ProviderID:   identity.ProviderID,
+				ProviderName: identity.ProviderName,
+				EmailID:      identity.EmailID,
+				Data:         identity.Data,
+				CreatedAt:    identity.CreatedAt,
+				UpdatedAt:    identity.UpdatedAt,
+			})
+		}
+	}
+
+	for i, credential := range credentials {
+		export.WebauthnCredentials[i] = PersonalDataWebauthnCredential{
+			ID:                credential.ID,
+			Name:              credential.Name,
+			AttestationType:   credential.AttestationType,
+			AttestationFormat: credential.AttestationFormat,
+			AAGUID:            credential.AAGUID,
+			Transports:        credential.Transports.GetNames(),
+			BackupEligible:    credential.BackupEligible,
+			BackupState:       credential.BackupState,
+			SuspectedClone:    credential.SuspectedClone,
+			DisabledAt:        credential.DisabledAt,
+			LastUsedAt:        credential.LastUsedAt,
+			CreatedAt:         credential.CreatedAt,
+			UpdatedAt:         credential.UpdatedAt,
+		}
+	}
+
+	if password != nil {
+		export.Password = &PersonalDataPassword{
+			CreatedAt: password.CreatedAt,
+			UpdatedAt: password.UpdatedAt,
+		}
+	}
+
+	for i, session := range sessions {
+		export.Sessions[i] = PersonalDataSession{
+			Used:      session.Used,
+			CreatedAt: session.CreatedAt,
+			UpdatedAt: session.UpdatedAt,
+		}
+	}
+
+	for i, role := range roles {
+		export.Roles[i] = PersonalDataRole{
+			Name:        role.Name,
+			Permissions: models.Roles{role}.GetPermissionNames(),
+		}
+	}
+
+	for _, membership := range memberships {
+		if membership.Organization == nil {
+			continue
+		}
+		exportedMembership := PersonalDataOrganizationMembership{
+			OrganizationID:   membership.Organization.ID,
+			OrganizationName: membership.Organization.Name,
+			OrganizationSlug: membership.Organization.Slug,
+			CreatedAt:        membership.CreatedAt,
+			UpdatedAt:        membership.UpdatedAt,
+		}
+		if membership.RoleID != nil {
+			if name, ok := roleNames[*membership.RoleID]; ok {
+				exportedMembership.Role = &name
+			}
+		}
+		export.OrganizationMemberships = append(export.OrganizationMemberships, exportedMembership)
+	}
+
+	for i, emailChange := range emailChanges {
+		export.EmailChanges[i] = PersonalDataEmailChange{
+			ID:              emailChange.ID,
+			OldAddress:      emailChange.OldAddress,
+			NewEmailID:      emailChange.NewEmailID,
+			RevertExpiresAt: emailChange.RevertExpiresAt,
+			RevertedAt:      emailChange.RevertedAt,
+			CreatedAt:       emailChange.CreatedAt,
+		}
+	}
+
+	for i, auditLog := range auditLogs {
+		export.AuditLogs[i] = PersonalDataAuditLog{
+			ID:                auditLog.ID,
+			Type:              string(auditLog.Type),
+			Error:             auditLog.Error,
+			ActorType:         string(auditLog.ActorType),
+			MetaHttpRequestId: auditLog.MetaHttpRequestId,
+			MetaSourceIp:      auditLog.MetaSourceIp,
+			MetaUserAgent:     auditLog.MetaUserAgent,
+			CreatedAt:         auditLog.CreatedAt,
+		}
+	}
+
+	return export
+}
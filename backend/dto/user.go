@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"github.com/gobuffalo/pop/v6/slices"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"time"
@@ -15,14 +16,69 @@ type CreateUserResponse struct {
 type GetUserResponse struct {
 	ID                  uuid.UUID                   `json:"id"`
 	Email               *string                     `json:"email,omitempty"`
+	Username            *string                     `json:"username,omitempty"`
 	WebauthnCredentials []models.WebauthnCredential `json:"webauthn_credentials"` // deprecated
 	UpdatedAt           time.Time                   `json:"updated_at"`
 	CreatedAt           time.Time                   `json:"created_at"`
 }
 
 type UserInfoResponse struct {
-	ID                    uuid.UUID `json:"id"`
-	EmailID               uuid.UUID `json:"email_id"`
-	Verified              bool      `json:"verified"`
-	HasWebauthnCredential bool      `json:"has_webauthn_credential"`
+	ID uuid.UUID `json:"id"`
+	// EmailID is not set when a user without an email address has been looked up by username.
+	EmailID               *uuid.UUID `json:"email_id,omitempty"`
+	Verified              bool       `json:"verified"`
+	HasWebauthnCredential bool       `json:"has_webauthn_credential"`
+}
+
+type UsernameSetRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+// UsernamePolicyErrorResponse is returned when a username does not satisfy the configured rules. Violations contains
+// a machine-readable code for every rule that is not met, e.g. "username_too_short".
+type UsernamePolicyErrorResponse struct {
+	Code       int      `json:"code"`
+	Message    string   `json:"message"`
+	Violations []string `json:"violations"`
+}
+
+type MeResponse struct {
+	ID             string     `json:"id"`
+	PublicMetadata slices.Map `json:"public_metadata,omitempty"`
+	UserProfile
+}
+
+// UserProfile contains the profile of a user. Fields without a value are omitted.
+type UserProfile struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	GivenName   *string `json:"given_name,omitempty"`
+	FamilyName  *string `json:"family_name,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
+}
+
+func FromUserProfileModel(model models.User) UserProfile {
+	return UserProfile{
+		DisplayName: model.DisplayName,
+		GivenName:   model.GivenName,
+		FamilyName:  model.FamilyName,
+		AvatarURL:   model.AvatarURL,
+		Locale:      model.Locale,
+	}
+}
+
+// UserProfileUpdateRequest changes the profile of the current user. Fields which are not present remain unchanged,
+// an empty string removes the value of a field.
+type UserProfileUpdateRequest struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	GivenName   *string `json:"given_name" validate:"omitempty,max=100"`
+	FamilyName  *string `json:"family_name" validate:"omitempty,max=100"`
+	AvatarURL   *string `json:"avatar_url" validate:"omitempty,http_url|len=0,max=2048"`
+	Locale      *string `json:"locale" validate:"omitempty,bcp47_language_tag|len=0"`
+}
+
+// UserMetadataResponse contains the metadata readable by the user. Private metadata is only available to admins.
+type UserMetadataResponse struct {
+	PublicMetadata slices.Map `json:"public_metadata"`
+	UnsafeMetadata slices.Map `json:"unsafe_metadata"`
 }
@@ -38,16 +38,20 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 			vErrs := make([]string, len(fieldErrors))
 			for i, err := range fieldErrors {
 				switch err.Tag() {
-				case "required":
+				case "required", "required_without":
 					vErrs[i] = fmt.Sprintf("%s is a required field", err.Field())
 				case "email":
 					vErrs[i] = fmt.Sprintf("%s must be a valid email address", err.Field())
 				case "uuid4":
 					vErrs[i] = fmt.Sprintf("%s must be a valid uuid4", err.Field())
-				case "url":
+				case "url", "http_url|len=0":
 					vErrs[i] = fmt.Sprintf("%s must be a valid URL", err.Field())
+				case "bcp47_language_tag", "bcp47_language_tag|len=0":
+					vErrs[i] = fmt.Sprintf("%s must be a valid BCP 47 language tag", err.Field())
 				case "gte":
 					vErrs[i] = fmt.Sprintf("length of %s must be greater or equal to %v", err.Field(), err.Param())
+				case "max":
+					vErrs[i] = fmt.Sprintf("length of %s must be lower or equal to %v", err.Field(), err.Param())
 				case "unique":
 					vErrs[i] = fmt.Sprintf("%s entries are not unique", err.Field())
 				default:
@@ -6,6 +6,11 @@ import (
 	"time"
 )
 
+// WebauthnRelatedOrigins is the content of /.well-known/webauthn, see https://w3c.github.io/webauthn/#sctn-related-origins
+type WebauthnRelatedOrigins struct {
+	Origins []string `json:"origins"`
+}
+
 type WebauthnCredentialUpdateRequest struct {
 	Name *string `json:"name"`
 }
@@ -21,20 +26,36 @@ type WebauthnCredentialResponse struct {
 	Transports      []string   `json:"transports"`
 	BackupEligible  bool       `json:"backup_eligible"`
 	BackupState     bool       `json:"backup_state"`
+	PrfSupported    bool       `json:"prf_supported"`
+	// AttestationFormat and AttestationTrust are only set for credentials registered with attestation evaluation.
+	AttestationFormat *string `json:"attestation_format,omitempty"`
+	AttestationTrust  *string `json:"attestation_trust,omitempty"`
+	// Authenticator is only set if the FIDO metadata service is enabled and contains the authenticator.
+	Authenticator *AuthenticatorMetadata `json:"authenticator,omitempty"`
+}
+
+// AuthenticatorMetadata describes an authenticator model as listed in the FIDO metadata service.
+type AuthenticatorMetadata struct {
+	Description         string `json:"description"`
+	Icon                string `json:"icon,omitempty"`
+	CertificationStatus string `json:"certification_status,omitempty"`
 }
 
 // FromWebauthnCredentialModel Converts the DB model to a DTO object
 func FromWebauthnCredentialModel(c *models.WebauthnCredential) *WebauthnCredentialResponse {
 	return &WebauthnCredentialResponse{
-		ID:              c.ID,
-		Name:            c.Name,
-		PublicKey:       c.PublicKey,
-		AttestationType: c.AttestationType,
-		AAGUID:          c.AAGUID,
-		LastUsedAt:      c.LastUsedAt,
-		CreatedAt:       c.CreatedAt,
-		Transports:      c.Transports.GetNames(),
-		BackupEligible:  c.BackupEligible,
-		BackupState:     c.BackupState,
+		ID:                c.ID,
+		Name:              c.Name,
+		PublicKey:         c.PublicKey,
+		AttestationType:   c.AttestationType,
+		AAGUID:            c.AAGUID,
+		LastUsedAt:        c.LastUsedAt,
+		CreatedAt:         c.CreatedAt,
+		Transports:        c.Transports.GetNames(),
+		BackupEligible:    c.BackupEligible,
+		BackupState:       c.BackupState,
+		PrfSupported:      c.PrfSupported,
+		AttestationFormat: c.AttestationFormat,
+		AttestationTrust:  c.AttestationTrust,
 	}
 }
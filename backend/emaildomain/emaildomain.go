@@ -0,0 +1,98 @@
+package emaildomain
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/gobwas/glob"
+	"github.com/teamhanko/hanko/backend/config"
+	"os"
+	"strings"
+)
+
+//go:embed disposable_domains.txt
+var bundledDisposableDomains string
+
+// Checker decides whether an email address may be used based on the domain restrictions of the configuration.
+type Checker struct {
+	cfg        config.EmailDomains
+	disposable map[string]struct{}
+}
+
+func NewChecker(cfg config.EmailDomains) (*Checker, error) {
+	checker := &Checker{cfg: cfg}
+	if !cfg.BlockDisposable {
+		return checker, nil
+	}
+
+	list := bundledDisposableDomains
+	if cfg.DisposableListFile != "" {
+		b, err := os.ReadFile(cfg.DisposableListFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read disposable email domain list: %w", err)
+		}
+		list = string(b)
+	}
+	checker.disposable = parseDomainList(list)
+
+	return checker, nil
+}
+
+// Allowed reports whether the domain of the email address passes the denylist, the allowlist and, if enabled, the
+// disposable domain check.
+func (c *Checker) Allowed(address string) bool {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(address[at+1:])
+
+	if matchesAny(c.cfg.DenylistGlobs, domain) {
+		return false
+	}
+
+	if len(c.cfg.AllowlistGlobs) > 0 && !matchesAny(c.cfg.AllowlistGlobs, domain) {
+		return false
+	}
+
+	return !c.isDisposable(domain)
+}
+
+// isDisposable returns true if the domain or one of its parent domains is a disposable email domain.
+func (c *Checker) isDisposable(domain string) bool {
+	for domain != "" {
+		if _, ok := c.disposable[domain]; ok {
+			return true
+		}
+
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+
+	return false
+}
+
+func matchesAny(globs []glob.Glob, domain string) bool {
+	for _, g := range globs {
+		if g.Match(domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseDomainList(list string) map[string]struct{} {
+	domains := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[line] = struct{}{}
+	}
+
+	return domains
+}
@@ -0,0 +1,60 @@
+package emaildomain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newChecker(t *testing.T, cfg config.EmailDomains) *Checker {
+	require.NoError(t, cfg.PostProcess())
+	checker, err := NewChecker(cfg)
+	require.NoError(t, err)
+	return checker
+}
+
+func TestChecker_Allowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.EmailDomains
+		address string
+		want    bool
+	}{
+		{name: "no restrictions", cfg: config.EmailDomains{}, address: "john.doe@mailinator.com", want: true},
+		{name: "allowlist match", cfg: config.EmailDomains{Allowlist: []string{"example.com"}}, address: "john.doe@example.com", want: true},
+		{name: "allowlist match is case insensitive", cfg: config.EmailDomains{Allowlist: []string{"Example.com"}}, address: "john.doe@EXAMPLE.COM", want: true},
+		{name: "allowlist mismatch", cfg: config.EmailDomains{Allowlist: []string{"example.com"}}, address: "john.doe@example.org", want: false},
+		{name: "allowlist single subdomain glob", cfg: config.EmailDomains{Allowlist: []string{"*.example.com"}}, address: "john.doe@mail.example.com", want: true},
+		{name: "allowlist single subdomain glob does not match nested subdomains", cfg: config.EmailDomains{Allowlist: []string{"*.example.com"}}, address: "john.doe@a.mail.example.com", want: false},
+		{name: "allowlist super glob matches nested subdomains", cfg: config.EmailDomains{Allowlist: []string{"**.example.com"}}, address: "john.doe@a.mail.example.com", want: true},
+		{name: "denylist match", cfg: config.EmailDomains{Denylist: []string{"example.org"}}, address: "john.doe@example.org", want: false},
+		{name: "denylist takes precedence", cfg: config.EmailDomains{Allowlist: []string{"*.example.com"}, Denylist: []string{"spam.example.com"}}, address: "john.doe@spam.example.com", want: false},
+		{name: "disposable domain", cfg: config.EmailDomains{BlockDisposable: true}, address: "john.doe@mailinator.com", want: false},
+		{name: "subdomain of disposable domain", cfg: config.EmailDomains{BlockDisposable: true}, address: "john.doe@team.mailinator.com", want: false},
+		{name: "regular domain with disposable check", cfg: config.EmailDomains{BlockDisposable: true}, address: "john.doe@example.com", want: true},
+		{name: "missing domain", cfg: config.EmailDomains{}, address: "john.doe", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newChecker(t, tt.cfg).Allowed(tt.address))
+		})
+	}
+}
+
+func TestNewChecker_DisposableListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# custom list\n\nthrowaway.test\n"), 0600))
+
+	checker := newChecker(t, config.EmailDomains{BlockDisposable: true, DisposableListFile: path})
+
+	assert.False(t, checker.Allowed("john.doe@throwaway.test"))
+	// the file replaces the bundled list
+	assert.True(t, checker.Allowed("john.doe@mailinator.com"))
+
+	_, err := NewChecker(config.EmailDomains{BlockDisposable: true, DisposableListFile: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+}
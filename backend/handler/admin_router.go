@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/labstack/echo-contrib/echoprometheus"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
+	"github.com/teamhanko/hanko/backend/invitation"
+	"github.com/teamhanko/hanko/backend/lockout"
+	"github.com/teamhanko/hanko/backend/mail"
 	hankoMiddleware "github.com/teamhanko/hanko/backend/middleware"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/template"
@@ -30,6 +36,7 @@ func NewAdminRouter(cfg *config.Config, persister persistence.Persister, prometh
 	}
 
 	e.Validator = dto.NewCustomValidator()
+	e.Use(auditlog.AdminActor())
 
 	if prometheus != nil {
 		e.Use(prometheus)
@@ -46,13 +53,103 @@ func NewAdminRouter(cfg *config.Config, persister persistence.Persister, prometh
 	health.GET("/alive", healthHandler.Alive)
 	health.GET("/ready", healthHandler.Ready)
 
-	userHandler := NewUserHandlerAdmin(persister)
+	mailer, err := mail.NewMailer(cfg.Passcode.Smtp)
+	if err != nil {
+		panic(fmt.Errorf("failed to create mailer: %w", err))
+	}
+
+	auditLogger := auditlog.NewLogger(persister, cfg.AuditLog)
+
+	lockoutManager, err := lockout.NewManager(cfg, persister, mailer, auditLogger)
+	if err != nil {
+		panic(fmt.Errorf("failed to create lockout manager: %w", err))
+	}
+
+	emailDomainChecker, err := emaildomain.NewChecker(cfg.Emails.Domains)
+	if err != nil {
+		panic(fmt.Errorf("failed to create email domain checker: %w", err))
+	}
+
+	userHandler := NewUserHandlerAdmin(cfg, persister, lockoutManager, auditLogger, emailDomainChecker)
 
 	user := g.Group("/users")
 	user.GET("", userHandler.List)
 	user.POST("", userHandler.Create)
 	user.GET("/:id", userHandler.Get)
 	user.DELETE("/:id", userHandler.Delete)
+	user.POST("/:id/unlock", userHandler.Unlock)
+	user.POST("/:id/block", userHandler.Block)
+	user.POST("/:id/unblock", userHandler.Unblock)
+	user.POST("/:id/restore", userHandler.Restore)
+	user.GET("/:id/export", userHandler.ExportPersonalData)
+
+	webauthnCredentialHandler := NewWebauthnCredentialHandlerAdmin(persister, auditLogger)
+
+	webauthnCredentials := user.Group("/:id/webauthn_credentials")
+	webauthnCredentials.GET("", webauthnCredentialHandler.List)
+	webauthnCredentials.DELETE("", webauthnCredentialHandler.DeleteAll)
+	webauthnCredentials.PATCH("/:credential_id", webauthnCredentialHandler.Update)
+	webauthnCredentials.DELETE("/:credential_id", webauthnCredentialHandler.Delete)
+
+	userMetadataHandler := NewUserMetadataHandlerAdmin(cfg, persister, auditLogger)
+
+	userMetadata := user.Group("/:id/metadata")
+	userMetadata.GET("", userMetadataHandler.Get)
+	userMetadata.PATCH("", userMetadataHandler.Patch)
+
+	roleHandler := NewRoleHandlerAdmin(persister, auditLogger)
+
+	roles := g.Group("/roles")
+	roles.GET("", roleHandler.List)
+	roles.POST("", roleHandler.Create)
+	roles.GET("/:id", roleHandler.Get)
+	roles.PATCH("/:id", roleHandler.Update)
+	roles.DELETE("/:id", roleHandler.Delete)
+
+	userRoles := user.Group("/:id/roles")
+	userRoles.GET("", roleHandler.ListUserRoles)
+	userRoles.PUT("/:role_id", roleHandler.AssignUserRole)
+	userRoles.DELETE("/:role_id", roleHandler.UnassignUserRole)
+
+	permissionHandler := NewPermissionHandlerAdmin(persister, auditLogger)
+
+	permissions := g.Group("/permissions")
+	permissions.GET("", permissionHandler.List)
+	permissions.POST("", permissionHandler.Create)
+	permissions.GET("/:id", permissionHandler.Get)
+	permissions.PATCH("/:id", permissionHandler.Update)
+	permissions.DELETE("/:id", permissionHandler.Delete)
+
+	organizationHandler := NewOrganizationHandlerAdmin(persister, auditLogger)
+
+	organizations := g.Group("/organizations")
+	organizations.GET("", organizationHandler.List)
+	organizations.POST("", organizationHandler.Create)
+	organizations.GET("/:id", organizationHandler.Get)
+	organizations.PATCH("/:id", organizationHandler.Update)
+	organizations.DELETE("/:id", organizationHandler.Delete)
+
+	organizationMembers := organizations.Group("/:id/members")
+	organizationMembers.GET("", organizationHandler.ListMembers)
+	organizationMembers.POST("", organizationHandler.AddMember)
+	organizationMembers.PATCH("/:user_id", organizationHandler.UpdateMember)
+	organizationMembers.DELETE("/:user_id", organizationHandler.RemoveMember)
+
+	if cfg.Account.Invitations.Enabled {
+		invitationManager, err := invitation.NewManager(cfg, persister, mailer)
+		if err != nil {
+			panic(fmt.Errorf("failed to create invitation manager: %w", err))
+		}
+
+		invitationHandler := NewInvitationHandlerAdmin(cfg, persister, invitationManager, auditLogger, emailDomainChecker)
+
+		invitations := g.Group("/invitations")
+		invitations.GET("", invitationHandler.List)
+		invitations.POST("", invitationHandler.Create)
+		invitations.GET("/:id", invitationHandler.Get)
+		invitations.DELETE("/:id", invitationHandler.Delete)
+		invitations.POST("/:id/resend", invitationHandler.Resend)
+	}
 
 	auditLogHandler := NewAuditLogHandler(persister)
 
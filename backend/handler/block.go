@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+	"time"
+)
+
+// checkBlocked rejects the login attempt of a blocked or soft deleted user. Like lockout.Manager.Check, the rejection
+// is audited with failureLogType and returned separately from internal errors, so that the caller can commit its
+// transaction anyway.
+func checkBlocked(tx *pop.Connection, c echo.Context, auditLogger auditlog.Logger, user *models.User, failureLogType models.AuditLogType) (*echo.HTTPError, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	if user.IsDeleted() {
+		err := auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("user deleted"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(fmt.Errorf("user deleted")), nil
+	}
+
+	if !user.IsBlocked(time.Now().UTC()) {
+		return nil, nil
+	}
+
+	err := auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("user blocked"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return echo.NewHTTPError(http.StatusForbidden, "user is blocked"), nil
+}
@@ -10,6 +10,7 @@ import (
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
@@ -18,18 +19,20 @@ import (
 )
 
 type EmailHandler struct {
-	persister      persistence.Persister
-	cfg            *config.Config
-	sessionManager session.Manager
-	auditLogger    auditlog.Logger
+	persister          persistence.Persister
+	cfg                *config.Config
+	sessionManager     session.Manager
+	auditLogger        auditlog.Logger
+	emailDomainChecker *emaildomain.Checker
 }
 
-func NewEmailHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger) (*EmailHandler, error) {
+func NewEmailHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, emailDomainChecker *emaildomain.Checker) (*EmailHandler, error) {
 	return &EmailHandler{
-		persister:      persister,
-		cfg:            cfg,
-		sessionManager: sessionManager,
-		auditLogger:    auditLogger,
+		persister:          persister,
+		cfg:                cfg,
+		sessionManager:     sessionManager,
+		auditLogger:        auditLogger,
+		emailDomainChecker: emailDomainChecker,
 	}, nil
 }
 
@@ -87,6 +90,10 @@ func (h *EmailHandler) Create(c echo.Context) error {
 
 	newEmailAddress := strings.ToLower(body.Address)
 
+	if !h.emailDomainChecker.Allowed(newEmailAddress) {
+		return newEmailDomainNotAllowedError(newEmailAddress)
+	}
+
 	email, err := h.persister.GetEmailPersister().FindByAddress(newEmailAddress)
 	if err != nil {
 		return fmt.Errorf("failed to fetch email from db: %w", err)
@@ -242,3 +249,9 @@ func (h *EmailHandler) Delete(c echo.Context) error {
 		return c.NoContent(http.StatusNoContent)
 	})
 }
+
+// newEmailDomainNotAllowedError is returned when the email domain settings reject an email address. It uses a status
+// code of its own, so that clients can tell it apart from invalid or already existing email addresses.
+func newEmailDomainNotAllowedError(address string) *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusUnprocessableEntity, "email domain is not allowed").SetInternal(fmt.Errorf("email domain of '%s' is not allowed", address))
+}
@@ -0,0 +1,493 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
+	"github.com/teamhanko/hanko/backend/mail"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rate_limiter"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EmailChangeHandler replaces the primary email address of the signed in user with a new address in one step, after
+// the user proved access to the new address with an email change passcode. The old address is notified about the
+// change and receives a link, which allows to revert the change within the configured period.
+type EmailChangeHandler struct {
+	passcodeHandler    *PasscodeHandler
+	persister          persistence.Persister
+	emailDomainChecker *emaildomain.Checker
+	cfg                *config.Config
+	auditLogger        auditlog.Logger
+}
+
+func NewEmailChangeHandler(passcodeHandler *PasscodeHandler, persister persistence.Persister, emailDomainChecker *emaildomain.Checker, cfg *config.Config, auditLogger auditlog.Logger) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		passcodeHandler:    passcodeHandler,
+		persister:          persister,
+		emailDomainChecker: emailDomainChecker,
+		cfg:                cfg,
+		auditLogger:        auditLogger,
+	}
+}
+
+// Init sends an email change passcode to the new email address. The address is only assigned to the user when the
+// passcode has been redeemed with Finish.
+func (h *EmailChangeHandler) Init(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("failed to cast session object")
+	}
+
+	userId, err := uuid.FromString(sessionToken.Subject())
+	if err != nil {
+		return fmt.Errorf("failed to parse subject as uuid: %w", err)
+	}
+
+	var body dto.EmailChangeInitRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
+	}
+
+	newEmailAddress := strings.ToLower(body.Address)
+
+	if primaryEmail := user.Emails.GetPrimary(); primaryEmail != nil && primaryEmail.Address == newEmailAddress {
+		return echo.NewHTTPError(http.StatusBadRequest, "email address is already the primary email address")
+	}
+
+	if !h.emailDomainChecker.Allowed(newEmailAddress) {
+		err = h.auditLogger.Create(c, models.AuditLogEmailChangeInitFailed, user, fmt.Errorf("email domain not allowed"))
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return newEmailDomainNotAllowedError(newEmailAddress)
+	}
+
+	email, err := h.persister.GetEmailPersister().FindByAddress(newEmailAddress)
+	if err != nil {
+		return fmt.Errorf("failed to get email: %w", err)
+	}
+
+	if email != nil && email.UserID != nil && *email.UserID != user.ID {
+		err = h.auditLogger.Create(c, models.AuditLogEmailChangeInitFailed, user, fmt.Errorf("email address already exists"))
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("email address already exists"))
+	}
+
+	if h.passcodeHandler.rateLimiter != nil {
+		err := rate_limiter.Limit(h.passcodeHandler.rateLimiter, userId, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	if email == nil {
+		// The email address will be assigned to the user only after passcode verification.
+		email = models.NewEmail(nil, newEmailAddress)
+		err = h.persister.GetEmailPersister().Create(*email)
+		if err != nil {
+			return fmt.Errorf("failed to store email: %w", err)
+		}
+	}
+
+	passcode, err := h.passcodeHandler.sendPasscode(c, user, email, models.PasscodePurposeEmailChange, models.AuditLogEmailChangeInitFailed)
+	if err != nil {
+		return err
+	}
+
+	err = h.auditLogger.Create(c, models.AuditLogEmailChangeInitSucceeded, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, dto.PasscodeReturn{
+		Id:        passcode.ID.String(),
+		TTL:       passcode.Ttl,
+		CreatedAt: passcode.CreatedAt,
+	})
+}
+
+// Finish redeems the email change passcode, makes the new email address the primary one and optionally deletes the old
+// primary email address. Afterwards a notification with a revert link is sent to the old address.
+func (h *EmailChangeHandler) Finish(c echo.Context) error {
+	startTime := time.Now().UTC()
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("failed to cast session object")
+	}
+
+	userId, err := uuid.FromString(sessionToken.Subject())
+	if err != nil {
+		return fmt.Errorf("failed to parse subject as uuid: %w", err)
+	}
+
+	var body dto.EmailChangeFinishRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	passcodeId, err := uuid.FromString(body.Id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse passcodeId as uuid").SetInternal(err)
+	}
+
+	// only if an internal server error occurs the transaction should be rolled back
+	var businessError error
+	transactionError := h.persister.Transaction(func(tx *pop.Connection) error {
+		emailPersister := h.persister.GetEmailPersisterWithConnection(tx)
+		primaryEmailPersister := h.persister.GetPrimaryEmailPersisterWithConnection(tx)
+
+		// the new email address is checked before the passcode is redeemed, so that a rejected change does not consume
+		// the passcode
+		rejection, err := h.checkNewEmail(tx, c, userId, passcodeId, body.RemoveOldEmail)
+		if err != nil {
+			return err
+		}
+		if rejection != nil {
+			businessError = rejection
+			return nil
+		}
+
+		passcode, user, redeemError, err := h.passcodeHandler.redeemPasscode(tx, c, passcodeId, body.Code, startTime, models.AuditLogEmailChangeFinalFailed, func(passcode *models.Passcode) bool {
+			// the passcode must have been issued to the signed in user
+			return passcode.Purpose == models.PasscodePurposeEmailChange && passcode.UserId == userId
+		})
+		if err != nil {
+			return err
+		}
+		if redeemError != nil {
+			businessError = redeemError
+			return nil
+		}
+
+		newEmail := passcode.Email
+		oldEmail := user.Emails.GetPrimary()
+
+		newEmail.Verified = true
+		newEmail.UserID = &user.ID
+		err = emailPersister.Update(newEmail)
+		if err != nil {
+			return fmt.Errorf("failed to update email: %w", err)
+		}
+
+		var primaryEmail *models.PrimaryEmail
+		if oldEmail != nil {
+			primaryEmail = oldEmail.PrimaryEmail
+			primaryEmail.EmailID = newEmail.ID
+			primaryEmail.UpdatedAt = time.Now().UTC()
+			err = primaryEmailPersister.Update(*primaryEmail)
+			if err != nil {
+				return fmt.Errorf("failed to change primary email: %w", err)
+			}
+		} else {
+			primaryEmail = models.NewPrimaryEmail(newEmail.ID, user.ID)
+			err = primaryEmailPersister.Create(*primaryEmail)
+			if err != nil {
+				return fmt.Errorf("failed to store primary email: %w", err)
+			}
+		}
+		newEmail.PrimaryEmail = primaryEmail
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPrimaryEmailChanged, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		if oldEmail != nil {
+			if body.RemoveOldEmail {
+				err = emailPersister.Delete(*oldEmail)
+				if err != nil {
+					return fmt.Errorf("failed to delete old email: %w", err)
+				}
+
+				err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailDeleted, user, nil)
+				if err != nil {
+					return fmt.Errorf("failed to create audit log: %w", err)
+				}
+			}
+
+			err = h.notifyOldAddress(tx, c, user, oldEmail.Address, newEmail.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailChangeFinalSucceeded, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, dto.FromEmailModel(&newEmail))
+	})
+
+	if businessError != nil {
+		return businessError
+	}
+
+	return transactionError
+}
+
+// Revert restores the old email address as the primary email address, deletes the emails added since the change and
+// signs out all sessions of the user, because the change might have been made by someone who took over the account.
+
+// checkNewEmail rejects an email change if the new email address of the passcode has been claimed by another user in
+// the meantime, is already the primary email address or would exceed the maximum number of email addresses. Unknown
+// passcodes and passcodes of other users or purposes are left to redeemPasscode.
+func (h *EmailChangeHandler) checkNewEmail(tx *pop.Connection, c echo.Context, userId uuid.UUID, passcodeId uuid.UUID, removeOldEmail bool) (*echo.HTTPError, error) {
+	passcode, err := h.persister.GetPasscodePersisterWithConnection(tx).Get(passcodeId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passcode: %w", err)
+	}
+	if passcode == nil || passcode.Purpose != models.PasscodePurposeEmailChange || passcode.UserId != userId {
+		return nil, nil
+	}
+
+	user, err := h.persister.GetUserPersisterWithConnection(tx).Get(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	newEmail := passcode.Email
+	if newEmail.UserID != nil && *newEmail.UserID != user.ID {
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailChangeFinalFailed, user, fmt.Errorf("email address has been claimed by another user"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusForbidden, "email address has been claimed by another user"), nil
+	}
+
+	oldEmail := user.Emails.GetPrimary()
+	if oldEmail != nil && oldEmail.ID == newEmail.ID {
+		return echo.NewHTTPError(http.StatusBadRequest, "email address is already the primary email address"), nil
+	}
+
+	// the new email address only counts towards the maximum if it is not assigned yet and does not replace the old one
+	if newEmail.UserID == nil && !(removeOldEmail && oldEmail != nil) && len(user.Emails) >= h.cfg.Emails.MaxNumOfAddresses {
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailChangeFinalFailed, user, fmt.Errorf("max number of email addresses reached"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusConflict).SetInternal(errors.New("max number of email addresses reached")), nil
+	}
+
+	return nil, nil
+}
+func (h *EmailChangeHandler) Revert(c echo.Context) error {
+	var body dto.EmailChangeRevertRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	// only if an internal server error occurs the transaction should be rolled back
+	var businessError error
+	transactionError := h.persister.Transaction(func(tx *pop.Connection) error {
+		emailChangePersister := h.persister.GetEmailChangePersisterWithConnection(tx)
+		emailPersister := h.persister.GetEmailPersisterWithConnection(tx)
+		primaryEmailPersister := h.persister.GetPrimaryEmailPersisterWithConnection(tx)
+
+		emailChange, err := emailChangePersister.GetByRevertToken(crypto.HashToken(body.Token))
+		if err != nil {
+			return fmt.Errorf("failed to get email change: %w", err)
+		}
+
+		var user *models.User
+		if emailChange != nil {
+			user, err = h.persister.GetUserPersisterWithConnection(tx).Get(emailChange.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+		}
+
+		if user == nil || emailChange.IsReverted() || emailChange.IsRevertExpired(time.Now().UTC()) {
+			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailChangeRevertFailed, user, fmt.Errorf("invalid or expired revert token"))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			businessError = echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired revert token")
+			return nil
+		}
+
+		blockedError, err := checkBlocked(tx, c, h.auditLogger, user, models.AuditLogEmailChangeRevertFailed)
+		if err != nil {
+			return err
+		}
+		if blockedError != nil {
+			businessError = blockedError
+			return nil
+		}
+
+		oldEmail, err := emailPersister.FindByAddress(emailChange.OldAddress)
+		if err != nil {
+			return fmt.Errorf("failed to get email: %w", err)
+		}
+
+		if oldEmail == nil {
+			// the old email address has been deleted with the change
+			oldEmail = models.NewEmail(&user.ID, emailChange.OldAddress)
+			oldEmail.Verified = true
+			err = emailPersister.Create(*oldEmail)
+			if err != nil {
+				return fmt.Errorf("failed to store email: %w", err)
+			}
+		} else if oldEmail.UserID != nil && *oldEmail.UserID != user.ID {
+			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailChangeRevertFailed, user, fmt.Errorf("email address has been claimed by another user"))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			businessError = echo.NewHTTPError(http.StatusConflict, "email address has been claimed by another user")
+			return nil
+		} else if oldEmail.UserID == nil || !oldEmail.Verified {
+			oldEmail.UserID = &user.ID
+			oldEmail.Verified = true
+			err = emailPersister.Update(*oldEmail)
+			if err != nil {
+				return fmt.Errorf("failed to update email: %w", err)
+			}
+		}
+
+		if currentPrimaryEmail := user.Emails.GetPrimary(); currentPrimaryEmail != nil {
+			primaryEmail := currentPrimaryEmail.PrimaryEmail
+			primaryEmail.EmailID = oldEmail.ID
+			primaryEmail.UpdatedAt = time.Now().UTC()
+			err = primaryEmailPersister.Update(*primaryEmail)
+			if err != nil {
+				return fmt.Errorf("failed to change primary email: %w", err)
+			}
+		} else {
+			err = primaryEmailPersister.Create(*models.NewPrimaryEmail(oldEmail.ID, user.ID))
+			if err != nil {
+				return fmt.Errorf("failed to store primary email: %w", err)
+			}
+		}
+
+		// Later changes are reverted as well, because they might have been made by the same attacker, who received
+		// their revert links at the addresses they added themselves.
+		emailChanges, err := emailChangePersister.ListByUserId(user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get email changes: %w", err)
+		}
+		revertedChanges := []models.EmailChange{*emailChange}
+		for _, laterChange := range emailChanges {
+			if laterChange.ID != emailChange.ID && !laterChange.IsReverted() && !laterChange.CreatedAt.Before(emailChange.CreatedAt) {
+				revertedChanges = append(revertedChanges, laterChange)
+			}
+		}
+
+		// The emails added with the reverted changes and every other email added since the change are deleted.
+		for _, email := range user.Emails {
+			if email.ID == oldEmail.ID {
+				continue
+			}
+			addedWithChange := false
+			for _, revertedChange := range revertedChanges {
+				if revertedChange.NewEmailID != nil && *revertedChange.NewEmailID == email.ID {
+					addedWithChange = true
+					break
+				}
+			}
+			if addedWithChange || email.CreatedAt.After(emailChange.CreatedAt) {
+				err = emailPersister.Delete(email)
+				if err != nil {
+					return fmt.Errorf("failed to delete new email: %w", err)
+				}
+			}
+		}
+
+		err = h.persister.GetSessionPersisterWithConnection(tx).DeleteByUserId(user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		now := time.Now().UTC()
+		for _, revertedChange := range revertedChanges {
+			revertedChange.RevertedAt = &now
+			revertedChange.UpdatedAt = now
+			err = emailChangePersister.Update(revertedChange)
+			if err != nil {
+				return fmt.Errorf("failed to update email change: %w", err)
+			}
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogEmailChangeReverted, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	if businessError != nil {
+		return businessError
+	}
+
+	return transactionError
+}
+
+// notifyOldAddress records the email change and sends the revert link to the old email address.
+func (h *EmailChangeHandler) notifyOldAddress(tx *pop.Connection, c echo.Context, user *models.User, oldAddress string, newEmailId uuid.UUID) error {
+	token, err := crypto.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate revert token: %w", err)
+	}
+
+	expiresAt := time.Now().UTC().Add(h.cfg.Emails.Change.RevertLinkTTL)
+	emailChange := models.NewEmailChange(user.ID, oldAddress, newEmailId, crypto.HashToken(token), expiresAt)
+	err = h.persister.GetEmailChangePersisterWithConnection(tx).Create(emailChange)
+	if err != nil {
+		return fmt.Errorf("failed to store email change: %w", err)
+	}
+
+	link, err := mail.Link(h.cfg.Emails.Change.RevertUrl, "revert_token", token)
+	if err != nil {
+		return fmt.Errorf("failed to create revert link: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"Link":        link,
+		"ServiceName": h.passcodeHandler.serviceConfig.Name,
+		"ExpiresAt":   expiresAt.Format(time.RFC1123),
+	}
+
+	err = h.passcodeHandler.sender.Send(c.Request().Header.Get("Accept-Language"), oldAddress, "emailChangedTextMail", "email_subject_email_changed", data)
+	if err != nil {
+		return fmt.Errorf("failed to send email change notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/test"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailChangeSuite(t *testing.T) {
+	// not run in parallel, because the email server always listens on the same port
+	s := new(emailChangeSuite)
+	s.WithEmailServer = true
+	suite.Run(t, s)
+}
+
+type emailChangeSuite struct {
+	test.Suite
+}
+
+const (
+	emailChangeUserId     = "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
+	emailChangeOldEmailId = "51b7c175-ceb6-45ba-aae6-0092221c1b84"
+	emailChangeNewEmailId = "6a3c4b1e-2f0d-4c6a-9e47-0d5b8f1c2a10"
+)
+
+func (s *emailChangeSuite) config() config.Config {
+	cfg := test.DefaultConfig
+	cfg.Emails.Change.Enabled = true
+	cfg.Emails.Change.RevertUrl = "https://app.example.com/email/revert"
+	cfg.Emails.Change.RevertLinkTTL = 72 * time.Hour
+	return cfg
+}
+
+func (s *emailChangeSuite) cookie(cfg config.Config) *http.Cookie {
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(emailChangeUserId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	return cookie
+}
+
+func (s *emailChangeSuite) TestEmailChangeHandler_Init() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/email_change")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+	cookie := s.cookie(cfg)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "sends passcode to new email address", body: `{"address": "John.New@example.com"}`, expectedCode: http.StatusOK},
+		{name: "rejects email address of another user", body: `{"address": "jane.doe@example.com"}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects current primary email address", body: `{"address": "john.doe@example.com"}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects invalid email address", body: `{"address": "invalid"}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			req := httptest.NewRequest(http.MethodPost, "/emails/change/initialize", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			if s.Equal(tt.expectedCode, rec.Code) && tt.expectedCode == http.StatusOK {
+				messages := s.EmailServer.Messages()
+				s.Contains(messages[len(messages)-1].MsgRequest(), "john.new@example.com")
+			}
+		})
+	}
+}
+
+func (s *emailChangeSuite) TestEmailChangeHandler_Finish() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/email_change")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	hashedPasscode, err := bcrypt.GenerateFromPassword([]byte("123456"), 12)
+	s.Require().NoError(err)
+
+	now := time.Now().UTC()
+	passcode := models.Passcode{
+		ID:        uuid.FromStringOrNil("0c4f2a9d-5b3e-4e71-8f6a-2d9c1b7e3a54"),
+		UserId:    uuid.FromStringOrNil(emailChangeUserId),
+		EmailID:   uuid.FromStringOrNil(emailChangeNewEmailId),
+		Ttl:       300,
+		Code:      string(hashedPasscode),
+		Purpose:   models.PasscodePurposeEmailChange,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.Require().NoError(s.Storage.GetPasscodePersister().Create(passcode))
+
+	body := fmt.Sprintf(`{"id": "%s", "code": "123456", "remove_old_email": true}`, passcode.ID)
+	req := httptest.NewRequest(http.MethodPost, "/emails/change/finalize", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(s.cookie(cfg))
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var response dto.EmailResponse
+		s.NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+		s.Equal("john.new@example.com", response.Address)
+		s.True(response.IsPrimary)
+		s.True(response.IsVerified)
+
+		emails, err := s.Storage.GetEmailPersister().FindByUserId(uuid.FromStringOrNil(emailChangeUserId))
+		s.Require().NoError(err)
+		s.Len(emails, 1)
+
+		messages := s.EmailServer.Messages()
+		message := messages[len(messages)-1].MsgRequest()
+		s.Contains(message, "john.doe@example.com")
+		s.Contains(message, "revert_token")
+	}
+}
+
+func (s *emailChangeSuite) TestEmailChangeHandler_Finish_ClaimedEmailKeepsPasscode() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/email_change")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	hashedPasscode, err := bcrypt.GenerateFromPassword([]byte("123456"), 12)
+	s.Require().NoError(err)
+
+	// the passcode was issued for an email address that has been claimed by another user in the meantime
+	now := time.Now().UTC()
+	passcode := models.Passcode{
+		ID:        uuid.FromStringOrNil("7e2b9c41-3d8a-4f56-b1e0-9a4c6d2f8e13"),
+		UserId:    uuid.FromStringOrNil(emailChangeUserId),
+		EmailID:   uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925"),
+		Ttl:       300,
+		Code:      string(hashedPasscode),
+		Purpose:   models.PasscodePurposeEmailChange,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.Require().NoError(s.Storage.GetPasscodePersister().Create(passcode))
+
+	body := fmt.Sprintf(`{"id": "%s", "code": "123456"}`, passcode.ID)
+	req := httptest.NewRequest(http.MethodPost, "/emails/change/finalize", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(s.cookie(cfg))
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	storedPasscode, err := s.Storage.GetPasscodePersister().Get(passcode.ID)
+	s.Require().NoError(err)
+	s.NotNil(storedPasscode)
+}
+
+func (s *emailChangeSuite) TestEmailChangeHandler_Revert() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/email_change")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	userId := uuid.FromStringOrNil(emailChangeUserId)
+
+	// simulate a finished email change which removed the old email address
+	newEmail := models.NewEmail(&userId, "attacker@example.com")
+	newEmail.Verified = true
+	s.Require().NoError(s.Storage.GetEmailPersister().Create(*newEmail))
+	user, err := s.Storage.GetUserPersister().Get(userId)
+	s.Require().NoError(err)
+	primaryEmail := user.Emails.GetPrimary().PrimaryEmail
+	primaryEmail.EmailID = newEmail.ID
+	s.Require().NoError(s.Storage.GetPrimaryEmailPersister().Update(*primaryEmail))
+	oldEmail := user.GetEmailById(uuid.FromStringOrNil(emailChangeOldEmailId))
+	s.Require().NoError(s.Storage.GetEmailPersister().Delete(*oldEmail))
+
+	emailChange := models.NewEmailChange(userId, "john.doe@example.com", newEmail.ID, crypto.HashToken("valid"), time.Now().UTC().Add(time.Hour))
+	s.Require().NoError(s.Storage.GetEmailChangePersister().Create(emailChange))
+	expiredEmailChange := models.NewEmailChange(userId, "john.doe@example.com", newEmail.ID, crypto.HashToken("expired"), time.Now().UTC().Add(-time.Hour))
+	s.Require().NoError(s.Storage.GetEmailChangePersister().Create(expiredEmailChange))
+	claimedEmailChange := models.NewEmailChange(userId, "jane.doe@example.com", newEmail.ID, crypto.HashToken("claimed"), time.Now().UTC().Add(time.Hour))
+	s.Require().NoError(s.Storage.GetEmailChangePersister().Create(claimedEmailChange))
+
+	tests := []struct {
+		name         string
+		token        string
+		expectedCode int
+	}{
+		{name: "rejects unknown token", token: "unknown", expectedCode: http.StatusUnauthorized},
+		{name: "rejects expired token", token: "expired", expectedCode: http.StatusUnauthorized},
+		{name: "rejects email address claimed by another user", token: "claimed", expectedCode: http.StatusConflict},
+		{name: "reverts email change", token: "valid", expectedCode: http.StatusNoContent},
+		{name: "rejects already used token", token: "valid", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			body := fmt.Sprintf(`{"token": "%s"}`, tt.token)
+			req := httptest.NewRequest(http.MethodPost, "/emails/change/revert", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(tt.expectedCode, rec.Code)
+		})
+	}
+
+	emails, err := s.Storage.GetEmailPersister().FindByUserId(userId)
+	s.Require().NoError(err)
+	if s.Len(emails, 1) {
+		s.Equal("john.doe@example.com", emails[0].Address)
+		s.True(emails[0].IsPrimary())
+	}
+}
+
+func (s *emailChangeSuite) TestEmailChangeHandler_Revert_ChainedChanges() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/email_change")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	userId := uuid.FromStringOrNil(emailChangeUserId)
+	now := time.Now().UTC()
+
+	// simulate a change from the original address to an address of an attacker, who then changes it again, so that
+	// the revert link of the second change is sent to the first address of the attacker
+	user, err := s.Storage.GetUserPersister().Get(userId)
+	s.Require().NoError(err)
+	primaryEmail := user.Emails.GetPrimary().PrimaryEmail
+
+	firstEmail := models.NewEmail(&userId, "attacker@example.com")
+	firstEmail.Verified = true
+	s.Require().NoError(s.Storage.GetEmailPersister().Create(*firstEmail))
+	firstChange := models.NewEmailChange(userId, "john.doe@example.com", firstEmail.ID, crypto.HashToken("first"), now.Add(time.Hour))
+	firstChange.CreatedAt = now.Add(-2 * time.Minute)
+	s.Require().NoError(s.Storage.GetEmailChangePersister().Create(firstChange))
+	primaryEmail.EmailID = firstEmail.ID
+	s.Require().NoError(s.Storage.GetPrimaryEmailPersister().Update(*primaryEmail))
+	s.Require().NoError(s.Storage.GetEmailPersister().Delete(*user.GetEmailById(uuid.FromStringOrNil(emailChangeOldEmailId))))
+
+	secondEmail := models.NewEmail(&userId, "attacker2@example.com")
+	secondEmail.Verified = true
+	s.Require().NoError(s.Storage.GetEmailPersister().Create(*secondEmail))
+	secondChange := models.NewEmailChange(userId, "attacker@example.com", secondEmail.ID, crypto.HashToken("second"), now.Add(time.Hour))
+	secondChange.CreatedAt = now.Add(-time.Minute)
+	s.Require().NoError(s.Storage.GetEmailChangePersister().Create(secondChange))
+	primaryEmail.EmailID = secondEmail.ID
+	s.Require().NoError(s.Storage.GetPrimaryEmailPersister().Update(*primaryEmail))
+	s.Require().NoError(s.Storage.GetEmailPersister().Delete(*firstEmail))
+
+	req := httptest.NewRequest(http.MethodPost, "/emails/change/revert", strings.NewReader(`{"token": "first"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	emails, err := s.Storage.GetEmailPersister().FindByUserId(userId)
+	s.Require().NoError(err)
+	if s.Len(emails, 1) {
+		s.Equal("john.doe@example.com", emails[0].Address)
+		s.True(emails[0].IsPrimary())
+	}
+
+	emailChange, err := s.Storage.GetEmailChangePersister().GetByRevertToken(crypto.HashToken("second"))
+	s.Require().NoError(err)
+	s.True(emailChange.IsReverted())
+
+	req = httptest.NewRequest(http.MethodPost, "/emails/change/revert", strings.NewReader(`{"token": "second"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
 	"github.com/teamhanko/hanko/backend/session"
 	"github.com/teamhanko/hanko/backend/test"
 	"net/http"
@@ -25,7 +26,10 @@ type emailSuite struct {
 }
 
 func (s *emailSuite) TestEmailHandler_New() {
-	emailHandler, err := NewEmailHandler(&config.Config{}, s.Storage, sessionManager{}, test.NewAuditLogger())
+	emailDomainChecker, err := emaildomain.NewChecker(config.EmailDomains{})
+	s.Require().NoError(err)
+
+	emailHandler, err := NewEmailHandler(&config.Config{}, s.Storage, sessionManager{}, test.NewAuditLogger(), emailDomainChecker)
 	s.NoError(err)
 	s.NotEmpty(emailHandler)
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/test"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -14,7 +15,7 @@ func TestHealthHandler_Ready(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	h := NewHealthHandler()
+	h := NewHealthHandler(test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 
 	if assert.NoError(t, h.Ready(c)) {
 		assert.Equal(t, `{"ready":true}`, strings.TrimSuffix(rec.Body.String(), "\n"))
@@ -26,7 +27,7 @@ func TestHealthHandler_Alive(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/health/alive", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	h := NewHealthHandler()
+	h := NewHealthHandler(test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 
 	if assert.NoError(t, h.Alive(c)) {
 		assert.Equal(t, `{"alive":true}`, strings.TrimSuffix(rec.Body.String(), "\n"))
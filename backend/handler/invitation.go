@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/invitation"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rbac"
+	"github.com/teamhanko/hanko/backend/session"
+	"net/http"
+	"time"
+)
+
+// InvitationHandler accepts invitations with the token of the invitation link. Accepting an invitation signs up the
+// invited user, or signs in the user the email address already belongs to, regardless of whether signup is allowed.
+type InvitationHandler struct {
+	cfg               *config.Config
+	persister         persistence.Persister
+	invitationManager *invitation.Manager
+	sessionManager    session.Manager
+	auditLogger       auditlog.Logger
+}
+
+func NewInvitationHandler(cfg *config.Config, persister persistence.Persister, invitationManager *invitation.Manager, sessionManager session.Manager, auditLogger auditlog.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		cfg:               cfg,
+		persister:         persister,
+		invitationManager: invitationManager,
+		sessionManager:    sessionManager,
+		auditLogger:       auditLogger,
+	}
+}
+
+func (h *InvitationHandler) Accept(c echo.Context) error {
+	var body dto.InvitationAcceptRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	// only if an internal server error occurs the transaction should be rolled back
+	var businessError error
+	transactionError := h.persister.Transaction(func(tx *pop.Connection) error {
+		inv, err := h.invitationManager.GetByToken(tx, body.Token)
+		if err != nil {
+			return fmt.Errorf("failed to get invitation: %w", err)
+		}
+
+		if inv == nil || inv.IsAccepted() || inv.IsExpired(time.Now().UTC()) {
+			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogInvitationAcceptFailed, nil, fmt.Errorf("invalid or expired invitation token"))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			businessError = echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired invitation token")
+			return nil
+		}
+
+		user, acceptError, err := h.getOrCreateUser(tx, c, inv)
+		if err != nil {
+			return err
+		}
+		if acceptError != nil {
+			businessError = acceptError
+			return nil
+		}
+
+		if inv.Metadata != nil {
+			err = applyInvitationMetadata(user, inv, h.cfg.UserMetadata)
+			if err != nil {
+				return err
+			}
+
+			user.UpdatedAt = time.Now().UTC()
+			err = h.persister.GetUserPersisterWithConnection(tx).Update(*user)
+			if err != nil {
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+		}
+
+		if inv.OrganizationID != nil {
+			err = h.addMembership(tx, inv, user)
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		inv.AcceptedAt = &now
+		inv.UserID = &user.ID
+		inv.UpdatedAt = now
+		err = h.persister.GetInvitationPersisterWithConnection(tx).Update(*inv)
+		if err != nil {
+			return fmt.Errorf("failed to update invitation: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogInvitationAccepted, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		err = h.sessionManager.GenerateCookieOrHeaderWithOrganization(tx, user.ID, inv.OrganizationID, c)
+		if err != nil {
+			return fmt.Errorf("failed to generate cookie or header: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, dto.InvitationAcceptResponse{UserID: user.ID})
+	})
+
+	if businessError != nil {
+		return businessError
+	}
+
+	return transactionError
+}
+
+// getOrCreateUser returns the user the invited email address belongs to, or signs up a new user with it. In both cases
+// the email address is verified, because the invitation link was sent to it.
+func (h *InvitationHandler) getOrCreateUser(tx *pop.Connection, c echo.Context, inv *models.Invitation) (*models.User, *echo.HTTPError, error) {
+	userPersister := h.persister.GetUserPersisterWithConnection(tx)
+	emailPersister := h.persister.GetEmailPersisterWithConnection(tx)
+	primaryEmailPersister := h.persister.GetPrimaryEmailPersisterWithConnection(tx)
+
+	email, err := emailPersister.FindByAddress(inv.Email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	if email != nil && email.UserID != nil {
+		user, err := userPersister.Get(*email.UserID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get user: %w", err)
+		}
+
+		blockedError, err := checkBlocked(tx, c, h.auditLogger, user, models.AuditLogInvitationAcceptFailed)
+		if err != nil || blockedError != nil {
+			return nil, blockedError, err
+		}
+
+		if !email.Verified {
+			email.Verified = true
+			err = emailPersister.Update(*email)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to update email: %w", err)
+			}
+		}
+
+		if user.Emails.GetPrimary() == nil {
+			err = primaryEmailPersister.Create(*models.NewPrimaryEmail(email.ID, user.ID))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to store primary email: %w", err)
+			}
+		}
+
+		return user, nil, nil
+	}
+
+	user := models.NewUser()
+	err = userPersister.Create(user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store user: %w", err)
+	}
+
+	err = rbac.GrantDefaultRole(h.cfg.Rbac, h.persister.GetRolePersisterWithConnection(tx), user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if email != nil {
+		// The email address exists but is not assigned to any user yet, hence it is assigned to the new user.
+		email.UserID = &user.ID
+		email.Verified = true
+		err = emailPersister.Update(*email)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update email: %w", err)
+		}
+	} else {
+		email = models.NewEmail(&user.ID, inv.Email)
+		email.Verified = true
+		err = emailPersister.Create(*email)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to store email: %w", err)
+		}
+	}
+
+	err = primaryEmailPersister.Create(*models.NewPrimaryEmail(email.ID, user.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store primary email: %w", err)
+	}
+
+	err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserCreated, &user, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return &user, nil, nil
+}
+
+// addMembership makes the user a member of the organization of the invitation. The role of an existing membership is
+// only replaced if the invitation has a role.
+func (h *InvitationHandler) addMembership(tx *pop.Connection, inv *models.Invitation, user *models.User) error {
+	membershipPersister := h.persister.GetOrganizationMembershipPersisterWithConnection(tx)
+	membership, err := membershipPersister.Get(*inv.OrganizationID, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get organization membership: %w", err)
+	}
+
+	if membership == nil {
+		err = membershipPersister.Create(models.NewOrganizationMembership(*inv.OrganizationID, user.ID, inv.RoleID))
+		if err != nil {
+			return fmt.Errorf("failed to create organization membership: %w", err)
+		}
+		return nil
+	}
+
+	if inv.RoleID != nil {
+		membership.RoleID = inv.RoleID
+		membership.UpdatedAt = time.Now().UTC()
+		err = membershipPersister.Update(*membership)
+		if err != nil {
+			return fmt.Errorf("failed to update organization membership: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/emaildomain"
+	"github.com/teamhanko/hanko/backend/invitation"
+	"github.com/teamhanko/hanko/backend/metadata"
+	"github.com/teamhanko/hanko/backend/pagination"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type InvitationHandlerAdmin struct {
+	cfg                *config.Config
+	persister          persistence.Persister
+	invitationManager  *invitation.Manager
+	auditLogger        auditlog.Logger
+	emailDomainChecker *emaildomain.Checker
+}
+
+func NewInvitationHandlerAdmin(cfg *config.Config, persister persistence.Persister, invitationManager *invitation.Manager, auditLogger auditlog.Logger, emailDomainChecker *emaildomain.Checker) *InvitationHandlerAdmin {
+	return &InvitationHandlerAdmin{
+		cfg:                cfg,
+		persister:          persister,
+		invitationManager:  invitationManager,
+		auditLogger:        auditLogger,
+		emailDomainChecker: emailDomainChecker,
+	}
+}
+
+func (h *InvitationHandlerAdmin) List(c echo.Context) error {
+	var request admin.InvitationListRequest
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &request)
+	if err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if request.Page == 0 {
+		request.Page = 1
+	}
+
+	if request.PerPage == 0 {
+		request.PerPage = 20
+	}
+
+	invitations, err := h.persister.GetInvitationPersister().List(request.Page, request.PerPage)
+	if err != nil {
+		return fmt.Errorf("failed to get list of invitations: %w", err)
+	}
+
+	count, err := h.persister.GetInvitationPersister().Count()
+	if err != nil {
+		return fmt.Errorf("failed to get total count of invitations: %w", err)
+	}
+
+	u, _ := url.Parse(fmt.Sprintf("%s://%s%s", c.Scheme(), c.Request().Host, c.Request().RequestURI))
+
+	c.Response().Header().Set("Link", pagination.CreateHeader(u, count, request.Page, request.PerPage))
+	c.Response().Header().Set("X-Total-Count", strconv.FormatInt(int64(count), 10))
+
+	roleNames := make(map[uuid.UUID]*string)
+	response := make([]admin.Invitation, len(invitations))
+	for i, inv := range invitations {
+		var roleName *string
+		if inv.RoleID != nil {
+			name, ok := roleNames[*inv.RoleID]
+			if !ok {
+				name, err = h.getRoleName(inv.RoleID)
+				if err != nil {
+					return err
+				}
+				roleNames[*inv.RoleID] = name
+			}
+			roleName = name
+		}
+		response[i] = admin.FromInvitationModel(inv, roleName)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *InvitationHandlerAdmin) Get(c echo.Context) error {
+	inv, err := h.getInvitation(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respond(c, http.StatusOK, inv)
+}
+
+func (h *InvitationHandlerAdmin) Create(c echo.Context) error {
+	var body admin.CreateInvitation
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	inv := models.NewInvitation(strings.ToLower(body.Email), "", time.Time{})
+
+	if h.cfg.Emails.Domains.ApplyToAdmin && !h.emailDomainChecker.Allowed(inv.Email) {
+		return newEmailDomainNotAllowedError(inv.Email)
+	}
+
+	if body.OrganizationID != nil {
+		org, err := h.persister.GetOrganizationPersister().Get(*body.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("failed to get organization: %w", err)
+		}
+		if org == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "organization does not exist")
+		}
+		inv.OrganizationID = &org.ID
+	}
+
+	if body.Role != nil && *body.Role != "" {
+		if inv.OrganizationID == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "role requires an organization_id")
+		}
+
+		role, err := h.persister.GetRolePersister().GetByName(*body.Role)
+		if err != nil {
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+		if role == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("role '%s' does not exist", *body.Role))
+		}
+		inv.RoleID = &role.ID
+	}
+
+	if body.Metadata != nil {
+		inv.Metadata = body.Metadata
+		// the metadata is applied when the invitation is accepted, so it is checked against an empty user here
+		err := applyInvitationMetadata(&models.User{}, &inv, h.cfg.UserMetadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	if body.Language != nil && *body.Language != "" {
+		inv.Language = body.Language
+	}
+
+	err := h.persister.Transaction(func(tx *pop.Connection) error {
+		pending, err := h.persister.GetInvitationPersisterWithConnection(tx).GetPendingByEmail(inv.Email)
+		if err != nil {
+			return fmt.Errorf("failed to get invitation: %w", err)
+		}
+		if pending != nil {
+			return echo.NewHTTPError(http.StatusConflict, "a pending invitation for this email address already exists")
+		}
+
+		err = h.invitationManager.Create(tx, &inv)
+		if err != nil {
+			return fmt.Errorf("failed to create invitation: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogInvitationCreated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.respond(c, http.StatusCreated, &inv)
+}
+
+// Resend sends the invitation again with a new link and extends its expiry. Previously sent links become invalid.
+func (h *InvitationHandlerAdmin) Resend(c echo.Context) error {
+	inv, err := h.getInvitation(c)
+	if err != nil {
+		return err
+	}
+
+	if inv.IsAccepted() {
+		return echo.NewHTTPError(http.StatusConflict, "invitation has already been accepted")
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err := h.invitationManager.Resend(tx, inv)
+		if err != nil {
+			return fmt.Errorf("failed to resend invitation: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogInvitationResent, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.respond(c, http.StatusOK, inv)
+}
+
+// Delete revokes the invitation, so that its link can no longer be used.
+func (h *InvitationHandlerAdmin) Delete(c echo.Context) error {
+	inv, err := h.getInvitation(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err := h.persister.GetInvitationPersisterWithConnection(tx).Delete(*inv)
+		if err != nil {
+			return fmt.Errorf("failed to delete invitation: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogInvitationRevoked, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *InvitationHandlerAdmin) respond(c echo.Context, status int, inv *models.Invitation) error {
+	roleName, err := h.getRoleName(inv.RoleID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(status, admin.FromInvitationModel(*inv, roleName))
+}
+
+func (h *InvitationHandlerAdmin) getInvitation(c echo.Context) (*models.Invitation, error) {
+	invitationId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse invitationId as uuid").SetInternal(err)
+	}
+
+	inv, err := h.persister.GetInvitationPersister().Get(invitationId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	if inv == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "invitation not found")
+	}
+
+	return inv, nil
+}
+
+func (h *InvitationHandlerAdmin) getRoleName(roleId *uuid.UUID) (*string, error) {
+	if roleId == nil {
+		return nil, nil
+	}
+
+	role, err := h.persister.GetRolePersister().Get(*roleId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &role.Name, nil
+}
+
+// applyInvitationMetadata applies the metadata patch of the invitation to the public and private metadata of the user.
+func applyInvitationMetadata(user *models.User, inv *models.Invitation, cfg config.UserMetadata) error {
+	if inv.Metadata == nil {
+		return nil
+	}
+
+	patch, err := json.Marshal(inv.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to serialize invitation metadata: %w", err)
+	}
+
+	err = metadata.Patch(user, patch, []metadata.Scope{metadata.ScopePublic, metadata.ScopePrivate}, cfg)
+	if err != nil {
+		var patchError *metadata.PatchError
+		if errors.As(err, &patchError) {
+			return echo.NewHTTPError(http.StatusBadRequest, patchError.Message).SetInternal(err)
+		}
+		return err
+	}
+
+	return nil
+}
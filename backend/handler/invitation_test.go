@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInvitationHandlerSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(invitationSuite))
+}
+
+type invitationSuite struct {
+	test.Suite
+}
+
+const (
+	invitationValidId        = "3c1f0b0e-7a34-4d8f-9c3e-1b2a9e6d7f01"
+	invitationExistingUserId = "3c1f0b0e-7a34-4d8f-9c3e-1b2a9e6d7f03"
+)
+
+func (s *invitationSuite) config() config.Config {
+	cfg := test.DefaultConfig
+	cfg.Account.AllowSignup = false
+	cfg.Account.Invitations.Enabled = true
+	cfg.Account.Invitations.AcceptUrl = "https://app.example.com/invitation"
+	return cfg
+}
+
+func (s *invitationSuite) TestInvitationHandlerAdmin_Create() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/invitation")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewAdminRouter(&cfg, s.Storage, nil)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "creates invitation", body: `{"email": "new@example.com"}`, expectedCode: http.StatusCreated},
+		{name: "creates invitation with organization and metadata", body: fmt.Sprintf(`{"email": "new2@example.com", "organization_id": "%s", "role": "editor", "metadata": {"public_metadata": {"plan": "team"}}}`, organizationAcmeId), expectedCode: http.StatusCreated},
+		{name: "creates invitation with language", body: `{"email": "new4@example.com", "language": "de"}`, expectedCode: http.StatusCreated},
+		{name: "rejects invalid language", body: `{"email": "new5@example.com", "language": "not a language"}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects pending invitation", body: `{"email": "invited@example.com"}`, expectedCode: http.StatusConflict},
+		{name: "rejects role without organization", body: `{"email": "new3@example.com", "role": "editor"}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects unknown role", body: fmt.Sprintf(`{"email": "new3@example.com", "organization_id": "%s", "role": "unknown"}`, organizationAcmeId), expectedCode: http.StatusBadRequest},
+		{name: "rejects unsafe metadata", body: `{"email": "new3@example.com", "metadata": {"unsafe_metadata": {"plan": "team"}}}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects invalid email", body: `{"email": "invalid"}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			req := httptest.NewRequest(http.MethodPost, "/invitations", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func (s *invitationSuite) TestInvitationHandlerAdmin_ResendAndRevoke() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/invitation")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewAdminRouter(&cfg, s.Storage, nil)
+
+	previous, err := s.Storage.GetInvitationPersister().Get(uuid.FromStringOrNil(invitationValidId))
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/invitations/%s/resend", invitationValidId), nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if s.Equal(http.StatusOK, rec.Code) {
+		var response admin.Invitation
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+		s.Equal("viewer", *response.Role)
+	}
+
+	resent, err := s.Storage.GetInvitationPersister().Get(uuid.FromStringOrNil(invitationValidId))
+	s.Require().NoError(err)
+	s.NotEqual(previous.Token, resent.Token)
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/invitations/%s", invitationValidId), nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusNoContent, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/invitations/%s", invitationValidId), nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusNotFound, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/invitations", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("2", rec.Header().Get("X-Total-Count"))
+}
+
+func (s *invitationSuite) TestInvitationHandler_Accept() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/invitation")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	cfg.Session.EnableAuthTokenHeader = true
+	cfg.UserMetadata.JwtClaims = []string{"public"}
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(`{"token": "valid-invitation-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	// the membership and the metadata are created in the accept transaction and must already be contained in the
+	// first session JWT
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.Verify(rec.Header().Get("X-Auth-Token"))
+	s.Require().NoError(err)
+	orgId, _ := token.Get("org_id")
+	s.Equal(organizationAcmeId, orgId)
+	orgSlug, _ := token.Get("org_slug")
+	s.Equal("acme", orgSlug)
+	orgRole, _ := token.Get("org_role")
+	s.Equal("viewer", orgRole)
+	publicMetadata, _ := token.Get("public_metadata")
+	s.Equal(map[string]interface{}{"plan": "team"}, publicMetadata)
+
+	var response dto.InvitationAcceptResponse
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+
+	user, err := s.Storage.GetUserPersister().Get(response.UserID)
+	s.Require().NoError(err)
+	s.Require().NotNil(user)
+	s.Require().NotNil(user.Emails.GetPrimary())
+	s.Equal("invited@example.com", user.Emails.GetPrimary().Address)
+	s.True(user.Emails.GetPrimary().Verified)
+	s.Equal("team", user.PublicMetadata["plan"])
+
+	membership, err := s.Storage.GetOrganizationMembershipPersister().Get(uuid.FromStringOrNil(organizationAcmeId), user.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(membership)
+	s.Equal("6a6b5c86-9f1c-4b7e-9e8a-0f7b3a1d2c02", membership.RoleID.String())
+
+	// the invitation can only be accepted once
+	req = httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(`{"token": "valid-invitation-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	logs, err := s.Storage.GetAuditLogPersister().List(0, 0, nil, nil, []string{"invitation_accepted", "invitation_accept_failed"}, "", "", "", "")
+	s.Require().NoError(err)
+	s.Len(logs, 2)
+}
+
+func (s *invitationSuite) TestInvitationHandler_Accept_ExistingUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/invitation")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(`{"token": "existing-user-invitation-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var response dto.InvitationAcceptResponse
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	s.Equal(organizationUserId, response.UserID.String())
+
+	membership, err := s.Storage.GetOrganizationMembershipPersister().Get(uuid.FromStringOrNil(organizationInitechId), response.UserID)
+	s.Require().NoError(err)
+	s.NotNil(membership)
+
+	inv, err := s.Storage.GetInvitationPersister().Get(uuid.FromStringOrNil(invitationExistingUserId))
+	s.Require().NoError(err)
+	s.True(inv.IsAccepted())
+}
+
+func (s *invitationSuite) TestInvitationHandler_Accept_InvalidToken() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/invitation")
+	s.Require().NoError(err)
+
+	cfg := s.config()
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	for _, token := range []string{"expired-invitation-token", "unknown-token"} {
+		req := httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(fmt.Sprintf(`{"token": "%s"}`, token)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		s.Equal(http.StatusUnauthorized, rec.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/lockout"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"net/http"
+)
+
+// LockoutHandler unlocks accounts with the token of the unlock link sent to the primary email address of a locked
+// user.
+type LockoutHandler struct {
+	persister      persistence.Persister
+	lockoutManager *lockout.Manager
+}
+
+func NewLockoutHandler(persister persistence.Persister, lockoutManager *lockout.Manager) *LockoutHandler {
+	return &LockoutHandler{
+		persister:      persister,
+		lockoutManager: lockoutManager,
+	}
+}
+
+type UnlockBody struct {
+	UnlockToken string `json:"unlock_token" validate:"required"`
+}
+
+func (h *LockoutHandler) Unlock(c echo.Context) error {
+	var body UnlockBody
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	// only if an internal server error occurs the transaction should be rolled back
+	var businessError error
+	transactionError := h.persister.Transaction(func(tx *pop.Connection) error {
+		unlockError, err := h.lockoutManager.UnlockWithToken(tx, c, body.UnlockToken)
+		if err != nil {
+			return err
+		}
+		if unlockError != nil {
+			businessError = unlockError
+			return nil
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	if businessError != nil {
+		return businessError
+	}
+
+	return transactionError
+}
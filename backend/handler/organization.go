@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/organization"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/session"
+	"net/http"
+)
+
+type OrganizationHandler struct {
+	persister      persistence.Persister
+	sessionManager session.Manager
+	auditLogger    auditlog.Logger
+}
+
+func NewOrganizationHandler(persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		persister:      persister,
+		sessionManager: sessionManager,
+		auditLogger:    auditLogger,
+	}
+}
+
+// List returns the organizations of the current user.
+func (h *OrganizationHandler) List(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("failed to cast session object")
+	}
+
+	userId, err := uuid.FromString(sessionToken.Subject())
+	if err != nil {
+		return fmt.Errorf("failed to parse subject as uuid: %w", err)
+	}
+
+	memberships, err := h.persister.GetOrganizationMembershipPersister().ListByUserId(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get organization memberships: %w", err)
+	}
+
+	roleNames, err := getRoleNames(h.persister.GetRolePersister(), memberships)
+	if err != nil {
+		return err
+	}
+
+	activeOrganizationId, _ := sessionToken.Get(organization.IdClaim)
+
+	response := make([]dto.OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		if membership.Organization == nil {
+			continue
+		}
+		org := dto.OrganizationResponse{
+			ID:     membership.Organization.ID,
+			Name:   membership.Organization.Name,
+			Slug:   membership.Organization.Slug,
+			Active: activeOrganizationId == membership.Organization.ID.String(),
+		}
+		if membership.RoleID != nil {
+			if name, ok := roleNames[*membership.RoleID]; ok {
+				org.Role = &name
+			}
+		}
+		response = append(response, org)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Switch reissues the session JWT with the requested organization as the active organization. The claims of the new
+// session JWT contain the organization and the role of the user within it. The refresh session of the current JWT is
+// switched as well instead of issuing an additional one.
+func (h *OrganizationHandler) Switch(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("failed to cast session object")
+	}
+
+	userId, err := uuid.FromString(sessionToken.Subject())
+	if err != nil {
+		return fmt.Errorf("failed to parse subject as uuid: %w", err)
+	}
+
+	var body dto.OrganizationSwitchRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
+	}
+
+	blockedError, err := checkBlocked(h.persister.GetConnection(), c, h.auditLogger, user, models.AuditLogOrganizationSwitchFailed)
+	if err != nil {
+		return err
+	}
+	if blockedError != nil {
+		return blockedError
+	}
+
+	if body.OrganizationID != nil {
+		membership, err := h.persister.GetOrganizationMembershipPersister().Get(*body.OrganizationID, userId)
+		if err != nil {
+			return fmt.Errorf("failed to get organization membership: %w", err)
+		}
+
+		if membership == nil {
+			return echo.NewHTTPError(http.StatusForbidden, "user is not a member of the organization")
+		}
+	}
+
+	err = h.sessionManager.SwitchOrganization(sessionToken, body.OrganizationID, c)
+	if err != nil {
+		return fmt.Errorf("failed to switch organization: %w", err)
+	}
+
+	err = h.auditLogger.Create(c, models.AuditLogOrganizationSwitched, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,474 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/organization"
+	"github.com/teamhanko/hanko/backend/pagination"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OrganizationHandlerAdmin struct {
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+}
+
+func NewOrganizationHandlerAdmin(persister persistence.Persister, auditLogger auditlog.Logger) *OrganizationHandlerAdmin {
+	return &OrganizationHandlerAdmin{
+		persister:   persister,
+		auditLogger: auditLogger,
+	}
+}
+
+func (h *OrganizationHandlerAdmin) List(c echo.Context) error {
+	var request admin.OrganizationListRequest
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &request)
+	if err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if request.Page == 0 {
+		request.Page = 1
+	}
+
+	if request.PerPage == 0 {
+		request.PerPage = 20
+	}
+
+	organizations, err := h.persister.GetOrganizationPersister().List(request.Page, request.PerPage)
+	if err != nil {
+		return fmt.Errorf("failed to get list of organizations: %w", err)
+	}
+
+	count, err := h.persister.GetOrganizationPersister().Count()
+	if err != nil {
+		return fmt.Errorf("failed to get total count of organizations: %w", err)
+	}
+
+	u, _ := url.Parse(fmt.Sprintf("%s://%s%s", c.Scheme(), c.Request().Host, c.Request().RequestURI))
+
+	c.Response().Header().Set("Link", pagination.CreateHeader(u, count, request.Page, request.PerPage))
+	c.Response().Header().Set("X-Total-Count", strconv.FormatInt(int64(count), 10))
+
+	response := make([]admin.Organization, len(organizations))
+	for i := range organizations {
+		response[i] = admin.FromOrganizationModel(organizations[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *OrganizationHandlerAdmin) Get(c echo.Context) error {
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromOrganizationModel(*org))
+}
+
+func (h *OrganizationHandlerAdmin) Create(c echo.Context) error {
+	var body admin.CreateOrganization
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name must not be empty")
+	}
+
+	if !organization.ValidSlug(body.Slug) {
+		return echo.NewHTTPError(http.StatusBadRequest, "slug must only contain lowercase letters, digits and single hyphens")
+	}
+
+	org := models.NewOrganization(name, body.Slug)
+
+	err := h.persister.Transaction(func(tx *pop.Connection) error {
+		organizationPersister := h.persister.GetOrganizationPersisterWithConnection(tx)
+		existing, err := organizationPersister.GetBySlug(org.Slug)
+		if err != nil {
+			return fmt.Errorf("failed to get organization: %w", err)
+		}
+		if existing != nil {
+			return echo.NewHTTPError(http.StatusConflict, "organization with this slug already exists")
+		}
+
+		err = organizationPersister.Create(org)
+		if err != nil {
+			return fmt.Errorf("failed to create organization: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogOrganizationCreated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, admin.FromOrganizationModel(org))
+}
+
+func (h *OrganizationHandlerAdmin) Update(c echo.Context) error {
+	var body admin.UpdateOrganization
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if body.Name != nil && strings.TrimSpace(*body.Name) == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name must not be empty")
+	}
+
+	if body.Slug != nil && !organization.ValidSlug(*body.Slug) {
+		return echo.NewHTTPError(http.StatusBadRequest, "slug must only contain lowercase letters, digits and single hyphens")
+	}
+
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		organizationPersister := h.persister.GetOrganizationPersisterWithConnection(tx)
+		if body.Slug != nil && *body.Slug != org.Slug {
+			existing, err := organizationPersister.GetBySlug(*body.Slug)
+			if err != nil {
+				return fmt.Errorf("failed to get organization: %w", err)
+			}
+			if existing != nil {
+				return echo.NewHTTPError(http.StatusConflict, "organization with this slug already exists")
+			}
+			org.Slug = *body.Slug
+		}
+		if body.Name != nil {
+			org.Name = strings.TrimSpace(*body.Name)
+		}
+		org.UpdatedAt = time.Now().UTC()
+
+		err = organizationPersister.Update(*org)
+		if err != nil {
+			return fmt.Errorf("failed to update organization: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogOrganizationUpdated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromOrganizationModel(*org))
+}
+
+// Delete deletes the organization and all of its memberships. Sessions in which the organization is active continue
+// without an active organization.
+func (h *OrganizationHandlerAdmin) Delete(c echo.Context) error {
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetOrganizationPersisterWithConnection(tx).Delete(*org)
+		if err != nil {
+			return fmt.Errorf("failed to delete organization: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogOrganizationDeleted, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *OrganizationHandlerAdmin) ListMembers(c echo.Context) error {
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	memberships, err := h.persister.GetOrganizationMembershipPersister().ListByOrganizationId(org.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get organization memberships: %w", err)
+	}
+
+	roleNames, err := getRoleNames(h.persister.GetRolePersister(), memberships)
+	if err != nil {
+		return err
+	}
+
+	response := make([]admin.OrganizationMember, len(memberships))
+	for i, membership := range memberships {
+		response[i] = fromOrganizationMembershipModel(membership, roleNames)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *OrganizationHandlerAdmin) AddMember(c echo.Context) error {
+	var body admin.AddOrganizationMember
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.persister.GetUserPersister().Get(body.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "user does not exist")
+	}
+
+	role, err := h.getRoleByName(body.Role)
+	if err != nil {
+		return err
+	}
+
+	membership := models.NewOrganizationMembership(org.ID, user.ID, nil)
+	roleNames := map[uuid.UUID]string{}
+	if role != nil {
+		membership.RoleID = &role.ID
+		roleNames[role.ID] = role.Name
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		membershipPersister := h.persister.GetOrganizationMembershipPersisterWithConnection(tx)
+		existing, err := membershipPersister.Get(org.ID, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get organization membership: %w", err)
+		}
+		if existing != nil {
+			return echo.NewHTTPError(http.StatusConflict, "user is already a member of the organization")
+		}
+
+		err = membershipPersister.Create(membership)
+		if err != nil {
+			return fmt.Errorf("failed to create organization membership: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogOrganizationMemberAdded, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, fromOrganizationMembershipModel(membership, roleNames))
+}
+
+func (h *OrganizationHandlerAdmin) UpdateMember(c echo.Context) error {
+	var body admin.UpdateOrganizationMember
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	user, membership, err := h.getMembership(c, org)
+	if err != nil {
+		return err
+	}
+
+	role, err := h.getRoleByName(body.Role)
+	if err != nil {
+		return err
+	}
+
+	roleNames := map[uuid.UUID]string{}
+	membership.RoleID = nil
+	if role != nil {
+		membership.RoleID = &role.ID
+		roleNames[role.ID] = role.Name
+	}
+	membership.UpdatedAt = time.Now().UTC()
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetOrganizationMembershipPersisterWithConnection(tx).Update(*membership)
+		if err != nil {
+			return fmt.Errorf("failed to update organization membership: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogOrganizationMemberUpdated, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fromOrganizationMembershipModel(*membership, roleNames))
+}
+
+func (h *OrganizationHandlerAdmin) RemoveMember(c echo.Context) error {
+	org, err := h.getOrganization(c)
+	if err != nil {
+		return err
+	}
+
+	user, membership, err := h.getMembership(c, org)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetOrganizationMembershipPersisterWithConnection(tx).Delete(*membership)
+		if err != nil {
+			return fmt.Errorf("failed to delete organization membership: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogOrganizationMemberRemoved, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *OrganizationHandlerAdmin) getOrganization(c echo.Context) (*models.Organization, error) {
+	organizationId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse organizationId as uuid").SetInternal(err)
+	}
+
+	org, err := h.persister.GetOrganizationPersister().Get(organizationId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	if org == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "organization not found")
+	}
+
+	return org, nil
+}
+
+func (h *OrganizationHandlerAdmin) getMembership(c echo.Context, org *models.Organization) (*models.User, *models.OrganizationMembership, error) {
+	userId, err := uuid.FromString(c.Param("user_id"))
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	membership, err := h.persister.GetOrganizationMembershipPersister().Get(org.ID, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+
+	if membership == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "organization member not found")
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, membership, nil
+}
+
+// getRoleByName returns nil if name is nil or empty and an error if the role does not exist.
+func (h *OrganizationHandlerAdmin) getRoleByName(name *string) (*models.Role, error) {
+	if name == nil || *name == "" {
+		return nil, nil
+	}
+
+	role, err := h.persister.GetRolePersister().GetByName(*name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	if role == nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("role '%s' does not exist", *name))
+	}
+
+	return role, nil
+}
+
+// getRoleNames returns the names of the roles of the memberships by role ID.
+func getRoleNames(persister persistence.RolePersister, memberships []models.OrganizationMembership) (map[uuid.UUID]string, error) {
+	roleNames := make(map[uuid.UUID]string)
+	for _, membership := range memberships {
+		if membership.RoleID == nil {
+			continue
+		}
+		if _, ok := roleNames[*membership.RoleID]; ok {
+			continue
+		}
+
+		role, err := persister.Get(*membership.RoleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get role: %w", err)
+		}
+		if role != nil {
+			roleNames[role.ID] = role.Name
+		}
+	}
+
+	return roleNames, nil
+}
+
+func fromOrganizationMembershipModel(membership models.OrganizationMembership, roleNames map[uuid.UUID]string) admin.OrganizationMember {
+	member := admin.OrganizationMember{
+		UserID:    membership.UserID,
+		CreatedAt: membership.CreatedAt,
+		UpdatedAt: membership.UpdatedAt,
+	}
+	if membership.RoleID != nil {
+		if name, ok := roleNames[*membership.RoleID]; ok {
+			member.Role = &name
+		}
+	}
+	return member
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/organization"
+	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrganizationHandlerSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(organizationSuite))
+}
+
+type organizationSuite struct {
+	test.Suite
+}
+
+const (
+	organizationUserId    = "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
+	organizationAcmeId    = "8f14e45f-ceea-467e-9d6e-2b1b4c3d5a01"
+	organizationInitechId = "8f14e45f-ceea-467e-9d6e-2b1b4c3d5a03"
+)
+
+func (s *organizationSuite) TestOrganizationHandlerAdmin_Create() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/organization")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "creates organization", body: `{"name": "Umbrella Corp", "slug": "umbrella-corp"}`, expectedCode: http.StatusCreated},
+		{name: "rejects existing slug", body: `{"name": "Acme 2", "slug": "acme"}`, expectedCode: http.StatusConflict},
+		{name: "rejects invalid slug", body: `{"name": "Acme 2", "slug": "Acme 2"}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects missing name", body: `{"slug": "acme-2"}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			req := httptest.NewRequest(http.MethodPost, "/organizations", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func (s *organizationSuite) TestOrganizationHandlerAdmin_Members() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/organization")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+	otherUserId := "38bf5a00-d7ea-40a5-a5de-48722c148925"
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/organizations/%s/members", organizationAcmeId), strings.NewReader(fmt.Sprintf(`{"user_id": "%s", "role": "viewer"}`, otherUserId)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusCreated, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/organizations/%s/members", organizationAcmeId), strings.NewReader(fmt.Sprintf(`{"user_id": "%s"}`, otherUserId)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusConflict, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/organizations/%s/members/%s", organizationAcmeId, otherUserId), strings.NewReader(`{"role": "editor"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/organizations/%s/members", organizationAcmeId), nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if s.Equal(http.StatusOK, rec.Code) {
+		var members []admin.OrganizationMember
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &members))
+		s.Require().Len(members, 2)
+		for _, member := range members {
+			s.Equal("editor", *member.Role)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/organizations/%s/members/%s", organizationAcmeId, otherUserId), nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusNoContent, rec.Code)
+
+	membership, err := s.Storage.GetOrganizationMembershipPersister().Get(uuid.FromStringOrNil(organizationAcmeId), uuid.FromStringOrNil(otherUserId))
+	s.Require().NoError(err)
+	s.Nil(membership)
+}
+
+func (s *organizationSuite) TestOrganizationHandler_ListAndSwitch() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/organization")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister(), organization.NewClaimsProvider(s.Storage))
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(organizationUserId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/organizations", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var organizations []dto.OrganizationResponse
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &organizations))
+		s.Require().Len(organizations, 2)
+		s.Equal("acme", organizations[0].Slug)
+		s.Equal("editor", *organizations[0].Role)
+		s.False(organizations[0].Active)
+		s.Equal("globex", organizations[1].Slug)
+		s.Nil(organizations[1].Role)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/user/organizations/switch", strings.NewReader(fmt.Sprintf(`{"organization_id": "%s"}`, organizationInitechId)))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/user/organizations/switch", strings.NewReader(fmt.Sprintf(`{"organization_id": "%s"}`, organizationAcmeId)))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	var switchedCookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == cookie.Name {
+			switchedCookie = c
+		}
+	}
+	s.Require().NotNil(switchedCookie)
+
+	parsedToken, err := sessionManager.Verify(switchedCookie.Value)
+	s.Require().NoError(err)
+	claim, _ := parsedToken.Get("org_id")
+	s.Equal(organizationAcmeId, claim)
+	claim, _ = parsedToken.Get("org_role")
+	s.Equal("editor", claim)
+
+	req = httptest.NewRequest(http.MethodGet, "/user/organizations", nil)
+	req.AddCookie(switchedCookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var organizations []dto.OrganizationResponse
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &organizations))
+		s.Require().Len(organizations, 2)
+		s.True(organizations[0].Active)
+		s.False(organizations[1].Active)
+	}
+}
+
+func (s *organizationSuite) TestOrganizationHandler_Switch_BlockedUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/organization")
+	s.Require().NoError(err)
+
+	user, err := s.Storage.GetUserPersister().Get(uuid.FromStringOrNil(organizationUserId))
+	s.Require().NoError(err)
+	blockedAt := time.Now().UTC()
+	user.BlockedAt = &blockedAt
+	s.Require().NoError(s.Storage.GetUserPersister().Update(*user))
+
+	cfg := test.DefaultConfig
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(organizationUserId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/user/organizations/switch", strings.NewReader(fmt.Sprintf(`{"organization_id": "%s"}`, organizationAcmeId)))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusForbidden, rec.Code)
+	s.Empty(rec.Result().Cookies())
+}
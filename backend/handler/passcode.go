@@ -8,27 +8,29 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/sethvargo/go-limiter"
+	"github.com/sethvargo/go-limiter/httplimit"
 	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/lockout"
 	"github.com/teamhanko/hanko/backend/mail"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/rate_limiter"
 	"github.com/teamhanko/hanko/backend/session"
 	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/gomail.v2"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type PasscodeHandler struct {
-	mailer            mail.Mailer
-	renderer          *mail.Renderer
+	sender            *mail.Sender
 	passcodeGenerator crypto.PasscodeGenerator
 	persister         persistence.Persister
-	emailConfig       config.Email
 	serviceConfig     config.Service
 	TTL               int
 	sessionManager    session.Manager
@@ -37,25 +39,44 @@ type PasscodeHandler struct {
 	rateLimiter       limiter.Store
 	exclusionEmail    string
 	exclusionCode     string
+	lockoutManager    *lockout.Manager
 }
 
-var maxPasscodeTries = 3
+type passcodeMail struct {
+	template string
+	subject  string
+}
+
+// passcodeMails maps each passcode purpose to its mail template and the translation key of the subject.
+var passcodeMails = map[models.PasscodePurpose]passcodeMail{
+	models.PasscodePurposeLogin:             {template: "loginTextMail", subject: "email_subject_login"},
+	models.PasscodePurposeEmailVerification: {template: "emailVerificationTextMail", subject: "email_subject_email_verification"},
+	models.PasscodePurposeAccountRecovery:   {template: "accountRecoveryTextMail", subject: "email_subject_account_recovery"},
+	models.PasscodePurposeSignup:            {template: "signupTextMail", subject: "email_subject_signup"},
+	models.PasscodePurposeEmailChange:       {template: "emailVerificationTextMail", subject: "email_subject_email_verification"},
+}
 
-func NewPasscodeHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, mailer mail.Mailer, auditLogger auditlog.Logger) (*PasscodeHandler, error) {
-	renderer, err := mail.NewRenderer()
+func NewPasscodeHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, mailer mail.Mailer, auditLogger auditlog.Logger, lockoutManager *lockout.Manager) (*PasscodeHandler, error) {
+	sender, err := mail.NewSender(mailer, cfg.Passcode.Email)
+	if err != nil {
+		return nil, err
+	}
+	alphabet := crypto.PasscodeAlphabetNumeric
+	if cfg.Passcode.Policy.Alphabet == config.PasscodeAlphabetAlphanumeric {
+		alphabet = crypto.PasscodeAlphabetAlphanumeric
+	}
+	passcodeGenerator, err := crypto.NewCustomPasscodeGenerator(cfg.Passcode.Policy.Length, alphabet)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new renderer: %w", err)
+		return nil, fmt.Errorf("failed to create passcode generator: %w", err)
 	}
 	var rateLimiter limiter.Store
 	if cfg.RateLimiter.Enabled {
 		rateLimiter = rate_limiter.NewRateLimiter(cfg.RateLimiter, cfg.RateLimiter.PasscodeLimits)
 	}
 	return &PasscodeHandler{
-		mailer:            mailer,
-		renderer:          renderer,
-		passcodeGenerator: crypto.NewPasscodeGenerator(),
+		sender:            sender,
+		passcodeGenerator: passcodeGenerator,
 		persister:         persister,
-		emailConfig:       cfg.Passcode.Email,
 		serviceConfig:     cfg.Service,
 		TTL:               cfg.Passcode.TTL,
 		sessionManager:    sessionManager,
@@ -64,6 +85,7 @@ func NewPasscodeHandler(cfg *config.Config, persister persistence.Persister, ses
 		rateLimiter:       rateLimiter,
 		exclusionEmail:    cfg.Passcode.ExclusionEmail,
 		exclusionCode:     cfg.Passcode.ExclusionCode,
+		lockoutManager:    lockoutManager,
 	}, nil
 }
 
@@ -77,14 +99,24 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 		return dto.ToHttpError(err)
 	}
 
-	userId, err := uuid.FromString(body.UserId)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
-	}
+	var user *models.User
+	var err error
+	if body.UserId != "" {
+		var userId uuid.UUID
+		userId, err = uuid.FromString(body.UserId)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+		}
 
-	user, err := h.persister.GetUserPersister().Get(userId)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		user, err = h.persister.GetUserPersister().Get(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+	} else {
+		user, err = getUserByUsername(h.persister, h.cfg, *body.Username)
+		if err != nil {
+			return err
+		}
 	}
 	if user == nil {
 		err = h.auditLogger.Create(c, models.AuditLogPasscodeLoginInitFailed, nil, fmt.Errorf("unknown user"))
@@ -94,8 +126,16 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("user not found"))
 	}
 
+	blockedError, err := checkBlocked(h.persister.GetConnection(), c, h.auditLogger, user, models.AuditLogPasscodeLoginInitFailed)
+	if err != nil {
+		return err
+	}
+	if blockedError != nil {
+		return blockedError
+	}
+
 	if h.rateLimiter != nil {
-		err := rate_limiter.Limit(h.rateLimiter, userId, c)
+		err := rate_limiter.Limit(h.rateLimiter, user.ID, c)
 		if err != nil {
 			return err
 		}
@@ -153,9 +193,76 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden).SetInternal(errors.New("email address is assigned to another user"))
 	}
 
+	purpose := passcodePurpose(sessionToken, email)
+	if body.Purpose != nil && *body.Purpose != string(purpose) {
+		return echo.NewHTTPError(http.StatusForbidden).SetInternal(fmt.Errorf("requested purpose does not match purpose %s", purpose))
+	}
+
+	passcodeModel, err := h.sendPasscode(c, user, email, purpose, models.AuditLogPasscodeLoginInitFailed)
+	if err != nil {
+		return err
+	}
+
+	err = h.auditLogger.Create(c, models.AuditLogPasscodeLoginInitSucceeded, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, dto.PasscodeReturn{
+		Id:        passcodeModel.ID.String(),
+		TTL:       passcodeModel.Ttl,
+		CreatedAt: passcodeModel.CreatedAt,
+	})
+}
+
+// passcodePurpose derives the purpose of a passcode sent to or redeemed for the given email address: users with a
+// session verify an email address, others sign up with an unverified or sign in with a verified email address.
+func passcodePurpose(sessionToken jwt.Token, email *models.Email) models.PasscodePurpose {
+	if sessionToken != nil {
+		return models.PasscodePurposeEmailVerification
+	} else if !email.Verified {
+		return models.PasscodePurposeSignup
+	}
+	return models.PasscodePurposeLogin
+}
+
+// sendPasscode creates a passcode for the given purpose, invalidates all passcodes previously sent to the email
+// address and mails the new code. Rejected requests are audited with failureLogType.
+func (h *PasscodeHandler) sendPasscode(c echo.Context, user *models.User, email *models.Email, purpose models.PasscodePurpose, failureLogType models.AuditLogType) (*models.Passcode, error) {
+	budgetExhausted, err := h.isFailedAttemptsBudgetExhausted(h.persister.GetFailedPasscodeAttemptPersister(), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if budgetExhausted {
+		err = h.auditLogger.Create(c, failureLogType, user, fmt.Errorf("failed attempts budget exhausted"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, echo.NewHTTPError(http.StatusTooManyRequests, "too many failed passcode attempts")
+	}
+
+	previousPasscodes, err := h.persister.GetPasscodePersister().FindByEmailId(email.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous passcodes: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if cooldown := h.cfg.Passcode.Policy.ResendCooldown; cooldown > 0 && len(previousPasscodes) > 0 {
+		resendAllowedAt := previousPasscodes[0].CreatedAt.Add(cooldown)
+		if resendAllowedAt.After(now) {
+			err = h.auditLogger.Create(c, failureLogType, user, fmt.Errorf("resend cooldown not elapsed"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create audit log: %w", err)
+			}
+			retryAfter := int(math.Ceil(resendAllowedAt.Sub(now).Seconds()))
+			c.Response().Header().Set(httplimit.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return nil, echo.NewHTTPError(http.StatusTooManyRequests, "a passcode has been sent recently, please wait before requesting a new one")
+		}
+	}
+
 	passcode, err := h.passcodeGenerator.Generate()
 	if err != nil {
-		return fmt.Errorf("failed to generate passcode: %w", err)
+		return nil, fmt.Errorf("failed to generate passcode: %w", err)
 	}
 
 	// Adds possibility to override (e.g. for Apple Reviews)
@@ -165,26 +272,37 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 
 	passcodeId, err := uuid.NewV4()
 	if err != nil {
-		return fmt.Errorf("failed to create passcodeId: %w", err)
+		return nil, fmt.Errorf("failed to create passcodeId: %w", err)
 	}
-	now := time.Now().UTC()
-	hashedPasscode, err := bcrypt.GenerateFromPassword([]byte(passcode), 12)
+	hashedPasscode, err := bcrypt.GenerateFromPassword([]byte(h.normalizeCode(passcode)), 12)
 	if err != nil {
-		return fmt.Errorf("failed to hash passcode: %w", err)
+		return nil, fmt.Errorf("failed to hash passcode: %w", err)
 	}
 	passcodeModel := models.Passcode{
 		ID:        passcodeId,
-		UserId:    userId,
+		UserId:    user.ID,
 		EmailID:   email.ID,
 		Ttl:       h.TTL,
 		Code:      string(hashedPasscode),
+		Purpose:   purpose,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
-	err = h.persister.GetPasscodePersister().Create(passcodeModel)
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		passcodePersister := h.persister.GetPasscodePersisterWithConnection(tx)
+		// A new passcode invalidates all passcodes previously sent to the same email address.
+		for _, previousPasscode := range previousPasscodes {
+			err := passcodePersister.Delete(previousPasscode)
+			if err != nil {
+				return fmt.Errorf("failed to delete previous passcode: %w", err)
+			}
+		}
+
+		return passcodePersister.Create(passcodeModel)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to store passcode: %w", err)
+		return nil, fmt.Errorf("failed to store passcode: %w", err)
 	}
 
 	durationTTL := time.Duration(h.TTL) * time.Second
@@ -194,35 +312,13 @@ func (h *PasscodeHandler) Init(c echo.Context) error {
 		"TTL":         fmt.Sprintf("%.0f", durationTTL.Minutes()),
 	}
 
-	lang := c.Request().Header.Get("Accept-Language")
-	str, err := h.renderer.Render("loginTextMail", lang, data)
-	if err != nil {
-		return fmt.Errorf("failed to render email template: %w", err)
-	}
-
-	message := gomail.NewMessage()
-	message.SetAddressHeader("To", email.Address, "")
-	message.SetAddressHeader("From", h.emailConfig.FromAddress, h.emailConfig.FromName)
-
-	message.SetHeader("Subject", h.renderer.Translate(lang, "email_subject_login", data))
-
-	message.SetBody("text/plain", str)
-
-	err = h.mailer.Send(message)
+	mailTemplate := passcodeMails[purpose]
+	err = h.sender.Send(c.Request().Header.Get("Accept-Language"), email.Address, mailTemplate.template, mailTemplate.subject, data)
 	if err != nil {
-		return fmt.Errorf("failed to send passcode: %w", err)
+		return nil, fmt.Errorf("failed to send passcode: %w", err)
 	}
 
-	err = h.auditLogger.Create(c, models.AuditLogPasscodeLoginInitSucceeded, user, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create audit log: %w", err)
-	}
-
-	return c.JSON(http.StatusOK, dto.PasscodeReturn{
-		Id:        passcodeId.String(),
-		TTL:       h.TTL,
-		CreatedAt: passcodeModel.CreatedAt,
-	})
+	return &passcodeModel, nil
 }
 
 func (h *PasscodeHandler) Finish(c echo.Context) error {
@@ -244,73 +340,24 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 	// only if an internal server error occurs the transaction should be rolled back
 	var businessError error
 	transactionError := h.persister.Transaction(func(tx *pop.Connection) error {
-		passcodePersister := h.persister.GetPasscodePersisterWithConnection(tx)
-		userPersister := h.persister.GetUserPersisterWithConnection(tx)
 		emailPersister := h.persister.GetEmailPersisterWithConnection(tx)
 		primaryEmailPersister := h.persister.GetPrimaryEmailPersisterWithConnection(tx)
-		passcode, err := passcodePersister.Get(passcodeId)
-		if err != nil {
-			return fmt.Errorf("failed to get passcode: %w", err)
-		}
-		if passcode == nil {
-			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasscodeLoginFinalFailed, nil, fmt.Errorf("unknown passcode"))
-			if err != nil {
-				return fmt.Errorf("failed to create audit log: %w", err)
-			}
-			businessError = echo.NewHTTPError(http.StatusUnauthorized, "passcode not found")
-			return nil
-		}
-
-		user, err := userPersister.Get(passcode.UserId)
+		existingSessionToken := h.GetSessionToken(c)
+		passcode, user, redeemError, err := h.redeemPasscode(tx, c, passcodeId, body.Code, startTime, models.AuditLogPasscodeLoginFinalFailed, func(passcode *models.Passcode) bool {
+			// The purpose is derived the same way as in Init, so that e.g. account recovery and email change
+			// passcodes can never be used to sign in, whatever purpose the client claims.
+			expectedPurpose := passcodePurpose(existingSessionToken, &passcode.Email)
+			return passcode.Purpose == expectedPurpose &&
+				(body.Purpose == nil || *body.Purpose == string(expectedPurpose))
+		})
 		if err != nil {
-			return fmt.Errorf("failed to get user: %w", err)
-		}
-
-		lastVerificationTime := passcode.CreatedAt.Add(time.Duration(passcode.Ttl) * time.Second)
-		if lastVerificationTime.Before(startTime) {
-			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasscodeLoginFinalFailed, user, fmt.Errorf("timed out passcode"))
-			if err != nil {
-				return fmt.Errorf("failed to create audit log: %w", err)
-			}
-			businessError = echo.NewHTTPError(http.StatusRequestTimeout, "passcode request timed out").SetInternal(errors.New(fmt.Sprintf("createdAt: %s -> lastVerificationTime: %s", passcode.CreatedAt, lastVerificationTime))) // TODO: maybe we should use BadRequest, because RequestTimeout might be to technical and can refer to different error
-			return nil
+			return err
 		}
-
-		err = bcrypt.CompareHashAndPassword([]byte(passcode.Code), []byte(body.Code))
-		if err != nil {
-			passcode.TryCount = passcode.TryCount + 1
-
-			if passcode.TryCount >= maxPasscodeTries {
-				err = passcodePersister.Delete(*passcode)
-				if err != nil {
-					return fmt.Errorf("failed to delete passcode: %w", err)
-				}
-				err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasscodeLoginFinalFailed, user, fmt.Errorf("max attempts reached"))
-				if err != nil {
-					return fmt.Errorf("failed to create audit log: %w", err)
-				}
-				businessError = echo.NewHTTPError(http.StatusGone, "max attempts reached")
-				return nil
-			}
-
-			err = passcodePersister.Update(*passcode)
-			if err != nil {
-				return fmt.Errorf("failed to update passcode: %w", err)
-			}
-
-			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasscodeLoginFinalFailed, user, fmt.Errorf("passcode invalid"))
-			if err != nil {
-				return fmt.Errorf("failed to create audit log: %w", err)
-			}
-			businessError = echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("passcode invalid"))
+		if redeemError != nil {
+			businessError = redeemError
 			return nil
 		}
 
-		err = passcodePersister.Delete(*passcode)
-		if err != nil {
-			return fmt.Errorf("failed to delete passcode: %w", err)
-		}
-
 		if passcode.Email.User != nil && passcode.Email.User.ID.String() != user.ID.String() {
 			return echo.NewHTTPError(http.StatusForbidden, "email address has been claimed by another user")
 		}
@@ -323,7 +370,6 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 			}
 		}
 
-		existingSessionToken := h.GetSessionToken(c)
 		// return forbidden when none of these cases matches
 		if !((existingSessionToken == nil && emailExistsForUser) || // normal login: when user logs in and the email used is associated with the user
 			(existingSessionToken == nil && len(user.Emails) == 0) || // register: when user register and the user has no emails
@@ -362,7 +408,7 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 			}
 		}
 
-		err = h.sessionManager.GenerateCookieOrHeader(passcode.UserId, c)
+		err = h.sessionManager.GenerateCookieOrHeader(tx, passcode.UserId, c)
 		if err != nil {
 			return fmt.Errorf("failed to generate cookie or header: %w", err)
 		}
@@ -386,6 +432,155 @@ func (h *PasscodeHandler) Finish(c echo.Context) error {
 	return transactionError
 }
 
+// redeemPasscode checks the code against the stored passcode and deletes the passcode on success. acceptPurpose
+// decides whether the passcode may be redeemed by the calling flow. Business errors are audited with
+// failureLogType and returned separately, so that the caller can commit the transaction anyway.
+func (h *PasscodeHandler) redeemPasscode(tx *pop.Connection, c echo.Context, passcodeId uuid.UUID, code string, startTime time.Time, failureLogType models.AuditLogType, acceptPurpose func(passcode *models.Passcode) bool) (*models.Passcode, *models.User, *echo.HTTPError, error) {
+	passcodePersister := h.persister.GetPasscodePersisterWithConnection(tx)
+	userPersister := h.persister.GetUserPersisterWithConnection(tx)
+	passcode, err := passcodePersister.Get(passcodeId)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get passcode: %w", err)
+	}
+	if passcode == nil {
+		err = h.auditLogger.CreateWithConnection(tx, c, failureLogType, nil, fmt.Errorf("unknown passcode"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, nil, echo.NewHTTPError(http.StatusUnauthorized, "passcode not found"), nil
+	}
+
+	user, err := userPersister.Get(passcode.UserId)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	lastVerificationTime := passcode.CreatedAt.Add(time.Duration(passcode.Ttl) * time.Second)
+	if lastVerificationTime.Before(startTime) {
+		err = h.auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("timed out passcode"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, nil, echo.NewHTTPError(http.StatusRequestTimeout, "passcode request timed out").SetInternal(errors.New(fmt.Sprintf("createdAt: %s -> lastVerificationTime: %s", passcode.CreatedAt, lastVerificationTime))), nil // TODO: maybe we should use BadRequest, because RequestTimeout might be to technical and can refer to different error
+	}
+
+	blockedError, err := checkBlocked(tx, c, h.auditLogger, user, failureLogType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if blockedError != nil {
+		return nil, nil, blockedError, nil
+	}
+
+	lockedError, err := h.lockoutManager.Check(tx, c, user, failureLogType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if lockedError != nil {
+		return nil, nil, lockedError, nil
+	}
+
+	if !acceptPurpose(passcode) {
+		err = h.auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("passcode used for wrong purpose"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, nil, echo.NewHTTPError(http.StatusForbidden, "passcode was issued for a different purpose"), nil
+	}
+
+	failedAttemptPersister := h.persister.GetFailedPasscodeAttemptPersisterWithConnection(tx)
+	budgetExhausted, err := h.isFailedAttemptsBudgetExhausted(failedAttemptPersister, passcode.UserId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if budgetExhausted {
+		err = h.auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("failed attempts budget exhausted"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, nil, echo.NewHTTPError(http.StatusTooManyRequests, "too many failed passcode attempts"), nil
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(passcode.Code), []byte(h.normalizeCode(code)))
+	if err != nil {
+		passcode.TryCount = passcode.TryCount + 1
+
+		err = failedAttemptPersister.Create(*models.NewFailedPasscodeAttempt(passcode.UserId))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to store failed passcode attempt: %w", err)
+		}
+
+		err = h.lockoutManager.RegisterFailure(tx, c, user)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to register failed login attempt: %w", err)
+		}
+
+		if passcode.TryCount >= h.cfg.Passcode.Policy.MaxAttempts {
+			err = passcodePersister.Delete(*passcode)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to delete passcode: %w", err)
+			}
+			err = h.auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("max attempts reached"))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+			}
+			return nil, nil, echo.NewHTTPError(http.StatusGone, "max attempts reached"), nil
+		}
+
+		err = passcodePersister.Update(*passcode)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to update passcode: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("passcode invalid"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, nil, echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("passcode invalid")), nil
+	}
+
+	err = passcodePersister.Delete(*passcode)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to delete passcode: %w", err)
+	}
+
+	err = failedAttemptPersister.DeleteByUserId(passcode.UserId)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to reset failed passcode attempts: %w", err)
+	}
+
+	err = h.lockoutManager.RegisterSuccess(tx, passcode.UserId)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to reset failed login attempts: %w", err)
+	}
+
+	return passcode, user, nil, nil
+}
+
+// isFailedAttemptsBudgetExhausted reports whether the user has used up the configured number of failed attempts
+// across all of their passcodes within the configured window.
+func (h *PasscodeHandler) isFailedAttemptsBudgetExhausted(persister persistence.FailedPasscodeAttemptPersister, userId uuid.UUID) (bool, error) {
+	policy := h.cfg.Passcode.Policy
+	if policy.MaxFailedAttempts <= 0 {
+		return false, nil
+	}
+
+	count, err := persister.CountByUserIdSince(userId, time.Now().UTC().Add(-policy.FailedAttemptsWindow))
+	if err != nil {
+		return false, fmt.Errorf("failed to count failed passcode attempts: %w", err)
+	}
+
+	return count >= policy.MaxFailedAttempts, nil
+}
+
+// normalizeCode makes alphanumeric passcodes case-insensitive.
+func (h *PasscodeHandler) normalizeCode(code string) string {
+	if h.cfg.Passcode.Policy.Alphabet == config.PasscodeAlphabetAlphanumeric {
+		return strings.ToUpper(strings.TrimSpace(code))
+	}
+	return code
+}
+
 func (h *PasscodeHandler) GetSessionToken(c echo.Context) jwt.Token {
 	var token jwt.Token
 	sessionCookie, _ := c.Cookie("hanko")
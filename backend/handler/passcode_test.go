@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -39,6 +40,7 @@ func (s *passcodeSuite) TestPasscodeHandler_Init() {
 
 	emailId := "51b7c175-ceb6-45ba-aae6-0092221c1b84"
 	unknownEmailId := "83618f24-2db8-4ea2-b370-ac8335f782d8"
+	signupPurpose := string(models.PasscodePurposeSignup)
 	tests := []struct {
 		name                 string
 		body                 dto.PasscodeInitRequest
@@ -69,6 +71,15 @@ func (s *passcodeSuite) TestPasscodeHandler_Init() {
 			},
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "with purpose not matching the verified email",
+			body: dto.PasscodeInitRequest{
+				UserId:  "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5",
+				EmailId: &emailId,
+				Purpose: &signupPurpose,
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
 		{
 			name: "with unknown emailID",
 			body: dto.PasscodeInitRequest{
@@ -118,6 +129,7 @@ func (s *passcodeSuite) TestPasscodeHandler_Finish() {
 		Ttl:       300,
 		Code:      string(hashedPasscode),
 		TryCount:  0,
+		Purpose:   models.PasscodePurposeLogin,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -129,20 +141,37 @@ func (s *passcodeSuite) TestPasscodeHandler_Finish() {
 		Ttl:       300,
 		Code:      string(hashedPasscode),
 		TryCount:  0,
+		Purpose:   models.PasscodePurposeLogin,
 		CreatedAt: now.Add(-500 * time.Second),
 		UpdatedAt: now,
 	}
 
+	accountRecoveryPasscode := passcode
+	accountRecoveryPasscode.Purpose = models.PasscodePurposeAccountRecovery
+
+	emailVerificationPasscode := passcode
+	emailVerificationPasscode.Purpose = models.PasscodePurposeEmailVerification
+
+	signupPasscode := passcode
+	signupPasscode.Purpose = models.PasscodePurposeSignup
+
+	emailChangePasscode := passcode
+	emailChangePasscode.Purpose = models.PasscodePurposeEmailChange
+
 	cfg := func() *config.Config {
 		return &test.DefaultConfig
 	}
 
+	signupPurpose := string(models.PasscodePurposeSignup)
+	loginPurpose := string(models.PasscodePurposeLogin)
+
 	tests := []struct {
 		name               string
 		passcodeId         string
 		retryCount         int
 		passcode           models.Passcode
 		code               string
+		purpose            *string
 		expectedStatusCode int
 		cfg                func() *config.Config
 	}{
@@ -183,6 +212,48 @@ func (s *passcodeSuite) TestPasscodeHandler_Finish() {
 			expectedStatusCode: http.StatusGone,
 			cfg:                cfg,
 		},
+		{
+			name:               "with account recovery passcode",
+			passcodeId:         "a2383922-dea3-46c8-be17-85b267c0d135",
+			passcode:           accountRecoveryPasscode,
+			code:               "123456",
+			expectedStatusCode: http.StatusForbidden,
+			cfg:                cfg,
+		},
+		{
+			name:               "with email verification passcode and no session",
+			passcodeId:         "a2383922-dea3-46c8-be17-85b267c0d135",
+			passcode:           emailVerificationPasscode,
+			code:               "123456",
+			expectedStatusCode: http.StatusForbidden,
+			cfg:                cfg,
+		},
+		{
+			name:               "with signup passcode for verified email",
+			passcodeId:         "a2383922-dea3-46c8-be17-85b267c0d135",
+			passcode:           signupPasscode,
+			code:               "123456",
+			expectedStatusCode: http.StatusForbidden,
+			cfg:                cfg,
+		},
+		{
+			name:               "with email change passcode",
+			passcodeId:         "a2383922-dea3-46c8-be17-85b267c0d135",
+			passcode:           emailChangePasscode,
+			code:               "123456",
+			purpose:            &loginPurpose,
+			expectedStatusCode: http.StatusForbidden,
+			cfg:                cfg,
+		},
+		{
+			name:               "with mismatching purpose",
+			passcodeId:         "a2383922-dea3-46c8-be17-85b267c0d135",
+			passcode:           passcode,
+			code:               "123456",
+			purpose:            &signupPurpose,
+			expectedStatusCode: http.StatusForbidden,
+			cfg:                cfg,
+		},
 		{
 			name:               "with wrong passcode ID",
 			passcodeId:         "297cfc1b-98cc-4ae1-bc83-bcafc7f0e876",
@@ -210,8 +281,9 @@ func (s *passcodeSuite) TestPasscodeHandler_Finish() {
 			s.Require().NoError(err)
 
 			body := dto.PasscodeFinishRequest{
-				Id:   currentTest.passcodeId,
-				Code: currentTest.code,
+				Id:      currentTest.passcodeId,
+				Code:    currentTest.code,
+				Purpose: currentTest.purpose,
 			}
 			bodyJson, err := json.Marshal(body)
 			s.Require().NoError(err)
@@ -245,6 +317,180 @@ func (s *passcodeSuite) TestPasscodeHandler_Finish() {
 	}
 }
 
+func (s *passcodeSuite) TestPasscodeHandler_Init_ResendCooldown() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+	err := s.LoadFixtures("../test/fixtures/passcode")
+	s.Require().NoError(err)
+	s.resetPasscodes()
+
+	cfg := test.DefaultConfig
+	cfg.Passcode.Policy.ResendCooldown = 30 * time.Second
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	rec := s.initPasscode(e)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	rec = s.initPasscode(e)
+	if s.Equal(http.StatusTooManyRequests, rec.Code) {
+		retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		s.Require().NoError(err)
+		s.Greater(retryAfter, 0)
+		s.LessOrEqual(retryAfter, 30)
+	}
+
+	passcodes, err := s.Storage.GetPasscodePersister().FindByEmailId(uuid.FromStringOrNil(passcodeEmailId))
+	s.Require().NoError(err)
+	s.Len(passcodes, 1)
+}
+
+func (s *passcodeSuite) TestPasscodeHandler_Init_BlockedUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+	err := s.LoadFixtures("../test/fixtures/passcode")
+	s.Require().NoError(err)
+	s.resetPasscodes()
+
+	user, err := s.Storage.GetUserPersister().Get(uuid.FromStringOrNil(passcodeUserId))
+	s.Require().NoError(err)
+	blockedAt := time.Now().UTC()
+	user.BlockedAt = &blockedAt
+	s.Require().NoError(s.Storage.GetUserPersister().Update(*user))
+
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil)
+
+	startTime := time.Now().UTC().Add(-time.Second)
+	rec := s.initPasscode(e)
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	passcodes, err := s.Storage.GetPasscodePersister().FindByEmailId(uuid.FromStringOrNil(passcodeEmailId))
+	s.Require().NoError(err)
+	s.Len(passcodes, 0)
+
+	logs, err := s.Storage.GetAuditLogPersister().List(0, 0, &startTime, nil, []string{string(models.AuditLogPasscodeLoginInitFailed)}, passcodeUserId, "", "", "")
+	s.Require().NoError(err)
+	s.Len(logs, 1)
+}
+
+func (s *passcodeSuite) TestPasscodeHandler_Init_InvalidatesPreviousPasscode() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+	err := s.LoadFixtures("../test/fixtures/passcode")
+	s.Require().NoError(err)
+	s.resetPasscodes()
+
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil)
+
+	previousPasscode := s.createPasscode("a2383922-dea3-46c8-be17-85b267c0d135", "123456")
+
+	rec := s.initPasscode(e)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var response dto.PasscodeReturn
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	s.NotEqual(previousPasscode.ID.String(), response.Id)
+
+	rec = s.finishPasscode(e, previousPasscode.ID.String(), "123456")
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	passcodes, err := s.Storage.GetPasscodePersister().FindByEmailId(uuid.FromStringOrNil(passcodeEmailId))
+	s.Require().NoError(err)
+	if s.Len(passcodes, 1) {
+		s.Equal(response.Id, passcodes[0].ID.String())
+	}
+}
+
+func (s *passcodeSuite) TestPasscodeHandler_Finish_FailedAttemptsBudget() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+	err := s.LoadFixtures("../test/fixtures/passcode")
+	s.Require().NoError(err)
+	s.resetPasscodes()
+
+	cfg := test.DefaultConfig
+	cfg.Passcode.Policy.MaxFailedAttempts = 4
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	// uses up the attempts of the first passcode
+	firstPasscode := s.createPasscode("a2383922-dea3-46c8-be17-85b267c0d135", "123456")
+	s.Equal(http.StatusUnauthorized, s.finishPasscode(e, firstPasscode.ID.String(), "654321").Code)
+	s.Equal(http.StatusUnauthorized, s.finishPasscode(e, firstPasscode.ID.String(), "654321").Code)
+	s.Equal(http.StatusGone, s.finishPasscode(e, firstPasscode.ID.String(), "654321").Code)
+
+	// the failed attempts of the first passcode count against the budget of the second one
+	secondPasscode := s.createPasscode("e7b2f4a1-3c6d-4b8e-9f01-2a5c7d9e1b34", "123456")
+	s.Equal(http.StatusUnauthorized, s.finishPasscode(e, secondPasscode.ID.String(), "654321").Code)
+	s.Equal(http.StatusTooManyRequests, s.finishPasscode(e, secondPasscode.ID.String(), "123456").Code)
+
+	// no new passcodes are sent while the budget is exhausted
+	s.Equal(http.StatusTooManyRequests, s.initPasscode(e).Code)
+
+	s.resetPasscodes()
+}
+
+const (
+	passcodeUserId  = "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
+	passcodeEmailId = "51b7c175-ceb6-45ba-aae6-0092221c1b84"
+)
+
+// resetPasscodes removes the passcodes and failed attempts other tests of the suite left for the fixture user.
+func (s *passcodeSuite) resetPasscodes() {
+	passcodes, err := s.Storage.GetPasscodePersister().FindByEmailId(uuid.FromStringOrNil(passcodeEmailId))
+	s.Require().NoError(err)
+	for _, passcode := range passcodes {
+		s.Require().NoError(s.Storage.GetPasscodePersister().Delete(passcode))
+	}
+	s.Require().NoError(s.Storage.GetFailedPasscodeAttemptPersister().DeleteByUserId(uuid.FromStringOrNil(passcodeUserId)))
+}
+
+func (s *passcodeSuite) createPasscode(id string, code string) models.Passcode {
+	hashedPasscode, err := bcrypt.GenerateFromPassword([]byte(code), 12)
+	s.Require().NoError(err)
+
+	now := time.Now().UTC()
+	passcode := models.Passcode{
+		ID:        uuid.FromStringOrNil(id),
+		UserId:    uuid.FromStringOrNil(passcodeUserId),
+		EmailID:   uuid.FromStringOrNil(passcodeEmailId),
+		Ttl:       300,
+		Code:      string(hashedPasscode),
+		Purpose:   models.PasscodePurposeLogin,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.Require().NoError(s.Storage.GetPasscodePersister().Create(passcode))
+
+	return passcode
+}
+
+func (s *passcodeSuite) initPasscode(e http.Handler) *httptest.ResponseRecorder {
+	bodyJson, err := json.Marshal(dto.PasscodeInitRequest{UserId: passcodeUserId})
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/passcode/login/initialize", bytes.NewReader(bodyJson))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (s *passcodeSuite) finishPasscode(e http.Handler, id string, code string) *httptest.ResponseRecorder {
+	bodyJson, err := json.Marshal(dto.PasscodeFinishRequest{Id: id, Code: code})
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/passcode/login/finalize", bytes.NewReader(bodyJson))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
 func (s *passcodeSuite) GetToEmailAddress(data string) string {
 	to := regexp.MustCompile("To: (.*)\r")
 	matchTo := to.FindStringSubmatch(data)
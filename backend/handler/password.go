@@ -10,14 +10,16 @@ import (
 	"github.com/sethvargo/go-limiter"
 	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/password_hash"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/lockout"
+	"github.com/teamhanko/hanko/backend/password_policy"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/rate_limiter"
 	"github.com/teamhanko/hanko/backend/session"
-	"golang.org/x/crypto/bcrypt"
 	"net/http"
-	"unicode/utf8"
+	"strings"
 )
 
 type PasswordHandler struct {
@@ -26,9 +28,12 @@ type PasswordHandler struct {
 	cfg            *config.Config
 	auditLogger    auditlog.Logger
 	rateLimiter    limiter.Store
+	policy         *password_policy.Policy
+	hasher         *password_hash.Hasher
+	lockoutManager *lockout.Manager
 }
 
-func NewPasswordHandler(persister persistence.Persister, sessionManager session.Manager, cfg *config.Config, auditLogger auditlog.Logger) *PasswordHandler {
+func NewPasswordHandler(persister persistence.Persister, sessionManager session.Manager, cfg *config.Config, auditLogger auditlog.Logger, lockoutManager *lockout.Manager) *PasswordHandler {
 	var rateLimiter limiter.Store
 	if cfg.RateLimiter.Enabled {
 		rateLimiter = rate_limiter.NewRateLimiter(cfg.RateLimiter, cfg.RateLimiter.PasswordLimits)
@@ -39,6 +44,9 @@ func NewPasswordHandler(persister persistence.Persister, sessionManager session.
 		cfg:            cfg,
 		auditLogger:    auditLogger,
 		rateLimiter:    rateLimiter,
+		policy:         password_policy.NewPolicy(cfg.Password),
+		hasher:         password_hash.NewHasher(cfg.Password.Hashing),
+		lockoutManager: lockoutManager,
 	}
 }
 
@@ -72,23 +80,6 @@ func (h *PasswordHandler) Set(c echo.Context) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	pwBytes := []byte(body.Password)
-	if utf8.RuneCountInString(body.Password) < h.cfg.Password.MinPasswordLength { // use utf8.RuneCountInString, so utf8 characters would count as 1
-		err = h.auditLogger.Create(c, models.AuditLogPasswordSetFailed, user, fmt.Errorf("password too short"))
-		if err != nil {
-			return fmt.Errorf("failed to create audit log: %w", err)
-		}
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("password must be at least %d characters long", h.cfg.Password.MinPasswordLength))
-	}
-
-	if len(pwBytes) > 72 {
-		err = h.auditLogger.Create(c, models.AuditLogPasswordSetFailed, user, fmt.Errorf("password too long"))
-		if err != nil {
-			return fmt.Errorf("failed to create audit log: %w", err)
-		}
-		return echo.NewHTTPError(http.StatusBadRequest, "password must not be longer than 72 bytes")
-	}
-
 	if user == nil {
 		err = h.auditLogger.Create(c, models.AuditLogPasswordSetFailed, user, fmt.Errorf("unknown user: %s", body.UserID))
 		if err != nil {
@@ -105,53 +96,134 @@ func (h *PasswordHandler) Set(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusForbidden).SetInternal(errors.New(fmt.Sprintf("session.userId %s tried to set password credentials for body.userId %s", sessionUserId, user.ID)))
 	}
 
+	violations, err := h.checkPasswordPolicy(user, body.Password)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return h.rejectPassword(c, user, models.AuditLogPasswordSetFailed, violations)
+	}
+
 	return h.persister.Transaction(func(tx *pop.Connection) error {
-		pwPersister := h.persister.GetPasswordCredentialPersisterWithConnection(tx)
-		pw, err := pwPersister.GetByUserID(user.ID)
+		created, err := h.storePassword(tx, user.ID, body.Password)
 		if err != nil {
-			return fmt.Errorf("failed to get credential: %w", err)
+			return err
 		}
 
-		hashedPassword, err := bcrypt.GenerateFromPassword(pwBytes, 12)
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasswordSetSucceeded, user, nil)
 		if err != nil {
-			return fmt.Errorf("failed to hash password: %s", err)
+			return fmt.Errorf("failed to create audit log: %w", err)
 		}
 
-		newPw := models.PasswordCredential{
-			UserId:   uuid.FromStringOrNil(body.UserID),
-			Password: string(hashedPassword),
+		if created {
+			return c.JSON(http.StatusCreated, nil)
 		}
+		return c.JSON(http.StatusOK, nil)
+	})
+}
 
-		if pw == nil {
-			err = pwPersister.Create(newPw)
-			if err != nil {
-				return fmt.Errorf("failed to create password: %w", err)
-			} else {
-				err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasswordSetSucceeded, user, nil)
-				if err != nil {
-					return fmt.Errorf("failed to create audit log: %w", err)
-				}
-				return c.JSON(http.StatusCreated, nil)
-			}
-		} else {
-			newPw.ID = pw.ID
-			err = pwPersister.Update(newPw)
-			if err != nil {
-				return fmt.Errorf("failed to set password: %w", err)
-			} else {
-				err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasswordSetSucceeded, user, nil)
-				if err != nil {
-					return fmt.Errorf("failed to create audit log: %w", err)
-				}
-				return c.JSON(http.StatusOK, nil)
+// checkPasswordPolicy returns the requirements of the password policy the new password of the user does not fulfil.
+func (h *PasswordHandler) checkPasswordPolicy(user *models.User, password string) ([]password_policy.Violation, error) {
+	var emailAddresses []string
+	for _, email := range user.Emails {
+		emailAddresses = append(emailAddresses, email.Address)
+	}
+
+	var previousHashes []string
+	if historySize := h.cfg.Password.Policy.HistorySize; historySize > 0 {
+		current, err := h.persister.GetPasswordCredentialPersister().GetByUserID(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credential: %w", err)
+		}
+		if current != nil {
+			previousHashes = append(previousHashes, current.Password)
+		}
+
+		entries, err := h.persister.GetPasswordHistoryPersister().ListByUserId(user.ID, historySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password history: %w", err)
+		}
+		for _, entry := range entries {
+			if current == nil || entry.Password != current.Password {
+				previousHashes = append(previousHashes, entry.Password)
 			}
 		}
+	}
+
+	return h.policy.Check(password, emailAddresses, previousHashes)
+}
+
+// rejectPassword audits the policy violations and responds with their machine-readable codes.
+func (h *PasswordHandler) rejectPassword(c echo.Context, user *models.User, auditLogType models.AuditLogType, violations []password_policy.Violation) error {
+	codes := make([]string, len(violations))
+	for i, violation := range violations {
+		codes[i] = string(violation)
+	}
+
+	err := h.auditLogger.Create(c, auditLogType, user, fmt.Errorf("password policy violated: %s", strings.Join(codes, ", ")))
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return c.JSON(http.StatusBadRequest, dto.PasswordPolicyErrorResponse{
+		Code:       http.StatusBadRequest,
+		Message:    h.policy.Describe(violations[0]),
+		Violations: codes,
 	})
 }
 
+// storePassword hashes the password and creates or replaces the password credential of the user. It reports
+// whether a new credential has been created.
+func (h *PasswordHandler) storePassword(tx *pop.Connection, userId uuid.UUID, password string) (bool, error) {
+	pwPersister := h.persister.GetPasswordCredentialPersisterWithConnection(tx)
+	pw, err := pwPersister.GetByUserID(userId)
+	if err != nil {
+		return false, fmt.Errorf("failed to get credential: %w", err)
+	}
+
+	hashedPassword, err := h.hasher.Hash(password)
+	if err != nil {
+		return false, err
+	}
+
+	if historySize := h.cfg.Password.Policy.HistorySize; historySize > 0 {
+		historyPersister := h.persister.GetPasswordHistoryPersisterWithConnection(tx)
+		err = historyPersister.Create(*models.NewPasswordHistoryEntry(userId, hashedPassword))
+		if err != nil {
+			return false, fmt.Errorf("failed to store password history entry: %w", err)
+		}
+		err = historyPersister.DeleteOlderByUserId(userId, historySize)
+		if err != nil {
+			return false, fmt.Errorf("failed to prune password history: %w", err)
+		}
+	}
+
+	newPw := models.PasswordCredential{
+		UserId:   userId,
+		Password: hashedPassword,
+	}
+
+	if pw == nil {
+		err = pwPersister.Create(newPw)
+		if err != nil {
+			return false, fmt.Errorf("failed to create password: %w", err)
+		}
+		return true, nil
+	}
+
+	newPw.ID = pw.ID
+	err = pwPersister.Update(newPw)
+	if err != nil {
+		return false, fmt.Errorf("failed to set password: %w", err)
+	}
+	return false, nil
+}
+
 type PasswordLoginBody struct {
-	UserId   string `json:"user_id" validate:"required,uuid4"`
-	Password string `json:"password" validate:"required"`
+	UserId string `json:"user_id" validate:"required_without=Username,omitempty,uuid4"`
+	// Username can be used instead of UserId if usernames are enabled.
+	Username *string `json:"username"`
+	Password string  `json:"password" validate:"required"`
 }
 
 func (h *PasswordHandler) Login(c echo.Context) error {
@@ -164,9 +236,27 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		return dto.ToHttpError(err)
 	}
 
-	userId, err := uuid.FromString(body.UserId)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "user_id is not a uuid").SetInternal(err)
+	var userId uuid.UUID
+	var user *models.User
+	var err error
+	if body.UserId != "" {
+		userId, err = uuid.FromString(body.UserId)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "user_id is not a uuid").SetInternal(err)
+		}
+	} else {
+		user, err = getUserByUsername(h.persister, h.cfg, *body.Username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			err = h.auditLogger.Create(c, models.AuditLogPasswordLoginFailed, nil, fmt.Errorf("unknown username: %s", *body.Username))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
+		}
+		userId = user.ID
 	}
 
 	if h.rateLimiter != nil {
@@ -176,20 +266,37 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		}
 	}
 
-	user, err := h.persister.GetUserPersister().Get(userId)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
-	}
 	if user == nil {
-		err = h.auditLogger.Create(c, models.AuditLogPasswordLoginFailed, nil, fmt.Errorf("unknown user: %s", userId))
+		user, err = h.persister.GetUserPersister().Get(userId)
 		if err != nil {
-			return fmt.Errorf("failed to create audit log: %w", err)
+			return fmt.Errorf("failed to get user: %w", err)
 		}
-		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
+		if user == nil {
+			err = h.auditLogger.Create(c, models.AuditLogPasswordLoginFailed, nil, fmt.Errorf("unknown user: %s", userId))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
+		}
+	}
+
+	blockedError, err := checkBlocked(h.persister.GetConnection(), c, h.auditLogger, user, models.AuditLogPasswordLoginFailed)
+	if err != nil {
+		return err
+	}
+	if blockedError != nil {
+		return blockedError
 	}
 
-	pwBytes := []byte(body.Password)
-	if len(pwBytes) > 72 {
+	lockedError, err := h.lockoutManager.Check(h.persister.GetConnection(), c, user, models.AuditLogPasswordLoginFailed)
+	if err != nil {
+		return err
+	}
+	if lockedError != nil {
+		return lockedError
+	}
+
+	if h.hasher.LimitsPasswordLength() && len([]byte(body.Password)) > 72 {
 		err = h.auditLogger.Create(c, models.AuditLogPasswordLoginFailed, user, errors.New("password too long"))
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
@@ -197,28 +304,54 @@ func (h *PasswordHandler) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "password must not be longer than 72 bytes")
 	}
 
-	pw, err := h.persister.GetPasswordCredentialPersister().GetByUserID(uuid.FromStringOrNil(body.UserId))
+	pw, err := h.persister.GetPasswordCredentialPersister().GetByUserID(user.ID)
 	if pw == nil {
 		err = h.auditLogger.Create(c, models.AuditLogPasswordLoginFailed, user, fmt.Errorf("user has no password credential"))
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
-		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New(fmt.Sprintf("no password credential found for: %s", body.UserId)))
+		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New(fmt.Sprintf("no password credential found for: %s", user.ID)))
 	}
 
 	if err != nil {
 		return fmt.Errorf("error retrieving credential: %w", err)
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(pw.Password), pwBytes); err != nil {
+	valid, err := h.hasher.Verify(body.Password, pw.Password)
+	if err != nil {
+		return fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !valid {
 		err = h.auditLogger.Create(c, models.AuditLogPasswordLoginFailed, user, fmt.Errorf("password hash not equal"))
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
-		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(err)
+		err = h.lockoutManager.RegisterFailure(h.persister.GetConnection(), c, user)
+		if err != nil {
+			return fmt.Errorf("failed to register failed login attempt: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("password hash not equal"))
+	}
+
+	err = h.lockoutManager.RegisterSuccess(h.persister.GetConnection(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reset failed login attempts: %w", err)
+	}
+
+	// upgrade hashes of other algorithms or outdated parameters while the plain password is at hand
+	if h.hasher.NeedsRehash(pw.Password) {
+		rehashed, err := h.hasher.Hash(body.Password)
+		if err != nil {
+			return err
+		}
+		pw.Password = rehashed
+		err = h.persister.GetPasswordCredentialPersister().Update(*pw)
+		if err != nil {
+			return fmt.Errorf("failed to update password hash: %w", err)
+		}
 	}
 
-	err = h.sessionManager.GenerateCookieOrHeader(pw.UserId, c)
+	err = h.sessionManager.GenerateCookieOrHeader(nil, pw.UserId, c)
 	if err != nil {
 		return fmt.Errorf("failed to generate cookie or header: %w", err)
 	}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rate_limiter"
+	"github.com/teamhanko/hanko/backend/session"
+	"net/http"
+	"time"
+)
+
+// PasswordResetHandler lets users who forgot their password set a new one after proving access to their primary
+// email address with an account recovery passcode.
+type PasswordResetHandler struct {
+	passcodeHandler *PasscodeHandler
+	passwordHandler *PasswordHandler
+	persister       persistence.Persister
+	sessionManager  session.Manager
+	cfg             *config.Config
+	auditLogger     auditlog.Logger
+}
+
+func NewPasswordResetHandler(passcodeHandler *PasscodeHandler, passwordHandler *PasswordHandler, persister persistence.Persister, sessionManager session.Manager, cfg *config.Config, auditLogger auditlog.Logger) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passcodeHandler: passcodeHandler,
+		passwordHandler: passwordHandler,
+		persister:       persister,
+		sessionManager:  sessionManager,
+		cfg:             cfg,
+		auditLogger:     auditLogger,
+	}
+}
+
+// errPasswordRejected rolls back the transaction of Finish when the new password violates the password policy.
+var errPasswordRejected = errors.New("password rejected")
+
+type PasswordResetInitBody struct {
+	UserId string `json:"user_id" validate:"required,uuid4"`
+}
+
+type PasswordResetFinishBody struct {
+	Id       string `json:"id" validate:"required,uuid4"`
+	Code     string `json:"code" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (h *PasswordResetHandler) Init(c echo.Context) error {
+	var body PasswordResetInitBody
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	userId, err := uuid.FromString(body.UserId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		err = h.auditLogger.Create(c, models.AuditLogPasswordResetInitFailed, nil, fmt.Errorf("unknown user"))
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("user not found"))
+	}
+
+	blockedError, err := checkBlocked(h.persister.GetConnection(), c, h.auditLogger, user, models.AuditLogPasswordResetInitFailed)
+	if err != nil {
+		return err
+	}
+	if blockedError != nil {
+		return blockedError
+	}
+
+	lockedError, err := h.passcodeHandler.lockoutManager.Check(h.persister.GetConnection(), c, user, models.AuditLogPasswordResetInitFailed)
+	if err != nil {
+		return err
+	}
+	if lockedError != nil {
+		return lockedError
+	}
+
+	if h.passcodeHandler.rateLimiter != nil {
+		err := rate_limiter.Limit(h.passcodeHandler.rateLimiter, userId, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	email := user.Emails.GetPrimary()
+	if email == nil {
+		err = h.auditLogger.Create(c, models.AuditLogPasswordResetInitFailed, user, fmt.Errorf("user has no primary email"))
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "user has no primary email address")
+	}
+
+	passcode, err := h.passcodeHandler.sendPasscode(c, user, email, models.PasscodePurposeAccountRecovery, models.AuditLogPasswordResetInitFailed)
+	if err != nil {
+		return err
+	}
+
+	err = h.auditLogger.Create(c, models.AuditLogPasswordResetInitSucceeded, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, dto.PasscodeReturn{
+		Id:        passcode.ID.String(),
+		TTL:       passcode.Ttl,
+		CreatedAt: passcode.CreatedAt,
+	})
+}
+
+func (h *PasswordResetHandler) Finish(c echo.Context) error {
+	startTime := time.Now().UTC()
+	var body PasswordResetFinishBody
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	passcodeId, err := uuid.FromString(body.Id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse passcodeId as uuid").SetInternal(err)
+	}
+
+	// Only the checks which do not depend on the user run before the passcode has been verified. The password history
+	// and the email addresses of the user must not be revealed to someone who does not know the passcode.
+	violations, err := h.passwordHandler.policy.Check(body.Password, nil, nil)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return h.passwordHandler.rejectPassword(c, nil, models.AuditLogPasswordResetFinalFailed, violations)
+	}
+
+	var rejectedUser *models.User
+	// only if an internal server error occurs the transaction should be rolled back
+	var businessError error
+	transactionError := h.persister.Transaction(func(tx *pop.Connection) error {
+		passcode, user, redeemError, err := h.passcodeHandler.redeemPasscode(tx, c, passcodeId, body.Code, startTime, models.AuditLogPasswordResetFinalFailed, func(passcode *models.Passcode) bool {
+			return passcode.Purpose == models.PasscodePurposeAccountRecovery
+		})
+		if err != nil {
+			return err
+		}
+		if redeemError != nil {
+			businessError = redeemError
+			return nil
+		}
+
+		violations, err = h.passwordHandler.checkPasswordPolicy(user, body.Password)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			// roll back the redemption, so that a rejected password does not use up the passcode
+			rejectedUser = user
+			return errPasswordRejected
+		}
+
+		// the passcode must have been sent to the address that is still the primary one
+		if primaryEmail := user.Emails.GetPrimary(); primaryEmail == nil || primaryEmail.ID != passcode.EmailID {
+			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasswordResetFinalFailed, user, fmt.Errorf("passcode was not sent to the primary email"))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			businessError = echo.NewHTTPError(http.StatusForbidden).SetInternal(errors.New("passcode was not sent to the primary email"))
+			return nil
+		}
+
+		_, err = h.passwordHandler.storePassword(tx, user.ID, body.Password)
+		if err != nil {
+			return err
+		}
+
+		if h.cfg.Password.RevokeSessionsOnReset {
+			err = h.persister.GetSessionPersisterWithConnection(tx).DeleteByUserId(user.ID)
+			if err != nil {
+				return fmt.Errorf("failed to revoke sessions: %w", err)
+			}
+		}
+
+		err = h.sessionManager.GenerateCookieOrHeader(tx, user.ID, c)
+		if err != nil {
+			return fmt.Errorf("failed to generate cookie or header: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPasswordResetFinalSucceeded, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, nil)
+	})
+
+	if businessError != nil {
+		return businessError
+	}
+
+	if errors.Is(transactionError, errPasswordRejected) {
+		return h.passwordHandler.rejectPassword(c, rejectedUser, models.AuditLogPasswordResetFinalFailed, violations)
+	}
+
+	return transactionError
+}
@@ -1,19 +1,24 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
 	"github.com/teamhanko/hanko/backend/session"
 	"github.com/teamhanko/hanko/backend/test"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPasswordSuite(t *testing.T) {
@@ -163,6 +168,31 @@ func (s *passwordSuite) TestPasswordHandler_Login() {
 			cfg:          cfg,
 			expectedCode: http.StatusUnauthorized,
 		},
+		{
+			name: "should login successful with username",
+			body: `{"username": "John.Doe", "password": "SuperSecure"}`,
+			cfg: func() *config.Config {
+				cfg := test.DefaultConfig
+				cfg.Password.Enabled = true
+				cfg.Username = config.DefaultConfig().Username
+				cfg.Username.Enabled = true
+				return &cfg
+			},
+			expectedCode:        http.StatusOK,
+			shouldContainCookie: true,
+		},
+		{
+			name:         "should not login with username if usernames are disabled",
+			body:         `{"username": "john.doe", "password": "SuperSecure"}`,
+			cfg:          cfg,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "should not login without user id and username",
+			body:         `{"password": "SuperSecure"}`,
+			cfg:          cfg,
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, currentTest := range tests {
@@ -196,6 +226,274 @@ func (s *passwordSuite) TestPasswordHandler_Login() {
 	}
 }
 
+func (s *passwordSuite) TestPasswordResetHandler() {
+	if testing.Short() {
+		s.T().Skip("skipping in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	userWithPassword := uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925")
+
+	cfg := test.DefaultConfig
+	cfg.Password.Enabled = true
+	cfg.Password.MinPasswordLength = 8
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/password/reset/initialize", strings.NewReader(fmt.Sprintf(`{"user_id": "%s"}`, userWithPassword)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var passcode dto.PasscodeReturn
+	err = json.Unmarshal(rec.Body.Bytes(), &passcode)
+	s.Require().NoError(err)
+
+	messages := s.EmailServer.Messages()
+	s.Require().NotEmpty(messages)
+	matches := regexp.MustCompile("Subject: Use passcode ([0-9]+) to recover your Test account").FindStringSubmatch(messages[len(messages)-1].MsgRequest())
+	s.Require().Len(matches, 2)
+	code := matches[1]
+
+	// the passcode can not be used to sign in
+	req = httptest.NewRequest(http.MethodPost, "/passcode/login/finalize", strings.NewReader(fmt.Sprintf(`{"id": "%s", "code": "%s"}`, passcode.Id, code)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	// a password that violates the policy is rejected without using up the passcode
+	req = httptest.NewRequest(http.MethodPost, "/password/reset/finalize", strings.NewReader(fmt.Sprintf(`{"id": "%s", "code": "%s", "password": "short"}`, passcode.Id, code)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/password/reset/finalize", strings.NewReader(fmt.Sprintf(`{"id": "%s", "code": "%s", "password": "NewSuperSecure"}`, passcode.Id, code)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/password/login", strings.NewReader(fmt.Sprintf(`{"user_id": "%s", "password": "NewSuperSecure"}`, userWithPassword)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code)
+}
+
+func (s *passwordSuite) TestPasswordResetHandler_WrongCodeDoesNotRevealPolicyViolations() {
+	if testing.Short() {
+		s.T().Skip("skipping in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	userWithPassword := uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925")
+
+	currentPassword, err := s.Storage.GetPasswordCredentialPersister().GetByUserID(userWithPassword)
+	s.Require().NoError(err)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("CurrentSuperSecure"), 12)
+	s.Require().NoError(err)
+	currentPassword.Password = string(hashedPassword)
+	s.Require().NoError(s.Storage.GetPasswordCredentialPersister().Update(*currentPassword))
+
+	cfg := test.DefaultConfig
+	cfg.Password.Enabled = true
+	cfg.Password.MinPasswordLength = 8
+	cfg.Password.Policy.HistorySize = 3
+	cfg.Password.Policy.DisallowEmailLocalPart = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/password/reset/initialize", strings.NewReader(fmt.Sprintf(`{"user_id": "%s"}`, userWithPassword)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var passcode dto.PasscodeReturn
+	err = json.Unmarshal(rec.Body.Bytes(), &passcode)
+	s.Require().NoError(err)
+
+	messages := s.EmailServer.Messages()
+	s.Require().NotEmpty(messages)
+	matches := regexp.MustCompile("Subject: Use passcode ([0-9]+) to recover your Test account").FindStringSubmatch(messages[len(messages)-1].MsgRequest())
+	s.Require().Len(matches, 2)
+	code := matches[1]
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+
+	for _, password := range []string{"CurrentSuperSecure", "john.doe+1-Secure"} {
+		req = httptest.NewRequest(http.MethodPost, "/password/reset/finalize", strings.NewReader(fmt.Sprintf(`{"id": "%s", "code": "%s", "password": "%s"}`, passcode.Id, wrongCode, password)))
+		req.Header.Set("Content-Type", "application/json")
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		s.Equal(http.StatusUnauthorized, rec.Code)
+		s.NotContains(rec.Body.String(), "password_previously_used")
+		s.NotContains(rec.Body.String(), "password_contains_email")
+	}
+
+	// with the right code the violation is reported without using up the passcode
+	req = httptest.NewRequest(http.MethodPost, "/password/reset/finalize", strings.NewReader(fmt.Sprintf(`{"id": "%s", "code": "%s", "password": "CurrentSuperSecure"}`, passcode.Id, code)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusBadRequest, rec.Code)
+	s.Contains(rec.Body.String(), "password_previously_used")
+
+	req = httptest.NewRequest(http.MethodPost, "/password/reset/finalize", strings.NewReader(fmt.Sprintf(`{"id": "%s", "code": "%s", "password": "NewSuperSecure"}`, passcode.Id, code)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code)
+}
+
+func (s *passwordSuite) TestPasswordLoginLockout() {
+	if testing.Short() {
+		s.T().Skip("skipping in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	userWithPassword := uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925")
+
+	cfg := test.DefaultConfig
+	cfg.Password.Enabled = true
+	cfg.Account.Lockout = config.AccountLockout{
+		Enabled:           true,
+		MaxFailedAttempts: 3,
+		Window:            time.Hour,
+		Duration:          time.Hour,
+		MaxDuration:       24 * time.Hour,
+		UnlockLinkUrl:     "https://app.example.com/unlock",
+		UnlockLinkTTL:     time.Hour,
+	}
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	login := func(password string) int {
+		req := httptest.NewRequest(http.MethodPost, "/password/login", strings.NewReader(fmt.Sprintf(`{"user_id": "%s", "password": "%s"}`, userWithPassword, password)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		s.Equal(http.StatusUnauthorized, login("verybadpassword"))
+	}
+
+	// the correct password is rejected while the account is locked
+	s.Equal(http.StatusLocked, login("SuperSecure"))
+
+	messages := s.EmailServer.Messages()
+	s.Require().NotEmpty(messages)
+	s.Contains(messages[len(messages)-1].MsgRequest(), "Subject: Your Test account has been locked")
+
+	adminRouter := NewAdminRouter(&cfg, s.Storage, nil)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%s/unlock", userWithPassword), nil)
+	rec := httptest.NewRecorder()
+	adminRouter.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var user admin.User
+	err = json.Unmarshal(rec.Body.Bytes(), &user)
+	s.Require().NoError(err)
+	s.Require().NotNil(user.Lockout)
+	s.False(user.Lockout.IsLocked)
+
+	s.Equal(http.StatusOK, login("SuperSecure"))
+}
+
+func (s *passwordSuite) TestPasswordLoginBlocked() {
+	if testing.Short() {
+		s.T().Skip("skipping in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	userWithPassword := uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925")
+
+	cfg := test.DefaultConfig
+	cfg.Password.Enabled = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+	adminRouter := NewAdminRouter(&cfg, s.Storage, nil)
+
+	login := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/password/login", strings.NewReader(fmt.Sprintf(`{"user_id": "%s", "password": "SuperSecure"}`, userWithPassword)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	s.Require().Equal(http.StatusOK, login())
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%s/block", userWithPassword), strings.NewReader(`{"reason": "spam"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	adminRouter.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var user admin.User
+	err = json.Unmarshal(rec.Body.Bytes(), &user)
+	s.Require().NoError(err)
+	s.Require().NotNil(user.Blocked)
+	s.Equal("spam", *user.Blocked.Reason)
+	s.Nil(user.Blocked.ExpiresAt)
+
+	s.Equal(http.StatusForbidden, login())
+
+	req = httptest.NewRequest(http.MethodGet, "/users?blocked=true", nil)
+	rec = httptest.NewRecorder()
+	adminRouter.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.Equal("1", rec.Header().Get("X-Total-Count"))
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%s/unblock", userWithPassword), nil)
+	rec = httptest.NewRecorder()
+	adminRouter.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	s.Equal(http.StatusOK, login())
+}
+
+func (s *passwordSuite) TestPasswordResetBlocked() {
+	if testing.Short() {
+		s.T().Skip("skipping in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	userWithPassword := uuid.FromStringOrNil("38bf5a00-d7ea-40a5-a5de-48722c148925")
+
+	user, err := s.Storage.GetUserPersister().Get(userWithPassword)
+	s.Require().NoError(err)
+	blockedAt := time.Now().UTC()
+	user.BlockedAt = &blockedAt
+	s.Require().NoError(s.Storage.GetUserPersister().Update(*user))
+
+	cfg := test.DefaultConfig
+	cfg.Password.Enabled = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	messageCount := len(s.EmailServer.Messages())
+
+	req := httptest.NewRequest(http.MethodPost, "/password/reset/initialize", strings.NewReader(fmt.Sprintf(`{"user_id": "%s"}`, userWithPassword)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusForbidden, rec.Code)
+	s.Len(s.EmailServer.Messages(), messageCount)
+}
+
 func (s *passwordSuite) GetDefaultSessionManager() session.Manager {
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	s.Require().NoError(err)
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rbac"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type PermissionHandlerAdmin struct {
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+}
+
+func NewPermissionHandlerAdmin(persister persistence.Persister, auditLogger auditlog.Logger) *PermissionHandlerAdmin {
+	return &PermissionHandlerAdmin{
+		persister:   persister,
+		auditLogger: auditLogger,
+	}
+}
+
+func (h *PermissionHandlerAdmin) List(c echo.Context) error {
+	permissions, err := h.persister.GetPermissionPersister().List()
+	if err != nil {
+		return fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	response := make([]admin.Permission, len(permissions))
+	for i := range permissions {
+		response[i] = admin.FromPermissionModel(permissions[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *PermissionHandlerAdmin) Get(c echo.Context) error {
+	permission, err := h.getPermission(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromPermissionModel(*permission))
+}
+
+func (h *PermissionHandlerAdmin) Create(c echo.Context) error {
+	var body admin.CreatePermission
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if !rbac.ValidName(body.Name) {
+		return echo.NewHTTPError(http.StatusBadRequest, "name contains invalid characters")
+	}
+
+	permission := models.NewPermission(body.Name, trimmedOrNil(body.Description))
+
+	err := h.persister.Transaction(func(tx *pop.Connection) error {
+		permissionPersister := h.persister.GetPermissionPersisterWithConnection(tx)
+		existing, err := permissionPersister.GetByName(permission.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get permission: %w", err)
+		}
+		if existing != nil {
+			return echo.NewHTTPError(http.StatusConflict, "permission already exists")
+		}
+
+		err = permissionPersister.Create(permission)
+		if err != nil {
+			return fmt.Errorf("failed to create permission: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPermissionCreated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, admin.FromPermissionModel(permission))
+}
+
+func (h *PermissionHandlerAdmin) Update(c echo.Context) error {
+	var body admin.UpdatePermission
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if body.Name != nil && !rbac.ValidName(*body.Name) {
+		return echo.NewHTTPError(http.StatusBadRequest, "name contains invalid characters")
+	}
+
+	permission, err := h.getPermission(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		permissionPersister := h.persister.GetPermissionPersisterWithConnection(tx)
+		if body.Name != nil && *body.Name != permission.Name {
+			existing, err := permissionPersister.GetByName(*body.Name)
+			if err != nil {
+				return fmt.Errorf("failed to get permission: %w", err)
+			}
+			if existing != nil {
+				return echo.NewHTTPError(http.StatusConflict, "permission already exists")
+			}
+			permission.Name = *body.Name
+		}
+		if body.Description != nil {
+			permission.Description = trimmedOrNil(body.Description)
+		}
+		permission.UpdatedAt = time.Now().UTC()
+
+		err = permissionPersister.Update(*permission)
+		if err != nil {
+			return fmt.Errorf("failed to update permission: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPermissionUpdated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromPermissionModel(*permission))
+}
+
+// Delete deletes the permission and revokes it from all roles.
+func (h *PermissionHandlerAdmin) Delete(c echo.Context) error {
+	permission, err := h.getPermission(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetPermissionPersisterWithConnection(tx).Delete(*permission)
+		if err != nil {
+			return fmt.Errorf("failed to delete permission: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogPermissionDeleted, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *PermissionHandlerAdmin) getPermission(c echo.Context) (*models.Permission, error) {
+	permissionId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse permissionId as uuid").SetInternal(err)
+	}
+
+	permission, err := h.persister.GetPermissionPersister().Get(permissionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permission: %w", err)
+	}
+
+	if permission == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "permission not found")
+	}
+
+	return permission, nil
+}
+
+// trimmedOrNil returns nil if value is nil or only contains whitespace and the trimmed value otherwise.
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
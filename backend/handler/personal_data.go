@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+)
+
+const personalDataAuditLogPageSize = 1000
+
+// exportPersonalData collects all data stored about the user and sends it as JSON file. The export is audited with
+// the user as actor, also if an admin requested it.
+func exportPersonalData(c echo.Context, persister persistence.Persister, auditLogger auditlog.Logger, user *models.User, includePrivateMetadata bool) error {
+	credentials, err := persister.GetWebauthnCredentialPersister().GetFromUser(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get webauthn credentials: %w", err)
+	}
+
+	password, err := persister.GetPasswordCredentialPersister().GetByUserID(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get password credential: %w", err)
+	}
+
+	sessions, err := persister.GetSessionPersister().ListByUserId(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	roles, err := persister.GetRolePersister().ListByUserId(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	memberships, err := persister.GetOrganizationMembershipPersister().ListByUserId(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get organization memberships: %w", err)
+	}
+
+	roleNames, err := getRoleNames(persister.GetRolePersister(), memberships)
+	if err != nil {
+		return err
+	}
+
+	emailChanges, err := persister.GetEmailChangePersister().ListByUserId(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get email changes: %w", err)
+	}
+
+	var auditLogs []models.AuditLog
+	for page := 1; ; page++ {
+		logs, err := persister.GetAuditLogPersister().List(page, personalDataAuditLogPageSize, nil, nil, nil, user.ID.String(), "", "", "")
+		if err != nil {
+			return fmt.Errorf("failed to get audit logs: %w", err)
+		}
+		auditLogs = append(auditLogs, logs...)
+		if len(logs) < personalDataAuditLogPageSize {
+			break
+		}
+	}
+
+	export := dto.FromPersonalDataModels(*user, credentials, password, sessions, roles, memberships, roleNames, emailChanges, auditLogs, includePrivateMetadata)
+
+	err = auditLogger.Create(c, models.AuditLogPersonalDataExported, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="personal-data-%s.json"`, user.ID))
+	return c.JSON(http.StatusOK, export)
+}
@@ -10,9 +10,16 @@ import (
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
+	"github.com/teamhanko/hanko/backend/invitation"
+	"github.com/teamhanko/hanko/backend/lockout"
 	"github.com/teamhanko/hanko/backend/mail"
+	"github.com/teamhanko/hanko/backend/mds"
+	"github.com/teamhanko/hanko/backend/metadata"
 	hankoMiddleware "github.com/teamhanko/hanko/backend/middleware"
+	"github.com/teamhanko/hanko/backend/organization"
 	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/rbac"
 	"github.com/teamhanko/hanko/backend/session"
 	"github.com/teamhanko/hanko/backend/template"
 )
@@ -72,7 +79,11 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	if err != nil {
 		panic(fmt.Errorf("failed to create jwk manager: %w", err))
 	}
-	sessionManager, err := session.NewManager(jwkManager, *cfg, persister.GetSessionPersister())
+	sessionManager, err := session.NewManager(jwkManager, *cfg, persister.GetSessionPersister(),
+		metadata.NewClaimsProvider(cfg.UserMetadata, persister),
+		rbac.NewClaimsProvider(cfg.Rbac, persister),
+		organization.NewClaimsProvider(persister),
+	)
 	if err != nil {
 		panic(fmt.Errorf("failed to create session generator: %w", err))
 	}
@@ -86,41 +97,94 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 
 	auditLogger := auditlog.NewLogger(persister, cfg.AuditLog)
 
+	lockoutManager, err := lockout.NewManager(cfg, persister, mailer, auditLogger)
+	if err != nil {
+		panic(fmt.Errorf("failed to create lockout manager: %w", err))
+	}
+
+	passcodeHandler, err := NewPasscodeHandler(cfg, persister, sessionManager, mailer, auditLogger, lockoutManager)
+	if err != nil {
+		panic(fmt.Errorf("failed to create public passcode handler: %w", err))
+	}
+
 	if cfg.Password.Enabled {
-		passwordHandler := NewPasswordHandler(persister, sessionManager, cfg, auditLogger)
+		passwordHandler := NewPasswordHandler(persister, sessionManager, cfg, auditLogger, lockoutManager)
+		passwordResetHandler := NewPasswordResetHandler(passcodeHandler, passwordHandler, persister, sessionManager, cfg, auditLogger)
 
 		password := g.Group("/password")
 		password.PUT("", passwordHandler.Set, sessionMiddleware)
 		password.POST("/login", passwordHandler.Login)
+
+		passwordReset := password.Group("/reset")
+		passwordReset.POST("/initialize", passwordResetHandler.Init)
+		passwordReset.POST("/finalize", passwordResetHandler.Finish)
+	}
+
+	emailDomainChecker, err := emaildomain.NewChecker(cfg.Emails.Domains)
+	if err != nil {
+		panic(fmt.Errorf("failed to create email domain checker: %w", err))
 	}
 
-	userHandler := NewUserHandler(cfg, persister, sessionManager, auditLogger)
+	userHandler := NewUserHandler(cfg, persister, sessionManager, auditLogger, emailDomainChecker)
 	statusHandler := NewStatusHandler(persister)
 
 	g.GET("/", statusHandler.Status)
 	g.GET("/me", userHandler.Me, sessionMiddleware)
+	g.PATCH("/user/profile", userHandler.UpdateProfile, sessionMiddleware)
+	g.GET("/user/export", userHandler.ExportPersonalData, sessionMiddleware)
 
 	user := g.Group("/users")
 	user.POST("", userHandler.Create)
 	user.GET("/:id", userHandler.Get, sessionMiddleware)
 
 	g.POST("/user", userHandler.GetUserIdByEmail)
+	if cfg.Username.Enabled {
+		g.PUT("/user/username", userHandler.SetUsername, sessionMiddleware)
+	}
+
+	userMetadataHandler := NewUserMetadataHandler(cfg, persister, auditLogger)
+	userMetadata := g.Group("/user/metadata", sessionMiddleware)
+	userMetadata.GET("", userMetadataHandler.Get)
+	userMetadata.PATCH("", userMetadataHandler.Patch)
+
+	organizationHandler := NewOrganizationHandler(persister, sessionManager, auditLogger)
+	organizations := g.Group("/user/organizations", sessionMiddleware)
+	organizations.GET("", organizationHandler.List)
+	organizations.POST("/switch", organizationHandler.Switch)
+
 	g.POST("/logout", userHandler.Logout, sessionMiddleware)
 
 	if cfg.Account.AllowDeletion {
 		g.DELETE("/user", userHandler.Delete, sessionMiddleware)
 	}
 
+	if cfg.Account.Lockout.Enabled {
+		lockoutHandler := NewLockoutHandler(persister, lockoutManager)
+		g.POST("/user/unlock", lockoutHandler.Unlock)
+	}
+
+	if cfg.Account.Invitations.Enabled {
+		invitationManager, err := invitation.NewManager(cfg, persister, mailer)
+		if err != nil {
+			panic(fmt.Errorf("failed to create invitation manager: %w", err))
+		}
+		invitationHandler := NewInvitationHandler(cfg, persister, invitationManager, sessionManager, auditLogger)
+		g.POST("/invitations/accept", invitationHandler.Accept)
+	}
+
 	healthHandler := NewHealthHandler(persister)
-	webauthnHandler, err := NewWebauthnHandler(cfg, persister, sessionManager, auditLogger)
-	if err != nil {
-		panic(fmt.Errorf("failed to create public webauthn handler: %w", err))
+	var metadataService *mds.Service
+	if cfg.Webauthn.MetadataService.Enabled {
+		metadataService, err = mds.NewService(cfg.Webauthn.MetadataService)
+		if err != nil {
+			panic(fmt.Errorf("failed to create metadata service: %w", err))
+		}
+		metadataService.Start()
 	}
-	passcodeHandler, err := NewPasscodeHandler(cfg, persister, sessionManager, mailer, auditLogger)
+	webauthnHandler, err := NewWebauthnHandler(cfg, persister, sessionManager, auditLogger, lockoutManager, metadataService)
 	if err != nil {
-		panic(fmt.Errorf("failed to create public passcode handler: %w", err))
+		panic(fmt.Errorf("failed to create public webauthn handler: %w", err))
 	}
-
 	health := e.Group("/health")
 	health.GET("/alive", healthHandler.Alive)
 	health.GET("/ready", healthHandler.Ready)
@@ -132,8 +196,10 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	wellKnown := g.Group("/.well-known")
 	wellKnown.GET("/jwks.json", wellKnownHandler.GetPublicKeys)
 	wellKnown.GET("/config", wellKnownHandler.GetConfig)
+	// browsers request the related origins from the root of the RP ID domain, so the path prefix is not applied
+	e.GET("/.well-known/webauthn", wellKnownHandler.GetWebauthnRelatedOrigins)
 
-	emailHandler, err := NewEmailHandler(cfg, persister, sessionManager, auditLogger)
+	emailHandler, err := NewEmailHandler(cfg, persister, sessionManager, auditLogger, emailDomainChecker)
 	if err != nil {
 		panic(fmt.Errorf("failed to create public email handler: %w", err))
 	}
@@ -143,6 +209,10 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	webauthnRegistration.POST("/initialize", webauthnHandler.BeginRegistration)
 	webauthnRegistration.POST("/finalize", webauthnHandler.FinishRegistration)
 
+	webauthnSignup := webauthn.Group("/signup")
+	webauthnSignup.POST("/initialize", webauthnHandler.BeginSignup)
+	webauthnSignup.POST("/finalize", webauthnHandler.FinishSignup)
+
 	webauthnLogin := webauthn.Group("/login")
 	webauthnLogin.POST("/initialize", webauthnHandler.BeginAuthentication)
 	webauthnLogin.POST("/finalize", webauthnHandler.FinishAuthentication)
@@ -163,7 +233,17 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	email.DELETE("/:id", emailHandler.Delete)
 	email.POST("/:id/set_primary", emailHandler.SetPrimaryEmail)
 
-	thirdPartyHandler := NewThirdPartyHandler(cfg, persister, sessionManager, auditLogger)
+	if cfg.Emails.Change.Enabled {
+		emailChangeHandler := NewEmailChangeHandler(passcodeHandler, persister, emailDomainChecker, cfg, auditLogger)
+
+		emailChange := g.Group("/emails/change")
+		emailChange.POST("/initialize", emailChangeHandler.Init, sessionMiddleware)
+		emailChange.POST("/finalize", emailChangeHandler.Finish, sessionMiddleware)
+		// the revert link is opened from the old email address, so no session is required
+		emailChange.POST("/revert", emailChangeHandler.Revert)
+	}
+
+	thirdPartyHandler := NewThirdPartyHandler(cfg, persister, sessionManager, auditLogger, emailDomainChecker)
 	thirdparty := g.Group("/thirdparty")
 	thirdparty.GET("/auth", thirdPartyHandler.Auth)
 	thirdparty.GET("/callback", thirdPartyHandler.Callback)
@@ -172,7 +252,7 @@ func NewPublicRouter(cfg *config.Config, persister persistence.Persister, promet
 	tokenHandler := NewTokenHandler(cfg, persister, sessionManager, auditLogger)
 	g.POST("/token", tokenHandler.Validate)
 
-	sessionHandler := NewSessionHandler(cfg, sessionManager)
+	sessionHandler := NewSessionHandler(cfg, sessionManager, persister)
 	sess := g.Group("/session")
 	sess.GET("/exchange", sessionHandler.ExchangeRefreshToken)
 
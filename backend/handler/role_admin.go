@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rbac"
+	"net/http"
+	"time"
+)
+
+type RoleHandlerAdmin struct {
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+}
+
+func NewRoleHandlerAdmin(persister persistence.Persister, auditLogger auditlog.Logger) *RoleHandlerAdmin {
+	return &RoleHandlerAdmin{
+		persister:   persister,
+		auditLogger: auditLogger,
+	}
+}
+
+func (h *RoleHandlerAdmin) List(c echo.Context) error {
+	roles, err := h.persister.GetRolePersister().List()
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, fromRoleModels(roles))
+}
+
+func (h *RoleHandlerAdmin) Get(c echo.Context) error {
+	role, err := h.getRole(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromRoleModel(*role))
+}
+
+func (h *RoleHandlerAdmin) Create(c echo.Context) error {
+	var body admin.CreateRole
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if !rbac.ValidName(body.Name) {
+		return echo.NewHTTPError(http.StatusBadRequest, "name contains invalid characters")
+	}
+
+	role := models.NewRole(body.Name, trimmedOrNil(body.Description))
+
+	err := h.persister.Transaction(func(tx *pop.Connection) error {
+		rolePersister := h.persister.GetRolePersisterWithConnection(tx)
+		existing, err := rolePersister.GetByName(role.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+		if existing != nil {
+			return echo.NewHTTPError(http.StatusConflict, "role already exists")
+		}
+
+		role.Permissions, err = h.getPermissionsByName(tx, body.Permissions)
+		if err != nil {
+			return err
+		}
+
+		err = rolePersister.Create(role)
+		if err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+
+		err = rolePersister.SetPermissions(role.ID, role.Permissions)
+		if err != nil {
+			return fmt.Errorf("failed to set role permissions: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogRoleCreated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, admin.FromRoleModel(role))
+}
+
+func (h *RoleHandlerAdmin) Update(c echo.Context) error {
+	var body admin.UpdateRole
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if body.Name != nil && !rbac.ValidName(*body.Name) {
+		return echo.NewHTTPError(http.StatusBadRequest, "name contains invalid characters")
+	}
+
+	role, err := h.getRole(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		rolePersister := h.persister.GetRolePersisterWithConnection(tx)
+		if body.Name != nil && *body.Name != role.Name {
+			existing, err := rolePersister.GetByName(*body.Name)
+			if err != nil {
+				return fmt.Errorf("failed to get role: %w", err)
+			}
+			if existing != nil {
+				return echo.NewHTTPError(http.StatusConflict, "role already exists")
+			}
+			role.Name = *body.Name
+		}
+		if body.Description != nil {
+			role.Description = trimmedOrNil(body.Description)
+		}
+		role.UpdatedAt = time.Now().UTC()
+
+		err = rolePersister.Update(*role)
+		if err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+
+		if body.Permissions != nil {
+			role.Permissions, err = h.getPermissionsByName(tx, *body.Permissions)
+			if err != nil {
+				return err
+			}
+
+			err = rolePersister.SetPermissions(role.ID, role.Permissions)
+			if err != nil {
+				return fmt.Errorf("failed to set role permissions: %w", err)
+			}
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogRoleUpdated, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromRoleModel(*role))
+}
+
+// Delete deletes the role and unassigns it from all users.
+func (h *RoleHandlerAdmin) Delete(c echo.Context) error {
+	role, err := h.getRole(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetRolePersisterWithConnection(tx).Delete(*role)
+		if err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogRoleDeleted, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListUserRoles returns the roles assigned to the user.
+func (h *RoleHandlerAdmin) ListUserRoles(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	roles, err := h.persister.GetRolePersister().ListByUserId(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, fromRoleModels(roles))
+}
+
+// AssignUserRole assigns the role to the user. Assigning a role which is already assigned is not an error.
+func (h *RoleHandlerAdmin) AssignUserRole(c echo.Context) error {
+	return h.changeUserRole(c, true)
+}
+
+func (h *RoleHandlerAdmin) UnassignUserRole(c echo.Context) error {
+	return h.changeUserRole(c, false)
+}
+
+func (h *RoleHandlerAdmin) changeUserRole(c echo.Context, assign bool) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	role, err := h.getRole(c.Param("role_id"))
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		rolePersister := h.persister.GetRolePersisterWithConnection(tx)
+		auditLogType := models.AuditLogUserRoleAssigned
+		if assign {
+			err = rolePersister.AssignToUser(user.ID, role.ID)
+		} else {
+			auditLogType = models.AuditLogUserRoleUnassigned
+			err = rolePersister.UnassignFromUser(user.ID, role.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to change user role: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, auditLogType, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *RoleHandlerAdmin) getRole(id string) (*models.Role, error) {
+	roleId, err := uuid.FromString(id)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse roleId as uuid").SetInternal(err)
+	}
+
+	role, err := h.persister.GetRolePersister().Get(roleId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	if role == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "role not found")
+	}
+
+	return role, nil
+}
+
+func (h *RoleHandlerAdmin) getUser(c echo.Context) (*models.User, error) {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	return user, nil
+}
+
+func (h *RoleHandlerAdmin) getPermissionsByName(tx *pop.Connection, names []string) (models.Permissions, error) {
+	permissionPersister := h.persister.GetPermissionPersisterWithConnection(tx)
+	permissions := models.Permissions{}
+	for _, name := range names {
+		permission, err := permissionPersister.GetByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get permission: %w", err)
+		}
+		if permission == nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("permission '%s' does not exist", name))
+		}
+		permissions = append(permissions, *permission)
+	}
+	return permissions, nil
+}
+
+func fromRoleModels(roles models.Roles) []admin.Role {
+	response := make([]admin.Role, len(roles))
+	for i := range roles {
+		response[i] = admin.FromRoleModel(roles[i])
+	}
+	return response
+}
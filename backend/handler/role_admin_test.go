@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoleHandlerAdminSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(roleAdminSuite))
+}
+
+type roleAdminSuite struct {
+	test.Suite
+}
+
+const (
+	roleAdminEditorRoleId = "6a6b5c86-9f1c-4b7e-9e8a-0f7b3a1d2c01"
+	roleAdminViewerRoleId = "6a6b5c86-9f1c-4b7e-9e8a-0f7b3a1d2c02"
+)
+
+func (s *roleAdminSuite) TestRoleHandlerAdmin_Create() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/role_admin")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "creates role", body: `{"name": "auditor", "description": "Reads documents", "permissions": ["documents:read"]}`, expectedCode: http.StatusCreated},
+		{name: "rejects existing name", body: `{"name": "editor"}`, expectedCode: http.StatusConflict},
+		{name: "rejects invalid name", body: `{"name": "super admin"}`, expectedCode: http.StatusBadRequest},
+		{name: "rejects unknown permission", body: `{"name": "owner", "permissions": ["documents:delete"]}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			req := httptest.NewRequest(http.MethodPost, "/roles", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(tt.expectedCode, rec.Code)
+		})
+	}
+
+	role, err := s.Storage.GetRolePersister().GetByName("auditor")
+	s.Require().NoError(err)
+	s.Require().NotNil(role)
+	s.Equal([]string{"documents:read"}, role.Permissions.GetNames())
+}
+
+func (s *roleAdminSuite) TestRoleHandlerAdmin_Update() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/role_admin")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/roles/%s", roleAdminViewerRoleId), strings.NewReader(`{"description": "Reads and writes documents", "permissions": ["documents:write"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var role admin.Role
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &role))
+		s.Equal("viewer", role.Name)
+		s.Equal("Reads and writes documents", *role.Description)
+		s.Require().Len(role.Permissions, 1)
+		s.Equal("documents:write", role.Permissions[0].Name)
+	}
+}
+
+func (s *roleAdminSuite) TestRoleHandlerAdmin_AssignUserRole() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/role_admin")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+	otherUserId := "38bf5a00-d7ea-40a5-a5de-48722c148925"
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s/roles/%s", otherUserId, roleAdminViewerRoleId), nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		s.Equal(http.StatusNoContent, rec.Code)
+	}
+
+	roles, err := s.Storage.GetRolePersister().ListByUserId(uuid.FromStringOrNil(otherUserId))
+	s.Require().NoError(err)
+	s.Equal([]string{"viewer"}, roles.GetNames())
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%s/roles/%s", otherUserId, roleAdminViewerRoleId), nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusNoContent, rec.Code)
+
+	roles, err = s.Storage.GetRolePersister().ListByUserId(uuid.FromStringOrNil(otherUserId))
+	s.Require().NoError(err)
+	s.Empty(roles)
+}
+
+func (s *roleAdminSuite) TestRoleHandlerAdmin_ListUsersByRole() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/role_admin")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?role=editor", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var users []admin.User
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &users))
+		s.Require().Len(users, 1)
+		s.Equal("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5", users[0].ID.String())
+		s.Equal("1", rec.Header().Get("X-Total-Count"))
+	}
+}
+
+func (s *roleAdminSuite) TestRoleHandlerAdmin_Delete() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/role_admin")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/roles/%s", roleAdminEditorRoleId), nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusNoContent, rec.Code)
+
+	roles, err := s.Storage.GetRolePersister().ListByUserId(uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"))
+	s.Require().NoError(err)
+	s.Empty(roles)
+}
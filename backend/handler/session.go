@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/getsentry/sentry-go"
 	sentryecho "github.com/getsentry/sentry-go/echo"
 	"github.com/labstack/echo/v4"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/session"
 	"net/http"
 	"strings"
@@ -15,13 +17,15 @@ type SessionHandler struct {
 	enableHeader bool
 	cookieName   string
 	manager      session.Manager
+	persister    persistence.Persister
 }
 
-func NewSessionHandler(cfg *config.Config, manager session.Manager) *SessionHandler {
+func NewSessionHandler(cfg *config.Config, manager session.Manager, persister persistence.Persister) *SessionHandler {
 	return &SessionHandler{
 		enableHeader: cfg.Session.EnableAuthTokenHeader,
 		cookieName:   cfg.Session.Cookie.Name + "-refresh",
 		manager:      manager,
+		persister:    persister,
 	}
 }
 
@@ -43,7 +47,27 @@ func (handler *SessionHandler) ExchangeRefreshToken(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "missing refresh token")
 	}
 
-	err := handler.manager.ExchangeRefreshToken(token, c)
+	// the sessions of a user are revoked when the user is blocked or deleted, but check the user anyway, so that a
+	// refresh token which is exchanged concurrently cannot be used
+	sess, err := handler.persister.GetSessionPersister().Get(token)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid refresh token").SetInternal(err)
+	}
+
+	user, err := handler.persister.GetUserPersister().Get(sess.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil || user.IsDeleted() {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid refresh token")
+	}
+
+	if user.IsBlocked(time.Now().UTC()) {
+		return echo.NewHTTPError(http.StatusForbidden, "user is blocked")
+	}
+
+	err = handler.manager.ExchangeRefreshToken(token, c)
 	if err != nil {
 		if hub != nil {
 			hub.WithScope(func(scope *sentry.Scope) {
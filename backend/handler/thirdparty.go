@@ -7,6 +7,7 @@ import (
 	auditlog "github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
@@ -22,18 +23,20 @@ const (
 )
 
 type ThirdPartyHandler struct {
-	auditLogger    auditlog.Logger
-	cfg            *config.Config
-	persister      persistence.Persister
-	sessionManager session.Manager
+	auditLogger        auditlog.Logger
+	cfg                *config.Config
+	persister          persistence.Persister
+	sessionManager     session.Manager
+	emailDomainChecker *emaildomain.Checker
 }
 
-func NewThirdPartyHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger) *ThirdPartyHandler {
+func NewThirdPartyHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, emailDomainChecker *emaildomain.Checker) *ThirdPartyHandler {
 	return &ThirdPartyHandler{
-		auditLogger:    auditLogger,
-		cfg:            cfg,
-		persister:      persister,
-		sessionManager: sessionManager,
+		auditLogger:        auditLogger,
+		cfg:                cfg,
+		persister:          persister,
+		sessionManager:     sessionManager,
+		emailDomainChecker: emailDomainChecker,
 	}
 }
 
@@ -178,7 +181,7 @@ func (h *ThirdPartyHandler) Callback(c echo.Context) error {
 			return thirdparty.ErrorInvalidRequest("could not retrieve user data from provider").WithCause(terr)
 		}
 
-		linkingResult, terr := thirdparty.LinkAccount(tx, h.cfg, h.persister, userData, provider.Name())
+		linkingResult, terr := thirdparty.LinkAccount(tx, h.cfg, h.persister, h.emailDomainChecker, userData, provider.Name())
 		if terr != nil {
 			return terr
 		}
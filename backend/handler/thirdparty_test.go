@@ -10,6 +10,7 @@ import (
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
 	"github.com/teamhanko/hanko/backend/session"
 	"github.com/teamhanko/hanko/backend/test"
 	"net/http"
@@ -46,7 +47,10 @@ func (s *thirdPartySuite) setUpHandler(cfg *config.Config) *ThirdPartyHandler {
 	sessionMngr, err := session.NewManager(jwkMngr, *cfg, s.Storage.GetSessionPersister())
 	s.Require().NoError(err)
 
-	handler := NewThirdPartyHandler(cfg, s.Storage, sessionMngr, auditLogger)
+	emailDomainChecker, err := emaildomain.NewChecker(cfg.Emails.Domains)
+	s.Require().NoError(err)
+
+	handler := NewThirdPartyHandler(cfg, s.Storage, sessionMngr, auditLogger, emailDomainChecker)
 	return handler
 }
 
@@ -77,12 +77,25 @@ func (h TokenHandler) Validate(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusUnprocessableEntity, "token has expired")
 		}
 
+		user, terr := h.persister.GetUserPersisterWithConnection(tx).Get(token.UserID)
+		if terr != nil {
+			return fmt.Errorf("failed to get user: %w", terr)
+		}
+
+		if user == nil || user.IsDeleted() {
+			return echo.NewHTTPError(http.StatusNotFound, "token not found")
+		}
+
+		if user.IsBlocked(time.Now().UTC()) {
+			return echo.NewHTTPError(http.StatusForbidden, "user is blocked")
+		}
+
 		terr = tokenPersister.Delete(*token)
 		if terr != nil {
 			return fmt.Errorf("failed to delete token from db: %w", terr)
 		}
 
-		err := h.sessionManager.GenerateCookieOrHeader(token.UserID, c)
+		err := h.sessionManager.GenerateCookieOrHeader(tx, token.UserID, c)
 		if err != nil {
 			return fmt.Errorf("failed to generate cookie or header: %w", err)
 		}
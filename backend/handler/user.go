@@ -10,26 +10,33 @@ import (
 	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/emaildomain"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rbac"
 	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/username"
 	"net/http"
 	"strings"
 )
 
 type UserHandler struct {
-	persister      persistence.Persister
-	sessionManager session.Manager
-	auditLogger    auditlog.Logger
-	cfg            *config.Config
+	persister          persistence.Persister
+	sessionManager     session.Manager
+	auditLogger        auditlog.Logger
+	cfg                *config.Config
+	usernamePolicy     *username.Policy
+	emailDomainChecker *emaildomain.Checker
 }
 
-func NewUserHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger) *UserHandler {
+func NewUserHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, emailDomainChecker *emaildomain.Checker) *UserHandler {
 	return &UserHandler{
-		persister:      persister,
-		auditLogger:    auditLogger,
-		sessionManager: sessionManager,
-		cfg:            cfg,
+		persister:          persister,
+		auditLogger:        auditLogger,
+		sessionManager:     sessionManager,
+		cfg:                cfg,
+		usernamePolicy:     username.NewPolicy(cfg.Username),
+		emailDomainChecker: emailDomainChecker,
 	}
 }
 
@@ -53,6 +60,10 @@ func (h *UserHandler) Create(c echo.Context) error {
 
 	body.Email = strings.ToLower(body.Email)
 
+	if !h.emailDomainChecker.Allowed(body.Email) {
+		return newEmailDomainNotAllowedError(body.Email)
+	}
+
 	return h.persister.Transaction(func(tx *pop.Connection) error {
 		newUser := models.NewUser()
 		err := h.persister.GetUserPersisterWithConnection(tx).Create(newUser)
@@ -60,6 +71,11 @@ func (h *UserHandler) Create(c echo.Context) error {
 			return fmt.Errorf("failed to store user: %w", err)
 		}
 
+		err = rbac.GrantDefaultRole(h.cfg.Rbac, h.persister.GetRolePersisterWithConnection(tx), newUser.ID)
+		if err != nil {
+			return err
+		}
+
 		email, err := h.persister.GetEmailPersisterWithConnection(tx).FindByAddress(body.Email)
 		if err != nil {
 			return fmt.Errorf("failed to get email: %w", err)
@@ -101,7 +117,7 @@ func (h *UserHandler) Create(c echo.Context) error {
 				return fmt.Errorf("failed to store primary email: %w", err)
 			}
 
-			err = h.sessionManager.GenerateCookieOrHeader(newUser.ID, c)
+			err = h.sessionManager.GenerateCookieOrHeader(tx, newUser.ID, c)
 			if err != nil {
 				return fmt.Errorf("failed to generate cookie or header: %w", err)
 			}
@@ -161,13 +177,16 @@ func (h *UserHandler) Get(c echo.Context) error {
 		ID:                  user.ID,
 		WebauthnCredentials: user.WebauthnCredentials,
 		Email:               emailAddress,
+		Username:            user.Username,
 		CreatedAt:           user.CreatedAt,
 		UpdatedAt:           user.UpdatedAt,
 	})
 }
 
 type UserGetByEmailBody struct {
-	Email string `json:"email" validate:"required,email"`
+	Email string `json:"email" validate:"required_without=Username,omitempty,email"`
+	// Username can be used instead of Email if usernames are enabled.
+	Username string `json:"username"`
 }
 
 func (h *UserHandler) GetUserIdByEmail(c echo.Context) error {
@@ -180,6 +199,10 @@ func (h *UserHandler) GetUserIdByEmail(c echo.Context) error {
 		return dto.ToHttpError(err)
 	}
 
+	if request.Email == "" {
+		return h.getUserIdByUsername(c, request.Username)
+	}
+
 	emailAddress := strings.ToLower(request.Email)
 	email, err := h.persister.GetEmailPersister().FindByAddress(emailAddress)
 	if err != nil {
@@ -198,18 +221,205 @@ func (h *UserHandler) GetUserIdByEmail(c echo.Context) error {
 	return c.JSON(http.StatusOK, dto.UserInfoResponse{
 		ID:                    *email.UserID,
 		Verified:              email.Verified,
-		EmailID:               email.ID,
+		EmailID:               &email.ID,
 		HasWebauthnCredential: len(credentials) > 0,
 	})
 }
 
+func (h *UserHandler) getUserIdByUsername(c echo.Context, name string) error {
+	user, err := getUserByUsername(h.persister, h.cfg, name)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound).SetInternal(errors.New("user not found"))
+	}
+
+	response := dto.UserInfoResponse{
+		ID:                    user.ID,
+		HasWebauthnCredential: len(user.WebauthnCredentials) > 0,
+	}
+	if email := user.Emails.GetPrimary(); email != nil {
+		response.EmailID = &email.ID
+		response.Verified = email.Verified
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// SetUsername sets or changes the username of the current user.
+func (h *UserHandler) SetUsername(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("missing or malformed jwt")
+	}
+
+	var body dto.UsernameSetRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	name := username.Normalize(body.Username)
+	violations := h.usernamePolicy.Check(name)
+	if len(violations) > 0 {
+		codes := make([]string, len(violations))
+		for i, violation := range violations {
+			codes[i] = string(violation)
+		}
+		return c.JSON(http.StatusBadRequest, dto.UsernamePolicyErrorResponse{
+			Code:       http.StatusBadRequest,
+			Message:    h.usernamePolicy.Describe(violations[0]),
+			Violations: codes,
+		})
+	}
+
+	userId := uuid.FromStringOrNil(sessionToken.Subject())
+
+	return h.persister.Transaction(func(tx *pop.Connection) error {
+		userPersister := h.persister.GetUserPersisterWithConnection(tx)
+		user, err := userPersister.Get(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if user == nil {
+			return echo.NewHTTPError(http.StatusNotFound).SetInternal(errors.New("user not found"))
+		}
+
+		if user.Username != nil && *user.Username == name {
+			return c.NoContent(http.StatusNoContent)
+		}
+
+		existingUser, err := userPersister.GetByUsername(name)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if existingUser != nil {
+			return echo.NewHTTPError(http.StatusConflict, "username is already taken")
+		}
+
+		user.Username = &name
+		err = userPersister.Update(*user)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUsernameChanged, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	})
+}
+
 func (h *UserHandler) Me(c echo.Context) error {
 	sessionToken, ok := c.Get("session").(jwt.Token)
 	if !ok {
 		return errors.New("failed to cast session object")
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"id": sessionToken.Subject()})
+	user, err := h.persister.GetUserPersister().Get(uuid.FromStringOrNil(sessionToken.Subject()))
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	response := dto.MeResponse{ID: sessionToken.Subject()}
+	if user != nil {
+		response.PublicMetadata = user.PublicMetadata
+		response.UserProfile = dto.FromUserProfileModel(*user)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdateProfile changes the profile fields of the current user which are present in the request body.
+func (h *UserHandler) UpdateProfile(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("missing or malformed jwt")
+	}
+
+	var body dto.UserProfileUpdateRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	userId := uuid.FromStringOrNil(sessionToken.Subject())
+
+	return h.persister.Transaction(func(tx *pop.Connection) error {
+		userPersister := h.persister.GetUserPersisterWithConnection(tx)
+		user, err := userPersister.Get(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if user == nil {
+			return echo.NewHTTPError(http.StatusNotFound).SetInternal(errors.New("user not found"))
+		}
+
+		for _, field := range []struct {
+			target **string
+			value  *string
+		}{
+			{&user.DisplayName, body.DisplayName},
+			{&user.GivenName, body.GivenName},
+			{&user.FamilyName, body.FamilyName},
+			{&user.AvatarURL, body.AvatarURL},
+			{&user.Locale, body.Locale},
+		} {
+			if field.value == nil {
+				continue
+			}
+			value := strings.TrimSpace(*field.value)
+			if value == "" {
+				*field.target = nil
+			} else {
+				*field.target = &value
+			}
+		}
+
+		err = userPersister.Update(*user)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserProfileUpdated, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, dto.FromUserProfileModel(*user))
+	})
+}
+
+// ExportPersonalData returns all data stored about the current user.
+func (h *UserHandler) ExportPersonalData(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("missing or malformed jwt")
+	}
+
+	user, err := h.persister.GetUserPersister().Get(uuid.FromStringOrNil(sessionToken.Subject()))
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound).SetInternal(errors.New("user not found"))
+	}
+
+	return exportPersonalData(c, h.persister, h.auditLogger, user, false)
 }
 
 func (h *UserHandler) Delete(c echo.Context) error {
@@ -233,7 +443,11 @@ func (h *UserHandler) Delete(c echo.Context) error {
 			return fmt.Errorf("unknown user")
 		}
 
-		err = h.persister.GetUserPersisterWithConnection(tx).Delete(*user)
+		if h.cfg.Account.SoftDelete.Enabled {
+			err = softDeleteUser(tx, h.persister, user)
+		} else {
+			err = h.persister.GetUserPersisterWithConnection(tx).Delete(*user)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to delete user: %w", err)
 		}
@@ -8,8 +8,12 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/emaildomain"
+	"github.com/teamhanko/hanko/backend/lockout"
 	"github.com/teamhanko/hanko/backend/pagination"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
@@ -21,13 +25,27 @@ import (
 )
 
 type UserHandlerAdmin struct {
-	persister persistence.Persister
+	persister          persistence.Persister
+	lockoutManager     *lockout.Manager
+	auditLogger        auditlog.Logger
+	softDelete         config.AccountSoftDelete
+	emailDomainChecker *emaildomain.Checker
+	// restrictEmailDomains applies the email domain settings to users created via the admin API.
+	restrictEmailDomains bool
 }
 
-func NewUserHandlerAdmin(persister persistence.Persister) *UserHandlerAdmin {
-	return &UserHandlerAdmin{persister: persister}
+func NewUserHandlerAdmin(cfg *config.Config, persister persistence.Persister, lockoutManager *lockout.Manager, auditLogger auditlog.Logger, emailDomainChecker *emaildomain.Checker) *UserHandlerAdmin {
+	return &UserHandlerAdmin{
+		persister:            persister,
+		lockoutManager:       lockoutManager,
+		auditLogger:          auditLogger,
+		softDelete:           cfg.Account.SoftDelete,
+		emailDomainChecker:   emailDomainChecker,
+		restrictEmailDomains: cfg.Emails.Domains.ApplyToAdmin,
+	}
 }
 
+// Delete soft deletes the user if soft deletion is enabled. Deleting a soft deleted user purges it immediately.
 func (h *UserHandlerAdmin) Delete(c echo.Context) error {
 	userId, err := uuid.FromString(c.Param("id"))
 	if err != nil {
@@ -44,6 +62,22 @@ func (h *UserHandlerAdmin) Delete(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "user not found")
 	}
 
+	if h.softDelete.Enabled && !user.IsDeleted() {
+		err = h.persister.Transaction(func(tx *pop.Connection) error {
+			err := softDeleteUser(tx, h.persister, user)
+			if err != nil {
+				return err
+			}
+
+			return h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserDeleted, user, nil)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+
 	err = p.Delete(*user)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
@@ -52,11 +86,227 @@ func (h *UserHandlerAdmin) Delete(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// Restore undoes the soft deletion of a user whose grace period has not ended yet.
+func (h *UserHandlerAdmin) Restore(c echo.Context) error {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		userPersister := h.persister.GetUserPersisterWithConnection(tx)
+		user, err := userPersister.Get(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if user == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+
+		if !user.IsDeleted() {
+			return echo.NewHTTPError(http.StatusConflict, "user is not deleted")
+		}
+
+		if user.DeletedAt.Add(h.softDelete.GracePeriod).Before(time.Now().UTC()) {
+			return echo.NewHTTPError(http.StatusGone, "grace period of the deleted user has ended")
+		}
+
+		user.DeletedAt = nil
+		err = userPersister.Update(*user)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserRestored, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.getUser(c, userId)
+}
+
+// Unlock lifts the lockout of a user who exceeded the number of failed login attempts.
+func (h *UserHandlerAdmin) Unlock(c echo.Context) error {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		return h.lockoutManager.Unlock(tx, c, user)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	user, err = h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, admin.FromUserModel(*user))
+}
+
+// Block prevents the user from logging in, optionally until the given expiry, and revokes the sessions of the user.
+func (h *UserHandlerAdmin) Block(c echo.Context) error {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	var body admin.BlockUser
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	now := time.Now().UTC()
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(now) {
+		return echo.NewHTTPError(http.StatusBadRequest, "expires_at must be in the future")
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		userPersister := h.persister.GetUserPersisterWithConnection(tx)
+		user, err := userPersister.Get(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if user == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+
+		user.BlockedAt = &now
+		user.BlockedReason = body.Reason
+		if body.ExpiresAt != nil {
+			expiresAt := body.ExpiresAt.UTC()
+			user.BlockedUntil = &expiresAt
+		} else {
+			user.BlockedUntil = nil
+		}
+
+		err = userPersister.Update(*user)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = h.persister.GetSessionPersisterWithConnection(tx).DeleteByUserId(user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserBlocked, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.getUser(c, userId)
+}
+
+// Unblock lifts the block of a user.
+func (h *UserHandlerAdmin) Unblock(c echo.Context) error {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		userPersister := h.persister.GetUserPersisterWithConnection(tx)
+		user, err := userPersister.Get(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if user == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+
+		if user.BlockedAt == nil {
+			return nil
+		}
+
+		user.BlockedAt = nil
+		user.BlockedUntil = nil
+		user.BlockedReason = nil
+		err = userPersister.Update(*user)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserUnblocked, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.getUser(c, userId)
+}
+
+// ExportPersonalData returns all data stored about the user including the private metadata.
+func (h *UserHandlerAdmin) ExportPersonalData(c echo.Context) error {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	return exportPersonalData(c, h.persister, h.auditLogger, user, true)
+}
+
+func (h *UserHandlerAdmin) getUser(c echo.Context, userId uuid.UUID) error {
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, admin.FromUserModel(*user))
+}
+
 type UserListRequest struct {
 	PerPage       int    `query:"per_page"`
 	Page          int    `query:"page"`
 	Email         string `query:"email"`
 	UserId        string `query:"user_id"`
+	Blocked       string `query:"blocked"`
+	Role          string `query:"role"`
 	SortDirection string `query:"sort_direction"`
 }
 
@@ -93,14 +343,23 @@ func (h *UserHandlerAdmin) List(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "sort_direction must be desc or asc")
 	}
 
+	var blocked *bool
+	if request.Blocked != "" {
+		value, err := strconv.ParseBool(request.Blocked)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "blocked must be true or false").SetInternal(err)
+		}
+		blocked = &value
+	}
+
 	email := strings.ToLower(request.Email)
 
-	users, err := h.persister.GetUserPersister().List(request.Page, request.PerPage, userId, email, request.SortDirection)
+	users, err := h.persister.GetUserPersister().List(request.Page, request.PerPage, userId, email, blocked, request.Role, request.SortDirection)
 	if err != nil {
 		return fmt.Errorf("failed to get list of users: %w", err)
 	}
 
-	userCount, err := h.persister.GetUserPersister().Count(userId, email)
+	userCount, err := h.persister.GetUserPersister().Count(userId, email, blocked, request.Role)
 	if err != nil {
 		return fmt.Errorf("failed to get total count of users: %w", err)
 	}
@@ -170,6 +429,14 @@ func (h *UserHandlerAdmin) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "only one primary email is allowed")
 	}
 
+	if h.restrictEmailDomains {
+		for _, email := range body.Emails {
+			if !h.emailDomainChecker.Allowed(email.Address) {
+				return newEmailDomainNotAllowedError(email.Address)
+			}
+		}
+	}
+
 	err := h.persister.GetConnection().Transaction(func(tx *pop.Connection) error {
 		u := models.User{
 			ID:        body.ID,
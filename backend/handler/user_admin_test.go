@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/test"
 	"net/http"
@@ -38,7 +39,7 @@ func (s *userAdminSuite) TestUserHandlerAdmin_Delete() {
 
 	s.Equal(http.StatusNoContent, rec.Code)
 
-	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "")
+	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
 	s.Require().NoError(err)
 	s.Equal(2, count)
 }
@@ -59,7 +60,7 @@ func (s *userAdminSuite) TestUserHandlerAdmin_Delete_UnknownUserId() {
 
 	s.Equal(http.StatusNotFound, rec.Code)
 
-	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "")
+	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
 	s.Require().NoError(err)
 	s.Equal(3, count)
 }
@@ -90,7 +91,7 @@ func (s *userAdminSuite) TestUserHandlerAdmin_List() {
 	e.ServeHTTP(rec, req)
 
 	s.Equal(http.StatusOK, rec.Code)
-	s.Equal("3", rec.Header().Get("X-Total-Count"))
+	s.Equal("4", rec.Header().Get("X-Total-Count"))
 	s.Equal("<http://example.com/users?page=1&per_page=20>; rel=\"first\"", rec.Header().Get("Link"))
 }
 
@@ -109,14 +110,59 @@ func (s *userAdminSuite) TestUserHandlerAdmin_List_Pagination() {
 	e.ServeHTTP(rec, req)
 
 	if s.Equal(http.StatusOK, rec.Code) {
-		s.Equal("3", rec.Header().Get("X-Total-Count"))
+		s.Equal("4", rec.Header().Get("X-Total-Count"))
 
 		var got []models.User
 		err = json.Unmarshal(rec.Body.Bytes(), &got)
 		s.Require().NoError(err)
 
 		s.Equal(1, len(got))
-		s.Equal("<http://example.com/users?page=3&per_page=1>; rel=\"last\",<http://example.com/users?page=2&per_page=1>; rel=\"next\"", rec.Header().Get("Link"))
+		s.Equal("<http://example.com/users?page=4&per_page=1>; rel=\"last\",<http://example.com/users?page=2&per_page=1>; rel=\"next\"", rec.Header().Get("Link"))
+	}
+}
+
+func (s *userAdminSuite) TestUserHandlerAdmin_List_UserWithoutEmail() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/user_admin")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	userWithoutEmail := uuid.FromStringOrNil("d41df4b7-c055-45e6-9faf-61aa92a4032e")
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCount string
+		expectListed  bool
+	}{
+		{name: "lists users without email address", query: "", expectedCount: "4", expectListed: true},
+		{name: "filters users without email address by email", query: "?email=john.doe", expectedCount: "3", expectListed: false},
+	}
+
+	for _, currentTest := range tests {
+		s.Run(currentTest.name, func() {
+			req := httptest.NewRequest(http.MethodGet, "/users"+currentTest.query, nil)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			if s.Equal(http.StatusOK, rec.Code) {
+				s.Equal(currentTest.expectedCount, rec.Header().Get("X-Total-Count"))
+
+				var got []models.User
+				err = json.Unmarshal(rec.Body.Bytes(), &got)
+				s.Require().NoError(err)
+
+				listed := false
+				for _, user := range got {
+					listed = listed || user.ID == userWithoutEmail
+				}
+				s.Equal(currentTest.expectListed, listed)
+			}
+		})
 	}
 }
 
@@ -247,3 +293,30 @@ func (s *userAdminSuite) TestUserHandlerAdmin_Create() {
 		})
 	}
 }
+
+func (s *userAdminSuite) TestUserHandlerAdmin_Create_EmailDomainNotAllowed() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	body := `{"emails": [{"address": "test@example.org", "is_primary": true}]}`
+
+	testConfig := test.DefaultConfig
+	testConfig.Emails.Domains = config.EmailDomains{Denylist: []string{"example.org"}}
+	s.Require().NoError(testConfig.PostProcess())
+
+	// the email domain settings only apply to the admin API if configured
+	e := NewAdminRouter(&testConfig, s.Storage, nil)
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code)
+
+	testConfig.Emails.Domains.ApplyToAdmin = true
+	e = NewAdminRouter(&testConfig, s.Storage, nil)
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(strings.Replace(body, "test@", "test2@", 1)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusUnprocessableEntity, rec.Code)
+}
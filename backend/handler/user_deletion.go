@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// softDeleteUser marks the user as deleted and revokes the sessions of the user. The emails of the user are kept, so
+// that they cannot be used by other users until the user is purged.
+func softDeleteUser(tx *pop.Connection, persister persistence.Persister, user *models.User) error {
+	now := time.Now().UTC()
+	user.DeletedAt = &now
+	err := persister.GetUserPersisterWithConnection(tx).Update(*user)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	err = persister.GetSessionPersisterWithConnection(tx).DeleteByUserId(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/metadata"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"io"
+	"net/http"
+	"time"
+)
+
+type UserMetadataHandler struct {
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+	cfg         *config.Config
+}
+
+func NewUserMetadataHandler(cfg *config.Config, persister persistence.Persister, auditLogger auditlog.Logger) *UserMetadataHandler {
+	return &UserMetadataHandler{
+		persister:   persister,
+		auditLogger: auditLogger,
+		cfg:         cfg,
+	}
+}
+
+func (h *UserMetadataHandler) Get(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.UserMetadataResponse{
+		PublicMetadata: user.PublicMetadata,
+		UnsafeMetadata: user.UnsafeMetadata,
+	})
+}
+
+// Patch applies a JSON merge patch to the unsafe metadata of the current user.
+func (h *UserMetadataHandler) Patch(c echo.Context) error {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return errors.New("missing or malformed jwt")
+	}
+
+	user, err := patchUserMetadata(c, h.persister, h.auditLogger, h.cfg.UserMetadata, uuid.FromStringOrNil(sessionToken.Subject()), []metadata.Scope{metadata.ScopeUnsafe})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dto.UserMetadataResponse{
+		PublicMetadata: user.PublicMetadata,
+		UnsafeMetadata: user.UnsafeMetadata,
+	})
+}
+
+func (h *UserMetadataHandler) getUser(c echo.Context) (*models.User, error) {
+	sessionToken, ok := c.Get("session").(jwt.Token)
+	if !ok {
+		return nil, errors.New("missing or malformed jwt")
+	}
+
+	user, err := h.persister.GetUserPersister().Get(uuid.FromStringOrNil(sessionToken.Subject()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound).SetInternal(errors.New("user not found"))
+	}
+
+	return user, nil
+}
+
+// patchUserMetadata applies the JSON merge patch in the request body to the given scopes of the user metadata and
+// stores the metadata. The user is read within the transaction and locked, so that concurrent patches are not lost.
+func patchUserMetadata(c echo.Context, persister persistence.Persister, auditLogger auditlog.Logger, cfg config.UserMetadata, userId uuid.UUID, scopes []metadata.Scope) (*models.User, error) {
+	// a patch may be larger than the resulting metadata, e.g. if it removes keys
+	maxPatchSize := 0
+	for _, scope := range scopes {
+		if scope == metadata.ScopeUnsafe {
+			maxPatchSize += 2 * cfg.UnsafeMaxSize
+		} else {
+			maxPatchSize += 2 * cfg.MaxSize
+		}
+	}
+
+	patch, err := io.ReadAll(io.LimitReader(c.Request().Body, int64(maxPatchSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(patch) > maxPatchSize {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "metadata patch is too large")
+	}
+
+	var user *models.User
+	err = persister.Transaction(func(tx *pop.Connection) error {
+		userPersister := persister.GetUserPersisterWithConnection(tx)
+		user, err = userPersister.GetForUpdate(userId)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+
+		err = metadata.Patch(user, patch, scopes, cfg)
+		if err != nil {
+			var patchError *metadata.PatchError
+			if errors.As(err, &patchError) {
+				return echo.NewHTTPError(http.StatusBadRequest, patchError.Message).SetInternal(err)
+			}
+			return err
+		}
+
+		user.UpdatedAt = time.Now().UTC()
+		err = userPersister.UpdateMetadata(*user)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = auditLogger.CreateWithConnection(tx, c, models.AuditLogUserMetadataUpdated, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
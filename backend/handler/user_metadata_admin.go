@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/metadata"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+)
+
+type UserMetadataHandlerAdmin struct {
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+	cfg         *config.Config
+}
+
+func NewUserMetadataHandlerAdmin(cfg *config.Config, persister persistence.Persister, auditLogger auditlog.Logger) *UserMetadataHandlerAdmin {
+	return &UserMetadataHandlerAdmin{
+		persister:   persister,
+		auditLogger: auditLogger,
+		cfg:         cfg,
+	}
+}
+
+func (h *UserMetadataHandlerAdmin) Get(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromUserMetadataModel(*user))
+}
+
+// Patch applies a JSON merge patch to the public, private and unsafe metadata of the user.
+func (h *UserMetadataHandlerAdmin) Patch(c echo.Context) error {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	scopes := []metadata.Scope{metadata.ScopePublic, metadata.ScopePrivate, metadata.ScopeUnsafe}
+	user, err := patchUserMetadata(c, h.persister, h.auditLogger, h.cfg.UserMetadata, userId, scopes)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromUserMetadataModel(*user))
+}
+
+func (h *UserMetadataHandlerAdmin) getUser(c echo.Context) (*models.User, error) {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	return user, nil
+}
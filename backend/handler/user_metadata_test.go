@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/metadata"
+	"github.com/teamhanko/hanko/backend/session"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserMetadataSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(userMetadataSuite))
+}
+
+type userMetadataSuite struct {
+	test.Suite
+}
+
+const userMetadataUserId = "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
+
+func (s *userMetadataSuite) TestUserMetadataHandler_Patch() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/user_with_webauthn_credential")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	cfg.UserMetadata.JwtClaims = []string{"public"}
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userMetadataUserId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "should not change public metadata",
+			body:         `{"public_metadata": {"plan": "pro"}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should not exceed the size limit",
+			body:         fmt.Sprintf(`{"unsafe_metadata": {"note": "%s"}}`, strings.Repeat("a", cfg.UserMetadata.UnsafeMaxSize)),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should change unsafe metadata",
+			body:         `{"unsafe_metadata": {"theme": "dark"}}`,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, currentTest := range tests {
+		s.Run(currentTest.name, func() {
+			req := httptest.NewRequest(http.MethodPatch, "/user/metadata", strings.NewReader(currentTest.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(currentTest.expectedCode, rec.Code)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/user/metadata", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var response dto.UserMetadataResponse
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+		s.Nil(response.PublicMetadata)
+		s.Equal("dark", response.UnsafeMetadata["theme"])
+	}
+}
+
+func (s *userMetadataSuite) TestUserMetadataHandlerAdmin_Patch() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/user_with_webauthn_credential")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	body := `{"public_metadata": {"plan": "pro"}, "private_metadata": {"stripe_id": "cus_123"}}`
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/users/%s/metadata", userMetadataUserId), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var response admin.UserMetadata
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+		s.Equal("pro", response.PublicMetadata["plan"])
+		s.Equal("cus_123", response.PrivateMetadata["stripe_id"])
+		s.Nil(response.UnsafeMetadata)
+	}
+
+	// public metadata is part of /me and the session JWT
+	cfg := test.DefaultConfig
+	cfg.UserMetadata.JwtClaims = []string{"public"}
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister(), metadata.NewClaimsProvider(cfg.UserMetadata, s.Storage))
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userMetadataUserId))
+	s.Require().NoError(err)
+
+	parsedToken, err := sessionManager.Verify(token)
+	s.Require().NoError(err)
+	claim, ok := parsedToken.Get("public_metadata")
+	s.Require().True(ok)
+	s.Equal(map[string]interface{}{"plan": "pro"}, claim)
+
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+	req = httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+
+	NewPublicRouter(&cfg, s.Storage, nil).ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var response dto.MeResponse
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+		s.Equal("pro", response.PublicMetadata["plan"])
+	}
+}
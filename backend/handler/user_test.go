@@ -7,7 +7,11 @@ import (
 	"github.com/gofrs/uuid"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
 	"github.com/teamhanko/hanko/backend/test"
@@ -16,6 +20,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestUserSuite(t *testing.T) {
@@ -51,7 +56,7 @@ func (s *userSuite) TestUserHandler_Create_TokenInCookie() {
 		s.NoError(err)
 		s.False(user.ID.IsNil())
 
-		count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "")
+		count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
 		s.NoError(err)
 		s.Equal(1, count)
 
@@ -96,7 +101,7 @@ func (s *userSuite) TestUserHandler_Create_TokenInHeader() {
 		s.NoError(err)
 		s.False(user.ID.IsNil())
 
-		count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "")
+		count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
 		s.NoError(err)
 		s.Equal(1, count)
 
@@ -136,7 +141,7 @@ func (s *userSuite) TestUserHandler_Create_CaseInsensitive() {
 		s.NoError(err)
 		s.False(user.ID.IsNil())
 
-		count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "")
+		count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
 		s.NoError(err)
 		s.Equal(1, count)
 
@@ -241,6 +246,69 @@ func (s *userSuite) TestUserHandler_Create_AccountCreationDisabled() {
 	s.Equal(http.StatusForbidden, rec.Code)
 }
 
+func (s *userSuite) TestUserHandler_Create_DefaultRoleClaims() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	cfg := test.DefaultConfig
+	cfg.Session.EnableAuthTokenHeader = true
+	cfg.Rbac = config.Rbac{JwtClaims: true, DefaultRole: "member"}
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	role := models.NewRole("member", nil)
+	s.Require().NoError(s.Storage.GetRolePersister().Create(role))
+
+	bodyJson, err := json.Marshal(UserCreateBody{Email: "jane.doe@example.com"})
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(bodyJson))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.Verify(rec.Header().Get("X-Auth-Token"))
+	s.Require().NoError(err)
+
+	// the default role is granted in the sign up transaction and must already be contained in the first session JWT
+	roles, ok := token.Get("roles")
+	s.Require().True(ok)
+	s.Equal([]interface{}{"member"}, roles)
+}
+
+func (s *userSuite) TestUserHandler_Create_EmailDomainNotAllowed() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	testConfig := test.DefaultConfig
+	testConfig.Emails.Domains = config.EmailDomains{Allowlist: []string{"example.com"}, BlockDisposable: true}
+	s.Require().NoError(testConfig.PostProcess())
+	e := NewPublicRouter(&testConfig, s.Storage, nil)
+
+	for _, address := range []string{"jane.doe@example.org", "jane.doe@mailinator.com"} {
+		body := UserCreateBody{Email: address}
+		bodyJson, err := json.Marshal(body)
+		s.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(bodyJson))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		s.Equal(http.StatusUnprocessableEntity, rec.Code)
+	}
+
+	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
+	s.Require().NoError(err)
+	s.Equal(0, count)
+}
+
 func (s *userSuite) TestUserHandler_Get() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
@@ -455,6 +523,113 @@ func (s *userSuite) TestUserHandler_GetUserIdByEmail_CaseInsensitive() {
 	}
 }
 
+func (s *userSuite) TestUserHandler_SetUsername() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
+
+	cfg := test.DefaultConfig
+	cfg.Username = config.DefaultConfig().Username
+	cfg.Username.Enabled = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "should reject invalid username",
+			body:         `{"username": "jane@example.com"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should reject username of another user",
+			body:         `{"username": "John.Doe"}`,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "should set username",
+			body:         `{"username": " Jane.Doe "}`,
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, currentTest := range tests {
+		s.Run(currentTest.name, func() {
+			req := httptest.NewRequest(http.MethodPut, "/user/username", strings.NewReader(currentTest.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(currentTest.expectedCode, rec.Code)
+		})
+	}
+
+	user, err := s.Storage.GetUserPersister().Get(uuid.FromStringOrNil(userId))
+	s.Require().NoError(err)
+	s.Require().NotNil(user.Username)
+	s.Equal("jane.doe", *user.Username)
+
+	logs, err := s.Storage.GetAuditLogPersister().List(0, 0, nil, nil, []string{"username_changed"}, "", "", "", "")
+	s.Require().NoError(err)
+	s.Len(logs, 1)
+}
+
+func (s *userSuite) TestUserHandler_GetUserIdByUsername() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/password")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	cfg.Username = config.DefaultConfig().Username
+	cfg.Username.Enabled = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"username": "John.Doe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		response := dto.UserInfoResponse{}
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		s.NoError(err)
+		s.Equal("38bf5a00-d7ea-40a5-a5de-48722c148925", response.ID.String())
+		s.Require().NotNil(response.EmailID)
+		s.Equal("38bf5a00-d7ea-40a5-a5de-48722c148925", response.EmailID.String())
+		s.True(response.Verified)
+	}
+
+	e = NewPublicRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req = httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"username": "john.doe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
 func (s *userSuite) TestUserHandler_Me() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode.")
@@ -571,7 +746,216 @@ func (s *userSuite) TestUserHandler_Delete() {
 		s.Equal("Max-Age=0", strings.TrimSpace(split[2]))
 	}
 
-	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "")
+	count, err := s.Storage.GetUserPersister().Count(uuid.Nil, "", nil, "")
 	s.NoError(err)
 	s.Equal(0, count)
 }
+
+func (s *userSuite) TestUserHandler_Delete_SoftDelete() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/user")
+	s.Require().NoError(err)
+
+	userId, _ := uuid.FromString("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	cfg := test.DefaultConfig
+	cfg.Account.AllowDeletion = true
+	cfg.Account.SoftDelete = config.AccountSoftDelete{Enabled: true, GracePeriod: time.Hour}
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+	adminRouter := NewAdminRouter(&cfg, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(cfg.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, cfg, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(userId)
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodDelete, "/user", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	user, err := s.Storage.GetUserPersister().Get(userId)
+	s.Require().NoError(err)
+	s.Require().NotNil(user)
+	s.True(user.IsDeleted())
+
+	// the email address of the deleted user is reserved
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email": "john.doe@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Equal(http.StatusConflict, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%s/restore", userId), nil)
+	rec = httptest.NewRecorder()
+	adminRouter.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var restoredUser admin.User
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &restoredUser))
+	s.Nil(restoredUser.DeletedAt)
+
+	logs, err := s.Storage.GetAuditLogPersister().List(0, 0, nil, nil, []string{"user_deleted", "user_restored"}, "", "", "", "")
+	s.Require().NoError(err)
+	s.Len(logs, 2)
+}
+
+func (s *userSuite) TestUserHandler_UpdateProfile() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/user")
+	s.Require().NoError(err)
+
+	userId := "b5dd5267-b462-48be-b70d-bcd6f1bbe7a5"
+
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, test.DefaultConfig, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expected     string
+	}{
+		{
+			name:         "should reject invalid avatar url",
+			body:         `{"avatar_url": "javascript:alert(1)"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should reject invalid locale",
+			body:         `{"locale": "not a locale"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should set profile",
+			body:         `{"display_name": "Jane Doe", "given_name": "Jane", "avatar_url": "https://example.com/jane.png", "locale": "de-DE"}`,
+			expectedCode: http.StatusOK,
+			expected:     `{"display_name": "Jane Doe", "given_name": "Jane", "avatar_url": "https://example.com/jane.png", "locale": "de-DE"}`,
+		},
+		{
+			name:         "should only change present fields and remove empty fields",
+			body:         `{"given_name": "", "family_name": "Doe"}`,
+			expectedCode: http.StatusOK,
+			expected:     `{"display_name": "Jane Doe", "family_name": "Doe", "avatar_url": "https://example.com/jane.png", "locale": "de-DE"}`,
+		},
+	}
+
+	for _, currentTest := range tests {
+		s.Run(currentTest.name, func() {
+			req := httptest.NewRequest(http.MethodPatch, "/user/profile", strings.NewReader(currentTest.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Require().Equal(currentTest.expectedCode, rec.Code)
+			if rec.Code == http.StatusOK {
+				s.JSONEq(currentTest.expected, rec.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var me dto.MeResponse
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &me))
+	s.Equal("Jane Doe", *me.DisplayName)
+}
+
+func (s *userSuite) TestUserHandler_ExportPersonalData() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/user")
+	s.Require().NoError(err)
+
+	userId := uuid.FromStringOrNil("b5dd5267-b462-48be-b70d-bcd6f1bbe7a5")
+	user, err := s.Storage.GetUserPersister().Get(userId)
+	s.Require().NoError(err)
+	user.PrivateMetadata = map[string]interface{}{"stripe_id": "cus_123"}
+	s.Require().NoError(s.Storage.GetUserPersister().Update(*user))
+
+	role := models.NewRole("editor", nil)
+	s.Require().NoError(s.Storage.GetRolePersister().Create(role))
+	s.Require().NoError(s.Storage.GetRolePersister().AssignToUser(userId, role.ID))
+	organization := models.NewOrganization("Acme Corp", "acme-corp")
+	s.Require().NoError(s.Storage.GetOrganizationPersister().Create(organization))
+	s.Require().NoError(s.Storage.GetOrganizationMembershipPersister().Create(models.NewOrganizationMembership(organization.ID, userId, &role.ID)))
+	emailChange := models.NewEmailChange(userId, "john.old@example.com", user.Emails[0].ID, crypto.HashToken("token"), time.Now().UTC().Add(time.Hour))
+	s.Require().NoError(s.Storage.GetEmailChangePersister().Create(emailChange))
+
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil)
+	adminRouter := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
+	s.Require().NoError(err)
+	sessionManager, err := session.NewManager(jwkManager, test.DefaultConfig, s.Storage.GetSessionPersister())
+	s.Require().NoError(err)
+	token, err := sessionManager.GenerateJWT(userId)
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/export", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.Contains(rec.Header().Get("Content-Disposition"), "attachment")
+
+	var export dto.PersonalDataExport
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &export))
+	s.Equal(dto.PersonalDataExportFormatVersion, export.FormatVersion)
+	s.Equal(userId, export.User.ID)
+	s.Require().Len(export.Emails, 1)
+	s.Equal("john.doe@example.com", export.Emails[0].Address)
+	s.Nil(export.User.PrivateMetadata)
+	if s.Len(export.Roles, 1) {
+		s.Equal("editor", export.Roles[0].Name)
+	}
+	if s.Len(export.OrganizationMemberships, 1) {
+		s.Equal("acme-corp", export.OrganizationMemberships[0].OrganizationSlug)
+		if s.NotNil(export.OrganizationMemberships[0].Role) {
+			s.Equal("editor", *export.OrganizationMemberships[0].Role)
+		}
+	}
+	if s.Len(export.EmailChanges, 1) {
+		s.Equal("john.old@example.com", export.EmailChanges[0].OldAddress)
+	}
+	s.NotContains(rec.Body.String(), crypto.HashToken("token"))
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/export", userId), nil)
+	rec = httptest.NewRecorder()
+	adminRouter.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	export = dto.PersonalDataExport{}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &export))
+	s.Equal("cus_123", export.User.PrivateMetadata["stripe_id"])
+	// the audit log of the first export is contained in the second one
+	s.Require().NotEmpty(export.AuditLogs)
+	s.Equal("personal_data_exported", export.AuditLogs[0].Type)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/username"
+)
+
+// getUserByUsername returns the user with the given username, which can be sent by clients instead of a user ID to
+// initialize a login. It returns nil if no such user exists or usernames are disabled.
+func getUserByUsername(persister persistence.Persister, cfg *config.Config, name string) (*models.User, error) {
+	if !cfg.Username.Enabled {
+		return nil, nil
+	}
+
+	user, err := persister.GetUserPersister().GetByUsername(username.Normalize(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
@@ -6,38 +6,90 @@ import (
 	"fmt"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gobuffalo/nulls"
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/teamhanko/hanko/backend/attestation"
 	"github.com/teamhanko/hanko/backend/audit_log"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/dto/intern"
+	"github.com/teamhanko/hanko/backend/lockout"
+	"github.com/teamhanko/hanko/backend/mds"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rbac"
 	"github.com/teamhanko/hanko/backend/session"
+	"golang.org/x/exp/slices"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type WebauthnHandler struct {
-	persister      persistence.Persister
-	webauthn       *webauthn.WebAuthn
-	sessionManager session.Manager
-	cfg            *config.Config
-	auditLogger    auditlog.Logger
+	persister       persistence.Persister
+	relyingParties  []relyingParty
+	sessionManager  session.Manager
+	cfg             *config.Config
+	auditLogger     auditlog.Logger
+	lockoutManager  *lockout.Manager
+	attestation     *attestation.Verifier
+	metadataService *mds.Service
 }
 
-// NewWebauthnHandler creates a new handler which handles all webauthn related routes
-func NewWebauthnHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger) (*WebauthnHandler, error) {
+// NewWebauthnHandler creates a new handler which handles all webauthn related routes. metadataService may be nil if
+// the FIDO metadata service is disabled.
+func NewWebauthnHandler(cfg *config.Config, persister persistence.Persister, sessionManager session.Manager, auditLogger auditlog.Logger, lockoutManager *lockout.Manager, metadataService *mds.Service) (*WebauthnHandler, error) {
+	var rootsProvider attestation.RootsProvider
+	if metadataService != nil {
+		rootsProvider = metadataService.AttestationRoots
+	}
+
+	attestationVerifier, err := attestation.NewVerifier(cfg.Webauthn.Attestation, rootsProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attestation verifier: %w", err)
+	}
+
+	var relyingParties []relyingParty
+	for _, rp := range cfg.Webauthn.RelyingParties() {
+		wa, err := newWebauthn(cfg, rp, attestationVerifier.ConveyancePreference())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create webauthn instance for relying party %s: %w", rp.Id, err)
+		}
+		relyingParties = append(relyingParties, relyingParty{origins: rp.AllOrigins(), webauthn: wa})
+	}
+
+	return &WebauthnHandler{
+		persister:       persister,
+		relyingParties:  relyingParties,
+		sessionManager:  sessionManager,
+		cfg:             cfg,
+		auditLogger:     auditLogger,
+		lockoutManager:  lockoutManager,
+		attestation:     attestationVerifier,
+		metadataService: metadataService,
+	}, nil
+}
+
+type relyingParty struct {
+	origins  []string
+	webauthn *webauthn.WebAuthn
+}
+
+func newWebauthn(cfg *config.Config, rp config.RelyingParty, conveyancePreference protocol.ConveyancePreference) (*webauthn.WebAuthn, error) {
+	displayName := rp.DisplayName
+	if displayName == "" {
+		displayName = cfg.Webauthn.RelyingParty.DisplayName
+	}
+
 	f := false
-	wa, err := webauthn.New(&webauthn.Config{
-		RPDisplayName:         cfg.Webauthn.RelyingParty.DisplayName,
-		RPID:                  cfg.Webauthn.RelyingParty.Id,
-		RPOrigins:             cfg.Webauthn.RelyingParty.Origins,
-		AttestationPreference: protocol.PreferNoAttestation,
+	return webauthn.New(&webauthn.Config{
+		RPDisplayName:         displayName,
+		RPID:                  rp.Id,
+		RPOrigins:             rp.AllOrigins(),
+		AttestationPreference: conveyancePreference,
 		AuthenticatorSelection: protocol.AuthenticatorSelection{
 			RequireResidentKey: &f,
 			ResidentKey:        protocol.ResidentKeyRequirementDiscouraged,
@@ -55,18 +107,23 @@ func NewWebauthnHandler(cfg *config.Config, persister persistence.Persister, ses
 			},
 		},
 	})
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create webauthn instance: %w", err)
+// getWebauthn returns the webauthn instance of the first relying party which accepts the origin. The first relying
+// party is the default if none accepts the origin, e.g. because no origin is given.
+func (h *WebauthnHandler) getWebauthn(origin string) *webauthn.WebAuthn {
+	for _, rp := range h.relyingParties {
+		if slices.Contains(rp.origins, origin) {
+			return rp.webauthn
+		}
 	}
+	return h.relyingParties[0].webauthn
+}
 
-	return &WebauthnHandler{
-		persister:      persister,
-		webauthn:       wa,
-		sessionManager: sessionManager,
-		cfg:            cfg,
-		auditLogger:    auditLogger,
-	}, nil
+// requestOrigin returns the value of the Origin header, which is used to choose the relying party when creating
+// options. When finishing a ceremony the relying party is chosen by the origin in the client data instead.
+func requestOrigin(c echo.Context) string {
+	return c.Request().Header.Get(echo.HeaderOrigin)
 }
 
 // BeginRegistration returns credential creation options for the WebAuthnAPI. It expects a valid session JWT in the request.
@@ -92,17 +149,24 @@ func (h *WebauthnHandler) BeginRegistration(c echo.Context) error {
 	}
 
 	t := true
-	options, sessionData, err := h.webauthn.BeginRegistration(
-		webauthnUser,
+	registrationOptions := []webauthn.RegistrationOption{
 		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
 			RequireResidentKey: &t,
 			ResidentKey:        protocol.ResidentKeyRequirementRequired,
 			UserVerification:   protocol.UserVerificationRequirement(h.cfg.Webauthn.UserVerification),
 		}),
-		webauthn.WithConveyancePreference(protocol.PreferNoAttestation),
+		webauthn.WithConveyancePreference(h.attestation.ConveyancePreference()),
 		// don't set the excludeCredentials list, so an already registered device can be re-registered
-	)
+	}
+	if h.cfg.Webauthn.Prf.Enabled {
+		extensions, err := h.getPrfExtension(user.ID)
+		if err != nil {
+			return err
+		}
+		registrationOptions = append(registrationOptions, webauthn.WithExtensions(extensions))
+	}
 
+	options, sessionData, err := h.getWebauthn(requestOrigin(c)).BeginRegistration(webauthnUser, registrationOptions...)
 	if err != nil {
 		return fmt.Errorf("failed to create webauthn creation options: %w", err)
 	}
@@ -171,32 +235,215 @@ func (h *WebauthnHandler) FinishRegistration(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("user not found"))
 		}
 
-		credential, err := h.webauthn.CreateCredential(webauthnUser, *intern.WebauthnSessionDataFromModel(sessionData), request)
-		if err != nil {
-			errorMessage := "failed to validate attestation"
-			errorStatus := http.StatusBadRequest
-			// Safari currently (v. 16.2) does not provide a UI in case of a (registration) ceremony
-			// being performed with an authenticator NOT protected by e.g. a PIN. While Chromium based browsers do offer
-			// a UI guiding through the setup of a PIN, Safari simply performs the ceremony without then setting the UV
-			// flag even if it is required. In order to provide an appropriate error message to the frontend/user, we
-			// need to return an error response distinguishable from other error cases. We use a dedicated/separate HTTP
-			// status code because it seemed a bit more robust than forcing the frontend to check on a matching
-			// (sub-)string in the error message in order to properly display the error.
-			if err, ok := err.(*protocol.Error); ok && err.Type == protocol.ErrVerification.Type && strings.Contains(err.DevInfo, "User verification") {
-				errorMessage = fmt.Sprintf("%s: %s: %s", errorMessage, err.Details, err.DevInfo)
-				errorStatus = http.StatusUnprocessableEntity
-			}
-			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnRegistrationFinalFailed, user, errors.New(errorMessage))
+		model, err := h.createCredential(tx, c, webauthnUser, user, sessionData, request)
+		if err != nil {
+			return err
+		}
+
+		err = h.persister.GetWebauthnCredentialPersisterWithConnection(tx).Create(*model)
+		if err != nil {
+			return fmt.Errorf("failed to store webauthn credential: %w", err)
+		}
+
+		err = sessionDataPersister.Delete(*sessionData)
+		if err != nil {
+			c.Logger().Errorf("failed to delete attestation session data: %w", err)
+		}
+
+		err = h.auditLogger.Create(c, models.AuditLogWebAuthnRegistrationFinalSucceeded, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"credential_id": model.ID, "user_id": webauthnUser.UserId.String()})
+	})
+}
+
+// createCredential validates the attestation response and checks the authenticator against the attestation policy.
+// The returned credential has not been stored yet.
+func (h *WebauthnHandler) createCredential(tx *pop.Connection, c echo.Context, webauthnUser *intern.WebauthnUser, user *models.User, sessionData *models.WebauthnSessionData, request *protocol.ParsedCredentialCreationData) (*models.WebauthnCredential, error) {
+	credential, err := h.getWebauthn(request.Response.CollectedClientData.Origin).CreateCredential(webauthnUser, *intern.WebauthnSessionDataFromModel(sessionData), request)
+	if err != nil {
+		errorMessage := "failed to validate attestation"
+		errorStatus := http.StatusBadRequest
+		// Safari currently (v. 16.2) does not provide a UI in case of a (registration) ceremony
+		// being performed with an authenticator NOT protected by e.g. a PIN. While Chromium based browsers do offer
+		// a UI guiding through the setup of a PIN, Safari simply performs the ceremony without then setting the UV
+		// flag even if it is required. In order to provide an appropriate error message to the frontend/user, we
+		// need to return an error response distinguishable from other error cases. We use a dedicated/separate HTTP
+		// status code because it seemed a bit more robust than forcing the frontend to check on a matching
+		// (sub-)string in the error message in order to properly display the error.
+		if err, ok := err.(*protocol.Error); ok && err.Type == protocol.ErrVerification.Type && strings.Contains(err.DevInfo, "User verification") {
+			errorMessage = fmt.Sprintf("%s: %s: %s", errorMessage, err.Details, err.DevInfo)
+			errorStatus = http.StatusUnprocessableEntity
+		}
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnRegistrationFinalFailed, user, errors.New(errorMessage))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		return nil, echo.NewHTTPError(errorStatus, errorMessage).SetInternal(err)
+	}
+
+	attestationResult := h.attestation.Evaluate(request.Response.AttestationObject)
+	err = h.attestation.Check(attestationResult)
+	if err == nil {
+		err = h.checkAuthenticatorStatus(attestationResult.AAGUID)
+	}
+	if err != nil {
+		logErr := h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnRegistrationFinalFailed, user, err)
+		if logErr != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", logErr)
+		}
+		return nil, echo.NewHTTPError(http.StatusForbidden, "authenticator not allowed").SetInternal(err)
+	}
+
+	backupEligible := request.Response.AttestationObject.AuthData.Flags.HasBackupEligible()
+	backupState := request.Response.AttestationObject.AuthData.Flags.HasBackupState()
+	model := intern.WebauthnCredentialToModel(credential, sessionData.UserId, backupEligible, backupState, attestationResult)
+	model.PrfSupported = intern.PrfEnabled(request.ClientExtensionResults)
+	return model, nil
+}
+
+// getPrfExtension returns the input of the PRF extension with the salt of the user. The salt is created on first use.
+func (h *WebauthnHandler) getPrfExtension(userId uuid.UUID) (protocol.AuthenticationExtensions, error) {
+	saltPersister := h.persister.GetWebauthnPrfSaltPersister()
+	salt, err := saltPersister.GetByUserId(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prf salt: %w", err)
+	}
+
+	if salt == nil {
+		salt, err = models.NewWebauthnPrfSalt(userId)
+		if err != nil {
+			return nil, err
+		}
+		err = saltPersister.Create(*salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store prf salt: %w", err)
+		}
+	}
+
+	return intern.PrfExtension(salt)
+}
+
+type BeginSignupBody struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+}
+
+// BeginSignup returns credential creation options for a user who does not exist yet. The user is created together with
+// the credential in FinishSignup, so that no user without a credential or an email address remains.
+func (h *WebauthnHandler) BeginSignup(c echo.Context) error {
+	if !h.cfg.Account.AllowSignup || !h.cfg.Account.AllowPasskeySignup {
+		return echo.NewHTTPError(http.StatusForbidden).SetInternal(errors.New("passkey signup is disabled"))
+	}
+
+	var body BeginSignupBody
+	if err := (&echo.DefaultBinder{}).BindBody(c, &body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	if err := c.Validate(body); err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	user := models.NewUser()
+	if body.DisplayName != nil && strings.TrimSpace(*body.DisplayName) != "" {
+		displayName := strings.TrimSpace(*body.DisplayName)
+		user.DisplayName = &displayName
+	}
+
+	t := true
+	registrationOptions := []webauthn.RegistrationOption{
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			RequireResidentKey: &t,
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.UserVerificationRequirement(h.cfg.Webauthn.UserVerification),
+		}),
+		webauthn.WithConveyancePreference(h.attestation.ConveyancePreference()),
+	}
+	if h.cfg.Webauthn.Prf.Enabled {
+		// the salt can not be stored before the user exists, so only ask whether the credential supports the extension
+		extensions, err := intern.PrfExtension(nil)
+		if err != nil {
+			return err
+		}
+		registrationOptions = append(registrationOptions, webauthn.WithExtensions(extensions))
+	}
+
+	options, sessionData, err := h.getWebauthn(requestOrigin(c)).BeginRegistration(intern.NewWebauthnUser(user, nil), registrationOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn creation options: %w", err)
+	}
+
+	sessionDataModel := intern.WebauthnSessionDataToModel(sessionData, models.WebauthnOperationSignup)
+	if user.DisplayName != nil {
+		sessionDataModel.UserDisplayName = nulls.NewString(*user.DisplayName)
+	}
+	err = h.persister.GetWebauthnSessionDataPersister().Create(*sessionDataModel)
+	if err != nil {
+		return fmt.Errorf("failed to store creation options session data: %w", err)
+	}
+
+	err = h.auditLogger.Create(c, models.AuditLogWebAuthnRegistrationInitSucceeded, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, options)
+}
+
+// FinishSignup validates the WebAuthnAPI response, creates the user with the credential and returns a session JWT.
+func (h *WebauthnHandler) FinishSignup(c echo.Context) error {
+	if !h.cfg.Account.AllowSignup || !h.cfg.Account.AllowPasskeySignup {
+		return echo.NewHTTPError(http.StatusForbidden).SetInternal(errors.New("passkey signup is disabled"))
+	}
+
+	request, err := protocol.ParseCredentialCreationResponse(c.Request())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return h.persister.Transaction(func(tx *pop.Connection) error {
+		sessionDataPersister := h.persister.GetWebauthnSessionDataPersisterWithConnection(tx)
+		sessionData, err := sessionDataPersister.GetByChallenge(request.Response.CollectedClientData.Challenge)
+		if err != nil {
+			return fmt.Errorf("failed to get webauthn signup session data: %w", err)
+		}
+
+		if sessionData != nil && sessionData.Operation != models.WebauthnOperationSignup {
+			sessionData = nil
+		}
+
+		if sessionData == nil {
+			err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnRegistrationFinalFailed, nil, fmt.Errorf("received unkown challenge"))
 			if err != nil {
 				return fmt.Errorf("failed to create audit log: %w", err)
 			}
+			return echo.NewHTTPError(http.StatusBadRequest, "Stored challenge and received challenge do not match").SetInternal(errors.New("sessionData not found"))
+		}
+
+		user := models.NewUser()
+		user.ID = sessionData.UserId
+		if sessionData.UserDisplayName.Valid {
+			user.DisplayName = &sessionData.UserDisplayName.String
+		}
+
+		model, err := h.createCredential(tx, c, intern.NewWebauthnUser(user, nil), nil, sessionData, request)
+		if err != nil {
+			return err
+		}
+
+		err = h.persister.GetUserPersisterWithConnection(tx).Create(user)
+		if err != nil {
+			return fmt.Errorf("failed to store user: %w", err)
+		}
 
-			return echo.NewHTTPError(errorStatus, errorMessage).SetInternal(err)
+		err = rbac.GrantDefaultRole(h.cfg.Rbac, h.persister.GetRolePersisterWithConnection(tx), user.ID)
+		if err != nil {
+			return err
 		}
 
-		backupEligible := request.Response.AttestationObject.AuthData.Flags.HasBackupEligible()
-		backupState := request.Response.AttestationObject.AuthData.Flags.HasBackupState()
-		model := intern.WebauthnCredentialToModel(credential, sessionData.UserId, backupEligible, backupState)
 		err = h.persister.GetWebauthnCredentialPersisterWithConnection(tx).Create(*model)
 		if err != nil {
 			return fmt.Errorf("failed to store webauthn credential: %w", err)
@@ -204,20 +451,32 @@ func (h *WebauthnHandler) FinishRegistration(c echo.Context) error {
 
 		err = sessionDataPersister.Delete(*sessionData)
 		if err != nil {
-			c.Logger().Errorf("failed to delete attestation session data: %w", err)
+			return fmt.Errorf("failed to delete signup session data: %w", err)
 		}
 
-		err = h.auditLogger.Create(c, models.AuditLogWebAuthnRegistrationFinalSucceeded, user, nil)
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserCreated, &user, nil)
 		if err != nil {
 			return fmt.Errorf("failed to create audit log: %w", err)
 		}
 
-		return c.JSON(http.StatusOK, map[string]string{"credential_id": model.ID, "user_id": webauthnUser.UserId.String()})
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnRegistrationFinalSucceeded, &user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+
+		err = h.sessionManager.GenerateCookieOrHeader(tx, user.ID, c)
+		if err != nil {
+			return fmt.Errorf("failed to generate cookie or header: %w", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"credential_id": model.ID, "user_id": user.ID.String()})
 	})
 }
 
 type BeginAuthenticationBody struct {
 	UserID *string `json:"user_id" validate:"uuid4"`
+	// Username can be used instead of UserID if usernames are enabled.
+	Username *string `json:"username"`
 }
 
 // BeginAuthentication returns credential assertion options for the WebAuthnAPI.
@@ -231,6 +490,21 @@ func (h *WebauthnHandler) BeginAuthentication(c echo.Context) error {
 	var options *protocol.CredentialAssertion
 	var sessionData *webauthn.SessionData
 	var user *models.User
+	if request.UserID == nil && request.Username != nil {
+		usernameUser, err := getUserByUsername(h.persister, h.cfg, *request.Username)
+		if err != nil {
+			return err
+		}
+		if usernameUser == nil {
+			err = h.auditLogger.Create(c, models.AuditLogWebAuthnAuthenticationInitFailed, nil, fmt.Errorf("unknown username"))
+			if err != nil {
+				return fmt.Errorf("failed to create audit log: %w", err)
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, "user not found")
+		}
+		userId := usernameUser.ID.String()
+		request.UserID = &userId
+	}
 	if request.UserID != nil {
 		// non discoverable login initialization
 		userId, err := uuid.FromString(*request.UserID)
@@ -254,11 +528,36 @@ func (h *WebauthnHandler) BeginAuthentication(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusBadRequest, "user not found")
 		}
 
+		blockedError, err := checkBlocked(h.persister.GetConnection(), c, h.auditLogger, user, models.AuditLogWebAuthnAuthenticationInitFailed)
+		if err != nil {
+			return err
+		}
+		if blockedError != nil {
+			return blockedError
+		}
+
+		lockedError, err := h.lockoutManager.Check(h.persister.GetConnection(), c, user, models.AuditLogWebAuthnAuthenticationInitFailed)
+		if err != nil {
+			return err
+		}
+		if lockedError != nil {
+			return lockedError
+		}
+
 		if len(webauthnUser.WebAuthnCredentials()) > 0 {
-			options, sessionData, err = h.webauthn.BeginLogin(
-				webauthnUser,
+			loginOptions := []webauthn.LoginOption{
 				webauthn.WithUserVerification(protocol.UserVerificationRequirement(h.cfg.Webauthn.UserVerification)),
-			)
+			}
+			// the user and therefore the salt is unknown for discoverable logins
+			if h.cfg.Webauthn.Prf.Enabled {
+				extensions, err := h.getPrfExtension(user.ID)
+				if err != nil {
+					return err
+				}
+				loginOptions = append(loginOptions, webauthn.WithAssertionExtensions(extensions))
+			}
+
+			options, sessionData, err = h.getWebauthn(requestOrigin(c)).BeginLogin(webauthnUser, loginOptions...)
 			if err != nil {
 				return fmt.Errorf("failed to create webauthn assertion options: %w", err)
 			}
@@ -266,7 +565,7 @@ func (h *WebauthnHandler) BeginAuthentication(c echo.Context) error {
 	}
 	if options == nil && sessionData == nil {
 		var err error
-		options, sessionData, err = h.webauthn.BeginDiscoverableLogin(
+		options, sessionData, err = h.getWebauthn(requestOrigin(c)).BeginDiscoverableLogin(
 			webauthn.WithUserVerification(protocol.UserVerificationRequirement(h.cfg.Webauthn.UserVerification)),
 		)
 		if err != nil {
@@ -343,7 +642,15 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
 			}
 
-			credential, err = h.webauthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (user webauthn.User, err error) {
+			lockedError, err := h.checkLockout(c, user)
+			if err != nil {
+				return err
+			}
+			if lockedError != nil {
+				return lockedError
+			}
+
+			credential, err = h.getWebauthn(request.Response.CollectedClientData.Origin).ValidateDiscoverableLogin(func(rawID, userHandle []byte) (user webauthn.User, err error) {
 				return webauthnUser, nil
 			}, *model, request)
 			if err != nil {
@@ -351,7 +658,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				if logErr != nil {
 					return fmt.Errorf("failed to create audit log: %w", err)
 				}
-				return echo.NewHTTPError(http.StatusUnauthorized, "failed to validate assertion").SetInternal(err)
+				return h.rejectAssertion(c, user, err)
 			}
 		} else {
 			// non discoverable Login
@@ -366,16 +673,30 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 				}
 				return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(errors.New("user not found"))
 			}
-			credential, err = h.webauthn.ValidateLogin(webauthnUser, *model, request)
+
+			lockedError, err := h.checkLockout(c, user)
+			if err != nil {
+				return err
+			}
+			if lockedError != nil {
+				return lockedError
+			}
+
+			credential, err = h.getWebauthn(request.Response.CollectedClientData.Origin).ValidateLogin(webauthnUser, *model, request)
 			if err != nil {
 				logErr := h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnAuthenticationFinalFailed, user, fmt.Errorf("assertion validation failed"))
 				if logErr != nil {
 					return fmt.Errorf("failed to create audit log: %w", err)
 				}
-				return echo.NewHTTPError(http.StatusUnauthorized, "failed to validate assertion").SetInternal(err)
+				return h.rejectAssertion(c, user, err)
 			}
 		}
 
+		err = h.lockoutManager.RegisterSuccess(tx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reset failed login attempts: %w", err)
+		}
+
 		var dbCred *models.WebauthnCredential
 		for i := range webauthnUser.WebauthnCredentials {
 			if webauthnUser.WebauthnCredentials[i].ID == base64.RawURLEncoding.EncodeToString(credential.ID) {
@@ -384,6 +705,18 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 			}
 		}
 		if dbCred != nil {
+			if credential.Authenticator.CloneWarning {
+				cloneError, err := h.handleCloneWarning(tx, c, user, dbCred)
+				if err != nil {
+					return err
+				}
+				if cloneError != nil {
+					return cloneError
+				}
+			} else {
+				dbCred.SignCount = int(credential.Authenticator.SignCount)
+			}
+
 			if dbCred.BackupEligible != request.Response.AuthenticatorData.Flags.HasBackupEligible() || dbCred.BackupState != request.Response.AuthenticatorData.Flags.HasBackupState() {
 				dbCred.BackupState = request.Response.AuthenticatorData.Flags.HasBackupState()
 				dbCred.BackupEligible = request.Response.AuthenticatorData.Flags.HasBackupEligible()
@@ -403,7 +736,7 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 			return fmt.Errorf("failed to delete assertion session data: %w", err)
 		}
 
-		err = h.sessionManager.GenerateCookieOrHeader(webauthnUser.UserId, c)
+		err = h.sessionManager.GenerateCookieOrHeader(tx, webauthnUser.UserId, c)
 		if err != nil {
 			return fmt.Errorf("failed to generate cookie or header: %w", err)
 		}
@@ -417,6 +750,90 @@ func (h *WebauthnHandler) FinishAuthentication(c echo.Context) error {
 	})
 }
 
+// checkLockout rejects the assertion of a blocked or locked user. It does not use the transaction of
+// FinishAuthentication, because the transaction is rolled back when the assertion is rejected.
+func (h *WebauthnHandler) checkLockout(c echo.Context, user *models.User) (*echo.HTTPError, error) {
+	blockedError, err := checkBlocked(h.persister.GetConnection(), c, h.auditLogger, user, models.AuditLogWebAuthnAuthenticationFinalFailed)
+	if err != nil || blockedError != nil {
+		return blockedError, err
+	}
+	return h.lockoutManager.Check(h.persister.GetConnection(), c, user, models.AuditLogWebAuthnAuthenticationFinalFailed)
+}
+
+// rejectAssertion counts the invalid assertion as failed login attempt. Like checkLockout, it does not use the
+// transaction of FinishAuthentication.
+func (h *WebauthnHandler) rejectAssertion(c echo.Context, user *models.User, validationError error) error {
+	err := h.lockoutManager.RegisterFailure(h.persister.GetConnection(), c, user)
+	if err != nil {
+		return fmt.Errorf("failed to register failed login attempt: %w", err)
+	}
+	return echo.NewHTTPError(http.StatusUnauthorized, "failed to validate assertion").SetInternal(validationError)
+}
+
+// handleCloneWarning marks the credential as suspected clone, because the signature counter of the assertion did not
+// increase. Depending on the clone detection mode it returns an error rejecting the assertion. In that case the
+// changes are not made within the transaction of FinishAuthentication, because the transaction is rolled back.
+func (h *WebauthnHandler) handleCloneWarning(tx *pop.Connection, c echo.Context, user *models.User, credential *models.WebauthnCredential) (*echo.HTTPError, error) {
+	credential.SuspectedClone = true
+	cloneError := fmt.Errorf("signature counter of credential %s did not increase", credential.ID)
+
+	var auditLogType models.AuditLogType
+	switch h.cfg.Webauthn.CloneDetection.Mode {
+	case config.CloneDetectionModeReject:
+		auditLogType = models.AuditLogWebAuthnCloneRejected
+	case config.CloneDetectionModeRejectAndDisable:
+		now := time.Now().UTC()
+		credential.DisabledAt = &now
+		auditLogType = models.AuditLogWebAuthnCloneCredentialDisabled
+	default:
+		// the credential is updated together with the last used date
+		err := h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnCloneDetected, user, cloneError)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil, nil
+	}
+
+	err := h.persister.GetWebauthnCredentialPersister().Update(*credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+
+	err = h.auditLogger.Create(c, auditLogType, user, cloneError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return echo.NewHTTPError(http.StatusUnauthorized, "failed to validate assertion").SetInternal(cloneError), nil
+}
+
+// checkAuthenticatorStatus rejects authenticators which are marked as compromised by the FIDO metadata service.
+func (h *WebauthnHandler) checkAuthenticatorStatus(aaguid uuid.UUID) error {
+	if h.metadataService == nil {
+		return nil
+	}
+	entry := h.metadataService.Get(aaguid)
+	if entry != nil && entry.IsCompromised() {
+		return fmt.Errorf("%w: authenticator %s is marked as compromised", attestation.ErrNotAllowed, aaguid)
+	}
+	return nil
+}
+
+func (h *WebauthnHandler) getAuthenticatorMetadata(aaguid uuid.UUID) *dto.AuthenticatorMetadata {
+	if h.metadataService == nil {
+		return nil
+	}
+	entry := h.metadataService.Get(aaguid)
+	if entry == nil {
+		return nil
+	}
+	return &dto.AuthenticatorMetadata{
+		Description:         entry.Description,
+		Icon:                entry.Icon,
+		CertificationStatus: entry.CertificationStatus,
+	}
+}
+
 func (h *WebauthnHandler) ListCredentials(c echo.Context) error {
 	sessionToken, ok := c.Get("session").(jwt.Token)
 	if !ok {
@@ -437,6 +854,7 @@ func (h *WebauthnHandler) ListCredentials(c echo.Context) error {
 
 	for i := range credentials {
 		response[i] = dto.FromWebauthnCredentialModel(&credentials[i])
+		response[i].Authenticator = h.getAuthenticatorMetadata(credentials[i].AAGUID)
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -550,9 +968,5 @@ func (h WebauthnHandler) getWebauthnUser(connection *pop.Connection, userId uuid
 		return nil, nil, fmt.Errorf("failed to get webauthn credentials: %w", err)
 	}
 
-	webauthnUser, err := intern.NewWebauthnUser(*user, credentials)
-	if err != nil {
-		return nil, nil, err
-	}
-	return webauthnUser, user, nil
+	return intern.NewWebauthnUser(*user, credentials), user, nil
 }
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/dto"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"net/http"
+)
+
+type WebauthnCredentialHandlerAdmin struct {
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+}
+
+func NewWebauthnCredentialHandlerAdmin(persister persistence.Persister, auditLogger auditlog.Logger) *WebauthnCredentialHandlerAdmin {
+	return &WebauthnCredentialHandlerAdmin{
+		persister:   persister,
+		auditLogger: auditLogger,
+	}
+}
+
+func (h *WebauthnCredentialHandlerAdmin) List(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	credentials, err := h.persister.GetWebauthnCredentialPersister().GetFromUser(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get webauthn credentials: %w", err)
+	}
+
+	response := make([]*admin.WebauthnCredential, len(credentials))
+	for i := range credentials {
+		response[i] = admin.FromWebauthnCredentialModel(&credentials[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WebauthnCredentialHandlerAdmin) Update(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var body dto.WebauthnCredentialUpdateRequest
+	err = (&echo.DefaultBinder{}).BindBody(c, &body)
+	if err != nil {
+		return dto.ToHttpError(err)
+	}
+
+	credential, err := h.getCredential(c, user)
+	if err != nil {
+		return err
+	}
+
+	if body.Name != nil {
+		credential.Name = body.Name
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetWebauthnCredentialPersisterWithConnection(tx).Update(*credential)
+		if err != nil {
+			return fmt.Errorf("failed to update webauthn credential: %w", err)
+		}
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnCredentialUpdated, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, admin.FromWebauthnCredentialModel(credential))
+}
+
+func (h *WebauthnCredentialHandlerAdmin) Delete(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	credential, err := h.getCredential(c, user)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		err = h.persister.GetWebauthnCredentialPersisterWithConnection(tx).Delete(*credential)
+		if err != nil {
+			return fmt.Errorf("failed to delete webauthn credential: %w", err)
+		}
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnCredentialDeleted, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteAll revokes all webauthn credentials of the user, e.g. after the user reported a lost device.
+func (h *WebauthnCredentialHandlerAdmin) DeleteAll(c echo.Context) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.persister.Transaction(func(tx *pop.Connection) error {
+		credentialPersister := h.persister.GetWebauthnCredentialPersisterWithConnection(tx)
+		credentials, err := credentialPersister.GetFromUser(user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get webauthn credentials: %w", err)
+		}
+
+		for _, credential := range credentials {
+			err = credentialPersister.Delete(credential)
+			if err != nil {
+				return fmt.Errorf("failed to delete webauthn credential: %w", err)
+			}
+		}
+
+		err = h.auditLogger.CreateWithConnection(tx, c, models.AuditLogWebAuthnCredentialsRevoked, user, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *WebauthnCredentialHandlerAdmin) getUser(c echo.Context) (*models.User, error) {
+	userId, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "failed to parse userId as uuid").SetInternal(err)
+	}
+
+	user, err := h.persister.GetUserPersister().Get(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	return user, nil
+}
+
+func (h *WebauthnCredentialHandlerAdmin) getCredential(c echo.Context, user *models.User) (*models.WebauthnCredential, error) {
+	credential, err := h.persister.GetWebauthnCredentialPersister().Get(c.Param("credential_id"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+
+	if credential == nil || credential.UserId != user.ID {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "webauthn credential not found").SetInternal(errors.New("the user does not have a webauthn credential with the specified credentialId"))
+	}
+
+	return credential, nil
+}
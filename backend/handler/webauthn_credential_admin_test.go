@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/dto/admin"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebauthnCredentialHandlerAdminSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(webauthnCredentialAdminSuite))
+}
+
+type webauthnCredentialAdminSuite struct {
+	test.Suite
+}
+
+const webauthnCredentialAdminCredentialId = "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH"
+
+func (s *webauthnCredentialAdminSuite) TestWebauthnCredentialHandlerAdmin_List() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/webauthn_credentials", userId), nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		var credentials []admin.WebauthnCredential
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &credentials))
+		s.Len(credentials, 2)
+		s.False(credentials[0].SuspectedClone)
+	}
+}
+
+func (s *webauthnCredentialAdminSuite) TestWebauthnCredentialHandlerAdmin_List_UnknownUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/webauthn_credentials", "1e5dcc5c-8570-43cb-ba8b-caa88bbfc7ac"), nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *webauthnCredentialAdminSuite) TestWebauthnCredentialHandlerAdmin_Update() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	cfg.AuditLog.Storage.Enabled = true
+	e := NewAdminRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/users/%s/webauthn_credentials/%s", userId, webauthnCredentialAdminCredentialId), strings.NewReader(`{"name": "Lost phone"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		credential, err := s.Storage.GetWebauthnCredentialPersister().Get(webauthnCredentialAdminCredentialId)
+		s.Require().NoError(err)
+		s.Equal("Lost phone", *credential.Name)
+
+		logs, err := s.Storage.GetAuditLogPersister().List(0, 0, nil, nil, []string{string(models.AuditLogWebAuthnCredentialUpdated)}, userId, "", "", "")
+		s.Require().NoError(err)
+		if s.Len(logs, 1) {
+			s.Equal(models.AuditLogActorTypeAdmin, logs[0].ActorType)
+		}
+	}
+}
+
+func (s *webauthnCredentialAdminSuite) TestWebauthnCredentialHandlerAdmin_Delete() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%s/webauthn_credentials/%s", userId, webauthnCredentialAdminCredentialId), nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusNoContent, rec.Code) {
+		credentials, err := s.Storage.GetWebauthnCredentialPersister().GetFromUser(uuid.FromStringOrNil(userId))
+		s.Require().NoError(err)
+		s.Len(credentials, 1)
+	}
+}
+
+func (s *webauthnCredentialAdminSuite) TestWebauthnCredentialHandlerAdmin_Delete_CredentialOfOtherUser() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	e := NewAdminRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%s/webauthn_credentials/%s", userId, "4iVZGFN_jktXJmwmBmaSq0Qr4T62T0jX7PS7XcgAWlM"), nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *webauthnCredentialAdminSuite) TestWebauthnCredentialHandlerAdmin_DeleteAll() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode.")
+	}
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	cfg.AuditLog.Storage.Enabled = true
+	e := NewAdminRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%s/webauthn_credentials", userId), nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusNoContent, rec.Code) {
+		credentials, err := s.Storage.GetWebauthnCredentialPersister().GetFromUser(uuid.FromStringOrNil(userId))
+		s.Require().NoError(err)
+		s.Len(credentials, 0)
+
+		logs, err := s.Storage.GetAuditLogPersister().List(0, 0, nil, nil, []string{string(models.AuditLogWebAuthnCredentialsRevoked)}, userId, "", "", "")
+		s.Require().NoError(err)
+		if s.Len(logs, 1) {
+			s.Equal(models.AuditLogActorTypeAdmin, logs[0].ActorType)
+		}
+	}
+}
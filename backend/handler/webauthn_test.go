@@ -4,12 +4,15 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/suite"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/crypto/jwk"
+	"github.com/teamhanko/hanko/backend/lockout"
+	"github.com/teamhanko/hanko/backend/mail"
 	"github.com/teamhanko/hanko/backend/persistence/models"
 	"github.com/teamhanko/hanko/backend/session"
 	"github.com/teamhanko/hanko/backend/test"
@@ -33,11 +36,40 @@ func (s *webauthnSuite) TestWebauthnHandler_NewHandler() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode")
 	}
-	handler, err := NewWebauthnHandler(&test.DefaultConfig, s.Storage, s.GetDefaultSessionManager(), test.NewAuditLogger())
+	mailer, err := mail.NewMailer(test.DefaultConfig.Passcode.Smtp)
+	s.Require().NoError(err)
+	lockoutManager, err := lockout.NewManager(&test.DefaultConfig, s.Storage, mailer, test.NewAuditLogger())
+	s.Require().NoError(err)
+	handler, err := NewWebauthnHandler(&test.DefaultConfig, s.Storage, s.GetDefaultSessionManager(), test.NewAuditLogger(), lockoutManager, nil)
 	s.NoError(err)
 	s.NotEmpty(handler)
 }
 
+func (s *webauthnSuite) TestWebauthnHandler_GetWebauthn() {
+	cfg := test.DefaultConfig
+	cfg.Webauthn.AdditionalRelyingParties = []config.RelyingParty{
+		{
+			Id:             "example.com",
+			Origins:        []string{"https://example.com"},
+			RelatedOrigins: []string{"https://example.de"},
+		},
+		{
+			Id:      "example.fr",
+			Origins: []string{"https://example.fr", "https://example.de"},
+		},
+	}
+
+	handler, err := NewWebauthnHandler(&cfg, nil, nil, nil, nil, nil)
+	s.Require().NoError(err)
+
+	s.Equal("localhost", handler.getWebauthn("http://localhost:8080").Config.RPID)
+	s.Equal("example.com", handler.getWebauthn("https://example.com").Config.RPID)
+	s.Equal("example.com", handler.getWebauthn("https://example.de").Config.RPID)
+	s.Equal("example.fr", handler.getWebauthn("https://example.fr").Config.RPID)
+	s.Equal("localhost", handler.getWebauthn("https://unknown.example").Config.RPID)
+	s.Equal("localhost", handler.getWebauthn("").Config.RPID)
+}
+
 func (s *webauthnSuite) TestWebauthnHandler_BeginRegistration() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode")
@@ -79,6 +111,63 @@ func (s *webauthnSuite) TestWebauthnHandler_BeginRegistration() {
 	}
 }
 
+func (s *webauthnSuite) TestWebauthnHandler_BeginRegistration_Prf() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/webauthn")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	cfg.Webauthn.Prf.Enabled = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	sessionManager := s.GetDefaultSessionManager()
+	token, err := sessionManager.GenerateJWT(uuid.FromStringOrNil(userId))
+	s.Require().NoError(err)
+	cookie, err := sessionManager.GenerateCookie(token)
+	s.Require().NoError(err)
+
+	var firstSalt string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/webauthn/registration/initialize", nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+		s.Require().Equal(http.StatusOK, rec.Code)
+
+		var options struct {
+			PublicKey struct {
+				Extensions struct {
+					Prf struct {
+						Eval struct {
+							First string `json:"first"`
+						} `json:"eval"`
+					} `json:"prf"`
+				} `json:"extensions"`
+			} `json:"publicKey"`
+		}
+		err = json.Unmarshal(rec.Body.Bytes(), &options)
+		s.Require().NoError(err)
+
+		salt := options.PublicKey.Extensions.Prf.Eval.First
+		s.NotEmpty(salt)
+		if i == 0 {
+			firstSalt = salt
+		} else {
+			// the salt of a user must not change, otherwise derived keys would change too
+			s.Equal(firstSalt, salt)
+		}
+	}
+
+	salt, err := s.Storage.GetWebauthnPrfSaltPersister().GetByUserId(uuid.FromStringOrNil(userId))
+	s.Require().NoError(err)
+	s.Require().NotNil(salt)
+	s.Equal(firstSalt, salt.Salt)
+}
+
 func (s *webauthnSuite) TestWebauthnHandler_FinalizeRegistration() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode")
@@ -162,6 +251,107 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeRegistration_SessionDataExpi
 	s.Equal(http.StatusBadRequest, rec.Code)
 }
 
+func (s *webauthnSuite) TestWebauthnHandler_BeginSignup() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+
+	cfg := test.DefaultConfig
+	cfg.Account.AllowPasskeySignup = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/webauthn/signup/initialize", strings.NewReader(`{"display_name": "Jane Doe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		creationOptions := protocol.CredentialCreation{}
+		err := json.Unmarshal(rec.Body.Bytes(), &creationOptions)
+		s.Require().NoError(err)
+
+		uId, err := base64.RawURLEncoding.DecodeString(creationOptions.Response.User.ID.(string))
+		s.Require().NoError(err)
+
+		s.NotEmpty(creationOptions.Response.Challenge)
+		s.Equal("Jane Doe", creationOptions.Response.User.DisplayName)
+		s.Equal(protocol.ResidentKeyRequirementRequired, creationOptions.Response.AuthenticatorSelection.ResidentKey)
+
+		sessionData, err := s.Storage.GetWebauthnSessionDataPersister().GetByChallenge(creationOptions.Response.Challenge.String())
+		s.Require().NoError(err)
+		s.Require().NotNil(sessionData)
+		s.Equal(models.WebauthnOperationSignup, sessionData.Operation)
+		s.Equal(uuid.FromBytesOrNil(uId), sessionData.UserId)
+		s.Equal("Jane Doe", sessionData.UserDisplayName.String)
+
+		user, err := s.Storage.GetUserPersister().Get(sessionData.UserId)
+		s.NoError(err)
+		s.Nil(user)
+	}
+}
+
+func (s *webauthnSuite) TestWebauthnHandler_BeginSignup_Disabled() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+
+	e := NewPublicRouter(&test.DefaultConfig, s.Storage, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/webauthn/signup/initialize", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	s.Equal(http.StatusForbidden, rec.Code)
+}
+
+func (s *webauthnSuite) TestWebauthnHandler_FinalizeSignup() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+
+	err := s.LoadFixtures("../test/fixtures/webauthn_signup")
+	s.Require().NoError(err)
+
+	cfg := test.DefaultConfig
+	cfg.Account.AllowPasskeySignup = true
+	e := NewPublicRouter(&cfg, s.Storage, nil)
+
+	body := `{
+"id": "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH",
+"rawId": "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH",
+"type": "public-key",
+"response": {
+"attestationObject": "o2NmbXRkbm9uZWdhdHRTdG10oGhhdXRoRGF0YVjeSZYN5YgOjGh0NBcPZHZgW4_krrmihjLHmVzzuoMdl2NFYmehnq3OAAI1vMYKZIsLJfHwVQMAWgGhXZHA-Erj4xfo8FKEcB_PmR7mOUVuOn7GZhLwV-kTSh2hrVc6QE7NOikFYXiDo2M_mJ3huHJkDnnc5dHtIxfedbpMdex5fY3hoFs-fwymQjtdqdvti5c4x6UBAgMmIAEhWCDxvVrRgK4vpnr6JxTx-KfpSNyQUtvc47ryryZmj-P5kSJYIDox8N9bHQBrxN-b5kXqfmj3GwAJW7nNCh8UPbus3B6I",
+"clientDataJSON": "eyJ0eXBlIjoid2ViYXV0aG4uY3JlYXRlIiwiY2hhbGxlbmdlIjoidE9yTkRDRDJ4UWY0ekZqRWp3eGFQOGZPRXJQM3p6MDhyTW9UbEpHdG5LVSIsIm9yaWdpbiI6Imh0dHA6Ly9sb2NhbGhvc3Q6ODA4MCIsImNyb3NzT3JpZ2luIjpmYWxzZX0"
+}
+}`
+
+	req := httptest.NewRequest(http.MethodPost, "/webauthn/signup/finalize", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	if s.Equal(http.StatusOK, rec.Code) {
+		s.Equal(`{"credential_id":"AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH","user_id":"d4c1b7a5-1e0a-4c5f-9d9e-5c3f1b2a7e61"}`, strings.TrimSpace(rec.Body.String()))
+		s.NotEmpty(rec.Result().Cookies())
+
+		user, err := s.Storage.GetUserPersister().Get(uuid.FromStringOrNil("d4c1b7a5-1e0a-4c5f-9d9e-5c3f1b2a7e61"))
+		s.Require().NoError(err)
+		s.Require().NotNil(user)
+		s.Equal("Jane Doe", *user.DisplayName)
+		s.Empty(user.Emails)
+		s.Len(user.WebauthnCredentials, 1)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/webauthn/signup/finalize", strings.NewReader(body))
+	rec2 := httptest.NewRecorder()
+
+	e.ServeHTTP(rec2, req2)
+	s.Equal(http.StatusBadRequest, rec2.Code)
+}
+
 func (s *webauthnSuite) TestWebauthnHandler_BeginAuthentication() {
 	if testing.Short() {
 		s.T().Skip("skipping test in short mode")
@@ -319,6 +509,79 @@ func (s *webauthnSuite) TestWebauthnHandler_FinalizeAuthentication_TokenInHeader
 	}
 }
 
+func (s *webauthnSuite) TestWebauthnHandler_FinalizeAuthentication_CloneDetection() {
+	if testing.Short() {
+		s.T().Skip("skipping test in short mode")
+	}
+
+	credentialId := "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH"
+	body := `{
+"id": "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH",
+"rawId": "AaFdkcD4SuPjF-jwUoRwH8-ZHuY5RW46fsZmEvBX6RNKHaGtVzpATs06KQVheIOjYz-YneG4cmQOedzl0e0jF951ukx17Hl9jeGgWz5_DKZCO12p2-2LlzjH",
+"type": "public-key",
+"response": {
+"authenticatorData": "SZYN5YgOjGh0NBcPZHZgW4_krrmihjLHmVzzuoMdl2MFYmezOw",
+"clientDataJSON": "eyJ0eXBlIjoid2ViYXV0aG4uZ2V0IiwiY2hhbGxlbmdlIjoiZ0tKS21oOTB2T3BZTzU1b0hwcWFIWF9vTUNxNG9UWnQtRDBiNnRlSXpyRSIsIm9yaWdpbiI6Imh0dHA6Ly9sb2NhbGhvc3Q6ODA4MCIsImNyb3NzT3JpZ2luIjpmYWxzZX0",
+"signature": "MEYCIQDi2vYVspG6pf38I4GyQCPOojGbvX4nwSPXCi0hm80twAIhAO3EWjhAnj0UpjU_l0AH5sEh3zq4LDvkvo3AUqaqfGYD",
+"userHandle": "7E7wSVuIQyGhcyGw7_BqBA"
+}
+}`
+
+	tests := []struct {
+		name           string
+		mode           config.CloneDetectionMode
+		expectedStatus int
+		expectDisabled bool
+	}{
+		{
+			name:           "log",
+			mode:           config.CloneDetectionModeLog,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "reject",
+			mode:           config.CloneDetectionModeReject,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "reject and disable",
+			mode:           config.CloneDetectionModeRejectAndDisable,
+			expectedStatus: http.StatusUnauthorized,
+			expectDisabled: true,
+		},
+	}
+
+	for _, currentTest := range tests {
+		s.Run(currentTest.name, func() {
+			err := s.LoadFixtures("../test/fixtures/webauthn")
+			s.Require().NoError(err)
+
+			// the signature counter of the assertion is 1650963259, so it does not increase
+			credentialPersister := s.Storage.GetWebauthnCredentialPersister()
+			credential, err := credentialPersister.Get(credentialId)
+			s.Require().NoError(err)
+			credential.SignCount = 1650963259
+			s.Require().NoError(credentialPersister.Update(*credential))
+
+			cfg := test.DefaultConfig
+			cfg.Webauthn.CloneDetection.Mode = currentTest.mode
+			e := NewPublicRouter(&cfg, s.Storage, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/webauthn/login/finalize", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			s.Equal(currentTest.expectedStatus, rec.Code)
+
+			credential, err = credentialPersister.Get(credentialId)
+			s.Require().NoError(err)
+			s.True(credential.SuspectedClone)
+			s.Equal(currentTest.expectDisabled, credential.IsDisabled())
+		})
+	}
+}
+
 func (s *webauthnSuite) GetDefaultSessionManager() session.Manager {
 	jwkManager, err := jwk.NewDefaultManager(test.DefaultConfig.Secrets.Keys, s.Storage.GetJwkPersister())
 	s.Require().NoError(err)
@@ -366,7 +629,7 @@ func (s sessionManager) GenerateCookie(token string) (*http.Cookie, error) {
 	}, nil
 }
 
-func (s sessionManager) GenerateCookieOrHeader(userId uuid.UUID, c echo.Context) error {
+func (s sessionManager) GenerateCookieOrHeader(tx *pop.Connection, userId uuid.UUID, c echo.Context) error {
 	token, err := s.GenerateJWT(userId)
 	if err != nil {
 		return err
@@ -381,6 +644,19 @@ func (s sessionManager) GenerateCookieOrHeader(userId uuid.UUID, c echo.Context)
 	return nil
 }
 
+func (s sessionManager) GenerateCookieOrHeaderWithOrganization(tx *pop.Connection, userId uuid.UUID, organizationId *uuid.UUID, c echo.Context) error {
+	return s.GenerateCookieOrHeader(tx, userId, c)
+}
+
+func (s sessionManager) SwitchOrganization(token jwt.Token, organizationId *uuid.UUID, c echo.Context) error {
+	userId, err := uuid.FromString(token.Subject())
+	if err != nil {
+		return err
+	}
+
+	return s.GenerateCookieOrHeader(nil, userId, c)
+}
+
 func (s sessionManager) ExchangeRefreshToken(id string, c echo.Context) error {
 	//TODO implement me
 	panic("implement me")
@@ -5,18 +5,21 @@ import (
 	"github.com/teamhanko/hanko/backend/config"
 	hankoJwk "github.com/teamhanko/hanko/backend/crypto/jwk"
 	dto "github.com/teamhanko/hanko/backend/dto"
+	"net"
 	"net/http"
 )
 
 type WellKnownHandler struct {
-	jwkManager hankoJwk.Manager
-	config     dto.PublicConfig
+	jwkManager     hankoJwk.Manager
+	config         dto.PublicConfig
+	relyingParties []config.RelyingParty
 }
 
 func NewWellKnownHandler(config config.Config, jwkManager hankoJwk.Manager) (*WellKnownHandler, error) {
 	return &WellKnownHandler{
-		config:     dto.FromConfig(config),
-		jwkManager: jwkManager,
+		config:         dto.FromConfig(config),
+		jwkManager:     jwkManager,
+		relyingParties: config.Webauthn.RelyingParties(),
 	}, nil
 }
 
@@ -33,3 +36,26 @@ func (h *WellKnownHandler) GetPublicKeys(c echo.Context) error {
 func (h *WellKnownHandler) GetConfig(c echo.Context) error {
 	return c.JSON(http.StatusOK, h.config)
 }
+
+// GetWebauthnRelatedOrigins returns the related origins of the relying party whose RP ID matches the requested host.
+func (h *WellKnownHandler) GetWebauthnRelatedOrigins(c echo.Context) error {
+	host := c.Request().Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	relyingParty := h.relyingParties[0]
+	for _, rp := range h.relyingParties {
+		if rp.Id == host {
+			relyingParty = rp
+			break
+		}
+	}
+
+	if len(relyingParty.RelatedOrigins) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+
+	c.Response().Header().Add("Cache-Control", "max-age=600")
+	return c.JSON(http.StatusOK, dto.WebauthnRelatedOrigins{Origins: relyingParty.AllOrigins()})
+}
@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/suite"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/dto"
 	"github.com/teamhanko/hanko/backend/test"
 	"net/http"
 	"net/http/httptest"
@@ -32,3 +36,41 @@ func (s *wellKnownSuite) TestWellKnownHandler_GetPublicKeys() {
 	s.Equal(http.StatusOK, rec.Code)
 	s.Equal("max-age=600", rec.Header().Get("Cache-Control"))
 }
+
+func (s *wellKnownSuite) TestWellKnownHandler_GetWebauthnRelatedOrigins() {
+	cfg := test.DefaultConfig
+	cfg.Webauthn.AdditionalRelyingParties = []config.RelyingParty{
+		{
+			Id:             "example.com",
+			Origins:        []string{"https://example.com"},
+			RelatedOrigins: []string{"https://example.de", "https://example.fr"},
+		},
+	}
+
+	handler, err := NewWellKnownHandler(cfg, nil)
+	s.Require().NoError(err)
+
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/webauthn", nil)
+	req.Host = "example.com"
+	rec := httptest.NewRecorder()
+	err = handler.GetWebauthnRelatedOrigins(e.NewContext(req, rec))
+
+	if s.NoError(err) && s.Equal(http.StatusOK, rec.Code) {
+		var relatedOrigins dto.WebauthnRelatedOrigins
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &relatedOrigins))
+		s.Equal([]string{"https://example.com", "https://example.de", "https://example.fr"}, relatedOrigins.Origins)
+	}
+
+	// the default relying party has no related origins
+	req = httptest.NewRequest(http.MethodGet, "/.well-known/webauthn", nil)
+	req.Host = "localhost:8000"
+	rec = httptest.NewRecorder()
+	err = handler.GetWebauthnRelatedOrigins(e.NewContext(req, rec))
+
+	httpError := &echo.HTTPError{}
+	if s.ErrorAs(err, &httpError) {
+		s.Equal(http.StatusNotFound, httpError.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package invitation
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
+	"github.com/teamhanko/hanko/backend/mail"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+// Manager issues invitation tokens and sends them as link to the invited email address.
+type Manager struct {
+	cfg         config.AccountInvitations
+	persister   persistence.Persister
+	sender      *mail.Sender
+	serviceName string
+}
+
+func NewManager(cfg *config.Config, persister persistence.Persister, mailer mail.Mailer) (*Manager, error) {
+	sender, err := mail.NewSender(mailer, cfg.Passcode.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		cfg:         cfg.Account.Invitations,
+		persister:   persister,
+		sender:      sender,
+		serviceName: cfg.Service.Name,
+	}, nil
+}
+
+// Create stores the invitation with a new token and sends the invitation email.
+func (m *Manager) Create(tx *pop.Connection, invitation *models.Invitation) error {
+	token, err := m.issueToken(invitation)
+	if err != nil {
+		return err
+	}
+
+	err = m.persister.GetInvitationPersisterWithConnection(tx).Create(*invitation)
+	if err != nil {
+		return err
+	}
+
+	return m.send(tx, invitation, token)
+}
+
+// Resend replaces the token of the invitation, which invalidates previously sent links, extends the expiry and sends
+// the invitation email again.
+func (m *Manager) Resend(tx *pop.Connection, invitation *models.Invitation) error {
+	token, err := m.issueToken(invitation)
+	if err != nil {
+		return err
+	}
+
+	err = m.persister.GetInvitationPersisterWithConnection(tx).Update(*invitation)
+	if err != nil {
+		return err
+	}
+
+	return m.send(tx, invitation, token)
+}
+
+// GetByToken returns the invitation the token from the email link was issued for or nil if there is none.
+func (m *Manager) GetByToken(tx *pop.Connection, token string) (*models.Invitation, error) {
+	return m.persister.GetInvitationPersisterWithConnection(tx).GetByToken(crypto.HashToken(token))
+}
+
+func (m *Manager) issueToken(invitation *models.Invitation) (string, error) {
+	token, err := crypto.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	invitation.Token = crypto.HashToken(token)
+	invitation.ExpiresAt = now.Add(m.cfg.Lifespan)
+	invitation.UpdatedAt = now
+
+	return token, nil
+}
+
+func (m *Manager) send(tx *pop.Connection, invitation *models.Invitation, token string) error {
+	link, err := mail.Link(m.cfg.AcceptUrl, "invitation_token", token)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation link: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"Link":        link,
+		"ServiceName": m.serviceName,
+		"ExpiresAt":   invitation.ExpiresAt.Format(time.RFC1123),
+	}
+
+	if invitation.OrganizationID != nil {
+		organization, err := m.persister.GetOrganizationPersisterWithConnection(tx).Get(*invitation.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("failed to get organization: %w", err)
+		}
+		if organization != nil {
+			data["OrganizationName"] = organization.Name
+		}
+	}
+
+	// the invitation is created by an admin, hence the language of the admin request is not the one of the invitee
+	lang := ""
+	if invitation.Language != nil {
+		lang = *invitation.Language
+	}
+
+	err = m.sender.Send(lang, invitation.Email, "invitationTextMail", "email_subject_invitation", data)
+	if err != nil {
+		return fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	return nil
+}
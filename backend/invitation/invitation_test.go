@@ -0,0 +1,112 @@
+package invitation
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"gopkg.in/gomail.v2"
+	"io"
+	"mime/quotedprintable"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type testMailer struct {
+	messages []*gomail.Message
+}
+
+func (m *testMailer) Send(message *gomail.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func newTestManager(t *testing.T, organizations []models.Organization) (*Manager, *testMailer) {
+	cfg := config.DefaultConfig()
+	cfg.Service.Name = "Test"
+	cfg.Account.Invitations = config.AccountInvitations{
+		Enabled:   true,
+		AcceptUrl: "https://app.example.com/invitation",
+		Lifespan:  time.Hour,
+	}
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, organizations, nil, nil, nil, nil)
+	mailer := &testMailer{}
+
+	manager, err := NewManager(cfg, persister, mailer)
+	require.NoError(t, err)
+
+	return manager, mailer
+}
+
+// messageBody returns the decoded message, as the body is quoted-printable encoded.
+func messageBody(t *testing.T, message *gomail.Message) string {
+	raw := bytes.Buffer{}
+	_, err := message.WriteTo(&raw)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(&raw))
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+var linkPattern = regexp.MustCompile(`https://\S+`)
+
+func TestManager_Create(t *testing.T) {
+	manager, mailer := newTestManager(t, nil)
+
+	invitation := models.NewInvitation("invited@example.com", "", time.Time{})
+	err := manager.Create(nil, &invitation)
+	require.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now().UTC().Add(time.Hour), invitation.ExpiresAt, time.Minute)
+	require.Len(t, mailer.messages, 1)
+	assert.Equal(t, []string{"invited@example.com"}, mailer.messages[0].GetHeader("To"))
+	assert.Equal(t, []string{"You have been invited to Test"}, mailer.messages[0].GetHeader("Subject"))
+
+	link, err := url.Parse(linkPattern.FindString(messageBody(t, mailer.messages[0])))
+	require.NoError(t, err)
+	token := link.Query().Get("invitation_token")
+	require.NotEmpty(t, token)
+	assert.NotEqual(t, token, invitation.Token)
+
+	stored, err := manager.GetByToken(nil, token)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, invitation.ID, stored.ID)
+}
+
+func TestManager_Create_WithOrganization(t *testing.T) {
+	organization := models.NewOrganization("Acme", "acme")
+	manager, mailer := newTestManager(t, []models.Organization{organization})
+
+	invitation := models.NewInvitation("invited@example.com", "", time.Time{})
+	invitation.OrganizationID = &organization.ID
+	err := manager.Create(nil, &invitation)
+	require.NoError(t, err)
+
+	assert.Contains(t, messageBody(t, mailer.messages[0]), "join Acme on Test")
+}
+
+func TestManager_Resend(t *testing.T) {
+	manager, mailer := newTestManager(t, nil)
+
+	invitation := models.NewInvitation("invited@example.com", "", time.Time{})
+	err := manager.Create(nil, &invitation)
+	require.NoError(t, err)
+	previousToken := invitation.Token
+
+	err = manager.Resend(nil, &invitation)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, previousToken, invitation.Token)
+	assert.Len(t, mailer.messages, 2)
+
+	stored, err := manager.persister.GetInvitationPersister().GetByToken(previousToken)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
@@ -0,0 +1,278 @@
+package lockout
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sethvargo/go-limiter/httplimit"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
+	"github.com/teamhanko/hanko/backend/mail"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Manager keeps track of failed password, passcode and WebAuthn login attempts and temporarily locks accounts when
+// too many of them happen within the configured window. All methods are no-ops when the lockout is disabled.
+type Manager struct {
+	cfg         config.AccountLockout
+	persister   persistence.Persister
+	auditLogger auditlog.Logger
+	sender      *mail.Sender
+	serviceName string
+}
+
+func NewManager(cfg *config.Config, persister persistence.Persister, mailer mail.Mailer, auditLogger auditlog.Logger) (*Manager, error) {
+	sender, err := mail.NewSender(mailer, cfg.Passcode.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		cfg:         cfg.Account.Lockout,
+		persister:   persister,
+		auditLogger: auditLogger,
+		sender:      sender,
+		serviceName: cfg.Service.Name,
+	}, nil
+}
+
+// Check rejects the login attempt if the account of the user is locked. The rejection is audited with failureLogType
+// and returned separately from internal errors, so that the caller can commit its transaction anyway.
+func (m *Manager) Check(tx *pop.Connection, c echo.Context, user *models.User, failureLogType models.AuditLogType) (*echo.HTTPError, error) {
+	if !m.cfg.Enabled {
+		return nil, nil
+	}
+
+	lockout, err := m.persister.GetUserLockoutPersisterWithConnection(tx).GetByUserId(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if lockout == nil || !lockout.IsLocked(now) {
+		return nil, nil
+	}
+
+	err = m.auditLogger.CreateWithConnection(tx, c, failureLogType, user, fmt.Errorf("account locked"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	retryAfter := int(math.Ceil(lockout.LockedUntil.Sub(now).Seconds()))
+	c.Response().Header().Set(httplimit.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return echo.NewHTTPError(http.StatusLocked, "account is temporarily locked"), nil
+}
+
+// RegisterFailure counts a failed login attempt of the user and locks the account when the attempt exhausts the
+// allowed number of failed attempts.
+func (m *Manager) RegisterFailure(tx *pop.Connection, c echo.Context, user *models.User) error {
+	if !m.cfg.Enabled {
+		return nil
+	}
+
+	lockoutPersister := m.persister.GetUserLockoutPersisterWithConnection(tx)
+	lockout, err := lockoutPersister.GetByUserId(user.ID)
+	if err != nil {
+		return err
+	}
+
+	create := lockout == nil
+	if create {
+		lockout = models.NewUserLockout(user.ID)
+	}
+
+	now := time.Now().UTC()
+	locked := registerFailure(m.cfg, lockout, now)
+
+	var unlockToken string
+	if locked && m.cfg.UnlockLinkUrl != "" {
+		unlockToken, err = crypto.GenerateRandomStringURLSafe(32)
+		if err != nil {
+			return fmt.Errorf("failed to generate unlock token: %w", err)
+		}
+		hashedToken := crypto.HashToken(unlockToken)
+		expiresAt := now.Add(m.cfg.UnlockLinkTTL)
+		lockout.UnlockToken = &hashedToken
+		lockout.UnlockTokenExpiresAt = &expiresAt
+	}
+
+	lockout.UpdatedAt = now
+	if create {
+		err = lockoutPersister.Create(*lockout)
+	} else {
+		err = lockoutPersister.Update(*lockout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !locked {
+		return nil
+	}
+
+	err = m.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserLocked, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	if unlockToken != "" {
+		return m.sendUnlockLink(c, user, unlockToken, *lockout.LockedUntil)
+	}
+
+	return nil
+}
+
+// RegisterSuccess resets the failed attempts of the user after a successful login.
+func (m *Manager) RegisterSuccess(tx *pop.Connection, userId uuid.UUID) error {
+	if !m.cfg.Enabled {
+		return nil
+	}
+
+	lockoutPersister := m.persister.GetUserLockoutPersisterWithConnection(tx)
+	lockout, err := lockoutPersister.GetByUserId(userId)
+	if err != nil {
+		return err
+	}
+	if lockout == nil || (lockout.FailedAttempts == 0 && lockout.LockoutCount == 0) {
+		return nil
+	}
+
+	reset(lockout)
+	return lockoutPersister.Update(*lockout)
+}
+
+// Unlock lifts the lockout of the user and resets the failed attempts.
+func (m *Manager) Unlock(tx *pop.Connection, c echo.Context, user *models.User) error {
+	lockoutPersister := m.persister.GetUserLockoutPersisterWithConnection(tx)
+	lockout, err := lockoutPersister.GetByUserId(user.ID)
+	if err != nil {
+		return err
+	}
+	if lockout == nil {
+		return nil
+	}
+
+	reset(lockout)
+	err = lockoutPersister.Update(*lockout)
+	if err != nil {
+		return err
+	}
+
+	err = m.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserUnlocked, user, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// UnlockWithToken lifts the lockout the unlock token from the email link was issued for. Invalid and expired tokens
+// are audited and returned as business error.
+func (m *Manager) UnlockWithToken(tx *pop.Connection, c echo.Context, token string) (*echo.HTTPError, error) {
+	lockout, err := m.persister.GetUserLockoutPersisterWithConnection(tx).GetByUnlockToken(crypto.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	if lockout != nil {
+		user, err = m.persister.GetUserPersisterWithConnection(tx).Get(lockout.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+
+	if lockout == nil || user == nil || lockout.UnlockTokenExpiresAt == nil || lockout.UnlockTokenExpiresAt.Before(time.Now().UTC()) {
+		err = m.auditLogger.CreateWithConnection(tx, c, models.AuditLogUserUnlockFailed, user, fmt.Errorf("invalid or expired unlock token"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired unlock token"), nil
+	}
+
+	return nil, m.Unlock(tx, c, user)
+}
+
+func (m *Manager) sendUnlockLink(c echo.Context, user *models.User, token string, lockedUntil time.Time) error {
+	email := user.Emails.GetPrimary()
+	if email == nil {
+		return nil
+	}
+
+	link, err := mail.Link(m.cfg.UnlockLinkUrl, "unlock_token", token)
+	if err != nil {
+		return fmt.Errorf("failed to create unlock link: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"Link":        link,
+		"ServiceName": m.serviceName,
+		"TTL":         fmt.Sprintf("%.0f", m.cfg.UnlockLinkTTL.Minutes()),
+		"LockedUntil": lockedUntil.Format(time.RFC1123),
+	}
+
+	err = m.sender.Send(c.Request().Header.Get("Accept-Language"), email.Address, "accountUnlockTextMail", "email_subject_account_unlock", data)
+	if err != nil {
+		return fmt.Errorf("failed to send unlock link: %w", err)
+	}
+
+	return nil
+}
+
+// registerFailure applies a failed attempt at the given time to the lockout and reports whether it locked the
+// account. Failed attempts are counted within a fixed window starting with the first one. Every lockout doubles the
+// duration of the previous one, unless the previous lockout ended more than the maximum duration ago.
+func registerFailure(cfg config.AccountLockout, lockout *models.UserLockout, now time.Time) bool {
+	if lockout.LockedUntil != nil && now.Sub(*lockout.LockedUntil) > cfg.MaxDuration {
+		lockout.LockoutCount = 0
+	}
+
+	if lockout.WindowStartedAt == nil || now.Sub(*lockout.WindowStartedAt) > cfg.Window {
+		lockout.FailedAttempts = 0
+		lockout.WindowStartedAt = &now
+	}
+
+	lockout.FailedAttempts++
+	if lockout.FailedAttempts < cfg.MaxFailedAttempts {
+		return false
+	}
+
+	lockout.LockoutCount++
+	lockedUntil := now.Add(duration(cfg, lockout.LockoutCount))
+	lockout.LockedUntil = &lockedUntil
+	lockout.FailedAttempts = 0
+	lockout.WindowStartedAt = nil
+	return true
+}
+
+// duration returns the duration of the nth consecutive lockout.
+func duration(cfg config.AccountLockout, lockoutCount int) time.Duration {
+	d := cfg.Duration
+	for i := 1; i < lockoutCount; i++ {
+		d *= 2
+		if d >= cfg.MaxDuration {
+			return cfg.MaxDuration
+		}
+	}
+	if d > cfg.MaxDuration {
+		return cfg.MaxDuration
+	}
+	return d
+}
+
+func reset(lockout *models.UserLockout) {
+	lockout.FailedAttempts = 0
+	lockout.WindowStartedAt = nil
+	lockout.LockoutCount = 0
+	lockout.LockedUntil = nil
+	lockout.UnlockToken = nil
+	lockout.UnlockTokenExpiresAt = nil
+	lockout.UpdatedAt = time.Now().UTC()
+}
@@ -0,0 +1,95 @@
+package lockout
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"testing"
+	"time"
+)
+
+var testConfig = config.AccountLockout{
+	Enabled:           true,
+	MaxFailedAttempts: 3,
+	Window:            15 * time.Minute,
+	Duration:          5 * time.Minute,
+	MaxDuration:       time.Hour,
+}
+
+func TestRegisterFailure(t *testing.T) {
+	lockout := models.NewUserLockout(uuid.Must(uuid.NewV4()))
+	now := time.Now().UTC()
+
+	assert.False(t, registerFailure(testConfig, lockout, now))
+	assert.False(t, registerFailure(testConfig, lockout, now.Add(time.Minute)))
+	assert.True(t, registerFailure(testConfig, lockout, now.Add(2*time.Minute)))
+
+	assert.True(t, lockout.IsLocked(now.Add(2*time.Minute)))
+	assert.False(t, lockout.IsLocked(now.Add(7*time.Minute)))
+	assert.Equal(t, 1, lockout.LockoutCount)
+	assert.Equal(t, 0, lockout.FailedAttempts)
+}
+
+func TestRegisterFailure_WindowExpired(t *testing.T) {
+	lockout := models.NewUserLockout(uuid.Must(uuid.NewV4()))
+	now := time.Now().UTC()
+
+	assert.False(t, registerFailure(testConfig, lockout, now))
+	assert.False(t, registerFailure(testConfig, lockout, now.Add(time.Minute)))
+	// the window started with the first attempt has expired, so counting starts again
+	assert.False(t, registerFailure(testConfig, lockout, now.Add(20*time.Minute)))
+	assert.Equal(t, 1, lockout.FailedAttempts)
+	assert.False(t, lockout.IsLocked(now.Add(20*time.Minute)))
+}
+
+func TestRegisterFailure_ExponentialBackoff(t *testing.T) {
+	lockout := models.NewUserLockout(uuid.Must(uuid.NewV4()))
+	now := time.Now().UTC()
+
+	expectedDurations := []time.Duration{5 * time.Minute, 10 * time.Minute, 20 * time.Minute, 40 * time.Minute, time.Hour, time.Hour}
+	for _, expected := range expectedDurations {
+		var locked bool
+		for i := 0; i < testConfig.MaxFailedAttempts; i++ {
+			locked = registerFailure(testConfig, lockout, now)
+		}
+		assert.True(t, locked)
+		assert.Equal(t, now.Add(expected), *lockout.LockedUntil)
+		now = lockout.LockedUntil.Add(time.Second)
+	}
+}
+
+func TestRegisterFailure_BackoffDecays(t *testing.T) {
+	lockout := models.NewUserLockout(uuid.Must(uuid.NewV4()))
+	now := time.Now().UTC()
+
+	for i := 0; i < testConfig.MaxFailedAttempts; i++ {
+		registerFailure(testConfig, lockout, now)
+	}
+	assert.Equal(t, 1, lockout.LockoutCount)
+
+	// the previous lockout ended longer than the maximum duration ago
+	now = lockout.LockedUntil.Add(2 * time.Hour)
+	for i := 0; i < testConfig.MaxFailedAttempts; i++ {
+		registerFailure(testConfig, lockout, now)
+	}
+	assert.Equal(t, 1, lockout.LockoutCount)
+	assert.Equal(t, now.Add(5*time.Minute), *lockout.LockedUntil)
+}
+
+func TestReset(t *testing.T) {
+	lockout := models.NewUserLockout(uuid.Must(uuid.NewV4()))
+	now := time.Now().UTC()
+	for i := 0; i < testConfig.MaxFailedAttempts; i++ {
+		registerFailure(testConfig, lockout, now)
+	}
+	token := crypto.HashToken("token")
+	lockout.UnlockToken = &token
+
+	reset(lockout)
+
+	assert.False(t, lockout.IsLocked(now))
+	assert.Equal(t, 0, lockout.LockoutCount)
+	assert.Nil(t, lockout.UnlockToken)
+}
@@ -19,8 +19,11 @@ func TestRenderer_Render(t *testing.T) {
 	assert.NotEmpty(t, renderer)
 
 	templateData := map[string]interface{}{
-		"TTL":  5,
-		"Code": "123456",
+		"TTL":         5,
+		"Code":        "123456",
+		"ServiceName": "Hanko",
+		"Link":        "https://example.com/invitation?invitation_token=abc",
+		"ExpiresAt":   "2024-04-26 10:00 UTC",
 	}
 
 	tests := []struct {
@@ -37,6 +40,41 @@ func TestRenderer_Render(t *testing.T) {
 			Expected: "Enter the following passcode on your login screen:\n\n123456\n\nThe passcode is valid for 5 minutes.",
 			WantErr:  false,
 		},
+		{
+			Name:     "Email verification text template",
+			Template: "emailVerificationTextMail",
+			Lang:     "en",
+			Expected: "Enter the following passcode to verify your new email address:\n\n123456\n\nThe passcode is valid for 5 minutes.\n\nIf you did not add this email address to an account, you can ignore this email.",
+			WantErr:  false,
+		},
+		{
+			Name:     "Account recovery text template",
+			Template: "accountRecoveryTextMail",
+			Lang:     "en",
+			Expected: "Enter the following passcode to recover access to your account:\n\n123456\n\nThe passcode is valid for 5 minutes.\n\nIf you did not request this, you can ignore this email. Your account has not been changed.",
+			WantErr:  false,
+		},
+		{
+			Name:     "Signup text template",
+			Template: "signupTextMail",
+			Lang:     "en",
+			Expected: "Enter the following passcode to complete your registration:\n\n123456\n\nThe passcode is valid for 5 minutes.",
+			WantErr:  false,
+		},
+		{
+			Name:     "Invitation text template",
+			Template: "invitationTextMail",
+			Lang:     "en",
+			Expected: "You have been invited to Hanko. Open the following link to accept the invitation:\n\nhttps://example.com/invitation?invitation_token=abc\n\nThe link is valid until 2024-04-26 10:00 UTC and can only be used once.\n\nIf you do not want to accept the invitation, you can ignore this email.",
+			WantErr:  false,
+		},
+		{
+			Name:     "Email changed text template",
+			Template: "emailChangedTextMail",
+			Lang:     "en",
+			Expected: "The primary email address of your Hanko account has been changed. Notifications are no longer sent to this email address.\n\nIf you did not make this change, open the following link to revert it. All sessions of your account will be signed out:\n\nhttps://example.com/invitation?invitation_token=abc\n\nThe link is valid until 2024-04-26 10:00 UTC and can only be used once.",
+			WantErr:  false,
+		},
 		{
 			Name:     "Not existing template",
 			Template: "NotExistingTemplate",
@@ -97,6 +135,36 @@ func TestRenderer_Translate(t *testing.T) {
 			},
 			Expected: "Use passcode 123456 to sign in to Test Service",
 		},
+		{
+			Name:      "Translate email_subject_email_verification",
+			MessageID: "email_subject_email_verification",
+			Lang:      "en",
+			Data: map[string]interface{}{
+				"ServiceName": "Test Service",
+				"Code":        "123456",
+			},
+			Expected: "Use passcode 123456 to verify your email address for Test Service",
+		},
+		{
+			Name:      "Translate email_subject_account_recovery",
+			MessageID: "email_subject_account_recovery",
+			Lang:      "en",
+			Data: map[string]interface{}{
+				"ServiceName": "Test Service",
+				"Code":        "123456",
+			},
+			Expected: "Use passcode 123456 to recover your Test Service account",
+		},
+		{
+			Name:      "Translate email_subject_signup",
+			MessageID: "email_subject_signup",
+			Lang:      "en",
+			Data: map[string]interface{}{
+				"ServiceName": "Test Service",
+				"Code":        "123456",
+			},
+			Expected: "Use passcode 123456 to sign up for Test Service",
+		},
 	}
 
 	for _, test := range tests {
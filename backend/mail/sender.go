@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"gopkg.in/gomail.v2"
+	"net/url"
+)
+
+// Sender renders mail templates and sends them as plain text mails from the configured address.
+type Sender struct {
+	mailer   Mailer
+	renderer *Renderer
+	from     config.Email
+}
+
+func NewSender(mailer Mailer, from config.Email) (*Sender, error) {
+	renderer, err := NewRenderer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new renderer: %w", err)
+	}
+
+	return &Sender{
+		mailer:   mailer,
+		renderer: renderer,
+		from:     from,
+	}, nil
+}
+
+// Send renders the template and the subject with the given data in the language lang, which can be the contents of an
+// Accept-Language header, and sends the mail to the given address.
+func (s *Sender) Send(lang string, to string, templateName string, subject string, data map[string]interface{}) error {
+	str, err := s.renderer.Render(templateName, lang, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	message := gomail.NewMessage()
+	message.SetAddressHeader("To", to, "")
+	message.SetAddressHeader("From", s.from.FromAddress, s.from.FromName)
+	message.SetHeader("Subject", s.renderer.Translate(lang, subject, data))
+	message.SetBody("text/plain", str)
+
+	return s.mailer.Send(message)
+}
+
+// Link returns the url with the token added as query parameter, for mails which contain a link with a token.
+func Link(rawUrl string, param string, token string) (string, error) {
+	link, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+	query := link.Query()
+	query.Set(param, token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
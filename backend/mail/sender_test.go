@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"gopkg.in/gomail.v2"
+	"testing"
+)
+
+type recordingMailer struct {
+	messages []*gomail.Message
+}
+
+func (m *recordingMailer) Send(message *gomail.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func TestSender_Send(t *testing.T) {
+	mailer := &recordingMailer{}
+	sender, err := NewSender(mailer, config.Email{FromAddress: "test@hanko.io", FromName: "Hanko Test"})
+	require.NoError(t, err)
+
+	data := map[string]interface{}{
+		"TTL":         5,
+		"Code":        "123456",
+		"ServiceName": "Hanko",
+	}
+	err = sender.Send("en", "john.doe@example.com", "loginTextMail", "email_subject_login", data)
+	require.NoError(t, err)
+	require.Len(t, mailer.messages, 1)
+
+	message := mailer.messages[0]
+	assert.Equal(t, []string{"john.doe@example.com"}, message.GetHeader("To"))
+	assert.Equal(t, []string{`"Hanko Test" <test@hanko.io>`}, message.GetHeader("From"))
+	assert.Equal(t, []string{"Use passcode 123456 to sign in to Hanko"}, message.GetHeader("Subject"))
+
+	body := &bytes.Buffer{}
+	_, err = message.WriteTo(body)
+	require.NoError(t, err)
+	assert.Contains(t, body.String(), "123456")
+
+	err = sender.Send("en", "john.doe@example.com", "unknownTextMail", "email_subject_login", data)
+	assert.Error(t, err)
+	assert.Len(t, mailer.messages, 1)
+}
+
+func TestLink(t *testing.T) {
+	link, err := Link("https://example.com/unlock?lang=en", "unlock_token", "a+b/c=")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/unlock?lang=en&unlock_token=a%2Bb%2Fc%3D", link)
+
+	_, err = Link("://invalid", "unlock_token", "abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+package maintenance
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/audit_log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+var (
+	purgedRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "hanko",
+		Subsystem: "maintenance",
+		Name:      "purged_rows_total",
+		Help:      "Number of rows purged by maintenance jobs.",
+	}, []string{"job"})
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "hanko",
+		Subsystem: "maintenance",
+		Name:      "job_runs_total",
+		Help:      "Number of maintenance job runs by result (success, failure or skipped if another instance ran the job).",
+	}, []string{"job", "result"})
+)
+
+// Job purges records. It gets the time of the run, so that every job of a run uses the same cutoff.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Purge    func(tx *pop.Connection, now time.Time) (int, error)
+}
+
+// Scheduler runs the maintenance jobs. Before a job runs, its lock is acquired for most of the interval, so that only
+// one of several instances runs the job per interval.
+type Scheduler struct {
+	persister  persistence.Persister
+	jobs       []Job
+	instanceId string
+	done       chan struct{}
+}
+
+func NewScheduler(cfg *config.Config, persister persistence.Persister) *Scheduler {
+	instanceId, _ := uuid.NewV4()
+	return &Scheduler{
+		persister:  persister,
+		jobs:       newJobs(cfg, persister),
+		instanceId: instanceId.String(),
+		done:       make(chan struct{}),
+	}
+}
+
+func newJobs(cfg *config.Config, persister persistence.Persister) []Job {
+	maintenance := cfg.Maintenance
+	passcodeTTL := time.Duration(cfg.Passcode.TTL) * time.Second
+	auditLogger := auditlog.NewLogger(persister, cfg.AuditLog)
+	return []Job{
+		{
+			Name:     "webauthn_session_data",
+			Interval: maintenance.WebauthnSessionData.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetWebauthnSessionDataPersisterWithConnection(tx).DeleteExpired(now.Add(-maintenance.WebauthnSessionData.Retention))
+			},
+		},
+		{
+			Name:     "passcodes",
+			Interval: maintenance.Passcodes.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetPasscodePersisterWithConnection(tx).DeleteCreatedBefore(now.Add(-passcodeTTL - maintenance.Passcodes.Retention))
+			},
+		},
+		{
+			Name:     "tokens",
+			Interval: maintenance.Tokens.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetTokenPersisterWithConnection(tx).DeleteExpired(now.Add(-maintenance.Tokens.Retention))
+			},
+		},
+		{
+			Name:     "sessions",
+			Interval: maintenance.Sessions.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetSessionPersisterWithConnection(tx).DeleteUsed(now.Add(-maintenance.Sessions.Retention))
+			},
+		},
+		{
+			Name:     "unassigned_emails",
+			Interval: maintenance.UnassignedEmails.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetEmailPersisterWithConnection(tx).DeleteUnassigned(now.Add(-maintenance.UnassignedEmails.Retention))
+			},
+		},
+		{
+			Name:     "deleted_users",
+			Interval: maintenance.DeletedUsers.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return purgeDeletedUsers(tx, persister, auditLogger, now.Add(-cfg.Account.SoftDelete.GracePeriod-maintenance.DeletedUsers.Retention))
+			},
+		},
+		{
+			Name:     "failed_passcode_attempts",
+			Interval: maintenance.FailedPasscodeAttempts.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetFailedPasscodeAttemptPersisterWithConnection(tx).DeleteCreatedBefore(now.Add(-cfg.Passcode.Policy.FailedAttemptsWindow - maintenance.FailedPasscodeAttempts.Retention))
+			},
+		},
+		{
+			Name:     "user_lockouts",
+			Interval: maintenance.UserLockouts.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				// the lockout count is reset once a lockout ended more than the maximum duration ago
+				return persister.GetUserLockoutPersisterWithConnection(tx).DeleteInactive(
+					now.Add(-cfg.Account.Lockout.MaxDuration-maintenance.UserLockouts.Retention),
+					now.Add(-cfg.Account.Lockout.Window-maintenance.UserLockouts.Retention),
+				)
+			},
+		},
+		{
+			Name:     "invitations",
+			Interval: maintenance.Invitations.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetInvitationPersisterWithConnection(tx).DeleteExpired(now.Add(-maintenance.Invitations.Retention))
+			},
+		},
+		{
+			Name:     "email_changes",
+			Interval: maintenance.EmailChanges.Interval,
+			Purge: func(tx *pop.Connection, now time.Time) (int, error) {
+				return persister.GetEmailChangePersisterWithConnection(tx).DeleteExpired(now.Add(-maintenance.EmailChanges.Retention))
+			},
+		},
+	}
+}
+
+// deletedUsersBatchSize limits the number of users purged per run, because every user is deleted and audited
+// separately. Remaining users are purged in the next runs.
+const deletedUsersBatchSize = 1000
+
+// purgeDeletedUsers hard deletes the users which have been soft deleted before the given time.
+func purgeDeletedUsers(tx *pop.Connection, persister persistence.Persister, auditLogger auditlog.Logger, before time.Time) (int, error) {
+	userPersister := persister.GetUserPersisterWithConnection(tx)
+	users, err := userPersister.ListDeletedBefore(before, deletedUsersBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range users {
+		err = userPersister.Delete(users[i])
+		if err != nil {
+			return 0, err
+		}
+
+		err = auditLogger.CreateWithConnection(tx, nil, models.AuditLogUserPurged, &users[i], nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create audit log: %w", err)
+		}
+	}
+
+	return len(users), nil
+}
+
+// Start runs every job in its interval until Stop is called.
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		go s.schedule(job)
+	}
+}
+
+func (s *Scheduler) Stop() {
+	close(s.done)
+}
+
+func (s *Scheduler) schedule(job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		_, _, err := s.Run(job)
+		if err != nil {
+			zeroLogger.Error().Err(err).Str("job", job.Name).Msg("maintenance job failed")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// RunAll runs every job once and returns the number of purged rows by job. Jobs which have been run by another
+// instance within their interval are skipped and not contained in the result.
+func (s *Scheduler) RunAll() (map[string]int, error) {
+	result := make(map[string]int)
+	for _, job := range s.jobs {
+		purged, ran, err := s.Run(job)
+		if err != nil {
+			return result, err
+		}
+		if ran {
+			result[job.Name] = purged
+		}
+	}
+	return result, nil
+}
+
+// Run runs the job if its lock can be acquired. It returns the number of purged rows and whether the job ran.
+func (s *Scheduler) Run(job Job) (int, bool, error) {
+	now := time.Now().UTC()
+	// The lock is released shortly before the next tick, so that the instance which ran the job last does not miss
+	// its next tick because of clock drift.
+	acquired, err := s.persister.GetMaintenanceLockPersister().Acquire(job.Name, s.instanceId, now, now.Add(job.Interval*9/10))
+	if err != nil {
+		jobRuns.WithLabelValues(job.Name, "failure").Inc()
+		return 0, false, fmt.Errorf("failed to acquire lock of job %s: %w", job.Name, err)
+	}
+	if !acquired {
+		jobRuns.WithLabelValues(job.Name, "skipped").Inc()
+		return 0, false, nil
+	}
+
+	var purged int
+	err = s.persister.Transaction(func(tx *pop.Connection) error {
+		purged, err = job.Purge(tx, now)
+		return err
+	})
+	if err != nil {
+		jobRuns.WithLabelValues(job.Name, "failure").Inc()
+		return 0, true, fmt.Errorf("failed to run job %s: %w", job.Name, err)
+	}
+
+	jobRuns.WithLabelValues(job.Name, "success").Inc()
+	purgedRows.WithLabelValues(job.Name).Add(float64(purged))
+	zeroLogger.Info().Str("job", job.Name).Int("purged", purged).Msg("maintenance job finished")
+
+	return purged, true, nil
+}
@@ -0,0 +1,163 @@
+package maintenance
+
+import (
+	"github.com/gobuffalo/nulls"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunAll(t *testing.T) {
+	now := time.Now().UTC()
+	userId := uuid.Must(uuid.NewV4())
+
+	sessionData := []models.WebauthnSessionData{
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now.Add(-3 * time.Hour), ExpiresAt: nulls.NewTime(now.Add(-2 * time.Hour))},
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now, ExpiresAt: nulls.NewTime(now.Add(time.Minute))},
+	}
+	passcodes := []models.Passcode{
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now},
+	}
+	tokens := []models.Token{
+		{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(-2 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(time.Minute)},
+	}
+	sessions := []models.Session{
+		{ID: "used", Used: true, UpdatedAt: now.Add(-8 * 24 * time.Hour)},
+		{ID: "recently used", Used: true, UpdatedAt: now.Add(-time.Hour)},
+		{ID: "unused", UpdatedAt: now.Add(-8 * 24 * time.Hour)},
+	}
+	deletedAt := now.Add(-31 * 24 * time.Hour)
+	recentlyDeletedAt := now.Add(-24 * time.Hour)
+	users := []models.User{
+		{ID: uuid.Must(uuid.NewV4()), DeletedAt: &deletedAt},
+		{ID: uuid.Must(uuid.NewV4()), DeletedAt: &recentlyDeletedAt},
+		{ID: userId},
+	}
+	emails := []models.Email{
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), CreatedAt: now},
+		{ID: uuid.Must(uuid.NewV4()), UserID: &userId, CreatedAt: now.Add(-48 * time.Hour)},
+	}
+	failedPasscodeAttempts := []models.FailedPasscodeAttempt{
+		{ID: uuid.Must(uuid.NewV4()), UserID: userId, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), UserID: userId, CreatedAt: now.Add(-time.Minute)},
+	}
+	lockedUntil := now.Add(-26 * time.Hour)
+	windowStartedAt := now.Add(-time.Minute)
+	userLockouts := []models.UserLockout{
+		{ID: uuid.Must(uuid.NewV4()), UserID: users[0].ID, LockedUntil: &lockedUntil},
+		{ID: uuid.Must(uuid.NewV4()), UserID: userId, WindowStartedAt: &windowStartedAt},
+	}
+	invitations := []models.Invitation{
+		{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(-8 * 24 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(-8 * 24 * time.Hour), AcceptedAt: &deletedAt},
+		{ID: uuid.Must(uuid.NewV4()), ExpiresAt: now.Add(24 * time.Hour)},
+	}
+	emailChanges := []models.EmailChange{
+		{ID: uuid.Must(uuid.NewV4()), UserID: userId, RevertExpiresAt: now.Add(-2 * time.Hour)},
+		{ID: uuid.Must(uuid.NewV4()), UserID: userId, RevertExpiresAt: now.Add(time.Hour)},
+	}
+
+	cfg := test.DefaultConfig
+	cfg.Maintenance = config.DefaultConfig().Maintenance
+	cfg.Account.SoftDelete = config.DefaultConfig().Account.SoftDelete
+	cfg.Account.Lockout = config.DefaultConfig().Account.Lockout
+	// the locks created by the migrations
+	locks := map[string]time.Time{
+		"webauthn_session_data":    {},
+		"passcodes":                {},
+		"tokens":                   {},
+		"sessions":                 {},
+		"unassigned_emails":        {},
+		"deleted_users":            {},
+		"failed_passcode_attempts": {},
+		"user_lockouts":            {},
+		"invitations":              {},
+		"email_changes":            {},
+	}
+	persister := test.NewPersister(users, passcodes, nil, nil, sessionData, nil, nil, emails, nil, nil, tokens, sessions, failedPasscodeAttempts, nil, userLockouts, nil, nil, nil, nil, nil, nil, invitations, emailChanges, locks)
+	scheduler := NewScheduler(&cfg, persister)
+
+	purged, err := scheduler.RunAll()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"webauthn_session_data":    2,
+		"passcodes":                1,
+		"tokens":                   1,
+		"sessions":                 1,
+		"unassigned_emails":        1,
+		"deleted_users":            1,
+		"failed_passcode_attempts": 1,
+		"user_lockouts":            1,
+		"invitations":              1,
+		"email_changes":            1,
+	}, purged)
+
+	// all jobs have been run within their interval
+	purged, err = scheduler.RunAll()
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+}
+
+func TestScheduler_Run_Locked(t *testing.T) {
+	cfg := test.DefaultConfig
+	cfg.Maintenance = config.DefaultConfig().Maintenance
+	locks := map[string]time.Time{"tokens": time.Now().Add(time.Minute)}
+	tokens := []models.Token{
+		{ID: uuid.Must(uuid.NewV4()), ExpiresAt: time.Now().Add(-2 * time.Hour)},
+	}
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, tokens, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, locks)
+	scheduler := NewScheduler(&cfg, persister)
+
+	for _, job := range scheduler.jobs {
+		if job.Name != "tokens" {
+			continue
+		}
+		purged, ran, err := scheduler.Run(job)
+		require.NoError(t, err)
+		assert.False(t, ran)
+		assert.Equal(t, 0, purged)
+	}
+}
+
+func TestScheduler_Run_MissingLock(t *testing.T) {
+	cfg := test.DefaultConfig
+	cfg.Maintenance = config.DefaultConfig().Maintenance
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	scheduler := NewScheduler(&cfg, persister)
+
+	purged, err := scheduler.RunAll()
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+}
+
+// Acquire only updates existing locks, so every job needs a lock created by a migration.
+func TestJobs_LocksCreatedByMigrations(t *testing.T) {
+	files, err := filepath.Glob("../persistence/migrations/*.up.fizz")
+	require.NoError(t, err)
+
+	var migrations strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		if strings.Contains(string(content), "INSERT INTO maintenance_locks") {
+			migrations.Write(content)
+		}
+	}
+
+	cfg := test.DefaultConfig
+	for _, job := range newJobs(&cfg, nil) {
+		assert.Contains(t, migrations.String(), "'"+job.Name+"'", "no migration creates the lock of job %s", job.Name)
+	}
+}
@@ -0,0 +1,270 @@
+package mds
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/go-webauthn/webauthn/metadata"
+	"github.com/gofrs/uuid"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry contains the metadata of an authenticator model.
+type Entry struct {
+	AAGUID      uuid.UUID
+	Description string
+	// Icon is a data URL of a PNG image.
+	Icon string
+	// CertificationStatus is the most recent certification status, e.g. "FIDO_CERTIFIED_L1" or "NOT_FIDO_CERTIFIED".
+	CertificationStatus string
+	// Statuses contains the status of every status report of the authenticator.
+	Statuses []metadata.AuthenticatorStatus
+	// AttestationRoots are the trust anchors for attestation certificates of the authenticator.
+	AttestationRoots []*x509.Certificate
+}
+
+// IsCompromised returns true if one of the status reports marks the authenticator as compromised or revoked.
+func (e *Entry) IsCompromised() bool {
+	for _, status := range e.Statuses {
+		if metadata.IsUndesiredAuthenticatorStatus(status) {
+			return true
+		}
+	}
+	return false
+}
+
+// Service provides the entries of a locally stored MDS3 BLOB. The BLOB is reloaded periodically so that it can be
+// updated without restarting the server.
+type Service struct {
+	cfg     config.WebauthnMetadataService
+	root    *x509.Certificate
+	mutex   sync.RWMutex
+	entries map[uuid.UUID]*Entry
+	done    chan struct{}
+}
+
+// NewService loads the BLOB. It fails if the BLOB can not be read or its signature can not be verified.
+func NewService(cfg config.WebauthnMetadataService) (*Service, error) {
+	root, err := loadRoot(cfg.RootCertificatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		cfg:  cfg,
+		root: root,
+		done: make(chan struct{}),
+	}
+
+	err = s.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload reads and verifies the BLOB again. The previously loaded entries are kept if this fails.
+func (s *Service) Reload() error {
+	blob, err := os.ReadFile(s.cfg.BlobPath)
+	if err != nil {
+		return fmt.Errorf("failed to read mds blob: %w", err)
+	}
+
+	entries, err := Parse(blob, s.root, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to parse mds blob: %w", err)
+	}
+
+	s.mutex.Lock()
+	s.entries = entries
+	s.mutex.Unlock()
+
+	return nil
+}
+
+// Start reloads the BLOB every ReloadInterval until Stop is called.
+func (s *Service) Start() {
+	go func() {
+		ticker := time.NewTicker(s.cfg.ReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := s.Reload()
+				if err != nil {
+					zeroLogger.Error().Err(err).Msg("failed to reload mds blob, keeping previous entries")
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Service) Stop() {
+	close(s.done)
+}
+
+// Get returns the entry for the AAGUID or nil if the authenticator is not contained in the BLOB.
+func (s *Service) Get(aaguid uuid.UUID) *Entry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.entries[aaguid]
+}
+
+// AttestationRoots returns the attestation trust anchors for the AAGUID. It can be used as an
+// attestation.RootsProvider.
+func (s *Service) AttestationRoots(aaguid uuid.UUID) []*x509.Certificate {
+	entry := s.Get(aaguid)
+	if entry == nil {
+		return nil
+	}
+	return entry.AttestationRoots
+}
+
+// Parse verifies the signature of the BLOB and returns its entries for FIDO2 authenticators by AAGUID. The signing
+// certificate chain from the x5c header must chain to root. Certificate revocation lists are not checked, because the
+// BLOB is processed offline.
+func Parse(blob []byte, root *x509.Certificate, now time.Time) (map[uuid.UUID]*Entry, error) {
+	message, err := jws.Parse(blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jws: %w", err)
+	}
+	if len(message.Signatures()) != 1 {
+		return nil, errors.New("expected exactly one signature")
+	}
+	headers := message.Signatures()[0].ProtectedHeaders()
+
+	chain, err := parseChain(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify signing certificate: %w", err)
+	}
+
+	payload, err := jws.Verify(blob, jws.WithKey(headers.Algorithm(), chain[0].PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify signature: %w", err)
+	}
+
+	var blobPayload metadata.MetadataBLOBPayload
+	err = json.Unmarshal(payload, &blobPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if nextUpdate, err := time.Parse("2006-01-02", blobPayload.NextUpdate); err == nil && now.After(nextUpdate) {
+		zeroLogger.Warn().Msgf("mds blob no. %d is outdated, a new blob was due on %s", blobPayload.Number, blobPayload.NextUpdate)
+	}
+
+	entries := make(map[uuid.UUID]*Entry)
+	for _, payloadEntry := range blobPayload.Entries {
+		// UAF and U2F authenticators are identified by AAID or attestation certificate key identifiers
+		if payloadEntry.AaGUID == "" {
+			continue
+		}
+		aaguid, err := uuid.FromString(payloadEntry.AaGUID)
+		if err != nil {
+			continue
+		}
+		entries[aaguid] = newEntry(aaguid, payloadEntry)
+	}
+
+	return entries, nil
+}
+
+func newEntry(aaguid uuid.UUID, payloadEntry metadata.MetadataBLOBPayloadEntry) *Entry {
+	entry := &Entry{
+		AAGUID:      aaguid,
+		Description: payloadEntry.MetadataStatement.Description,
+		Icon:        payloadEntry.MetadataStatement.Icon,
+	}
+
+	for _, report := range payloadEntry.StatusReports {
+		entry.Statuses = append(entry.Statuses, report.Status)
+		if report.Status == metadata.NotFidoCertified || strings.HasPrefix(string(report.Status), string(metadata.FidoCertified)) {
+			// status reports are ordered chronologically
+			entry.CertificationStatus = string(report.Status)
+		}
+	}
+
+	for _, encoded := range payloadEntry.MetadataStatement.AttestationRootCertificates {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(raw)
+		if err != nil {
+			continue
+		}
+		entry.AttestationRoots = append(entry.AttestationRoots, certificate)
+	}
+
+	return entry
+}
+
+func parseChain(headers jws.Headers) ([]*x509.Certificate, error) {
+	x5c := headers.X509CertChain()
+	if x5c == nil || x5c.Len() == 0 {
+		return nil, errors.New("missing x5c header")
+	}
+
+	chain := make([]*x509.Certificate, x5c.Len())
+	for i := range chain {
+		encoded, _ := x5c.Get(i)
+		raw, err := base64.StdEncoding.DecodeString(string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode certificate %d of x5c header: %w", i, err)
+		}
+		chain[i], err = x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %d of x5c header: %w", i, err)
+		}
+	}
+	return chain, nil
+}
+
+// loadRoot reads the first certificate from the PEM file at path or returns the FIDO Alliance MDS3 root certificate if
+// path is empty.
+func loadRoot(path string) (*x509.Certificate, error) {
+	if path == "" {
+		raw, err := base64.StdEncoding.DecodeString(metadata.ProductionMDSRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode mds root certificate: %w", err)
+		}
+		return x509.ParseCertificate(raw)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mds root certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s does not contain a pem encoded certificate", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
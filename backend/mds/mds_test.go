@@ -0,0 +1,201 @@
+package mds
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/go-webauthn/webauthn/metadata"
+	"github.com/gofrs/uuid"
+	"github.com/lestrrat-go/jwx/v2/cert"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	certifiedAAGUID   = uuid.FromStringOrNil("ee882879-721c-4913-9775-3dfcce97072a")
+	compromisedAAGUID = uuid.FromStringOrNil("08987058-cadc-4b81-b6e1-30de50dcbe96")
+)
+
+func TestParse(t *testing.T) {
+	root, rootKey := createCertificate(t, nil, nil, true)
+	signer, signerKey := createCertificate(t, root, rootKey, false)
+	attestationRoot, _ := createCertificate(t, nil, nil, true)
+
+	blob := createBlob(t, testPayload(attestationRoot), signer, signerKey)
+
+	entries, err := Parse(blob, root, time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	certified := entries[certifiedAAGUID]
+	require.NotNil(t, certified)
+	assert.Equal(t, "Certified Authenticator", certified.Description)
+	assert.Equal(t, "data:image/png;base64,iVBORw0KGgo=", certified.Icon)
+	assert.Equal(t, string(metadata.FidoCertifiedL1), certified.CertificationStatus)
+	assert.False(t, certified.IsCompromised())
+	require.Len(t, certified.AttestationRoots, 1)
+	assert.True(t, attestationRoot.Equal(certified.AttestationRoots[0]))
+
+	compromised := entries[compromisedAAGUID]
+	require.NotNil(t, compromised)
+	assert.Equal(t, string(metadata.FidoCertified), compromised.CertificationStatus)
+	assert.True(t, compromised.IsCompromised())
+}
+
+func TestParse_UntrustedSigner(t *testing.T) {
+	root, _ := createCertificate(t, nil, nil, true)
+	otherRoot, otherRootKey := createCertificate(t, nil, nil, true)
+	signer, signerKey := createCertificate(t, otherRoot, otherRootKey, false)
+
+	blob := createBlob(t, testPayload(nil), signer, signerKey)
+
+	_, err := Parse(blob, root, time.Now())
+	assert.Error(t, err)
+}
+
+func TestParse_InvalidSignature(t *testing.T) {
+	root, rootKey := createCertificate(t, nil, nil, true)
+	signer, _ := createCertificate(t, root, rootKey, false)
+	_, otherKey := createCertificate(t, root, rootKey, false)
+
+	blob := createBlob(t, testPayload(nil), signer, otherKey)
+
+	_, err := Parse(blob, root, time.Now())
+	assert.Error(t, err)
+}
+
+func TestService(t *testing.T) {
+	root, rootKey := createCertificate(t, nil, nil, true)
+	signer, signerKey := createCertificate(t, root, rootKey, false)
+
+	dir := t.TempDir()
+	rootPath := filepath.Join(dir, "root.pem")
+	require.NoError(t, os.WriteFile(rootPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0600))
+	blobPath := filepath.Join(dir, "blob.jwt")
+	require.NoError(t, os.WriteFile(blobPath, createBlob(t, metadata.MetadataBLOBPayload{Number: 1}, signer, signerKey), 0600))
+
+	service, err := NewService(config.WebauthnMetadataService{
+		Enabled:             true,
+		BlobPath:            blobPath,
+		RootCertificatePath: rootPath,
+		ReloadInterval:      time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, service.Get(certifiedAAGUID))
+
+	require.NoError(t, os.WriteFile(blobPath, createBlob(t, testPayload(nil), signer, signerKey), 0600))
+	require.NoError(t, service.Reload())
+	assert.NotNil(t, service.Get(certifiedAAGUID))
+
+	// a broken blob must not replace the previously loaded entries
+	require.NoError(t, os.WriteFile(blobPath, []byte("invalid"), 0600))
+	assert.Error(t, service.Reload())
+	assert.NotNil(t, service.Get(certifiedAAGUID))
+}
+
+func TestLoadRoot_Default(t *testing.T) {
+	root, err := loadRoot("")
+	require.NoError(t, err)
+	assert.True(t, root.IsCA)
+}
+
+func testPayload(attestationRoot *x509.Certificate) metadata.MetadataBLOBPayload {
+	var attestationRoots []string
+	if attestationRoot != nil {
+		attestationRoots = append(attestationRoots, base64.StdEncoding.EncodeToString(attestationRoot.Raw))
+	}
+
+	return metadata.MetadataBLOBPayload{
+		Number:     2,
+		NextUpdate: time.Now().Add(24 * time.Hour).Format("2006-01-02"),
+		Entries: []metadata.MetadataBLOBPayloadEntry{
+			{
+				AaGUID: certifiedAAGUID.String(),
+				MetadataStatement: metadata.MetadataStatement{
+					Description:                 "Certified Authenticator",
+					Icon:                        "data:image/png;base64,iVBORw0KGgo=",
+					AttestationRootCertificates: attestationRoots,
+				},
+				StatusReports: []metadata.StatusReport{
+					{Status: metadata.NotFidoCertified},
+					{Status: metadata.FidoCertifiedL1},
+					{Status: metadata.UpdateAvailable},
+				},
+			},
+			{
+				AaGUID: compromisedAAGUID.String(),
+				MetadataStatement: metadata.MetadataStatement{
+					Description: "Compromised Authenticator",
+				},
+				StatusReports: []metadata.StatusReport{
+					{Status: metadata.FidoCertified},
+					{Status: metadata.UserKeyRemoteCompromise},
+				},
+			},
+			{
+				// U2F authenticators don't have an AAGUID
+				AttestationCertificateKeyIdentifiers: []string{"923881fe2f214ee465484371aeb72e97f5a58e0a"},
+			},
+		},
+	}
+}
+
+func createBlob(t *testing.T, payload metadata.MetadataBLOBPayload, signer *x509.Certificate, key *ecdsa.PrivateKey) []byte {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	var chain cert.Chain
+	require.NoError(t, chain.AddString(base64.StdEncoding.EncodeToString(signer.Raw)))
+	headers := jws.NewHeaders()
+	require.NoError(t, headers.Set(jws.X509CertChainKey, &chain))
+
+	blob, err := jws.Sign(data, jws.WithKey(jwa.ES256, key, jws.WithProtectedHeaders(headers)))
+	require.NoError(t, err)
+	return blob
+}
+
+// createCertificate creates a self-signed CA certificate if parent is nil, otherwise a certificate signed by parent.
+func createCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Hanko MDS Test", Organization: []string{"Hanko"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return certificate, key
+}
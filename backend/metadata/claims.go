@@ -0,0 +1,55 @@
+package metadata
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+)
+
+// ClaimsProvider adds the metadata of the configured scopes to session JWTs.
+type ClaimsProvider struct {
+	persister persistence.Persister
+	scopes    []Scope
+}
+
+func NewClaimsProvider(cfg config.UserMetadata, persister persistence.Persister) *ClaimsProvider {
+	scopes := make([]Scope, len(cfg.JwtClaims))
+	for i, scope := range cfg.JwtClaims {
+		scopes[i] = Scope(scope)
+	}
+	return &ClaimsProvider{
+		persister: persister,
+		scopes:    scopes,
+	}
+}
+
+// Claims returns a claim for every configured scope in which the user has metadata.
+func (p *ClaimsProvider) Claims(tx *pop.Connection, userId uuid.UUID) (map[string]interface{}, error) {
+	claims := make(map[string]interface{})
+	if len(p.scopes) == 0 {
+		return claims, nil
+	}
+
+	userPersister := p.persister.GetUserPersister()
+	if tx != nil {
+		userPersister = p.persister.GetUserPersisterWithConnection(tx)
+	}
+
+	user, err := userPersister.Get(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return claims, nil
+	}
+
+	for _, scope := range p.scopes {
+		if metadata := Get(user, scope); len(metadata) > 0 {
+			claims[scope.Key()] = map[string]interface{}(metadata)
+		}
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,176 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type Scope string
+
+const (
+	ScopePublic  Scope = "public"
+	ScopePrivate Scope = "private"
+	ScopeUnsafe  Scope = "unsafe"
+)
+
+// Key returns the name of the scope in JSON documents and JWT claims, e.g. "public_metadata".
+func (s Scope) Key() string {
+	return string(s) + "_metadata"
+}
+
+// Get returns the metadata of the user in the scope.
+func Get(user *models.User, scope Scope) slices.Map {
+	switch scope {
+	case ScopePublic:
+		return user.PublicMetadata
+	case ScopePrivate:
+		return user.PrivateMetadata
+	case ScopeUnsafe:
+		return user.UnsafeMetadata
+	default:
+		return nil
+	}
+}
+
+func set(user *models.User, scope Scope, metadata slices.Map) {
+	switch scope {
+	case ScopePublic:
+		user.PublicMetadata = metadata
+	case ScopePrivate:
+		user.PrivateMetadata = metadata
+	case ScopeUnsafe:
+		user.UnsafeMetadata = metadata
+	}
+}
+
+// PatchError is returned by Patch if the patch is not applicable, e.g. because it contains a scope which must not be
+// changed or the result exceeds the size limit.
+type PatchError struct {
+	Message string
+}
+
+func (e *PatchError) Error() string {
+	return e.Message
+}
+
+// Patch applies a JSON merge patch (RFC 7386) to the metadata of the user. The patch is an object with the keys of the
+// scopes, e.g. {"unsafe_metadata": {"theme": "dark", "beta": null}}. Only the given scopes may be contained in the
+// patch. A scope set to null removes all of its metadata.
+func Patch(user *models.User, patch []byte, scopes []Scope, cfg config.UserMetadata) error {
+	var document map[string]interface{}
+	err := json.Unmarshal(patch, &document)
+	if err != nil || document == nil {
+		return &PatchError{Message: "patch must be a JSON object"}
+	}
+
+	patched := make(map[Scope]slices.Map)
+	for key, value := range document {
+		scope, ok := findScope(key, scopes)
+		if !ok {
+			return &PatchError{Message: fmt.Sprintf("%s must not be changed", key)}
+		}
+
+		if value == nil {
+			patched[scope] = nil
+			continue
+		}
+
+		scopePatch, ok := value.(map[string]interface{})
+		if !ok {
+			return &PatchError{Message: fmt.Sprintf("%s must be an object or null", key)}
+		}
+
+		merged := mergePatch(map[string]interface{}(Get(user, scope)), scopePatch)
+		if len(merged) == 0 {
+			merged = nil
+		}
+
+		size, err := Size(merged)
+		if err != nil {
+			return fmt.Errorf("failed to serialize %s: %w", key, err)
+		}
+		if maxSize := maxSize(scope, cfg); size > maxSize {
+			return &PatchError{Message: fmt.Sprintf("%s must not be larger than %d bytes", key, maxSize)}
+		}
+
+		patched[scope] = merged
+	}
+
+	for scope, metadata := range patched {
+		set(user, scope, metadata)
+	}
+
+	return nil
+}
+
+// Size returns the number of bytes of the metadata serialized as JSON.
+func Size(metadata map[string]interface{}) (int, error) {
+	if metadata == nil {
+		return 0, nil
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Validate checks the size of the metadata of all scopes, e.g. before metadata is imported.
+func Validate(user *models.User, cfg config.UserMetadata) error {
+	for _, scope := range []Scope{ScopePublic, ScopePrivate, ScopeUnsafe} {
+		size, err := Size(Get(user, scope))
+		if err != nil {
+			return fmt.Errorf("failed to serialize %s: %w", scope.Key(), err)
+		}
+		if maxSize := maxSize(scope, cfg); size > maxSize {
+			return errors.New(fmt.Sprintf("%s must not be larger than %d bytes", scope.Key(), maxSize))
+		}
+	}
+	return nil
+}
+
+func maxSize(scope Scope, cfg config.UserMetadata) int {
+	if scope == ScopeUnsafe {
+		return cfg.UnsafeMaxSize
+	}
+	return cfg.MaxSize
+}
+
+func findScope(key string, scopes []Scope) (Scope, bool) {
+	for _, scope := range scopes {
+		if scope.Key() == key {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+// mergePatch returns the result of applying the patch to the target according to RFC 7386. The target is not modified.
+func mergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(target))
+	for key, value := range target {
+		result[key] = value
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		valuePatch, ok := value.(map[string]interface{})
+		if !ok {
+			result[key] = value
+			continue
+		}
+
+		valueTarget, _ := result[key].(map[string]interface{})
+		result[key] = mergePatch(valueTarget, valuePatch)
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"errors"
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"strings"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	cfg := config.DefaultConfig().UserMetadata
+	allScopes := []Scope{ScopePublic, ScopePrivate, ScopeUnsafe}
+
+	tests := []struct {
+		name     string
+		patch    string
+		scopes   []Scope
+		expected models.User
+		errMsg   string
+	}{
+		{
+			name:   "merges nested objects and removes null values",
+			patch:  `{"public_metadata": {"plan": "pro", "settings": {"theme": "dark", "beta": null}}}`,
+			scopes: allScopes,
+			expected: models.User{
+				PublicMetadata:  slices.Map{"plan": "pro", "settings": map[string]interface{}{"theme": "dark"}},
+				PrivateMetadata: slices.Map{"stripe_id": "cus_123"},
+				UnsafeMetadata:  slices.Map{"onboarded": true},
+			},
+		},
+		{
+			name:   "removes a scope",
+			patch:  `{"private_metadata": null}`,
+			scopes: allScopes,
+			expected: models.User{
+				PublicMetadata: slices.Map{"plan": "free", "settings": map[string]interface{}{"beta": true}},
+				UnsafeMetadata: slices.Map{"onboarded": true},
+			},
+		},
+		{
+			name:   "rejects scope which must not be changed",
+			patch:  `{"unsafe_metadata": {"onboarded": false}, "public_metadata": {"plan": "pro"}}`,
+			scopes: []Scope{ScopeUnsafe},
+			errMsg: "public_metadata must not be changed",
+		},
+		{
+			name:   "rejects scope which is not an object",
+			patch:  `{"unsafe_metadata": "dark"}`,
+			scopes: allScopes,
+			errMsg: "unsafe_metadata must be an object or null",
+		},
+		{
+			name:   "rejects metadata larger than the limit",
+			patch:  `{"unsafe_metadata": {"note": "` + strings.Repeat("a", cfg.UnsafeMaxSize) + `"}}`,
+			scopes: allScopes,
+			errMsg: "unsafe_metadata must not be larger than 2048 bytes",
+		},
+		{
+			name:   "rejects patch which is not an object",
+			patch:  `[]`,
+			scopes: allScopes,
+			errMsg: "patch must be a JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{
+				PublicMetadata:  slices.Map{"plan": "free", "settings": map[string]interface{}{"beta": true}},
+				PrivateMetadata: slices.Map{"stripe_id": "cus_123"},
+				UnsafeMetadata:  slices.Map{"onboarded": true},
+			}
+
+			err := Patch(&user, []byte(tt.patch), tt.scopes, cfg)
+			if tt.errMsg != "" {
+				var patchError *PatchError
+				require.True(t, errors.As(err, &patchError))
+				assert.Equal(t, tt.errMsg, patchError.Message)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, user)
+		})
+	}
+}
+
+func TestClaimsProvider_Claims(t *testing.T) {
+	user := models.NewUser()
+	user.PublicMetadata = slices.Map{"plan": "pro"}
+	user.PrivateMetadata = slices.Map{"stripe_id": "cus_123"}
+
+	cfg := config.DefaultConfig().UserMetadata
+	cfg.JwtClaims = []string{"public", "unsafe"}
+	provider := NewClaimsProvider(cfg, test.NewPersister([]models.User{user}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
+
+	claims, err := provider.Claims(nil, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"public_metadata": map[string]interface{}{"plan": "pro"}}, claims)
+}
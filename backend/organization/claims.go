@@ -0,0 +1,71 @@
+package organization
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+)
+
+const (
+	IdClaim          = "org_id"
+	SlugClaim        = "org_slug"
+	RoleClaim        = "org_role"
+	PermissionsClaim = "org_permissions"
+)
+
+// ClaimsProvider adds the active organization of a session and the role of the user within it to session JWTs.
+type ClaimsProvider struct {
+	persister persistence.Persister
+}
+
+func NewClaimsProvider(persister persistence.Persister) *ClaimsProvider {
+	return &ClaimsProvider{
+		persister: persister,
+	}
+}
+
+// Claims returns no claims, because they depend on the active organization of the session.
+func (p *ClaimsProvider) Claims(tx *pop.Connection, userId uuid.UUID) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+// OrganizationClaims returns the "org_id" and "org_slug" claims and, if the user has a role within the organization,
+// the "org_role" and "org_permissions" claims. No claims are returned if the user is not a member of the
+// organization (anymore).
+func (p *ClaimsProvider) OrganizationClaims(tx *pop.Connection, userId uuid.UUID, organizationId uuid.UUID) (map[string]interface{}, error) {
+	claims := make(map[string]interface{})
+
+	membershipPersister := p.persister.GetOrganizationMembershipPersister()
+	rolePersister := p.persister.GetRolePersister()
+	if tx != nil {
+		membershipPersister = p.persister.GetOrganizationMembershipPersisterWithConnection(tx)
+		rolePersister = p.persister.GetRolePersisterWithConnection(tx)
+	}
+
+	membership, err := membershipPersister.Get(organizationId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+	if membership == nil || membership.Organization == nil {
+		return claims, nil
+	}
+
+	claims[IdClaim] = membership.Organization.ID.String()
+	claims[SlugClaim] = membership.Organization.Slug
+
+	if membership.RoleID == nil {
+		return claims, nil
+	}
+
+	role, err := rolePersister.Get(*membership.RoleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if role != nil {
+		claims[RoleClaim] = role.Name
+		claims[PermissionsClaim] = role.Permissions.GetNames()
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,14 @@
+package organization
+
+import "regexp"
+
+// MaxSlugLength is the maximum length of organization slugs.
+const MaxSlugLength = 100
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidSlug reports whether slug can be used as the slug of an organization. Slugs consist of lowercase letters and
+// digits separated by single hyphens, e.g. "acme-corp".
+func ValidSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugPattern.MatchString(slug)
+}
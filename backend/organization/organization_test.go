@@ -0,0 +1,55 @@
+package organization
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"strings"
+	"testing"
+)
+
+func TestValidSlug(t *testing.T) {
+	assert.True(t, ValidSlug("acme"))
+	assert.True(t, ValidSlug("acme-corp-2"))
+	assert.False(t, ValidSlug(""))
+	assert.False(t, ValidSlug("Acme"))
+	assert.False(t, ValidSlug("acme--corp"))
+	assert.False(t, ValidSlug("-acme"))
+	assert.False(t, ValidSlug("acme corp"))
+	assert.False(t, ValidSlug(strings.Repeat("a", MaxSlugLength+1)))
+}
+
+func TestClaimsProvider_OrganizationClaims(t *testing.T) {
+	userId, _ := uuid.NewV4()
+	acme := models.NewOrganization("Acme", "acme")
+	globex := models.NewOrganization("Globex", "globex")
+	admin := models.NewRole("org-admin", nil)
+	admin.Permissions = models.Permissions{models.NewPermission("members:write", nil)}
+
+	acmeMembership := models.NewOrganizationMembership(acme.ID, userId, &admin.ID)
+	acmeMembership.Organization = &acme
+	globexMembership := models.NewOrganizationMembership(globex.ID, userId, nil)
+	globexMembership.Organization = &globex
+
+	provider := NewClaimsProvider(test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []models.Role{admin}, nil, nil, nil, []models.OrganizationMembership{acmeMembership, globexMembership}, nil, nil, nil))
+
+	claims, err := provider.OrganizationClaims(nil, userId, acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"org_id":          acme.ID.String(),
+		"org_slug":        "acme",
+		"org_role":        "org-admin",
+		"org_permissions": []string{"members:write"},
+	}, claims)
+
+	claims, err = provider.OrganizationClaims(nil, userId, globex.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"org_id": globex.ID.String(), "org_slug": "globex"}, claims)
+
+	otherId, _ := uuid.NewV4()
+	claims, err = provider.OrganizationClaims(nil, userId, otherId)
+	require.NoError(t, err)
+	assert.Empty(t, claims)
+}
@@ -0,0 +1,126 @@
+package password_policy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachChecker reports whether a password is part of a breached password corpus. Implementations only ever see
+// the first five characters of the SHA-1 hash of the password outside the process (k-anonymity).
+type BreachChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+const hashPrefixLength = 5
+
+func NewBreachChecker(cfg config.PasswordBreachCheck) BreachChecker {
+	minOccurrences := cfg.MinOccurrences
+	if minOccurrences < 1 {
+		minOccurrences = 1
+	}
+
+	if cfg.Source == config.PasswordBreachCheckSourceHTTP {
+		return &httpBreachChecker{
+			endpoint:       cfg.Endpoint,
+			client:         &http.Client{Timeout: cfg.Timeout},
+			minOccurrences: minOccurrences,
+		}
+	}
+
+	return &rangeFileBreachChecker{
+		directory:      cfg.RangeDirectory,
+		minOccurrences: minOccurrences,
+	}
+}
+
+// rangeFileBreachChecker looks up hashes in a directory with one range file per hash prefix, e.g. "21BD1.txt".
+type rangeFileBreachChecker struct {
+	directory      string
+	minOccurrences int
+}
+
+func (c *rangeFileBreachChecker) IsBreached(password string) (bool, error) {
+	if _, err := os.Stat(c.directory); err != nil {
+		return false, fmt.Errorf("range directory is not accessible: %w", err)
+	}
+
+	prefix, suffix := hashPassword(password)
+	file, err := os.Open(filepath.Join(c.directory, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open range file: %w", err)
+	}
+	defer file.Close()
+
+	return containsSuffix(file, suffix, c.minOccurrences)
+}
+
+// httpBreachChecker queries an endpoint implementing the range API of Have I Been Pwned.
+type httpBreachChecker struct {
+	endpoint       string
+	client         *http.Client
+	minOccurrences int
+}
+
+func (c *httpBreachChecker) IsBreached(password string) (bool, error) {
+	prefix, suffix := hashPassword(password)
+	req, err := http.NewRequest(http.MethodGet, c.endpoint+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create range request: %w", err)
+	}
+	// padding hides the number of suffixes per prefix from observers of the response size
+	req.Header.Set("Add-Padding", "true")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to request range: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("range request returned unexpected status: %d", res.StatusCode)
+	}
+
+	return containsSuffix(res.Body, suffix, c.minOccurrences)
+}
+
+// hashPassword returns the upper case hex encoded SHA-1 hash of the password split into the prefix that is used for
+// the lookup and the remaining suffix.
+func hashPassword(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:hashPrefixLength], hash[hashPrefixLength:]
+}
+
+// containsSuffix scans a range in the format "SUFFIX:COUNT", one entry per line. Padding entries have a count of 0
+// and are therefore never matched.
+func containsSuffix(r io.Reader, suffix string, minOccurrences int) (bool, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		entrySuffix, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || !strings.EqualFold(entrySuffix, suffix) {
+			continue
+		}
+		occurrences, err := strconv.Atoi(count)
+		if err != nil {
+			return false, fmt.Errorf("invalid count in range entry: %w", err)
+		}
+		return occurrences >= minOccurrences, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read range: %w", err)
+	}
+	return false, nil
+}
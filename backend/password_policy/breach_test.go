@@ -0,0 +1,101 @@
+package password_policy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+const passwordRange = "003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n011053FD0102E94D6AE2F8B83D76FAF94F6:0\r\n"
+
+func TestRangeFileBreachChecker_IsBreached(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(passwordRange), 0600)
+	assert.NoError(t, err)
+
+	checker := NewBreachChecker(config.PasswordBreachCheck{
+		Source:         config.PasswordBreachCheckSourceRangeFile,
+		RangeDirectory: dir,
+	})
+
+	breached, err := checker.IsBreached("password")
+	assert.NoError(t, err)
+	assert.True(t, breached)
+
+	breached, err = checker.IsBreached("this password has no range file")
+	assert.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestRangeFileBreachChecker_IsBreached_MissingDirectory(t *testing.T) {
+	checker := NewBreachChecker(config.PasswordBreachCheck{
+		Source:         config.PasswordBreachCheckSourceRangeFile,
+		RangeDirectory: filepath.Join(t.TempDir(), "missing"),
+	})
+
+	_, err := checker.IsBreached("password")
+	assert.Error(t, err)
+}
+
+func TestHttpBreachChecker_IsBreached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/range/5BAA6" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		_, _ = w.Write([]byte(passwordRange))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		password       string
+		minOccurrences int
+		expected       bool
+	}{
+		{name: "breached password", password: "password", minOccurrences: 1, expected: true},
+		{name: "breached password below threshold", password: "password", minOccurrences: 10000000, expected: false},
+		{name: "unknown password", password: "correct horse battery staple", minOccurrences: 1, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checker := NewBreachChecker(config.PasswordBreachCheck{
+				Source:         config.PasswordBreachCheckSourceHTTP,
+				Endpoint:       server.URL + "/range/",
+				MinOccurrences: test.minOccurrences,
+			})
+
+			breached, err := checker.IsBreached(test.password)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, breached)
+		})
+	}
+}
+
+func TestHttpBreachChecker_IsBreached_UnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	checker := NewBreachChecker(config.PasswordBreachCheck{
+		Source:   config.PasswordBreachCheckSourceHTTP,
+		Endpoint: server.URL + "/range/",
+	})
+
+	_, err := checker.IsBreached("password")
+	assert.Error(t, err)
+}
+
+func TestContainsSuffix_IgnoresPadding(t *testing.T) {
+	found, err := containsSuffix(strings.NewReader(passwordRange), "011053FD0102E94D6AE2F8B83D76FAF94F6", 1)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
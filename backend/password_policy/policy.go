@@ -0,0 +1,163 @@
+package password_policy
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto/password_hash"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation is a machine-readable code describing a requirement the password does not fulfil.
+type Violation string
+
+const (
+	ViolationTooShort         Violation = "password_too_short"
+	ViolationTooLong          Violation = "password_too_long"
+	ViolationMissingLowercase Violation = "password_missing_lowercase"
+	ViolationMissingUppercase Violation = "password_missing_uppercase"
+	ViolationMissingDigit     Violation = "password_missing_digit"
+	ViolationMissingSymbol    Violation = "password_missing_symbol"
+	ViolationContainsEmail    Violation = "password_contains_email"
+	ViolationPreviouslyUsed   Violation = "password_previously_used"
+	ViolationBreached         Violation = "password_breached"
+)
+
+const (
+	maxPasswordBytes            = 72 // bcrypt ignores everything after the 72nd byte
+	minEmailLocalPartCharacters = 3  // shorter local parts would reject too many passwords
+)
+
+type Policy struct {
+	cfg           config.Password
+	hasher        *password_hash.Hasher
+	breachChecker BreachChecker
+}
+
+// NewPolicy returns a Policy for the given password settings. The breach check is only set up when enabled.
+func NewPolicy(cfg config.Password) *Policy {
+	var breachChecker BreachChecker
+	if cfg.Policy.BreachCheck.Enabled {
+		breachChecker = NewBreachChecker(cfg.Policy.BreachCheck)
+	}
+
+	return &Policy{
+		cfg:           cfg,
+		hasher:        password_hash.NewHasher(cfg.Hashing),
+		breachChecker: breachChecker,
+	}
+}
+
+// Check returns all violations of the password. emailAddresses are the addresses of the user the password belongs
+// to, previousHashes are the hashes of the passwords the user must not reuse. An error is only returned if the
+// breach check could not be performed.
+func (p *Policy) Check(password string, emailAddresses []string, previousHashes []string) ([]Violation, error) {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password) // use utf8.RuneCountInString, so utf8 characters would count as 1
+	if length < p.cfg.MinPasswordLength {
+		violations = append(violations, ViolationTooShort)
+	}
+	if (p.hasher.LimitsPasswordLength() && len([]byte(password)) > maxPasswordBytes) || (p.cfg.Policy.MaxPasswordLength > 0 && length > p.cfg.Policy.MaxPasswordLength) {
+		violations = append(violations, ViolationTooLong)
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.Policy.RequireLowercase && !hasLower {
+		violations = append(violations, ViolationMissingLowercase)
+	}
+	if p.cfg.Policy.RequireUppercase && !hasUpper {
+		violations = append(violations, ViolationMissingUppercase)
+	}
+	if p.cfg.Policy.RequireDigit && !hasDigit {
+		violations = append(violations, ViolationMissingDigit)
+	}
+	if p.cfg.Policy.RequireSymbol && !hasSymbol {
+		violations = append(violations, ViolationMissingSymbol)
+	}
+
+	if p.cfg.Policy.DisallowEmailLocalPart && containsEmailLocalPart(password, emailAddresses) {
+		violations = append(violations, ViolationContainsEmail)
+	}
+
+	if p.cfg.Policy.HistorySize > 0 {
+		for _, hash := range previousHashes {
+			// hashes that can not be verified, e.g. imported ones of an algorithm no longer configured, are skipped
+			if reused, _ := p.hasher.Verify(password, hash); reused {
+				violations = append(violations, ViolationPreviouslyUsed)
+				break
+			}
+		}
+	}
+
+	if p.breachChecker != nil {
+		breached, err := p.breachChecker.IsBreached(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check password against breached passwords: %w", err)
+		}
+		if breached {
+			violations = append(violations, ViolationBreached)
+		}
+	}
+
+	return violations, nil
+}
+
+// Describe returns a human-readable explanation of the violation.
+func (p *Policy) Describe(violation Violation) string {
+	switch violation {
+	case ViolationTooShort:
+		return fmt.Sprintf("password must be at least %d characters long", p.cfg.MinPasswordLength)
+	case ViolationTooLong:
+		if !p.hasher.LimitsPasswordLength() {
+			return fmt.Sprintf("password must not be longer than %d characters", p.cfg.Policy.MaxPasswordLength)
+		}
+		if p.cfg.Policy.MaxPasswordLength > 0 {
+			return fmt.Sprintf("password must not be longer than %d characters or 72 bytes", p.cfg.Policy.MaxPasswordLength)
+		}
+		return "password must not be longer than 72 bytes"
+	case ViolationMissingLowercase:
+		return "password must contain a lower case letter"
+	case ViolationMissingUppercase:
+		return "password must contain an upper case letter"
+	case ViolationMissingDigit:
+		return "password must contain a digit"
+	case ViolationMissingSymbol:
+		return "password must contain a symbol"
+	case ViolationContainsEmail:
+		return "password must not contain your email address"
+	case ViolationPreviouslyUsed:
+		return "password has been used before"
+	case ViolationBreached:
+		return "password has appeared in a data breach"
+	default:
+		return string(violation)
+	}
+}
+
+func containsEmailLocalPart(password string, emailAddresses []string) bool {
+	lowerPassword := strings.ToLower(password)
+	for _, address := range emailAddresses {
+		localPart, _, found := strings.Cut(address, "@")
+		if !found || utf8.RuneCountInString(localPart) < minEmailLocalPartCharacters {
+			continue
+		}
+		if strings.Contains(lowerPassword, strings.ToLower(localPart)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package password_policy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	previousHash, err := bcrypt.GenerateFromPassword([]byte("OldPassword1!"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	strictPolicy := config.PasswordPolicy{
+		MaxPasswordLength:      20,
+		RequireLowercase:       true,
+		RequireUppercase:       true,
+		RequireDigit:           true,
+		RequireSymbol:          true,
+		DisallowEmailLocalPart: true,
+		HistorySize:            3,
+	}
+
+	tests := []struct {
+		name     string
+		policy   config.PasswordPolicy
+		password string
+		expected []Violation
+	}{
+		{
+			name:     "valid password without policy",
+			password: "verybadpassword",
+			expected: nil,
+		},
+		{
+			name:     "too short",
+			password: "short",
+			expected: []Violation{ViolationTooShort},
+		},
+		{
+			name:     "longer than 72 bytes",
+			password: "thisIsAVeryLongPasswordThatIsUsedToTestIfAnErrorWillBeReturnedForTooLongPasswords",
+			expected: []Violation{ViolationTooLong},
+		},
+		{
+			name:     "valid password with strict policy",
+			policy:   strictPolicy,
+			password: "Correct-Horse-42",
+			expected: nil,
+		},
+		{
+			name:     "longer than max password length",
+			policy:   strictPolicy,
+			password: "Correct-Horse-Battery-Staple-42",
+			expected: []Violation{ViolationTooLong},
+		},
+		{
+			name:     "missing character classes",
+			policy:   strictPolicy,
+			password: "correcthorse",
+			expected: []Violation{ViolationMissingUppercase, ViolationMissingDigit, ViolationMissingSymbol},
+		},
+		{
+			name:     "contains email local part",
+			policy:   strictPolicy,
+			password: "John.Doe-Horse-42",
+			expected: []Violation{ViolationContainsEmail},
+		},
+		{
+			name:     "previously used",
+			policy:   strictPolicy,
+			password: "OldPassword1!",
+			expected: []Violation{ViolationPreviouslyUsed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := NewPolicy(config.Password{
+				MinPasswordLength: 8,
+				Policy:            test.policy,
+			})
+
+			violations, err := policy.Check(test.password, []string{"john.doe@example.com"}, []string{string(previousHash)})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, violations)
+		})
+	}
+}
+
+func TestPolicy_Check_ShortEmailLocalPart(t *testing.T) {
+	policy := NewPolicy(config.Password{
+		MinPasswordLength: 8,
+		Policy:            config.PasswordPolicy{DisallowEmailLocalPart: true},
+	})
+
+	violations, err := policy.Check("jo-password", []string{"jo@example.com"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type EmailChangePersister interface {
+	GetByRevertToken(revertToken string) (*models.EmailChange, error)
+	// ListByUserId returns the email changes of the user, oldest first.
+	ListByUserId(userId uuid.UUID) ([]models.EmailChange, error)
+	Create(emailChange models.EmailChange) error
+	Update(emailChange models.EmailChange) error
+	// DeleteExpired deletes the email changes whose revert link expired before the given time.
+	DeleteExpired(before time.Time) (int, error)
+}
+
+type emailChangePersister struct {
+	db *pop.Connection
+}
+
+func NewEmailChangePersister(db *pop.Connection) EmailChangePersister {
+	return &emailChangePersister{db: db}
+}
+
+func (p *emailChangePersister) GetByRevertToken(revertToken string) (*models.EmailChange, error) {
+	emailChange := models.EmailChange{}
+	err := p.db.Where("revert_token = ?", revertToken).First(&emailChange)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	return &emailChange, nil
+}
+
+func (p *emailChangePersister) ListByUserId(userId uuid.UUID) ([]models.EmailChange, error) {
+	emailChanges := []models.EmailChange{}
+	err := p.db.Where("user_id = ?", userId).Order("created_at asc").All(&emailChanges)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch email changes: %w", err)
+	}
+
+	return emailChanges, nil
+}
+
+func (p *emailChangePersister) Create(emailChange models.EmailChange) error {
+	vErr, err := p.db.ValidateAndCreate(&emailChange)
+	if err != nil {
+		return fmt.Errorf("failed to store email change: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("email change object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *emailChangePersister) Update(emailChange models.EmailChange) error {
+	vErr, err := p.db.ValidateAndUpdate(&emailChange)
+	if err != nil {
+		return fmt.Errorf("failed to update email change: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("email change object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *emailChangePersister) DeleteExpired(before time.Time) (int, error) {
+	count, err := p.db.RawQuery("DELETE FROM email_changes WHERE revert_expires_at < ?", before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired email changes: %w", err)
+	}
+
+	return count, nil
+}
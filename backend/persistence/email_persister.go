@@ -7,6 +7,7 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 type EmailPersister interface {
@@ -17,6 +18,7 @@ type EmailPersister interface {
 	Create(models.Email) error
 	Update(models.Email) error
 	Delete(models.Email) error
+	DeleteUnassigned(before time.Time) (int, error)
 }
 
 type emailPersister struct {
@@ -124,3 +126,14 @@ func (e *emailPersister) Delete(email models.Email) error {
 
 	return nil
 }
+
+// DeleteUnassigned deletes the email addresses which have been created before the given time and are not assigned to a
+// user. Their passcodes are deleted as well.
+func (e *emailPersister) DeleteUnassigned(before time.Time) (int, error) {
+	count, err := e.db.RawQuery("DELETE FROM emails WHERE user_id IS NULL AND created_at < ?", before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete unassigned emails: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type FailedPasscodeAttemptPersister interface {
+	Create(models.FailedPasscodeAttempt) error
+	CountByUserIdSince(userId uuid.UUID, since time.Time) (int, error)
+	DeleteByUserId(userId uuid.UUID) error
+	DeleteCreatedBefore(before time.Time) (int, error)
+}
+
+type failedPasscodeAttemptPersister struct {
+	db *pop.Connection
+}
+
+func NewFailedPasscodeAttemptPersister(db *pop.Connection) FailedPasscodeAttemptPersister {
+	return &failedPasscodeAttemptPersister{db: db}
+}
+
+func (p *failedPasscodeAttemptPersister) Create(attempt models.FailedPasscodeAttempt) error {
+	vErr, err := p.db.ValidateAndCreate(&attempt)
+	if err != nil {
+		return fmt.Errorf("failed to store failed passcode attempt: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("failed passcode attempt object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *failedPasscodeAttemptPersister) CountByUserIdSince(userId uuid.UUID, since time.Time) (int, error) {
+	count, err := p.db.
+		Where("user_id = ?", userId).
+		Where("created_at > ?", since).
+		Count(&models.FailedPasscodeAttempt{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count failed passcode attempts: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *failedPasscodeAttemptPersister) DeleteByUserId(userId uuid.UUID) error {
+	err := p.db.Where("user_id = ?", userId).Delete(&models.FailedPasscodeAttempt{})
+	if err != nil {
+		return fmt.Errorf("failed to delete failed passcode attempts: %w", err)
+	}
+
+	return nil
+}
+
+func (p *failedPasscodeAttemptPersister) DeleteCreatedBefore(before time.Time) (int, error) {
+	count, err := p.db.RawQuery("DELETE FROM failed_passcode_attempts WHERE created_at < ?", before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete failed passcode attempts: %w", err)
+	}
+
+	return count, nil
+}
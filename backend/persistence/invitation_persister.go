@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type InvitationPersister interface {
+	Get(id uuid.UUID) (*models.Invitation, error)
+	GetByToken(token string) (*models.Invitation, error)
+	// GetPendingByEmail returns the invitation for the email address which is neither accepted nor expired.
+	GetPendingByEmail(email string) (*models.Invitation, error)
+	List(page int, perPage int) ([]models.Invitation, error)
+	Count() (int, error)
+	Create(invitation models.Invitation) error
+	Update(invitation models.Invitation) error
+	Delete(invitation models.Invitation) error
+	// DeleteExpired deletes the invitations which have not been accepted and expired before the given time.
+	DeleteExpired(before time.Time) (int, error)
+}
+
+type invitationPersister struct {
+	db *pop.Connection
+}
+
+func NewInvitationPersister(db *pop.Connection) InvitationPersister {
+	return &invitationPersister{db: db}
+}
+
+func (p *invitationPersister) Get(id uuid.UUID) (*models.Invitation, error) {
+	invitation := models.Invitation{}
+	err := p.db.Find(&invitation, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (p *invitationPersister) GetByToken(token string) (*models.Invitation, error) {
+	invitation := models.Invitation{}
+	err := p.db.Where("token = ?", token).First(&invitation)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (p *invitationPersister) GetPendingByEmail(email string) (*models.Invitation, error) {
+	invitation := models.Invitation{}
+	err := p.db.
+		Where("email = ? AND accepted_at IS NULL AND expires_at > ?", email, time.Now().UTC()).
+		First(&invitation)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (p *invitationPersister) List(page int, perPage int) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	err := p.db.Q().Order("created_at desc").Paginate(page, perPage).All(&invitations)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+func (p *invitationPersister) Count() (int, error) {
+	count, err := p.db.Count(&models.Invitation{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get invitation count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *invitationPersister) Create(invitation models.Invitation) error {
+	vErr, err := p.db.ValidateAndCreate(&invitation)
+	if err != nil {
+		return fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("invitation object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *invitationPersister) Update(invitation models.Invitation) error {
+	vErr, err := p.db.ValidateAndUpdate(&invitation)
+	if err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("invitation object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *invitationPersister) Delete(invitation models.Invitation) error {
+	err := p.db.Destroy(&invitation)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+
+	return nil
+}
+
+func (p *invitationPersister) DeleteExpired(before time.Time) (int, error) {
+	count, err := p.db.RawQuery("DELETE FROM invitations WHERE accepted_at IS NULL AND expires_at < ?", before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired invitations: %w", err)
+	}
+
+	return count, nil
+}
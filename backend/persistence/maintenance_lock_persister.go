@@ -0,0 +1,34 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"time"
+)
+
+type MaintenanceLockPersister interface {
+	Acquire(name string, owner string, now time.Time, until time.Time) (bool, error)
+}
+
+type maintenanceLockPersister struct {
+	db *pop.Connection
+}
+
+func NewMaintenanceLockPersister(db *pop.Connection) MaintenanceLockPersister {
+	return &maintenanceLockPersister{db: db}
+}
+
+// Acquire takes the lock of the maintenance job with the given name until the given time, unless another instance
+// holds it. A single conditional update is used, so that only one of several concurrently acquiring instances succeeds.
+// The locks are created by migrations.
+func (p *maintenanceLockPersister) Acquire(name string, owner string, now time.Time, until time.Time) (bool, error) {
+	count, err := p.db.RawQuery(
+		"UPDATE maintenance_locks SET locked_by = ?, locked_until = ?, updated_at = ? WHERE name = ? AND locked_until <= ?",
+		owner, until, now, name, now,
+	).ExecWithCount()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire maintenance lock: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -6,24 +6,76 @@ import (
 )
 
 type AuditLog struct {
-	ID                uuid.UUID    `db:"id" json:"id"`
-	Type              AuditLogType `db:"type" json:"type"`
-	Error             *string      `db:"error" json:"error,omitempty"`
-	MetaHttpRequestId string       `db:"meta_http_request_id" json:"meta_http_request_id"`
-	MetaSourceIp      string       `db:"meta_source_ip" json:"meta_source_ip"`
-	MetaUserAgent     string       `db:"meta_user_agent" json:"meta_user_agent"`
-	ActorUserId       *uuid.UUID   `db:"actor_user_id" json:"actor_user_id,omitempty"`
-	ActorEmail        *string      `db:"actor_email" json:"actor_email,omitempty"`
-	CreatedAt         time.Time    `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time    `db:"updated_at" json:"updated_at"`
+	ID                uuid.UUID         `db:"id" json:"id"`
+	Type              AuditLogType      `db:"type" json:"type"`
+	Error             *string           `db:"error" json:"error,omitempty"`
+	MetaHttpRequestId string            `db:"meta_http_request_id" json:"meta_http_request_id"`
+	MetaSourceIp      string            `db:"meta_source_ip" json:"meta_source_ip"`
+	MetaUserAgent     string            `db:"meta_user_agent" json:"meta_user_agent"`
+	ActorUserId       *uuid.UUID        `db:"actor_user_id" json:"actor_user_id,omitempty"`
+	ActorEmail        *string           `db:"actor_email" json:"actor_email,omitempty"`
+	ActorType         AuditLogActorType `db:"actor_type" json:"actor_type"`
+	CreatedAt         time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time         `db:"updated_at" json:"updated_at"`
 }
 
+// AuditLogActorType is "admin" for actions performed via the admin API and "system" for actions performed by
+// maintenance jobs. In these cases ActorUserId and ActorEmail identify the affected user.
+type AuditLogActorType string
+
+const (
+	AuditLogActorTypeUser   AuditLogActorType = "user"
+	AuditLogActorTypeAdmin  AuditLogActorType = "admin"
+	AuditLogActorTypeSystem AuditLogActorType = "system"
+)
+
 type AuditLogType string
 
 var (
 	AuditLogUserCreated   AuditLogType = "user_created"
 	AuditLogUserLoggedOut AuditLogType = "user_logged_out"
 	AuditLogUserDeleted   AuditLogType = "user_deleted"
+	AuditLogUserRestored  AuditLogType = "user_restored"
+	AuditLogUserPurged    AuditLogType = "user_purged"
+
+	AuditLogUserLocked       AuditLogType = "user_locked"
+	AuditLogUserUnlocked     AuditLogType = "user_unlocked"
+	AuditLogUserUnlockFailed AuditLogType = "user_unlock_failed"
+
+	AuditLogUserBlocked   AuditLogType = "user_blocked"
+	AuditLogUserUnblocked AuditLogType = "user_unblocked"
+
+	AuditLogUsernameChanged AuditLogType = "username_changed"
+
+	AuditLogUserMetadataUpdated AuditLogType = "user_metadata_updated"
+
+	AuditLogUserProfileUpdated AuditLogType = "user_profile_updated"
+
+	AuditLogPersonalDataExported AuditLogType = "personal_data_exported"
+
+	AuditLogRoleCreated        AuditLogType = "role_created"
+	AuditLogRoleUpdated        AuditLogType = "role_updated"
+	AuditLogRoleDeleted        AuditLogType = "role_deleted"
+	AuditLogPermissionCreated  AuditLogType = "permission_created"
+	AuditLogPermissionUpdated  AuditLogType = "permission_updated"
+	AuditLogPermissionDeleted  AuditLogType = "permission_deleted"
+	AuditLogUserRoleAssigned   AuditLogType = "user_role_assigned"
+	AuditLogUserRoleUnassigned AuditLogType = "user_role_unassigned"
+
+	AuditLogOrganizationCreated       AuditLogType = "organization_created"
+	AuditLogOrganizationUpdated       AuditLogType = "organization_updated"
+	AuditLogOrganizationDeleted       AuditLogType = "organization_deleted"
+	AuditLogOrganizationMemberAdded   AuditLogType = "organization_member_added"
+	AuditLogOrganizationMemberUpdated AuditLogType = "organization_member_updated"
+	AuditLogOrganizationMemberRemoved AuditLogType = "organization_member_removed"
+	AuditLogOrganizationSwitched      AuditLogType = "organization_switched"
+	AuditLogOrganizationSwitchFailed  AuditLogType = "organization_switch_failed"
+
+	AuditLogInvitationCreated      AuditLogType = "invitation_created"
+	AuditLogInvitationResent       AuditLogType = "invitation_resent"
+	AuditLogInvitationRevoked      AuditLogType = "invitation_revoked"
+	AuditLogInvitationAccepted     AuditLogType = "invitation_accepted"
+	AuditLogInvitationAcceptFailed AuditLogType = "invitation_accept_failed"
 
 	AuditLogPasswordSetSucceeded AuditLogType = "password_set_succeeded"
 	AuditLogPasswordSetFailed    AuditLogType = "password_set_failed"
@@ -31,6 +83,11 @@ var (
 	AuditLogPasswordLoginSucceeded AuditLogType = "password_login_succeeded"
 	AuditLogPasswordLoginFailed    AuditLogType = "password_login_failed"
 
+	AuditLogPasswordResetInitSucceeded  AuditLogType = "password_reset_init_succeeded"
+	AuditLogPasswordResetInitFailed     AuditLogType = "password_reset_init_failed"
+	AuditLogPasswordResetFinalSucceeded AuditLogType = "password_reset_final_succeeded"
+	AuditLogPasswordResetFinalFailed    AuditLogType = "password_reset_final_failed"
+
 	AuditLogPasscodeLoginInitSucceeded  AuditLogType = "passcode_login_init_succeeded"
 	AuditLogPasscodeLoginInitFailed     AuditLogType = "passcode_login_init_failed"
 	AuditLogPasscodeLoginFinalSucceeded AuditLogType = "passcode_login_final_succeeded"
@@ -49,11 +106,24 @@ var (
 	AuditLogWebAuthnCredentialUpdated AuditLogType = "webauthn_credential_updated"
 	AuditLogWebAuthnCredentialDeleted AuditLogType = "webauthn_credential_deleted"
 
+	AuditLogWebAuthnCredentialsRevoked AuditLogType = "webauthn_credentials_revoked"
+
+	AuditLogWebAuthnCloneDetected           AuditLogType = "webauthn_clone_detected"
+	AuditLogWebAuthnCloneRejected           AuditLogType = "webauthn_clone_rejected"
+	AuditLogWebAuthnCloneCredentialDisabled AuditLogType = "webauthn_clone_credential_disabled"
+
 	AuditLogEmailCreated        AuditLogType = "email_created"
 	AuditLogEmailDeleted        AuditLogType = "email_deleted"
 	AuditLogEmailVerified       AuditLogType = "email_verified"
 	AuditLogPrimaryEmailChanged AuditLogType = "primary_email_changed"
 
+	AuditLogEmailChangeInitSucceeded  AuditLogType = "email_change_init_succeeded"
+	AuditLogEmailChangeInitFailed     AuditLogType = "email_change_init_failed"
+	AuditLogEmailChangeFinalSucceeded AuditLogType = "email_change_final_succeeded"
+	AuditLogEmailChangeFinalFailed    AuditLogType = "email_change_final_failed"
+	AuditLogEmailChangeReverted       AuditLogType = "email_change_reverted"
+	AuditLogEmailChangeRevertFailed   AuditLogType = "email_change_revert_failed"
+
 	AuditLogThirdPartySignUpSucceeded    AuditLogType = "thirdparty_signup_succeeded"
 	AuditLogThirdPartySignInSucceeded    AuditLogType = "thirdparty_signin_succeeded"
 	AuditLogThirdPartySignInSignUpFailed AuditLogType = "thirdparty_signin_signup_failed"
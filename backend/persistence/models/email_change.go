@@ -0,0 +1,62 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// EmailChange records the replacement of the primary email address OldAddress of a user with the email NewEmailID. A
+// notification with a revert link is sent to OldAddress, only the SHA-256 hash of the revert token is stored in
+// RevertToken.
+type EmailChange struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	UserID          uuid.UUID  `db:"user_id" json:"user_id"`
+	OldAddress      string     `db:"old_address" json:"old_address"`
+	NewEmailID      *uuid.UUID `db:"new_email_id" json:"new_email_id,omitempty"`
+	RevertToken     string     `db:"revert_token" json:"-"`
+	RevertExpiresAt time.Time  `db:"revert_expires_at" json:"revert_expires_at"`
+	RevertedAt      *time.Time `db:"reverted_at" json:"reverted_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func NewEmailChange(userId uuid.UUID, oldAddress string, newEmailId uuid.UUID, revertToken string, revertExpiresAt time.Time) EmailChange {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return EmailChange{
+		ID:              id,
+		UserID:          userId,
+		OldAddress:      oldAddress,
+		NewEmailID:      &newEmailId,
+		RevertToken:     revertToken,
+		RevertExpiresAt: revertExpiresAt,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// IsReverted returns true if the email change has already been reverted.
+func (emailChange *EmailChange) IsReverted() bool {
+	return emailChange.RevertedAt != nil
+}
+
+// IsRevertExpired returns true if the email change can no longer be reverted at the given time.
+func (emailChange *EmailChange) IsRevertExpired(now time.Time) bool {
+	return !now.Before(emailChange.RevertExpiresAt)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (emailChange *EmailChange) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: emailChange.ID},
+		&validators.UUIDIsPresent{Name: "UserID", Field: emailChange.UserID},
+		&validators.EmailIsPresent{Name: "OldAddress", Field: emailChange.OldAddress},
+		&validators.StringIsPresent{Name: "RevertToken", Field: emailChange.RevertToken},
+		&validators.TimeIsPresent{Name: "RevertExpiresAt", Field: emailChange.RevertExpiresAt},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: emailChange.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: emailChange.UpdatedAt},
+	), nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// FailedPasscodeAttempt records a wrong passcode entered by a user. The attempts are counted across all passcodes of a
+// user, so that requesting fresh passcodes does not reset the budget of failed attempts.
+type FailedPasscodeAttempt struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func NewFailedPasscodeAttempt(userID uuid.UUID) *FailedPasscodeAttempt {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return &FailedPasscodeAttempt{
+		ID:        id,
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (attempt *FailedPasscodeAttempt) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: attempt.ID},
+		&validators.UUIDIsPresent{Name: "UserID", Field: attempt.UserID},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: attempt.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: attempt.UpdatedAt},
+	), nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// Invitation allows the owner of Email to sign up, even when the signup is disabled. Only the SHA-256 hash of the
+// invitation token is stored in Token. Metadata is a merge patch with "public_metadata" and "private_metadata" keys,
+// which is applied to the user when the invitation is accepted. Language is the language of the invitation email, the
+// default language is used if it is not set.
+type Invitation struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	Email          string     `db:"email" json:"email"`
+	Token          string     `db:"token" json:"-"`
+	OrganizationID *uuid.UUID `db:"organization_id" json:"organization_id,omitempty"`
+	RoleID         *uuid.UUID `db:"role_id" json:"role_id,omitempty"`
+	Metadata       slices.Map `db:"metadata" json:"metadata,omitempty"`
+	Language       *string    `db:"language" json:"language,omitempty"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	AcceptedAt     *time.Time `db:"accepted_at" json:"accepted_at,omitempty"`
+	UserID         *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func NewInvitation(email string, token string, expiresAt time.Time) Invitation {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return Invitation{
+		ID:        id,
+		Email:     email,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsAccepted returns true if the invitation has already been used.
+func (invitation *Invitation) IsAccepted() bool {
+	return invitation.AcceptedAt != nil
+}
+
+// IsExpired returns true if the invitation can no longer be accepted at the given time.
+func (invitation *Invitation) IsExpired(now time.Time) bool {
+	return !now.Before(invitation.ExpiresAt)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (invitation *Invitation) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: invitation.ID},
+		&validators.EmailIsPresent{Name: "Email", Field: invitation.Email},
+		&validators.StringIsPresent{Name: "Token", Field: invitation.Token},
+		&validators.TimeIsPresent{Name: "ExpiresAt", Field: invitation.ExpiresAt},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: invitation.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: invitation.UpdatedAt},
+	), nil
+}
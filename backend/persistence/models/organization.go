@@ -0,0 +1,78 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// Organization is a tenant, e.g. a customer company, which users belong to through memberships.
+type Organization struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Name string    `db:"name" json:"name"`
+	// Slug is the unique, URL-safe identifier of the organization, e.g. "acme-corp".
+	Slug      string    `db:"slug" json:"slug"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func NewOrganization(name string, slug string) Organization {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return Organization{
+		ID:        id,
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (organization *Organization) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: organization.ID},
+		&validators.StringIsPresent{Name: "Name", Field: organization.Name},
+		&validators.StringIsPresent{Name: "Slug", Field: organization.Slug},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: organization.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: organization.UpdatedAt},
+	), nil
+}
+
+// OrganizationMembership makes a user a member of an organization. RoleID is the role of the user within the
+// organization and nil if the member has no role.
+type OrganizationMembership struct {
+	ID             uuid.UUID     `db:"id" json:"id"`
+	OrganizationID uuid.UUID     `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID     `db:"user_id" json:"user_id"`
+	RoleID         *uuid.UUID    `db:"role_id" json:"role_id,omitempty"`
+	Organization   *Organization `belongs_to:"organization" json:"organization,omitempty"`
+	CreatedAt      time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+func NewOrganizationMembership(organizationID uuid.UUID, userID uuid.UUID, roleID *uuid.UUID) OrganizationMembership {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return OrganizationMembership{
+		ID:             id,
+		OrganizationID: organizationID,
+		UserID:         userID,
+		RoleID:         roleID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (membership *OrganizationMembership) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: membership.ID},
+		&validators.UUIDIsPresent{Name: "OrganizationID", Field: membership.OrganizationID},
+		&validators.UUIDIsPresent{Name: "UserID", Field: membership.UserID},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: membership.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: membership.UpdatedAt},
+	), nil
+}
@@ -8,17 +8,30 @@ import (
 	"time"
 )
 
+// PasscodePurpose describes what a passcode may be used for. A passcode can only be redeemed for the purpose it
+// was issued for.
+type PasscodePurpose string
+
+const (
+	PasscodePurposeLogin             PasscodePurpose = "login"
+	PasscodePurposeEmailVerification PasscodePurpose = "email_verification"
+	PasscodePurposeAccountRecovery   PasscodePurpose = "account_recovery"
+	PasscodePurposeSignup            PasscodePurpose = "signup"
+	PasscodePurposeEmailChange       PasscodePurpose = "email_change"
+)
+
 // Passcode is used by pop to map your passcodes database table to your go code.
 type Passcode struct {
-	ID        uuid.UUID `db:"id"`
-	UserId    uuid.UUID `db:"user_id"`
-	EmailID   uuid.UUID `db:"email_id"`
-	Ttl       int       `db:"ttl"` // in seconds
-	Code      string    `db:"code"`
-	TryCount  int       `db:"try_count"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
-	Email     Email     `belongs_to:"email"`
+	ID        uuid.UUID       `db:"id"`
+	UserId    uuid.UUID       `db:"user_id"`
+	EmailID   uuid.UUID       `db:"email_id"`
+	Ttl       int             `db:"ttl"` // in seconds
+	Code      string          `db:"code"`
+	TryCount  int             `db:"try_count"`
+	Purpose   PasscodePurpose `db:"purpose"`
+	CreatedAt time.Time       `db:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at"`
+	Email     Email           `belongs_to:"email"`
 }
 
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
@@ -27,6 +40,13 @@ func (passcode *Passcode) Validate(tx *pop.Connection) (*validate.Errors, error)
 		&validators.UUIDIsPresent{Name: "ID", Field: passcode.ID},
 		&validators.UUIDIsPresent{Name: "UserID", Field: passcode.UserId},
 		&validators.StringLengthInRange{Name: "Code", Field: passcode.Code, Min: 6},
+		&validators.StringInclusion{Name: "Purpose", Field: string(passcode.Purpose), List: []string{
+			string(PasscodePurposeLogin),
+			string(PasscodePurposeEmailVerification),
+			string(PasscodePurposeAccountRecovery),
+			string(PasscodePurposeSignup),
+			string(PasscodePurposeEmailChange),
+		}},
 		&validators.TimeIsPresent{Name: "CreatedAt", Field: passcode.CreatedAt},
 		&validators.TimeIsPresent{Name: "UpdatedAt", Field: passcode.UpdatedAt},
 	), nil
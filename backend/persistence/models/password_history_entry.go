@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// PasswordHistoryEntry keeps the hash of a password a user has set, so that recently used passwords can be rejected.
+type PasswordHistoryEntry struct {
+	ID        uuid.UUID `db:"id"`
+	UserId    uuid.UUID `db:"user_id"`
+	Password  string    `db:"password"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func NewPasswordHistoryEntry(userId uuid.UUID, hashedPassword string) *PasswordHistoryEntry {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return &PasswordHistoryEntry{
+		ID:        id,
+		UserId:    userId,
+		Password:  hashedPassword,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (entry *PasswordHistoryEntry) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: entry.ID},
+		&validators.UUIDIsPresent{Name: "UserId", Field: entry.UserId},
+		&validators.StringIsPresent{Name: "Password", Field: entry.Password},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: entry.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: entry.UpdatedAt},
+	), nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// Permission is a named permission, e.g. "documents:write", which is granted to users through their roles.
+type Permission struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description *string   `db:"description" json:"description,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type Permissions []Permission
+
+func NewPermission(name string, description *string) Permission {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return Permission{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// GetNames returns the names of the permissions.
+func (permissions Permissions) GetNames() []string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = permission.Name
+	}
+	return names
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (permission *Permission) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: permission.ID},
+		&validators.StringIsPresent{Name: "Name", Field: permission.Name},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: permission.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: permission.UpdatedAt},
+	), nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// Role is a named set of permissions which can be assigned to users.
+type Role struct {
+	ID          uuid.UUID   `db:"id" json:"id"`
+	Name        string      `db:"name" json:"name"`
+	Description *string     `db:"description" json:"description,omitempty"`
+	Permissions Permissions `many_to_many:"role_permissions" json:"permissions"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+type Roles []Role
+
+func NewRole(name string, description *string) Role {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return Role{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		Permissions: Permissions{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// GetNames returns the names of the roles.
+func (roles Roles) GetNames() []string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
+// GetPermissionNames returns the names of all permissions granted by the roles without duplicates.
+func (roles Roles) GetPermissionNames() []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				names = append(names, permission.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (role *Role) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: role.ID},
+		&validators.StringIsPresent{Name: "Name", Field: role.Name},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: role.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: role.UpdatedAt},
+	), nil
+}
+
+// RolePermission grants a permission to a role.
+type RolePermission struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	RoleID       uuid.UUID `db:"role_id" json:"role_id"`
+	PermissionID uuid.UUID `db:"permission_id" json:"permission_id"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func NewRolePermission(roleID uuid.UUID, permissionID uuid.UUID) RolePermission {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return RolePermission{
+		ID:           id,
+		RoleID:       roleID,
+		PermissionID: permissionID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// UserRole assigns a role to a user.
+type UserRole struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	RoleID    uuid.UUID `db:"role_id" json:"role_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func NewUserRole(userID uuid.UUID, roleID uuid.UUID) UserRole {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return UserRole{
+		ID:        id,
+		UserID:    userID,
+		RoleID:    roleID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoles_GetPermissionNames(t *testing.T) {
+	roles := Roles{
+		{Name: "editor", Permissions: Permissions{{Name: "documents:read"}, {Name: "documents:write"}}},
+		{Name: "viewer", Permissions: Permissions{{Name: "documents:read"}}},
+		{Name: "guest"},
+	}
+
+	assert.Equal(t, []string{"editor", "viewer", "guest"}, roles.GetNames())
+	assert.Equal(t, []string{"documents:read", "documents:write"}, roles.GetPermissionNames())
+	assert.Equal(t, []string{}, Roles{}.GetPermissionNames())
+}
@@ -16,8 +16,11 @@ type Session struct {
 	UserID    uuid.UUID `db:"user_id"`
 	Used      bool      `db:"used"`
 	UsedCount int       `db:"used_count"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	// ActiveOrganizationID is the organization selected for the session. It is kept when the refresh token is
+	// exchanged.
+	ActiveOrganizationID *uuid.UUID `db:"active_organization_id"`
+	CreatedAt            time.Time  `db:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at"`
 }
 
 func NewSession(userID uuid.UUID) (*Session, error) {
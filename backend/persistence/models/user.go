@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/pop/v6/slices"
 	"github.com/gobuffalo/validate/v3"
 	"github.com/gobuffalo/validate/v3/validators"
 	"github.com/gofrs/uuid"
@@ -13,6 +14,20 @@ type User struct {
 	ID                  uuid.UUID            `db:"id" json:"id"`
 	WebauthnCredentials []WebauthnCredential `has_many:"webauthn_credentials" json:"webauthn_credentials,omitempty"`
 	Emails              Emails               `has_many:"emails" json:"-"`
+	Lockout             *UserLockout         `has_one:"user_lockouts" json:"-"`
+	DisplayName         *string              `db:"display_name" json:"display_name,omitempty"`
+	GivenName           *string              `db:"given_name" json:"given_name,omitempty"`
+	FamilyName          *string              `db:"family_name" json:"family_name,omitempty"`
+	AvatarURL           *string              `db:"avatar_url" json:"avatar_url,omitempty"`
+	Locale              *string              `db:"locale" json:"locale,omitempty"`
+	Username            *string              `db:"username" json:"username,omitempty"`
+	PublicMetadata      slices.Map           `db:"public_metadata" json:"public_metadata,omitempty"`
+	PrivateMetadata     slices.Map           `db:"private_metadata" json:"-"`
+	UnsafeMetadata      slices.Map           `db:"unsafe_metadata" json:"unsafe_metadata,omitempty"`
+	BlockedAt           *time.Time           `db:"blocked_at" json:"-"`
+	BlockedUntil        *time.Time           `db:"blocked_until" json:"-"`
+	BlockedReason       *string              `db:"blocked_reason" json:"-"`
+	DeletedAt           *time.Time           `db:"deleted_at" json:"-"`
 	CreatedAt           time.Time            `db:"created_at" json:"created_at"`
 	UpdatedAt           time.Time            `db:"updated_at" json:"updated_at"`
 }
@@ -35,6 +50,16 @@ func (user *User) GetEmailById(emailId uuid.UUID) *Email {
 	return nil
 }
 
+// IsBlocked reports whether the user is blocked at the given time. A block without BlockedUntil does not expire.
+func (user *User) IsBlocked(now time.Time) bool {
+	return user.BlockedAt != nil && (user.BlockedUntil == nil || user.BlockedUntil.After(now))
+}
+
+// IsDeleted reports whether the user has been soft deleted.
+func (user *User) IsDeleted() bool {
+	return user.DeletedAt != nil
+}
+
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
 func (user *User) Validate(tx *pop.Connection) (*validate.Errors, error) {
 	return validate.Validate(
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// UserLockout holds the failed login attempts of a user across all login methods and whether the account is
+// temporarily locked because of them. LockoutCount is the number of consecutive lockouts, it determines the duration
+// of the next one.
+type UserLockout struct {
+	ID                   uuid.UUID  `db:"id" json:"id"`
+	UserID               uuid.UUID  `db:"user_id" json:"user_id"`
+	FailedAttempts       int        `db:"failed_attempts" json:"failed_attempts"`
+	WindowStartedAt      *time.Time `db:"window_started_at" json:"window_started_at,omitempty"`
+	LockoutCount         int        `db:"lockout_count" json:"lockout_count"`
+	LockedUntil          *time.Time `db:"locked_until" json:"locked_until,omitempty"`
+	UnlockToken          *string    `db:"unlock_token" json:"-"`
+	UnlockTokenExpiresAt *time.Time `db:"unlock_token_expires_at" json:"-"`
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func NewUserLockout(userID uuid.UUID) *UserLockout {
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return &UserLockout{
+		ID:        id,
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsLocked reports whether the account is locked at the given time.
+func (lockout *UserLockout) IsLocked(now time.Time) bool {
+	return lockout.LockedUntil != nil && lockout.LockedUntil.After(now)
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (lockout *UserLockout) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: lockout.ID},
+		&validators.UUIDIsPresent{Name: "UserID", Field: lockout.UserID},
+		&validators.IntIsGreaterThan{Name: "FailedAttempts", Field: lockout.FailedAttempts, Compared: -1},
+		&validators.IntIsGreaterThan{Name: "LockoutCount", Field: lockout.LockoutCount, Compared: -1},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: lockout.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: lockout.UpdatedAt},
+	), nil
+}
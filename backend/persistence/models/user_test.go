@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUser_IsBlocked(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		user     User
+		expected bool
+	}{
+		{name: "not blocked", user: User{}},
+		{name: "blocked without expiry", user: User{BlockedAt: &past}, expected: true},
+		{name: "suspended until the future", user: User{BlockedAt: &past, BlockedUntil: &future}, expected: true},
+		{name: "suspension expired", user: User{BlockedAt: &past, BlockedUntil: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.user.IsBlocked(now))
+		})
+	}
+}
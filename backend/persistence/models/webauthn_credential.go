@@ -23,6 +23,22 @@ type WebauthnCredential struct {
 	Transports      Transports `has_many:"webauthn_credential_transports" json:"-"`
 	BackupEligible  bool       `db:"backup_eligible" json:"-"`
 	BackupState     bool       `db:"backup_state" json:"-"`
+	// AttestationFormat is the format of the attestation statement conveyed on registration, e.g. "packed".
+	AttestationFormat *string `db:"attestation_format" json:"-"`
+	// AttestationTrust is the result of the evaluation of the attestation on registration: "none", "self",
+	// "untrusted" or "trusted". It is not set for credentials registered before attestations were evaluated.
+	AttestationTrust *string `db:"attestation_trust" json:"-"`
+	// SuspectedClone is set when the signature counter of an assertion did not increase.
+	SuspectedClone bool `db:"suspected_clone" json:"-"`
+	// DisabledAt is set when the credential has been disabled. Disabled credentials can not be used for logins.
+	DisabledAt *time.Time `db:"disabled_at" json:"-"`
+	// PrfSupported is set when the authenticator reported on registration that the credential supports the PRF
+	// extension.
+	PrfSupported bool `db:"prf_supported" json:"-"`
+}
+
+func (credential *WebauthnCredential) IsDisabled() bool {
+	return credential.DisabledAt != nil
 }
 
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// WebauthnPrfSalt is the salt which is evaluated with the PRF extension of the credentials of a user. The same salt
+// is used for all credentials of the user, so that the frontend derives a stable key per credential.
+type WebauthnPrfSalt struct {
+	ID     uuid.UUID `db:"id" json:"id"`
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	// Salt is base64url encoded without padding.
+	Salt      string    `db:"salt" json:"-"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// NewWebauthnPrfSalt creates a salt of 32 random bytes for the user.
+func NewWebauthnPrfSalt(userID uuid.UUID) (*WebauthnPrfSalt, error) {
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate prf salt: %w", err)
+	}
+
+	id, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	return &WebauthnPrfSalt{
+		ID:        id,
+		UserID:    userID,
+		Salt:      base64.RawURLEncoding.EncodeToString(salt),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
+func (salt *WebauthnPrfSalt) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.UUIDIsPresent{Name: "ID", Field: salt.ID},
+		&validators.UUIDIsPresent{Name: "UserID", Field: salt.UserID},
+		&validators.StringIsPresent{Name: "Salt", Field: salt.Salt},
+		&validators.TimeIsPresent{Name: "CreatedAt", Field: salt.CreatedAt},
+		&validators.TimeIsPresent{Name: "UpdatedAt", Field: salt.UpdatedAt},
+	), nil
+}
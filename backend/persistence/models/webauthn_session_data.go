@@ -14,6 +14,8 @@ type Operation string
 var (
 	WebauthnOperationRegistration   Operation = "registration"
 	WebauthnOperationAuthentication Operation = "authentication"
+	// WebauthnOperationSignup is a registration of the first credential of a user which does not exist yet.
+	WebauthnOperationSignup Operation = "signup"
 )
 
 // WebauthnSessionData is used by pop to map your webauthn_session_data database table to your go code.
@@ -27,6 +29,8 @@ type WebauthnSessionData struct {
 	Operation          Operation                              `db:"operation"`
 	AllowedCredentials []WebauthnSessionDataAllowedCredential `has_many:"webauthn_session_data_allowed_credentials"`
 	ExpiresAt          nulls.Time                             `db:"expires_at"`
+	// UserDisplayName is the display name of the user to be created on signup.
+	UserDisplayName nulls.String `db:"user_display_name"`
 }
 
 // Validate gets run every time you call a "pop.Validate*" (pop.ValidateAndSave, pop.ValidateAndCreate, pop.ValidateAndUpdate) method.
@@ -34,7 +38,7 @@ func (sd *WebauthnSessionData) Validate(tx *pop.Connection) (*validate.Errors, e
 	return validate.Validate(
 		&validators.UUIDIsPresent{Name: "ID", Field: sd.ID},
 		&validators.StringIsPresent{Name: "Challenge", Field: sd.Challenge},
-		&validators.StringInclusion{Name: "Operation", Field: string(sd.Operation), List: []string{string(WebauthnOperationRegistration), string(WebauthnOperationAuthentication)}},
+		&validators.StringInclusion{Name: "Operation", Field: string(sd.Operation), List: []string{string(WebauthnOperationRegistration), string(WebauthnOperationAuthentication), string(WebauthnOperationSignup)}},
 		&validators.TimeIsPresent{Name: "UpdatedAt", Field: sd.UpdatedAt},
 		&validators.TimeIsPresent{Name: "CreatedAt", Field: sd.CreatedAt},
 	), nil
@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type OrganizationMembershipPersister interface {
+	Get(organizationId uuid.UUID, userId uuid.UUID) (*models.OrganizationMembership, error)
+	ListByOrganizationId(organizationId uuid.UUID) ([]models.OrganizationMembership, error)
+	// ListByUserId returns the memberships of the user including their organization, ordered by organization name.
+	ListByUserId(userId uuid.UUID) ([]models.OrganizationMembership, error)
+	Create(membership models.OrganizationMembership) error
+	Update(membership models.OrganizationMembership) error
+	Delete(membership models.OrganizationMembership) error
+}
+
+type organizationMembershipPersister struct {
+	db *pop.Connection
+}
+
+func NewOrganizationMembershipPersister(db *pop.Connection) OrganizationMembershipPersister {
+	return &organizationMembershipPersister{db: db}
+}
+
+func (p *organizationMembershipPersister) Get(organizationId uuid.UUID, userId uuid.UUID) (*models.OrganizationMembership, error) {
+	membership := models.OrganizationMembership{}
+	err := p.db.EagerPreload("Organization").Where("organization_id = ? AND user_id = ?", organizationId, userId).First(&membership)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+
+	return &membership, nil
+}
+
+func (p *organizationMembershipPersister) ListByOrganizationId(organizationId uuid.UUID) ([]models.OrganizationMembership, error) {
+	memberships := []models.OrganizationMembership{}
+	err := p.db.Where("organization_id = ?", organizationId).Order("created_at asc").All(&memberships)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch organization memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+func (p *organizationMembershipPersister) ListByUserId(userId uuid.UUID) ([]models.OrganizationMembership, error) {
+	memberships := []models.OrganizationMembership{}
+	err := p.db.
+		EagerPreload("Organization").
+		Join("organizations", "organizations.id = organization_memberships.organization_id").
+		Where("organization_memberships.user_id = ?", userId).
+		Order("organizations.name asc").
+		All(&memberships)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch organization memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+func (p *organizationMembershipPersister) Create(membership models.OrganizationMembership) error {
+	vErr, err := p.db.ValidateAndCreate(&membership)
+	if err != nil {
+		return fmt.Errorf("failed to store organization membership: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("organization membership object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *organizationMembershipPersister) Update(membership models.OrganizationMembership) error {
+	vErr, err := p.db.ValidateAndUpdate(&membership)
+	if err != nil {
+		return fmt.Errorf("failed to update organization membership: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("organization membership object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *organizationMembershipPersister) Delete(membership models.OrganizationMembership) error {
+	err := p.db.Destroy(&membership)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization membership: %w", err)
+	}
+
+	return nil
+}
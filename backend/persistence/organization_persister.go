@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type OrganizationPersister interface {
+	Get(id uuid.UUID) (*models.Organization, error)
+	GetBySlug(slug string) (*models.Organization, error)
+	List(page int, perPage int) ([]models.Organization, error)
+	Count() (int, error)
+	Create(organization models.Organization) error
+	Update(organization models.Organization) error
+	Delete(organization models.Organization) error
+}
+
+type organizationPersister struct {
+	db *pop.Connection
+}
+
+func NewOrganizationPersister(db *pop.Connection) OrganizationPersister {
+	return &organizationPersister{db: db}
+}
+
+func (p *organizationPersister) Get(id uuid.UUID) (*models.Organization, error) {
+	organization := models.Organization{}
+	err := p.db.Find(&organization, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return &organization, nil
+}
+
+func (p *organizationPersister) GetBySlug(slug string) (*models.Organization, error) {
+	organization := models.Organization{}
+	err := p.db.Where("slug = ?", slug).First(&organization)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return &organization, nil
+}
+
+func (p *organizationPersister) List(page int, perPage int) ([]models.Organization, error) {
+	organizations := []models.Organization{}
+	err := p.db.Q().Order("name asc").Paginate(page, perPage).All(&organizations)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch organizations: %w", err)
+	}
+
+	return organizations, nil
+}
+
+func (p *organizationPersister) Count() (int, error) {
+	count, err := p.db.Count(&models.Organization{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get organization count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *organizationPersister) Create(organization models.Organization) error {
+	vErr, err := p.db.ValidateAndCreate(&organization)
+	if err != nil {
+		return fmt.Errorf("failed to store organization: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("organization object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *organizationPersister) Update(organization models.Organization) error {
+	vErr, err := p.db.ValidateAndUpdate(&organization)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("organization object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *organizationPersister) Delete(organization models.Organization) error {
+	err := p.db.Destroy(&organization)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	return nil
+}
@@ -7,13 +7,16 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 type PasscodePersister interface {
 	Get(uuid.UUID) (*models.Passcode, error)
+	FindByEmailId(uuid.UUID) ([]models.Passcode, error)
 	Create(models.Passcode) error
 	Update(models.Passcode) error
 	Delete(models.Passcode) error
+	DeleteCreatedBefore(before time.Time) (int, error)
 }
 
 type passcodePersister struct {
@@ -37,6 +40,20 @@ func (p *passcodePersister) Get(id uuid.UUID) (*models.Passcode, error) {
 	return &passcode, nil
 }
 
+// FindByEmailId returns all passcodes sent to the given email address, newest first.
+func (p *passcodePersister) FindByEmailId(emailId uuid.UUID) ([]models.Passcode, error) {
+	var passcodes []models.Passcode
+	err := p.db.Where("email_id = ?", emailId).Order("created_at desc").All(&passcodes)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return passcodes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passcodes: %w", err)
+	}
+
+	return passcodes, nil
+}
+
 func (p *passcodePersister) Create(passcode models.Passcode) error {
 	vErr, err := p.db.ValidateAndCreate(&passcode)
 	if err != nil {
@@ -71,3 +88,12 @@ func (p *passcodePersister) Delete(passcode models.Passcode) error {
 
 	return nil
 }
+
+func (p *passcodePersister) DeleteCreatedBefore(before time.Time) (int, error) {
+	count, err := p.db.RawQuery("DELETE FROM passcodes WHERE created_at < ?", before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete passcodes: %w", err)
+	}
+
+	return count, nil
+}
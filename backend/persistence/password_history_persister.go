@@ -0,0 +1,68 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type PasswordHistoryPersister interface {
+	Create(entry models.PasswordHistoryEntry) error
+	ListByUserId(userId uuid.UUID, limit int) ([]models.PasswordHistoryEntry, error)
+	DeleteOlderByUserId(userId uuid.UUID, keep int) error
+}
+
+type passwordHistoryPersister struct {
+	db *pop.Connection
+}
+
+func NewPasswordHistoryPersister(db *pop.Connection) PasswordHistoryPersister {
+	return &passwordHistoryPersister{db: db}
+}
+
+func (p *passwordHistoryPersister) Create(entry models.PasswordHistoryEntry) error {
+	vErr, err := p.db.ValidateAndCreate(&entry)
+	if err != nil {
+		return fmt.Errorf("failed to store password history entry: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("password history entry object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+// ListByUserId returns the most recent password history entries of the user, newest first.
+func (p *passwordHistoryPersister) ListByUserId(userId uuid.UUID, limit int) ([]models.PasswordHistoryEntry, error) {
+	var entries []models.PasswordHistoryEntry
+	err := p.db.
+		Where("user_id = ?", userId).
+		Order("created_at desc").
+		Limit(limit).
+		All(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteOlderByUserId deletes all but the given number of most recent password history entries of the user.
+func (p *passwordHistoryPersister) DeleteOlderByUserId(userId uuid.UUID, keep int) error {
+	var entries []models.PasswordHistoryEntry
+	err := p.db.Where("user_id = ?", userId).Order("created_at desc").All(&entries)
+	if err != nil {
+		return fmt.Errorf("failed to get password history: %w", err)
+	}
+
+	for i := keep; i < len(entries); i++ {
+		err = p.db.Destroy(&entries[i])
+		if err != nil {
+			return fmt.Errorf("failed to delete password history entry: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type PermissionPersister interface {
+	Get(id uuid.UUID) (*models.Permission, error)
+	GetByName(name string) (*models.Permission, error)
+	List() (models.Permissions, error)
+	Create(permission models.Permission) error
+	Update(permission models.Permission) error
+	Delete(permission models.Permission) error
+}
+
+type permissionPersister struct {
+	db *pop.Connection
+}
+
+func NewPermissionPersister(db *pop.Connection) PermissionPersister {
+	return &permissionPersister{db: db}
+}
+
+func (p *permissionPersister) Get(id uuid.UUID) (*models.Permission, error) {
+	permission := models.Permission{}
+	err := p.db.Find(&permission, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permission: %w", err)
+	}
+
+	return &permission, nil
+}
+
+func (p *permissionPersister) GetByName(name string) (*models.Permission, error) {
+	permission := models.Permission{}
+	err := p.db.Where("name = ?", name).First(&permission)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permission: %w", err)
+	}
+
+	return &permission, nil
+}
+
+func (p *permissionPersister) List() (models.Permissions, error) {
+	permissions := models.Permissions{}
+	err := p.db.Order("name asc").All(&permissions)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (p *permissionPersister) Create(permission models.Permission) error {
+	vErr, err := p.db.ValidateAndCreate(&permission)
+	if err != nil {
+		return fmt.Errorf("failed to store permission: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("permission object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *permissionPersister) Update(permission models.Permission) error {
+	vErr, err := p.db.ValidateAndUpdate(&permission)
+	if err != nil {
+		return fmt.Errorf("failed to update permission: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("permission object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *permissionPersister) Delete(permission models.Permission) error {
+	err := p.db.Destroy(&permission)
+	if err != nil {
+		return fmt.Errorf("failed to delete permission: %w", err)
+	}
+
+	return nil
+}
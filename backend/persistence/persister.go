@@ -29,6 +29,15 @@ type Persister interface {
 	GetUserPersisterWithConnection(tx *pop.Connection) UserPersister
 	GetPasscodePersister() PasscodePersister
 	GetPasscodePersisterWithConnection(tx *pop.Connection) PasscodePersister
+	GetFailedPasscodeAttemptPersister() FailedPasscodeAttemptPersister
+	GetFailedPasscodeAttemptPersisterWithConnection(tx *pop.Connection) FailedPasscodeAttemptPersister
+	GetPasswordHistoryPersister() PasswordHistoryPersister
+	GetPasswordHistoryPersisterWithConnection(tx *pop.Connection) PasswordHistoryPersister
+	GetUserLockoutPersister() UserLockoutPersister
+	GetUserLockoutPersisterWithConnection(tx *pop.Connection) UserLockoutPersister
+	GetWebauthnPrfSaltPersister() WebauthnPrfSaltPersister
+	GetWebauthnPrfSaltPersisterWithConnection(tx *pop.Connection) WebauthnPrfSaltPersister
+	GetMaintenanceLockPersister() MaintenanceLockPersister
 	GetPasswordCredentialPersister() PasswordCredentialPersister
 	GetPasswordCredentialPersisterWithConnection(tx *pop.Connection) PasswordCredentialPersister
 	GetWebauthnCredentialPersister() WebauthnCredentialPersister
@@ -47,6 +56,18 @@ type Persister interface {
 	GetTokenPersisterWithConnection(tx *pop.Connection) TokenPersister
 	GetSessionPersister() SessionPersister
 	GetSessionPersisterWithConnection(tx *pop.Connection) SessionPersister
+	GetRolePersister() RolePersister
+	GetRolePersisterWithConnection(tx *pop.Connection) RolePersister
+	GetPermissionPersister() PermissionPersister
+	GetPermissionPersisterWithConnection(tx *pop.Connection) PermissionPersister
+	GetOrganizationPersister() OrganizationPersister
+	GetOrganizationPersisterWithConnection(tx *pop.Connection) OrganizationPersister
+	GetOrganizationMembershipPersister() OrganizationMembershipPersister
+	GetOrganizationMembershipPersisterWithConnection(tx *pop.Connection) OrganizationMembershipPersister
+	GetInvitationPersister() InvitationPersister
+	GetInvitationPersisterWithConnection(tx *pop.Connection) InvitationPersister
+	GetEmailChangePersister() EmailChangePersister
+	GetEmailChangePersisterWithConnection(tx *pop.Connection) EmailChangePersister
 	Health() error
 	HealthWithConnection(tx *pop.Connection) error
 }
@@ -180,6 +201,42 @@ func (p *persister) GetPasscodePersisterWithConnection(tx *pop.Connection) Passc
 	return NewPasscodePersister(tx)
 }
 
+func (p *persister) GetFailedPasscodeAttemptPersister() FailedPasscodeAttemptPersister {
+	return NewFailedPasscodeAttemptPersister(p.DB)
+}
+
+func (p *persister) GetFailedPasscodeAttemptPersisterWithConnection(tx *pop.Connection) FailedPasscodeAttemptPersister {
+	return NewFailedPasscodeAttemptPersister(tx)
+}
+
+func (p *persister) GetPasswordHistoryPersister() PasswordHistoryPersister {
+	return NewPasswordHistoryPersister(p.DB)
+}
+
+func (p *persister) GetPasswordHistoryPersisterWithConnection(tx *pop.Connection) PasswordHistoryPersister {
+	return NewPasswordHistoryPersister(tx)
+}
+
+func (p *persister) GetUserLockoutPersister() UserLockoutPersister {
+	return NewUserLockoutPersister(p.DB)
+}
+
+func (p *persister) GetUserLockoutPersisterWithConnection(tx *pop.Connection) UserLockoutPersister {
+	return NewUserLockoutPersister(tx)
+}
+
+func (p *persister) GetWebauthnPrfSaltPersister() WebauthnPrfSaltPersister {
+	return NewWebauthnPrfSaltPersister(p.DB)
+}
+
+func (p *persister) GetWebauthnPrfSaltPersisterWithConnection(tx *pop.Connection) WebauthnPrfSaltPersister {
+	return NewWebauthnPrfSaltPersister(tx)
+}
+
+func (p *persister) GetMaintenanceLockPersister() MaintenanceLockPersister {
+	return NewMaintenanceLockPersister(p.DB)
+}
+
 func (p *persister) GetPasswordCredentialPersister() PasswordCredentialPersister {
 	return NewPasswordCredentialPersister(p.DB)
 }
@@ -256,6 +313,54 @@ func (p *persister) GetSessionPersisterWithConnection(tx *pop.Connection) Sessio
 	return NewSessionPersister(tx)
 }
 
+func (p *persister) GetRolePersister() RolePersister {
+	return NewRolePersister(p.DB)
+}
+
+func (p *persister) GetRolePersisterWithConnection(tx *pop.Connection) RolePersister {
+	return NewRolePersister(tx)
+}
+
+func (p *persister) GetPermissionPersister() PermissionPersister {
+	return NewPermissionPersister(p.DB)
+}
+
+func (p *persister) GetPermissionPersisterWithConnection(tx *pop.Connection) PermissionPersister {
+	return NewPermissionPersister(tx)
+}
+
+func (p *persister) GetOrganizationPersister() OrganizationPersister {
+	return NewOrganizationPersister(p.DB)
+}
+
+func (p *persister) GetOrganizationPersisterWithConnection(tx *pop.Connection) OrganizationPersister {
+	return NewOrganizationPersister(tx)
+}
+
+func (p *persister) GetOrganizationMembershipPersister() OrganizationMembershipPersister {
+	return NewOrganizationMembershipPersister(p.DB)
+}
+
+func (p *persister) GetOrganizationMembershipPersisterWithConnection(tx *pop.Connection) OrganizationMembershipPersister {
+	return NewOrganizationMembershipPersister(tx)
+}
+
+func (p *persister) GetInvitationPersister() InvitationPersister {
+	return NewInvitationPersister(p.DB)
+}
+
+func (p *persister) GetInvitationPersisterWithConnection(tx *pop.Connection) InvitationPersister {
+	return NewInvitationPersister(tx)
+}
+
+func (p *persister) GetEmailChangePersister() EmailChangePersister {
+	return NewEmailChangePersister(p.DB)
+}
+
+func (p *persister) GetEmailChangePersisterWithConnection(tx *pop.Connection) EmailChangePersister {
+	return NewEmailChangePersister(tx)
+}
+
 func (p *persister) Health() error {
 	return p.DB.RawQuery("SELECT 1").Exec()
 }
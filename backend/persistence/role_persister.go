@@ -0,0 +1,163 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type RolePersister interface {
+	Get(id uuid.UUID) (*models.Role, error)
+	GetByName(name string) (*models.Role, error)
+	List() (models.Roles, error)
+	ListByUserId(userId uuid.UUID) (models.Roles, error)
+	Create(role models.Role) error
+	Update(role models.Role) error
+	Delete(role models.Role) error
+	// SetPermissions replaces the permissions granted by the role.
+	SetPermissions(roleId uuid.UUID, permissions models.Permissions) error
+	// AssignToUser assigns the role to the user. Assigning a role twice is a no-op.
+	AssignToUser(userId uuid.UUID, roleId uuid.UUID) error
+	UnassignFromUser(userId uuid.UUID, roleId uuid.UUID) error
+}
+
+type rolePersister struct {
+	db *pop.Connection
+}
+
+func NewRolePersister(db *pop.Connection) RolePersister {
+	return &rolePersister{db: db}
+}
+
+func (p *rolePersister) Get(id uuid.UUID) (*models.Role, error) {
+	role := models.Role{}
+	err := p.db.EagerPreload("Permissions").Find(&role, id)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return &role, nil
+}
+
+func (p *rolePersister) GetByName(name string) (*models.Role, error) {
+	role := models.Role{}
+	err := p.db.EagerPreload("Permissions").Where("name = ?", name).First(&role)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return &role, nil
+}
+
+func (p *rolePersister) List() (models.Roles, error) {
+	roles := models.Roles{}
+	err := p.db.EagerPreload("Permissions").Order("name asc").All(&roles)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (p *rolePersister) ListByUserId(userId uuid.UUID) (models.Roles, error) {
+	roles := models.Roles{}
+	err := p.db.
+		EagerPreload("Permissions").
+		Join("user_roles", "user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userId).
+		Order("roles.name asc").
+		All(&roles)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch roles of user: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (p *rolePersister) Create(role models.Role) error {
+	vErr, err := p.db.ValidateAndCreate(&role)
+	if err != nil {
+		return fmt.Errorf("failed to store role: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("role object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *rolePersister) Update(role models.Role) error {
+	vErr, err := p.db.ValidateAndUpdate(&role)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("role object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *rolePersister) Delete(role models.Role) error {
+	err := p.db.Destroy(&role)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	return nil
+}
+
+func (p *rolePersister) SetPermissions(roleId uuid.UUID, permissions models.Permissions) error {
+	err := p.db.RawQuery("DELETE FROM role_permissions WHERE role_id = ?", roleId).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to delete role permissions: %w", err)
+	}
+
+	for _, permission := range permissions {
+		rolePermission := models.NewRolePermission(roleId, permission.ID)
+		err = p.db.Create(&rolePermission)
+		if err != nil {
+			return fmt.Errorf("failed to store role permission: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *rolePersister) AssignToUser(userId uuid.UUID, roleId uuid.UUID) error {
+	exists, err := p.db.Where("user_id = ? AND role_id = ?", userId, roleId).Exists(&models.UserRole{})
+	if err != nil {
+		return fmt.Errorf("failed to check user role: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	userRole := models.NewUserRole(userId, roleId)
+	err = p.db.Create(&userRole)
+	if err != nil {
+		return fmt.Errorf("failed to store user role: %w", err)
+	}
+
+	return nil
+}
+
+func (p *rolePersister) UnassignFromUser(userId uuid.UUID, roleId uuid.UUID) error {
+	err := p.db.RawQuery("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?", userId, roleId).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to delete user role: %w", err)
+	}
+
+	return nil
+}
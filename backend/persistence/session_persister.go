@@ -1,9 +1,13 @@
 package persistence
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 type SessionPersister interface {
@@ -11,6 +15,9 @@ type SessionPersister interface {
 	Get(id string) (*models.Session, error)
 	Update(session *models.Session) error
 	Delete(id string) error
+	DeleteByUserId(userId uuid.UUID) error
+	ListByUserId(userId uuid.UUID) ([]models.Session, error)
+	DeleteUsed(before time.Time) (int, error)
 }
 
 type sessionPersister struct {
@@ -66,3 +73,27 @@ func (p *sessionPersister) Delete(id string) error {
 
 	return p.db.Destroy(session)
 }
+
+func (p *sessionPersister) DeleteByUserId(userId uuid.UUID) error {
+	return p.db.Where("user_id = ?", userId).Delete(&models.Session{})
+}
+
+func (p *sessionPersister) ListByUserId(userId uuid.UUID) ([]models.Session, error) {
+	sessions := []models.Session{}
+	err := p.db.Where("user_id = ?", userId).Order("created_at asc").All(&sessions)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteUsed deletes the sessions whose refresh token has last been used before the given time.
+func (p *sessionPersister) DeleteUsed(before time.Time) (int, error) {
+	count, err := p.db.RawQuery("DELETE FROM sessions WHERE used = ? AND updated_at < ?", true, before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete used sessions: %w", err)
+	}
+
+	return count, nil
+}
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"github.com/gobuffalo/pop/v6"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 type TokenPersister interface {
 	Create(token models.Token) error
 	GetByValue(value string) (*models.Token, error)
 	Delete(token models.Token) error
+	DeleteExpired(before time.Time) (int, error)
 }
 
 type tokenPersister struct {
@@ -56,3 +58,12 @@ func (t tokenPersister) Delete(token models.Token) error {
 
 	return nil
 }
+
+func (t tokenPersister) DeleteExpired(before time.Time) (int, error) {
+	count, err := t.db.RawQuery("DELETE FROM tokens WHERE expires_at < ?", before).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired tokens: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+type UserLockoutPersister interface {
+	GetByUserId(userId uuid.UUID) (*models.UserLockout, error)
+	GetByUnlockToken(token string) (*models.UserLockout, error)
+	Create(lockout models.UserLockout) error
+	Update(lockout models.UserLockout) error
+	// DeleteInactive deletes the lockouts whose lock and unlock token ended before lockedUntilBefore and whose window
+	// of failed attempts started before windowStartedBefore, i.e. which no longer affect future lockouts.
+	DeleteInactive(lockedUntilBefore time.Time, windowStartedBefore time.Time) (int, error)
+}
+
+type userLockoutPersister struct {
+	db *pop.Connection
+}
+
+func NewUserLockoutPersister(db *pop.Connection) UserLockoutPersister {
+	return &userLockoutPersister{db: db}
+}
+
+func (p *userLockoutPersister) GetByUserId(userId uuid.UUID) (*models.UserLockout, error) {
+	lockout := models.UserLockout{}
+	err := p.db.Where("user_id = ?", userId).First(&lockout)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user lockout: %w", err)
+	}
+
+	return &lockout, nil
+}
+
+// GetByUnlockToken returns the lockout the (hashed) unlock token was issued for.
+func (p *userLockoutPersister) GetByUnlockToken(token string) (*models.UserLockout, error) {
+	lockout := models.UserLockout{}
+	err := p.db.Where("unlock_token = ?", token).First(&lockout)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user lockout: %w", err)
+	}
+
+	return &lockout, nil
+}
+
+func (p *userLockoutPersister) Create(lockout models.UserLockout) error {
+	vErr, err := p.db.ValidateAndCreate(&lockout)
+	if err != nil {
+		return fmt.Errorf("failed to store user lockout: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("user lockout object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *userLockoutPersister) Update(lockout models.UserLockout) error {
+	vErr, err := p.db.ValidateAndUpdate(&lockout)
+	if err != nil {
+		return fmt.Errorf("failed to update user lockout: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("user lockout object validation failed: %w", vErr)
+	}
+
+	return nil
+}
+
+func (p *userLockoutPersister) DeleteInactive(lockedUntilBefore time.Time, windowStartedBefore time.Time) (int, error) {
+	count, err := p.db.RawQuery(
+		"DELETE FROM user_lockouts WHERE (locked_until IS NULL OR locked_until < ?) AND (unlock_token_expires_at IS NULL OR unlock_token_expires_at < ?) AND (window_started_at IS NULL OR window_started_at < ?)",
+		lockedUntilBefore, lockedUntilBefore, windowStartedBefore,
+	).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user lockouts: %w", err)
+	}
+
+	return count, nil
+}
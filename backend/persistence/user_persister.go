@@ -7,15 +7,20 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 type UserPersister interface {
 	Get(uuid.UUID) (*models.User, error)
+	GetForUpdate(uuid.UUID) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
 	Create(models.User) error
 	Update(models.User) error
+	UpdateMetadata(models.User) error
 	Delete(models.User) error
-	List(page int, perPage int, userId uuid.UUID, email string, sortDirection string) ([]models.User, error)
-	Count(userId uuid.UUID, email string) (int, error)
+	List(page int, perPage int, userId uuid.UUID, email string, blocked *bool, role string, sortDirection string) ([]models.User, error)
+	Count(userId uuid.UUID, email string, blocked *bool, role string) (int, error)
+	ListDeletedBefore(before time.Time, limit int) ([]models.User, error)
 }
 
 type userPersister struct {
@@ -28,7 +33,7 @@ func NewUserPersister(db *pop.Connection) UserPersister {
 
 func (p *userPersister) Get(id uuid.UUID) (*models.User, error) {
 	user := models.User{}
-	err := p.db.EagerPreload("Emails", "Emails.PrimaryEmail", "Emails.Identity", "WebauthnCredentials").Find(&user, id)
+	err := p.db.EagerPreload("Emails", "Emails.PrimaryEmail", "Emails.Identity", "WebauthnCredentials", "Lockout").Find(&user, id)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -39,6 +44,17 @@ func (p *userPersister) Get(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// GetForUpdate returns the user like Get, but locks the row of the user until the end of the transaction, so that
+// concurrent read-modify-write updates of the user are serialized.
+func (p *userPersister) GetForUpdate(id uuid.UUID) (*models.User, error) {
+	err := p.db.RawQuery("SELECT id FROM users WHERE id = ? FOR UPDATE", id).Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	return p.Get(id)
+}
+
 func (p *userPersister) GetByEmail(email string) (*models.User, error) {
 	user := models.User{}
 	err := p.db.Eager().Where("email = (?)", email).First(&user)
@@ -52,6 +68,19 @@ func (p *userPersister) GetByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+func (p *userPersister) GetByUsername(username string) (*models.User, error) {
+	user := models.User{}
+	err := p.db.EagerPreload("Emails", "Emails.PrimaryEmail", "Emails.Identity", "WebauthnCredentials", "Lockout").Where("username = ?", username).First(&user)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
 func (p *userPersister) Create(user models.User) error {
 	vErr, err := p.db.ValidateAndCreate(&user)
 	if err != nil {
@@ -78,6 +107,17 @@ func (p *userPersister) Update(user models.User) error {
 	return nil
 }
 
+// UpdateMetadata only stores the metadata of the user, so that other columns changed in the meantime are not
+// overwritten.
+func (p *userPersister) UpdateMetadata(user models.User) error {
+	err := p.db.UpdateColumns(&user, "public_metadata", "private_metadata", "unsafe_metadata", "updated_at")
+	if err != nil {
+		return fmt.Errorf("failed to update user metadata: %w", err)
+	}
+
+	return nil
+}
+
 func (p *userPersister) Delete(user models.User) error {
 	err := p.db.Destroy(&user)
 	if err != nil {
@@ -87,16 +127,31 @@ func (p *userPersister) Delete(user models.User) error {
 	return nil
 }
 
-func (p *userPersister) List(page int, perPage int, userId uuid.UUID, email string, sortDirection string) ([]models.User, error) {
+// ListDeletedBefore returns the users which have been soft deleted before the given time, oldest first.
+func (p *userPersister) ListDeletedBefore(before time.Time, limit int) ([]models.User, error) {
+	users := []models.User{}
+	err := p.db.
+		EagerPreload("Emails", "Emails.PrimaryEmail").
+		Where("deleted_at < ?", before).
+		Order("deleted_at asc").
+		Limit(limit).
+		All(&users)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch deleted users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (p *userPersister) List(page int, perPage int, userId uuid.UUID, email string, blocked *bool, role string, sortDirection string) ([]models.User, error) {
 	users := []models.User{}
 
 	query := p.db.
 		Q().
-		EagerPreload("Emails", "Emails.PrimaryEmail", "WebauthnCredentials").
+		EagerPreload("Emails", "Emails.PrimaryEmail", "WebauthnCredentials", "Lockout").
 		LeftJoin("emails", "emails.user_id = users.id")
-	query = p.addQueryParamsToSqlQuery(query, userId, email)
+	query = p.addQueryParamsToSqlQuery(query, userId, email, blocked, role)
 	err := query.GroupBy("users.id").
-		Having("count(emails.id) > 0").
 		Order(fmt.Sprintf("users.created_at %s", sortDirection)).
 		Paginate(page, perPage).
 		All(&users)
@@ -111,13 +166,12 @@ func (p *userPersister) List(page int, perPage int, userId uuid.UUID, email stri
 	return users, nil
 }
 
-func (p *userPersister) Count(userId uuid.UUID, email string) (int, error) {
+func (p *userPersister) Count(userId uuid.UUID, email string, blocked *bool, role string) (int, error) {
 	query := p.db.
 		Q().
 		LeftJoin("emails", "emails.user_id = users.id")
-	query = p.addQueryParamsToSqlQuery(query, userId, email)
+	query = p.addQueryParamsToSqlQuery(query, userId, email, blocked, role)
 	count, err := query.GroupBy("users.id").
-		Having("count(emails.id) > 0").
 		Count(&models.User{})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get user count: %w", err)
@@ -126,13 +180,24 @@ func (p *userPersister) Count(userId uuid.UUID, email string) (int, error) {
 	return count, nil
 }
 
-func (p *userPersister) addQueryParamsToSqlQuery(query *pop.Query, userId uuid.UUID, email string) *pop.Query {
+func (p *userPersister) addQueryParamsToSqlQuery(query *pop.Query, userId uuid.UUID, email string, blocked *bool, role string) *pop.Query {
 	if email != "" {
 		query = query.Where("emails.address LIKE ?", "%"+email+"%")
 	}
 	if !userId.IsNil() {
 		query = query.Where("users.id = ?", userId)
 	}
+	if blocked != nil {
+		now := time.Now().UTC()
+		if *blocked {
+			query = query.Where("users.blocked_at IS NOT NULL AND (users.blocked_until IS NULL OR users.blocked_until > ?)", now)
+		} else {
+			query = query.Where("(users.blocked_at IS NULL OR users.blocked_until <= ?)", now)
+		}
+	}
+	if role != "" {
+		query = query.Where("users.id IN (SELECT user_roles.user_id FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE roles.name = ?)", role)
+	}
 
 	return query
 }
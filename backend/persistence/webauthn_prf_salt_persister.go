@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+type WebauthnPrfSaltPersister interface {
+	GetByUserId(userId uuid.UUID) (*models.WebauthnPrfSalt, error)
+	Create(salt models.WebauthnPrfSalt) error
+}
+
+type webauthnPrfSaltPersister struct {
+	db *pop.Connection
+}
+
+func NewWebauthnPrfSaltPersister(db *pop.Connection) WebauthnPrfSaltPersister {
+	return &webauthnPrfSaltPersister{db: db}
+}
+
+func (p *webauthnPrfSaltPersister) GetByUserId(userId uuid.UUID) (*models.WebauthnPrfSalt, error) {
+	salt := models.WebauthnPrfSalt{}
+	err := p.db.Where("user_id = ?", userId).First(&salt)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn prf salt: %w", err)
+	}
+
+	return &salt, nil
+}
+
+func (p *webauthnPrfSaltPersister) Create(salt models.WebauthnPrfSalt) error {
+	vErr, err := p.db.ValidateAndCreate(&salt)
+	if err != nil {
+		return fmt.Errorf("failed to store webauthn prf salt: %w", err)
+	}
+
+	if vErr != nil && vErr.HasAny() {
+		return fmt.Errorf("webauthn prf salt object validation failed: %w", vErr)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 type WebauthnSessionDataPersister interface {
@@ -15,6 +16,7 @@ type WebauthnSessionDataPersister interface {
 	Create(sessionData models.WebauthnSessionData) error
 	Update(sessionData models.WebauthnSessionData) error
 	Delete(sessionData models.WebauthnSessionData) error
+	DeleteExpired(before time.Time) (int, error)
 }
 
 type webauthnSessionDataPersister struct {
@@ -89,3 +91,17 @@ func (p *webauthnSessionDataPersister) Delete(sessionData models.WebauthnSession
 
 	return nil
 }
+
+// DeleteExpired deletes the session data which expired before the given time. Session data without expiry, which was
+// created before expiries were stored, is deleted if it was created before the given time.
+func (p *webauthnSessionDataPersister) DeleteExpired(before time.Time) (int, error) {
+	count, err := p.db.RawQuery(
+		"DELETE FROM webauthn_session_data WHERE expires_at < ? OR (expires_at IS NULL AND created_at < ?)",
+		before, before,
+	).ExecWithCount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessionData: %w", err)
+	}
+
+	return count, nil
+}
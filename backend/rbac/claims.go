@@ -0,0 +1,50 @@
+package rbac
+
+import (
+	"fmt"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+)
+
+const (
+	RolesClaim       = "roles"
+	PermissionsClaim = "permissions"
+)
+
+// ClaimsProvider adds the names of the roles of a user and of the permissions granted by them to session JWTs.
+type ClaimsProvider struct {
+	persister persistence.Persister
+	enabled   bool
+}
+
+func NewClaimsProvider(cfg config.Rbac, persister persistence.Persister) *ClaimsProvider {
+	return &ClaimsProvider{
+		persister: persister,
+		enabled:   cfg.JwtClaims,
+	}
+}
+
+// Claims returns the "roles" and "permissions" claims. Both are empty lists if the user has no roles.
+func (p *ClaimsProvider) Claims(tx *pop.Connection, userId uuid.UUID) (map[string]interface{}, error) {
+	claims := make(map[string]interface{})
+	if !p.enabled {
+		return claims, nil
+	}
+
+	rolePersister := p.persister.GetRolePersister()
+	if tx != nil {
+		rolePersister = p.persister.GetRolePersisterWithConnection(tx)
+	}
+
+	roles, err := rolePersister.ListByUserId(userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	claims[RolesClaim] = roles.GetNames()
+	claims[PermissionsClaim] = roles.GetPermissionNames()
+
+	return claims, nil
+}
@@ -0,0 +1,45 @@
+package rbac
+
+import (
+	"fmt"
+	"github.com/gofrs/uuid"
+	zeroLogger "github.com/rs/zerolog/log"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"regexp"
+)
+
+// MaxNameLength is the maximum length of role and permission names.
+const MaxNameLength = 100
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.:\-]*$`)
+
+// ValidName reports whether name can be used as the name of a role or permission. Names must start with a letter or
+// digit and may contain letters, digits and "_", ".", ":" and "-", e.g. "admin" or "documents:write".
+func ValidName(name string) bool {
+	return len(name) <= MaxNameLength && namePattern.MatchString(name)
+}
+
+// GrantDefaultRole assigns the configured default role to a newly created user. Nothing is assigned if no default role
+// is configured or the role does not exist, so that sign ups do not fail because of a missing role.
+func GrantDefaultRole(cfg config.Rbac, persister persistence.RolePersister, userId uuid.UUID) error {
+	if cfg.DefaultRole == "" {
+		return nil
+	}
+
+	role, err := persister.GetByName(cfg.DefaultRole)
+	if err != nil {
+		return fmt.Errorf("failed to get default role: %w", err)
+	}
+	if role == nil {
+		zeroLogger.Warn().Str("role", cfg.DefaultRole).Msg("default role does not exist, no role assigned")
+		return nil
+	}
+
+	err = persister.AssignToUser(userId, role.ID)
+	if err != nil {
+		return fmt.Errorf("failed to assign default role: %w", err)
+	}
+
+	return nil
+}
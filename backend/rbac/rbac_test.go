@@ -0,0 +1,66 @@
+package rbac
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/test"
+	"strings"
+	"testing"
+)
+
+func TestValidName(t *testing.T) {
+	assert.True(t, ValidName("admin"))
+	assert.True(t, ValidName("documents:write"))
+	assert.True(t, ValidName("billing.invoices-read_all"))
+	assert.False(t, ValidName(""))
+	assert.False(t, ValidName(":admin"))
+	assert.False(t, ValidName("super admin"))
+	assert.False(t, ValidName(strings.Repeat("a", MaxNameLength+1)))
+}
+
+func TestGrantDefaultRole(t *testing.T) {
+	userId, _ := uuid.NewV4()
+	role := models.NewRole("member", nil)
+
+	persister := test.NewRolePersister([]models.Role{role}, nil)
+	err := GrantDefaultRole(config.Rbac{DefaultRole: "member"}, persister, userId)
+	require.NoError(t, err)
+
+	roles, err := persister.ListByUserId(userId)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"member"}, roles.GetNames())
+
+	persister = test.NewRolePersister(nil, nil)
+	err = GrantDefaultRole(config.Rbac{DefaultRole: "member"}, persister, userId)
+	require.NoError(t, err)
+
+	roles, err = persister.ListByUserId(userId)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+}
+
+func TestClaimsProvider_Claims(t *testing.T) {
+	userId, _ := uuid.NewV4()
+	editor := models.NewRole("editor", nil)
+	editor.Permissions = models.Permissions{models.NewPermission("documents:read", nil), models.NewPermission("documents:write", nil)}
+	viewer := models.NewRole("viewer", nil)
+	viewer.Permissions = models.Permissions{models.NewPermission("documents:read", nil)}
+	persister := test.NewPersister(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []models.Role{editor, viewer}, []models.UserRole{
+		models.NewUserRole(userId, editor.ID),
+		models.NewUserRole(userId, viewer.ID),
+	}, nil, nil, nil, nil, nil, nil)
+
+	claims, err := NewClaimsProvider(config.Rbac{JwtClaims: true}, persister).Claims(nil, userId)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"roles":       []string{"editor", "viewer"},
+		"permissions": []string{"documents:read", "documents:write"},
+	}, claims)
+
+	claims, err = NewClaimsProvider(config.Rbac{}, persister).Claims(nil, userId)
+	require.NoError(t, err)
+	assert.Empty(t, claims)
+}
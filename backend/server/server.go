@@ -4,6 +4,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/teamhanko/hanko/backend/config"
 	"github.com/teamhanko/hanko/backend/handler"
+	"github.com/teamhanko/hanko/backend/maintenance"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"sync"
 )
@@ -19,3 +20,10 @@ func StartAdmin(cfg *config.Config, wg *sync.WaitGroup, persister persistence.Pe
 	router := handler.NewAdminRouter(cfg, persister, prometheus)
 	router.Logger.Fatal(router.Start(cfg.Server.Admin.Address))
 }
+
+// StartMaintenance runs the maintenance jobs in the background if they are enabled.
+func StartMaintenance(cfg *config.Config, persister persistence.Persister) {
+	if cfg.Maintenance.Enabled {
+		maintenance.NewScheduler(cfg, persister).Start()
+	}
+}
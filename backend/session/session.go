@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"github.com/getsentry/sentry-go"
 	sentryecho "github.com/getsentry/sentry-go/echo"
+	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/crypto"
 	hankoJwk "github.com/teamhanko/hanko/backend/crypto/jwk"
 	hankoJwt "github.com/teamhanko/hanko/backend/crypto/jwt"
 	"github.com/teamhanko/hanko/backend/persistence"
@@ -21,11 +23,30 @@ type Manager interface {
 	GenerateJWT(uuid.UUID) (string, error)
 	Verify(string) (jwt.Token, error)
 	GenerateCookie(string) (*http.Cookie, error)
-	GenerateCookieOrHeader(uuid.UUID, echo.Context) error
+	GenerateCookieOrHeader(*pop.Connection, uuid.UUID, echo.Context) error
+	GenerateCookieOrHeaderWithOrganization(*pop.Connection, uuid.UUID, *uuid.UUID, echo.Context) error
+	SwitchOrganization(jwt.Token, *uuid.UUID, echo.Context) error
 	ExchangeRefreshToken(string, echo.Context) error
 	DeleteCookie(echo.Context) error
 }
 
+// SessionIdClaim identifies the refresh session a session JWT has been issued for. It contains the hash of the
+// refresh token, so that the token itself is not revealed by the JWT.
+const SessionIdClaim = "sid"
+
+// ClaimsProvider adds custom claims to the session JWTs of a user. tx is the transaction in which the user has been
+// changed, so that the claims contain uncommitted changes, e.g. the roles granted on sign up. It is nil if the JWT is
+// not issued within a transaction.
+type ClaimsProvider interface {
+	Claims(tx *pop.Connection, userId uuid.UUID) (map[string]interface{}, error)
+}
+
+// OrganizationClaimsProvider can be implemented by a ClaimsProvider to add claims about the active organization of a
+// session. It is only called for sessions with an active organization.
+type OrganizationClaimsProvider interface {
+	OrganizationClaims(tx *pop.Connection, userId uuid.UUID, organizationId uuid.UUID) (map[string]interface{}, error)
+}
+
 // Manager is used to create and verify session JWTs
 type manager struct {
 	jwtGenerator       hankoJwt.Generator
@@ -37,6 +58,7 @@ type manager struct {
 	issuer             string
 	audience           []string
 	persister          persistence.SessionPersister
+	claimsProviders    []ClaimsProvider
 }
 
 type cookieConfig struct {
@@ -47,8 +69,9 @@ type cookieConfig struct {
 	Secure   bool
 }
 
-// NewManager returns a new Manager which will be used to create and verify sessions JWTs
-func NewManager(jwkManager hankoJwk.Manager, config config.Config, persister persistence.SessionPersister) (Manager, error) {
+// NewManager returns a new Manager which will be used to create and verify sessions JWTs. The claims of the
+// claimsProviders are added to every session JWT.
+func NewManager(jwkManager hankoJwk.Manager, config config.Config, persister persistence.SessionPersister, claimsProviders ...ClaimsProvider) (Manager, error) {
 	signatureKey, err := jwkManager.GetSigningKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session generator: %w", err)
@@ -102,11 +125,16 @@ func NewManager(jwkManager hankoJwk.Manager, config config.Config, persister per
 		refreshTokenPath:   refreshTokenPath,
 		audience:           audience,
 		persister:          persister,
+		claimsProviders:    claimsProviders,
 	}, nil
 }
 
 // GenerateJWT creates a new session JWT for the given user
 func (m *manager) GenerateJWT(userId uuid.UUID) (string, error) {
+	return m.generateJWT(nil, userId, nil, nil)
+}
+
+func (m *manager) generateJWT(tx *pop.Connection, userId uuid.UUID, organizationId *uuid.UUID, session *models.Session) (string, error) {
 	issuedAt := time.Now()
 	expiration := issuedAt.Add(m.sessionLength)
 
@@ -118,6 +146,31 @@ func (m *manager) GenerateJWT(userId uuid.UUID) (string, error) {
 	if m.issuer != "" {
 		_ = token.Set(jwt.IssuerKey, m.issuer)
 	}
+	if session != nil {
+		_ = token.Set(SessionIdClaim, crypto.HashToken(session.ID))
+	}
+
+	for _, claimsProvider := range m.claimsProviders {
+		claims, err := claimsProvider.Claims(tx, userId)
+		if err != nil {
+			return "", fmt.Errorf("failed to get claims: %w", err)
+		}
+		for key, value := range claims {
+			_ = token.Set(key, value)
+		}
+
+		organizationClaimsProvider, ok := claimsProvider.(OrganizationClaimsProvider)
+		if organizationId == nil || !ok {
+			continue
+		}
+		claims, err = organizationClaimsProvider.OrganizationClaims(tx, userId, *organizationId)
+		if err != nil {
+			return "", fmt.Errorf("failed to get organization claims: %w", err)
+		}
+		for key, value := range claims {
+			_ = token.Set(key, value)
+		}
+	}
 
 	signed, err := m.jwtGenerator.Sign(token)
 	if err != nil {
@@ -165,44 +218,121 @@ func (m *manager) GenerateRefreshCookie(sessionId string) (*http.Cookie, error)
 	}, nil
 }
 
-// GenerateCookieOrHeader creates a new session cookie or applies the header for the given user
-func (m *manager) GenerateCookieOrHeader(userId uuid.UUID, e echo.Context) error {
-	token, err := m.GenerateJWT(userId)
+// GenerateCookieOrHeader creates a new session cookie or applies the header for the given user. The claims are read
+// within tx, which is nil if the session is not created within a transaction.
+func (m *manager) GenerateCookieOrHeader(tx *pop.Connection, userId uuid.UUID, e echo.Context) error {
+	return m.GenerateCookieOrHeaderWithOrganization(tx, userId, nil, e)
+}
+
+// GenerateCookieOrHeaderWithOrganization creates a new session cookie or applies the header for the given user with
+// the given organization as the active organization of the session. The session has no active organization if
+// organizationId is nil.
+func (m *manager) GenerateCookieOrHeaderWithOrganization(tx *pop.Connection, userId uuid.UUID, organizationId *uuid.UUID, e echo.Context) error {
+	var session *models.Session
+	if m.enableRefreshToken && m.persister != nil {
+		var err error
+		session, err = models.NewSession(userId)
+		if err != nil {
+			return err
+		}
+		session.ActiveOrganizationID = organizationId
+
+		err = m.persister.Create(session)
+		if err != nil {
+			return err
+		}
+	}
+
+	token, err := m.generateJWT(tx, userId, organizationId, session)
 	if err != nil {
 		return err
 	}
 
+	m.setToken(token, e)
+
+	if session == nil {
+		return nil
+	}
+
 	if m.enableHeader {
-		e.Response().Header().Set("X-Auth-Token", token)
+		e.Response().Header().Set("X-Refresh-Token", session.ID)
 	} else {
-		cookie, _ := m.GenerateCookie(token)
+		cookie, _ := m.GenerateRefreshCookie(session.ID)
 		e.SetCookie(cookie)
 	}
 
-	e.Response().Header().Set("X-Session-Lifetime", fmt.Sprintf("%d", int(m.sessionLength.Seconds())))
+	return nil
+}
+
+// SwitchOrganization reissues the session JWT of the given token with the given organization as the active
+// organization. The refresh session the token has been issued for keeps its refresh token and is updated, so that
+// exchanging the refresh token keeps the organization. A new refresh session is only created if the refresh session
+// of the token does not exist anymore.
+func (m *manager) SwitchOrganization(token jwt.Token, organizationId *uuid.UUID, e echo.Context) error {
+	userId, err := uuid.FromString(token.Subject())
+	if err != nil {
+		return fmt.Errorf("failed to parse subject as uuid: %w", err)
+	}
 
 	if !m.enableRefreshToken || m.persister == nil {
-		return nil
+		return m.GenerateCookieOrHeaderWithOrganization(nil, userId, organizationId, e)
 	}
 
-	session, err := models.NewSession(userId)
+	session, err := m.getSession(userId, token)
 	if err != nil {
 		return err
 	}
 
-	err = m.persister.Create(session)
+	if session == nil || session.Used {
+		return m.GenerateCookieOrHeaderWithOrganization(nil, userId, organizationId, e)
+	}
+
+	session.ActiveOrganizationID = organizationId
+	session.UpdatedAt = time.Now().UTC()
+	err = m.persister.Update(session)
 	if err != nil {
 		return err
 	}
 
+	signed, err := m.generateJWT(nil, userId, organizationId, session)
+	if err != nil {
+		return err
+	}
+
+	m.setToken(signed, e)
+	return nil
+}
+
+// getSession returns the refresh session the token has been issued for or nil if there is none.
+func (m *manager) getSession(userId uuid.UUID, token jwt.Token) (*models.Session, error) {
+	sessionId, ok := token.Get(SessionIdClaim)
+	if !ok {
+		return nil, nil
+	}
+
+	sessions, err := m.persister.ListByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		if crypto.HashToken(sessions[i].ID) == sessionId {
+			return &sessions[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *manager) setToken(token string, e echo.Context) {
 	if m.enableHeader {
-		e.Response().Header().Set("X-Refresh-Token", session.ID)
+		e.Response().Header().Set("X-Auth-Token", token)
 	} else {
-		cookie, _ := m.GenerateRefreshCookie(session.ID)
+		cookie, _ := m.GenerateCookie(token)
 		e.SetCookie(cookie)
 	}
 
-	return nil
+	e.Response().Header().Set("X-Session-Lifetime", fmt.Sprintf("%d", int(m.sessionLength.Seconds())))
 }
 
 // ExchangeRefreshToken refreshes the session cookie for the given user based on the given id of the refresh token
@@ -239,7 +369,7 @@ func (m *manager) ExchangeRefreshToken(id string, e echo.Context) error {
 	sess.Used = true
 	sess.UsedCount++
 
-	err = m.GenerateCookieOrHeader(sess.UserID, e)
+	err = m.GenerateCookieOrHeaderWithOrganization(nil, sess.UserID, sess.ActiveOrganizationID, e)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/lestrrat-go/jwx/v2/jwt"
@@ -194,7 +195,7 @@ func TestGenerator_RefreshToken(t *testing.T) {
 	uid, err := uuid.NewV4()
 	assert.NoError(t, err)
 
-	err = sessionGenerator.GenerateCookieOrHeader(uid, c)
+	err = sessionGenerator.GenerateCookieOrHeader(nil, uid, c)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(rec.Result().Cookies()))
 
@@ -233,3 +234,90 @@ func TestGenerator_RefreshToken(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(rec.Result().Cookies()))
 }
+
+type organizationClaimsProvider struct{}
+
+func (p organizationClaimsProvider) Claims(tx *pop.Connection, userId uuid.UUID) (map[string]interface{}, error) {
+	return map[string]interface{}{"custom": "value"}, nil
+}
+
+func (p organizationClaimsProvider) OrganizationClaims(tx *pop.Connection, userId uuid.UUID, organizationId uuid.UUID) (map[string]interface{}, error) {
+	return map[string]interface{}{"org_id": organizationId.String()}, nil
+}
+
+func TestGenerator_GenerateCookieOrHeaderWithOrganization(t *testing.T) {
+	manager := test.JwkManager{}
+	cfg := config.Config{
+		Session: config.Session{Lifespan: "5m", EnableAuthTokenHeader: true},
+	}
+	sessionGenerator, err := NewManager(&manager, cfg, nil, organizationClaimsProvider{})
+	require.NoError(t, err)
+
+	userId, _ := uuid.NewV4()
+	organizationId, _ := uuid.NewV4()
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	err = sessionGenerator.GenerateCookieOrHeaderWithOrganization(nil, userId, &organizationId, e.NewContext(nil, rec))
+	require.NoError(t, err)
+
+	token, err := sessionGenerator.Verify(rec.Header().Get("X-Auth-Token"))
+	require.NoError(t, err)
+	value, _ := token.Get("custom")
+	assert.Equal(t, "value", value)
+	value, _ = token.Get("org_id")
+	assert.Equal(t, organizationId.String(), value)
+
+	rec = httptest.NewRecorder()
+	err = sessionGenerator.GenerateCookieOrHeader(nil, userId, e.NewContext(nil, rec))
+	require.NoError(t, err)
+
+	token, err = sessionGenerator.Verify(rec.Header().Get("X-Auth-Token"))
+	require.NoError(t, err)
+	_, ok := token.Get("org_id")
+	assert.False(t, ok)
+}
+
+func TestGenerator_SwitchOrganization(t *testing.T) {
+	manager := test.JwkManager{}
+	cfg := config.Config{
+		Session: config.Session{Lifespan: "5m", EnableAuthTokenHeader: true, EnableRefreshToken: true},
+	}
+	persister := test.NewSessionPersister(nil)
+	sessionGenerator, err := NewManager(&manager, cfg, persister, organizationClaimsProvider{})
+	require.NoError(t, err)
+
+	userId, _ := uuid.NewV4()
+	organizationId, _ := uuid.NewV4()
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	err = sessionGenerator.GenerateCookieOrHeader(nil, userId, e.NewContext(nil, rec))
+	require.NoError(t, err)
+	refreshToken := rec.Header().Get("X-Refresh-Token")
+	require.NotEmpty(t, refreshToken)
+
+	token, err := sessionGenerator.Verify(rec.Header().Get("X-Auth-Token"))
+	require.NoError(t, err)
+	sessionId, ok := token.Get(SessionIdClaim)
+	require.True(t, ok)
+
+	rec = httptest.NewRecorder()
+	err = sessionGenerator.SwitchOrganization(token, &organizationId, e.NewContext(nil, rec))
+	require.NoError(t, err)
+	assert.Empty(t, rec.Header().Get("X-Refresh-Token"))
+
+	switchedToken, err := sessionGenerator.Verify(rec.Header().Get("X-Auth-Token"))
+	require.NoError(t, err)
+	value, _ := switchedToken.Get("org_id")
+	assert.Equal(t, organizationId.String(), value)
+	value, _ = switchedToken.Get(SessionIdClaim)
+	assert.Equal(t, sessionId, value)
+
+	sessions, err := persister.ListByUserId(userId)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, refreshToken, sessions[0].ID)
+	require.NotNil(t, sessions[0].ActiveOrganizationID)
+	assert.Equal(t, organizationId, *sessions[0].ActiveOrganizationID)
+}
@@ -1,6 +1,9 @@
 package test
 
-import "github.com/teamhanko/hanko/backend/config"
+import (
+	"github.com/teamhanko/hanko/backend/config"
+	"time"
+)
 
 var DefaultConfig = config.Config{
 	Webauthn: config.WebauthnSettings{
@@ -12,6 +15,12 @@ var DefaultConfig = config.Config{
 		},
 		Timeout:          60000,
 		UserVerification: "preferred",
+		Attestation: config.WebauthnAttestation{
+			ConveyancePreference: "none",
+		},
+		CloneDetection: config.WebauthnCloneDetection{
+			Mode: config.CloneDetectionModeLog,
+		},
 	},
 	Secrets: config.Secrets{
 		Keys: []string{"abcdefghijklmnop"},
@@ -26,6 +35,13 @@ var DefaultConfig = config.Config{
 			FromName:    "Hanko Test",
 		},
 		TTL: 300,
+		Policy: config.PasscodePolicy{
+			Length:               6,
+			Alphabet:             config.PasscodeAlphabetNumeric,
+			MaxAttempts:          3,
+			MaxFailedAttempts:    10,
+			FailedAttemptsWindow: time.Hour,
+		},
 	},
 	Session: config.Session{
 		Lifespan: "1h",
@@ -40,4 +56,8 @@ var DefaultConfig = config.Config{
 		AllowSignup:   true,
 		AllowDeletion: false,
 	},
+	UserMetadata: config.UserMetadata{
+		MaxSize:       16384,
+		UnsafeMaxSize: 2048,
+	},
 }
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+func NewEmailChangePersister(init []models.EmailChange) persistence.EmailChangePersister {
+	return &emailChangePersister{append([]models.EmailChange{}, init...)}
+}
+
+type emailChangePersister struct {
+	emailChanges []models.EmailChange
+}
+
+func (p *emailChangePersister) GetByRevertToken(revertToken string) (*models.EmailChange, error) {
+	for _, emailChange := range p.emailChanges {
+		if emailChange.RevertToken == revertToken {
+			d := emailChange
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *emailChangePersister) ListByUserId(userId uuid.UUID) ([]models.EmailChange, error) {
+	var emailChanges []models.EmailChange
+	for _, emailChange := range p.emailChanges {
+		if emailChange.UserID == userId {
+			emailChanges = append(emailChanges, emailChange)
+		}
+	}
+	return emailChanges, nil
+}
+
+func (p *emailChangePersister) Create(emailChange models.EmailChange) error {
+	p.emailChanges = append(p.emailChanges, emailChange)
+	return nil
+}
+
+func (p *emailChangePersister) Update(emailChange models.EmailChange) error {
+	for i, data := range p.emailChanges {
+		if data.ID == emailChange.ID {
+			p.emailChanges[i] = emailChange
+		}
+	}
+	return nil
+}
+
+func (p *emailChangePersister) DeleteExpired(before time.Time) (int, error) {
+	var kept []models.EmailChange
+	for _, emailChange := range p.emailChanges {
+		if !emailChange.RevertExpiresAt.Before(before) {
+			kept = append(kept, emailChange)
+		}
+	}
+	count := len(p.emailChanges) - len(kept)
+	p.emailChanges = kept
+	return count, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 func NewEmailPersister(init []models.Email) persistence.EmailPersister {
@@ -79,3 +80,15 @@ func (e *emailPersister) CountByUserId(userId uuid.UUID) (int, error) {
 	}
 	return count, nil
 }
+
+func (e *emailPersister) DeleteUnassigned(before time.Time) (int, error) {
+	var kept []models.Email
+	for _, email := range e.emails {
+		if email.UserID != nil || !email.CreatedAt.Before(before) {
+			kept = append(kept, email)
+		}
+	}
+	count := len(e.emails) - len(kept)
+	e.emails = kept
+	return count, nil
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+func NewFailedPasscodeAttemptPersister(init []models.FailedPasscodeAttempt) persistence.FailedPasscodeAttemptPersister {
+	return &failedPasscodeAttemptPersister{append([]models.FailedPasscodeAttempt{}, init...)}
+}
+
+type failedPasscodeAttemptPersister struct {
+	attempts []models.FailedPasscodeAttempt
+}
+
+func (p *failedPasscodeAttemptPersister) Create(attempt models.FailedPasscodeAttempt) error {
+	p.attempts = append(p.attempts, attempt)
+	return nil
+}
+
+func (p *failedPasscodeAttemptPersister) CountByUserIdSince(userId uuid.UUID, since time.Time) (int, error) {
+	count := 0
+	for _, attempt := range p.attempts {
+		if attempt.UserID == userId && attempt.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (p *failedPasscodeAttemptPersister) DeleteByUserId(userId uuid.UUID) error {
+	var remaining []models.FailedPasscodeAttempt
+	for _, attempt := range p.attempts {
+		if attempt.UserID != userId {
+			remaining = append(remaining, attempt)
+		}
+	}
+	p.attempts = remaining
+	return nil
+}
+
+func (p *failedPasscodeAttemptPersister) DeleteCreatedBefore(before time.Time) (int, error) {
+	var kept []models.FailedPasscodeAttempt
+	for _, attempt := range p.attempts {
+		if !attempt.CreatedAt.Before(before) {
+			kept = append(kept, attempt)
+		}
+	}
+	count := len(p.attempts) - len(kept)
+	p.attempts = kept
+	return count, nil
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+func NewInvitationPersister(init []models.Invitation) persistence.InvitationPersister {
+	return &invitationPersister{append([]models.Invitation{}, init...)}
+}
+
+type invitationPersister struct {
+	invitations []models.Invitation
+}
+
+func (p *invitationPersister) Get(id uuid.UUID) (*models.Invitation, error) {
+	for _, invitation := range p.invitations {
+		if invitation.ID == id {
+			d := invitation
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *invitationPersister) GetByToken(token string) (*models.Invitation, error) {
+	for _, invitation := range p.invitations {
+		if invitation.Token == token {
+			d := invitation
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *invitationPersister) GetPendingByEmail(email string) (*models.Invitation, error) {
+	now := time.Now().UTC()
+	for _, invitation := range p.invitations {
+		if invitation.Email == email && !invitation.IsAccepted() && !invitation.IsExpired(now) {
+			d := invitation
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *invitationPersister) List(page int, perPage int) ([]models.Invitation, error) {
+	return append([]models.Invitation{}, p.invitations...), nil
+}
+
+func (p *invitationPersister) Count() (int, error) {
+	return len(p.invitations), nil
+}
+
+func (p *invitationPersister) Create(invitation models.Invitation) error {
+	p.invitations = append(p.invitations, invitation)
+	return nil
+}
+
+func (p *invitationPersister) Update(invitation models.Invitation) error {
+	for i, data := range p.invitations {
+		if data.ID == invitation.ID {
+			p.invitations[i] = invitation
+		}
+	}
+	return nil
+}
+
+func (p *invitationPersister) Delete(invitation models.Invitation) error {
+	index := -1
+	for i, data := range p.invitations {
+		if data.ID == invitation.ID {
+			index = i
+		}
+	}
+	if index > -1 {
+		p.invitations = append(p.invitations[:index], p.invitations[index+1:]...)
+	}
+
+	return nil
+}
+
+func (p *invitationPersister) DeleteExpired(before time.Time) (int, error) {
+	var kept []models.Invitation
+	for _, invitation := range p.invitations {
+		if invitation.AcceptedAt != nil || !invitation.ExpiresAt.Before(before) {
+			kept = append(kept, invitation)
+		}
+	}
+	count := len(p.invitations) - len(kept)
+	p.invitations = kept
+	return count, nil
+}
@@ -0,0 +1,29 @@
+package test
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence"
+	"time"
+)
+
+func NewMaintenanceLockPersister(init map[string]time.Time) persistence.MaintenanceLockPersister {
+	locks := make(map[string]time.Time)
+	for name, lockedUntil := range init {
+		locks[name] = lockedUntil
+	}
+	return &maintenanceLockPersister{locks}
+}
+
+type maintenanceLockPersister struct {
+	locks map[string]time.Time
+}
+
+// Acquire only takes locks contained in the initial locks, like the real persister only takes locks created by
+// migrations.
+func (p *maintenanceLockPersister) Acquire(name string, owner string, now time.Time, until time.Time) (bool, error) {
+	lockedUntil, ok := p.locks[name]
+	if !ok || lockedUntil.After(now) {
+		return false, nil
+	}
+	p.locks[name] = until
+	return true, nil
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+func NewOrganizationMembershipPersister(init []models.OrganizationMembership) persistence.OrganizationMembershipPersister {
+	return &organizationMembershipPersister{append([]models.OrganizationMembership{}, init...)}
+}
+
+type organizationMembershipPersister struct {
+	memberships []models.OrganizationMembership
+}
+
+func (p *organizationMembershipPersister) Get(organizationId uuid.UUID, userId uuid.UUID) (*models.OrganizationMembership, error) {
+	for _, membership := range p.memberships {
+		if membership.OrganizationID == organizationId && membership.UserID == userId {
+			d := membership
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *organizationMembershipPersister) ListByOrganizationId(organizationId uuid.UUID) ([]models.OrganizationMembership, error) {
+	var memberships []models.OrganizationMembership
+	for _, membership := range p.memberships {
+		if membership.OrganizationID == organizationId {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+
+func (p *organizationMembershipPersister) ListByUserId(userId uuid.UUID) ([]models.OrganizationMembership, error) {
+	var memberships []models.OrganizationMembership
+	for _, membership := range p.memberships {
+		if membership.UserID == userId {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+
+func (p *organizationMembershipPersister) Create(membership models.OrganizationMembership) error {
+	p.memberships = append(p.memberships, membership)
+	return nil
+}
+
+func (p *organizationMembershipPersister) Update(membership models.OrganizationMembership) error {
+	for i, data := range p.memberships {
+		if data.ID == membership.ID {
+			p.memberships[i] = membership
+		}
+	}
+	return nil
+}
+
+func (p *organizationMembershipPersister) Delete(membership models.OrganizationMembership) error {
+	index := -1
+	for i, data := range p.memberships {
+		if data.ID == membership.ID {
+			index = i
+		}
+	}
+	if index > -1 {
+		p.memberships = append(p.memberships[:index], p.memberships[index+1:]...)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+func NewOrganizationPersister(init []models.Organization) persistence.OrganizationPersister {
+	return &organizationPersister{append([]models.Organization{}, init...)}
+}
+
+type organizationPersister struct {
+	organizations []models.Organization
+}
+
+func (p *organizationPersister) Get(id uuid.UUID) (*models.Organization, error) {
+	for _, organization := range p.organizations {
+		if organization.ID == id {
+			d := organization
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *organizationPersister) GetBySlug(slug string) (*models.Organization, error) {
+	for _, organization := range p.organizations {
+		if organization.Slug == slug {
+			d := organization
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *organizationPersister) List(page int, perPage int) ([]models.Organization, error) {
+	return append([]models.Organization{}, p.organizations...), nil
+}
+
+func (p *organizationPersister) Count() (int, error) {
+	return len(p.organizations), nil
+}
+
+func (p *organizationPersister) Create(organization models.Organization) error {
+	p.organizations = append(p.organizations, organization)
+	return nil
+}
+
+func (p *organizationPersister) Update(organization models.Organization) error {
+	for i, data := range p.organizations {
+		if data.ID == organization.ID {
+			p.organizations[i] = organization
+		}
+	}
+	return nil
+}
+
+func (p *organizationPersister) Delete(organization models.Organization) error {
+	index := -1
+	for i, data := range p.organizations {
+		if data.ID == organization.ID {
+			index = i
+		}
+	}
+	if index > -1 {
+		p.organizations = append(p.organizations[:index], p.organizations[index+1:]...)
+	}
+
+	return nil
+}
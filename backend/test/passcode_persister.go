@@ -4,6 +4,8 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+	"time"
 )
 
 func NewPasscodePersister(init []models.Passcode) persistence.PasscodePersister {
@@ -25,6 +27,19 @@ func (p *passcodePersister) Get(id uuid.UUID) (*models.Passcode, error) {
 	return found, nil
 }
 
+func (p *passcodePersister) FindByEmailId(emailId uuid.UUID) ([]models.Passcode, error) {
+	var found []models.Passcode
+	for _, data := range p.passcodes {
+		if data.EmailID == emailId {
+			found = append(found, data)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].CreatedAt.After(found[j].CreatedAt)
+	})
+	return found, nil
+}
+
 func (p *passcodePersister) Create(passcode models.Passcode) error {
 	p.passcodes = append(p.passcodes, passcode)
 	return nil
@@ -52,3 +67,15 @@ func (p *passcodePersister) Delete(passcode models.Passcode) error {
 
 	return nil
 }
+
+func (p *passcodePersister) DeleteCreatedBefore(before time.Time) (int, error) {
+	var kept []models.Passcode
+	for _, passcode := range p.passcodes {
+		if !passcode.CreatedAt.Before(before) {
+			kept = append(kept, passcode)
+		}
+	}
+	count := len(p.passcodes) - len(kept)
+	p.passcodes = kept
+	return count, nil
+}
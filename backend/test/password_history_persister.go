@@ -0,0 +1,49 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"sort"
+)
+
+func NewPasswordHistoryPersister(init []models.PasswordHistoryEntry) persistence.PasswordHistoryPersister {
+	return &passwordHistoryPersister{append([]models.PasswordHistoryEntry{}, init...)}
+}
+
+type passwordHistoryPersister struct {
+	entries []models.PasswordHistoryEntry
+}
+
+func (p *passwordHistoryPersister) Create(entry models.PasswordHistoryEntry) error {
+	p.entries = append(p.entries, entry)
+	return nil
+}
+
+func (p *passwordHistoryPersister) ListByUserId(userId uuid.UUID, limit int) ([]models.PasswordHistoryEntry, error) {
+	var found []models.PasswordHistoryEntry
+	for _, entry := range p.entries {
+		if entry.UserId == userId {
+			found = append(found, entry)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].CreatedAt.After(found[j].CreatedAt)
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+func (p *passwordHistoryPersister) DeleteOlderByUserId(userId uuid.UUID, keep int) error {
+	kept, _ := p.ListByUserId(userId, keep)
+	remaining := kept
+	for _, entry := range p.entries {
+		if entry.UserId != userId {
+			remaining = append(remaining, entry)
+		}
+	}
+	p.entries = remaining
+	return nil
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+func NewPermissionPersister(init []models.Permission) persistence.PermissionPersister {
+	return &permissionPersister{append([]models.Permission{}, init...)}
+}
+
+type permissionPersister struct {
+	permissions []models.Permission
+}
+
+func (p *permissionPersister) Get(id uuid.UUID) (*models.Permission, error) {
+	for _, permission := range p.permissions {
+		if permission.ID == id {
+			d := permission
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *permissionPersister) GetByName(name string) (*models.Permission, error) {
+	for _, permission := range p.permissions {
+		if permission.Name == name {
+			d := permission
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *permissionPersister) List() (models.Permissions, error) {
+	return append(models.Permissions{}, p.permissions...), nil
+}
+
+func (p *permissionPersister) Create(permission models.Permission) error {
+	p.permissions = append(p.permissions, permission)
+	return nil
+}
+
+func (p *permissionPersister) Update(permission models.Permission) error {
+	for i, data := range p.permissions {
+		if data.ID == permission.ID {
+			p.permissions[i] = permission
+		}
+	}
+	return nil
+}
+
+func (p *permissionPersister) Delete(permission models.Permission) error {
+	index := -1
+	for i, data := range p.permissions {
+		if data.ID == permission.ID {
+			index = i
+		}
+	}
+	if index > -1 {
+		p.permissions = append(p.permissions[:index], p.permissions[index+1:]...)
+	}
+
+	return nil
+}
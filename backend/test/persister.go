@@ -4,38 +4,61 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
-func NewPersister(user []models.User, passcodes []models.Passcode, jwks []models.Jwk, credentials []models.WebauthnCredential, sessionData []models.WebauthnSessionData, passwords []models.PasswordCredential, auditLogs []models.AuditLog, emails []models.Email, primaryEmails []models.PrimaryEmail, identities []models.Identity, tokens []models.Token, sessions []models.Session) persistence.Persister {
+func NewPersister(user []models.User, passcodes []models.Passcode, jwks []models.Jwk, credentials []models.WebauthnCredential, sessionData []models.WebauthnSessionData, passwords []models.PasswordCredential, auditLogs []models.AuditLog, emails []models.Email, primaryEmails []models.PrimaryEmail, identities []models.Identity, tokens []models.Token, sessions []models.Session, failedPasscodeAttempts []models.FailedPasscodeAttempt, passwordHistory []models.PasswordHistoryEntry, userLockouts []models.UserLockout, webauthnPrfSalts []models.WebauthnPrfSalt, roles []models.Role, userRoles []models.UserRole, permissions []models.Permission, organizations []models.Organization, organizationMemberships []models.OrganizationMembership, invitations []models.Invitation, emailChanges []models.EmailChange, maintenanceLocks map[string]time.Time) persistence.Persister {
 	return &persister{
-		userPersister:                NewUserPersister(user),
-		passcodePersister:            NewPasscodePersister(passcodes),
-		jwkPersister:                 NewJwkPersister(jwks),
-		webauthnCredentialPersister:  NewWebauthnCredentialPersister(credentials),
-		webauthnSessionDataPersister: NewWebauthnSessionDataPersister(sessionData),
-		passwordCredentialPersister:  NewPasswordCredentialPersister(passwords),
-		auditLogPersister:            NewAuditLogPersister(auditLogs),
-		emailPersister:               NewEmailPersister(emails),
-		primaryEmailPersister:        NewPrimaryEmailPersister(primaryEmails),
-		identityPersister:            NewIdentityPersister(identities),
-		tokenPersister:               NewTokenPersister(tokens),
-		sessionPersister:             NewSessionPersister(sessions),
+		userPersister:                   NewUserPersister(user),
+		passcodePersister:               NewPasscodePersister(passcodes),
+		jwkPersister:                    NewJwkPersister(jwks),
+		webauthnCredentialPersister:     NewWebauthnCredentialPersister(credentials),
+		webauthnSessionDataPersister:    NewWebauthnSessionDataPersister(sessionData),
+		passwordCredentialPersister:     NewPasswordCredentialPersister(passwords),
+		auditLogPersister:               NewAuditLogPersister(auditLogs),
+		emailPersister:                  NewEmailPersister(emails),
+		primaryEmailPersister:           NewPrimaryEmailPersister(primaryEmails),
+		identityPersister:               NewIdentityPersister(identities),
+		tokenPersister:                  NewTokenPersister(tokens),
+		sessionPersister:                NewSessionPersister(sessions),
+		failedPasscodeAttemptPersister:  NewFailedPasscodeAttemptPersister(failedPasscodeAttempts),
+		passwordHistoryPersister:        NewPasswordHistoryPersister(passwordHistory),
+		userLockoutPersister:            NewUserLockoutPersister(userLockouts),
+		webauthnPrfSaltPersister:        NewWebauthnPrfSaltPersister(webauthnPrfSalts),
+		rolePersister:                   NewRolePersister(roles, userRoles),
+		permissionPersister:             NewPermissionPersister(permissions),
+		organizationPersister:           NewOrganizationPersister(organizations),
+		organizationMembershipPersister: NewOrganizationMembershipPersister(organizationMemberships),
+		invitationPersister:             NewInvitationPersister(invitations),
+		emailChangePersister:            NewEmailChangePersister(emailChanges),
+		maintenanceLockPersister:        NewMaintenanceLockPersister(maintenanceLocks),
 	}
 }
 
 type persister struct {
-	userPersister                persistence.UserPersister
-	passcodePersister            persistence.PasscodePersister
-	jwkPersister                 persistence.JwkPersister
-	webauthnCredentialPersister  persistence.WebauthnCredentialPersister
-	webauthnSessionDataPersister persistence.WebauthnSessionDataPersister
-	passwordCredentialPersister  persistence.PasswordCredentialPersister
-	auditLogPersister            persistence.AuditLogPersister
-	emailPersister               persistence.EmailPersister
-	primaryEmailPersister        persistence.PrimaryEmailPersister
-	identityPersister            persistence.IdentityPersister
-	tokenPersister               persistence.TokenPersister
-	sessionPersister             persistence.SessionPersister
+	userPersister                   persistence.UserPersister
+	passcodePersister               persistence.PasscodePersister
+	jwkPersister                    persistence.JwkPersister
+	webauthnCredentialPersister     persistence.WebauthnCredentialPersister
+	webauthnSessionDataPersister    persistence.WebauthnSessionDataPersister
+	passwordCredentialPersister     persistence.PasswordCredentialPersister
+	auditLogPersister               persistence.AuditLogPersister
+	emailPersister                  persistence.EmailPersister
+	primaryEmailPersister           persistence.PrimaryEmailPersister
+	identityPersister               persistence.IdentityPersister
+	tokenPersister                  persistence.TokenPersister
+	sessionPersister                persistence.SessionPersister
+	failedPasscodeAttemptPersister  persistence.FailedPasscodeAttemptPersister
+	passwordHistoryPersister        persistence.PasswordHistoryPersister
+	userLockoutPersister            persistence.UserLockoutPersister
+	webauthnPrfSaltPersister        persistence.WebauthnPrfSaltPersister
+	rolePersister                   persistence.RolePersister
+	permissionPersister             persistence.PermissionPersister
+	organizationPersister           persistence.OrganizationPersister
+	organizationMembershipPersister persistence.OrganizationMembershipPersister
+	invitationPersister             persistence.InvitationPersister
+	emailChangePersister            persistence.EmailChangePersister
+	maintenanceLockPersister        persistence.MaintenanceLockPersister
 }
 
 func (p *persister) GetPasswordCredentialPersister() persistence.PasswordCredentialPersister {
@@ -70,6 +93,42 @@ func (p *persister) GetPasscodePersisterWithConnection(tx *pop.Connection) persi
 	return p.passcodePersister
 }
 
+func (p *persister) GetFailedPasscodeAttemptPersister() persistence.FailedPasscodeAttemptPersister {
+	return p.failedPasscodeAttemptPersister
+}
+
+func (p *persister) GetFailedPasscodeAttemptPersisterWithConnection(tx *pop.Connection) persistence.FailedPasscodeAttemptPersister {
+	return p.failedPasscodeAttemptPersister
+}
+
+func (p *persister) GetPasswordHistoryPersister() persistence.PasswordHistoryPersister {
+	return p.passwordHistoryPersister
+}
+
+func (p *persister) GetPasswordHistoryPersisterWithConnection(tx *pop.Connection) persistence.PasswordHistoryPersister {
+	return p.passwordHistoryPersister
+}
+
+func (p *persister) GetUserLockoutPersister() persistence.UserLockoutPersister {
+	return p.userLockoutPersister
+}
+
+func (p *persister) GetUserLockoutPersisterWithConnection(tx *pop.Connection) persistence.UserLockoutPersister {
+	return p.userLockoutPersister
+}
+
+func (p *persister) GetWebauthnPrfSaltPersister() persistence.WebauthnPrfSaltPersister {
+	return p.webauthnPrfSaltPersister
+}
+
+func (p *persister) GetWebauthnPrfSaltPersisterWithConnection(tx *pop.Connection) persistence.WebauthnPrfSaltPersister {
+	return p.webauthnPrfSaltPersister
+}
+
+func (p *persister) GetMaintenanceLockPersister() persistence.MaintenanceLockPersister {
+	return p.maintenanceLockPersister
+}
+
 func (p *persister) GetWebauthnCredentialPersister() persistence.WebauthnCredentialPersister {
 	return p.webauthnCredentialPersister
 }
@@ -143,6 +202,54 @@ func (p *persister) GetSessionPersisterWithConnection(tx *pop.Connection) persis
 	return p.sessionPersister
 }
 
+func (p *persister) GetRolePersister() persistence.RolePersister {
+	return p.rolePersister
+}
+
+func (p *persister) GetRolePersisterWithConnection(tx *pop.Connection) persistence.RolePersister {
+	return p.rolePersister
+}
+
+func (p *persister) GetPermissionPersister() persistence.PermissionPersister {
+	return p.permissionPersister
+}
+
+func (p *persister) GetPermissionPersisterWithConnection(tx *pop.Connection) persistence.PermissionPersister {
+	return p.permissionPersister
+}
+
+func (p *persister) GetOrganizationPersister() persistence.OrganizationPersister {
+	return p.organizationPersister
+}
+
+func (p *persister) GetOrganizationPersisterWithConnection(tx *pop.Connection) persistence.OrganizationPersister {
+	return p.organizationPersister
+}
+
+func (p *persister) GetOrganizationMembershipPersister() persistence.OrganizationMembershipPersister {
+	return p.organizationMembershipPersister
+}
+
+func (p *persister) GetOrganizationMembershipPersisterWithConnection(tx *pop.Connection) persistence.OrganizationMembershipPersister {
+	return p.organizationMembershipPersister
+}
+
+func (p *persister) GetInvitationPersister() persistence.InvitationPersister {
+	return p.invitationPersister
+}
+
+func (p *persister) GetInvitationPersisterWithConnection(tx *pop.Connection) persistence.InvitationPersister {
+	return p.invitationPersister
+}
+
+func (p *persister) GetEmailChangePersister() persistence.EmailChangePersister {
+	return p.emailChangePersister
+}
+
+func (p *persister) GetEmailChangePersisterWithConnection(tx *pop.Connection) persistence.EmailChangePersister {
+	return p.emailChangePersister
+}
+
 func (p *persister) Health() error {
 	return nil
 }
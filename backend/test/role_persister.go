@@ -0,0 +1,117 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+func NewRolePersister(roles []models.Role, userRoles []models.UserRole) persistence.RolePersister {
+	return &rolePersister{
+		roles:     append([]models.Role{}, roles...),
+		userRoles: append([]models.UserRole{}, userRoles...),
+	}
+}
+
+type rolePersister struct {
+	roles     []models.Role
+	userRoles []models.UserRole
+}
+
+func (p *rolePersister) Get(id uuid.UUID) (*models.Role, error) {
+	for _, role := range p.roles {
+		if role.ID == id {
+			d := role
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *rolePersister) GetByName(name string) (*models.Role, error) {
+	for _, role := range p.roles {
+		if role.Name == name {
+			d := role
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *rolePersister) List() (models.Roles, error) {
+	return append(models.Roles{}, p.roles...), nil
+}
+
+func (p *rolePersister) ListByUserId(userId uuid.UUID) (models.Roles, error) {
+	roles := models.Roles{}
+	for _, userRole := range p.userRoles {
+		if userRole.UserID != userId {
+			continue
+		}
+		for _, role := range p.roles {
+			if role.ID == userRole.RoleID {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles, nil
+}
+
+func (p *rolePersister) Create(role models.Role) error {
+	p.roles = append(p.roles, role)
+	return nil
+}
+
+func (p *rolePersister) Update(role models.Role) error {
+	for i, data := range p.roles {
+		if data.ID == role.ID {
+			role.Permissions = data.Permissions
+			p.roles[i] = role
+		}
+	}
+	return nil
+}
+
+func (p *rolePersister) Delete(role models.Role) error {
+	index := -1
+	for i, data := range p.roles {
+		if data.ID == role.ID {
+			index = i
+		}
+	}
+	if index > -1 {
+		p.roles = append(p.roles[:index], p.roles[index+1:]...)
+	}
+
+	return nil
+}
+
+func (p *rolePersister) SetPermissions(roleId uuid.UUID, permissions models.Permissions) error {
+	for i, data := range p.roles {
+		if data.ID == roleId {
+			p.roles[i].Permissions = append(models.Permissions{}, permissions...)
+		}
+	}
+	return nil
+}
+
+func (p *rolePersister) AssignToUser(userId uuid.UUID, roleId uuid.UUID) error {
+	for _, userRole := range p.userRoles {
+		if userRole.UserID == userId && userRole.RoleID == roleId {
+			return nil
+		}
+	}
+	p.userRoles = append(p.userRoles, models.NewUserRole(userId, roleId))
+	return nil
+}
+
+func (p *rolePersister) UnassignFromUser(userId uuid.UUID, roleId uuid.UUID) error {
+	userRoles := []models.UserRole{}
+	for _, userRole := range p.userRoles {
+		if userRole.UserID != userId || userRole.RoleID != roleId {
+			userRoles = append(userRoles, userRole)
+		}
+	}
+	p.userRoles = userRoles
+	return nil
+}
@@ -2,8 +2,10 @@ package test
 
 import (
 	"errors"
+	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 func NewSessionPersister(init []models.Session) persistence.SessionPersister {
@@ -19,8 +21,8 @@ type sessionPersister struct {
 	tokens map[string]models.Session
 }
 
-func (s sessionPersister) Create(session models.Session) error {
-	s.tokens[session.ID] = session
+func (s sessionPersister) Create(session *models.Session) error {
+	s.tokens[session.ID] = *session
 	return nil
 }
 
@@ -33,8 +35,45 @@ func (s sessionPersister) Get(id string) (*models.Session, error) {
 	return &tok, nil
 }
 
+func (s sessionPersister) Update(session *models.Session) error {
+	s.tokens[session.ID] = *session
+	return nil
+}
+
 func (s sessionPersister) Delete(id string) error {
 	delete(s.tokens, id)
 
 	return nil
 }
+
+func (s sessionPersister) DeleteByUserId(userId uuid.UUID) error {
+	for id, session := range s.tokens {
+		if session.UserID == userId {
+			delete(s.tokens, id)
+		}
+	}
+
+	return nil
+}
+
+func (s sessionPersister) ListByUserId(userId uuid.UUID) ([]models.Session, error) {
+	var result []models.Session
+	for _, session := range s.tokens {
+		if session.UserID == userId {
+			result = append(result, session)
+		}
+	}
+
+	return result, nil
+}
+
+func (s sessionPersister) DeleteUsed(before time.Time) (int, error) {
+	count := 0
+	for id, session := range s.tokens {
+		if session.Used && session.UpdatedAt.Before(before) {
+			delete(s.tokens, id)
+			count++
+		}
+	}
+	return count, nil
+}
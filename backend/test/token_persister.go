@@ -3,6 +3,7 @@ package test
 import (
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 func NewTokenPersister(init []models.Token) persistence.TokenPersister {
@@ -41,3 +42,15 @@ func (t tokenPersister) Delete(token models.Token) error {
 
 	return nil
 }
+
+func (t *tokenPersister) DeleteExpired(before time.Time) (int, error) {
+	var kept []models.Token
+	for _, token := range t.tokens {
+		if !token.ExpiresAt.Before(before) {
+			kept = append(kept, token)
+		}
+	}
+	count := len(t.tokens) - len(kept)
+	t.tokens = kept
+	return count, nil
+}
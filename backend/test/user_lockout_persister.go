@@ -0,0 +1,64 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
+)
+
+func NewUserLockoutPersister(init []models.UserLockout) persistence.UserLockoutPersister {
+	return &userLockoutPersister{append([]models.UserLockout{}, init...)}
+}
+
+type userLockoutPersister struct {
+	lockouts []models.UserLockout
+}
+
+func (p *userLockoutPersister) GetByUserId(userId uuid.UUID) (*models.UserLockout, error) {
+	for _, lockout := range p.lockouts {
+		if lockout.UserID == userId {
+			l := lockout
+			return &l, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *userLockoutPersister) GetByUnlockToken(token string) (*models.UserLockout, error) {
+	for _, lockout := range p.lockouts {
+		if lockout.UnlockToken != nil && *lockout.UnlockToken == token {
+			l := lockout
+			return &l, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *userLockoutPersister) Create(lockout models.UserLockout) error {
+	p.lockouts = append(p.lockouts, lockout)
+	return nil
+}
+
+func (p *userLockoutPersister) Update(lockout models.UserLockout) error {
+	for i, existing := range p.lockouts {
+		if existing.ID == lockout.ID {
+			p.lockouts[i] = lockout
+		}
+	}
+	return nil
+}
+
+func (p *userLockoutPersister) DeleteInactive(lockedUntilBefore time.Time, windowStartedBefore time.Time) (int, error) {
+	var kept []models.UserLockout
+	for _, lockout := range p.lockouts {
+		if (lockout.LockedUntil != nil && !lockout.LockedUntil.Before(lockedUntilBefore)) ||
+			(lockout.UnlockTokenExpiresAt != nil && !lockout.UnlockTokenExpiresAt.Before(lockedUntilBefore)) ||
+			(lockout.WindowStartedAt != nil && !lockout.WindowStartedAt.Before(windowStartedBefore)) {
+			kept = append(kept, lockout)
+		}
+	}
+	count := len(p.lockouts) - len(kept)
+	p.lockouts = kept
+	return count, nil
+}
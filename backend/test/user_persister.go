@@ -4,6 +4,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 func NewUserPersister(init []models.User) persistence.UserPersister {
@@ -25,6 +26,21 @@ func (p *userPersister) Get(id uuid.UUID) (*models.User, error) {
 	return found, nil
 }
 
+func (p *userPersister) GetForUpdate(id uuid.UUID) (*models.User, error) {
+	return p.Get(id)
+}
+
+func (p *userPersister) GetByUsername(username string) (*models.User, error) {
+	var found *models.User
+	for _, data := range p.users {
+		if data.Username != nil && *data.Username == username {
+			d := data
+			found = &d
+		}
+	}
+	return found, nil
+}
+
 func (p *userPersister) Create(user models.User) error {
 	p.users = append(p.users, user)
 	return nil
@@ -39,6 +55,18 @@ func (p *userPersister) Update(user models.User) error {
 	return nil
 }
 
+func (p *userPersister) UpdateMetadata(user models.User) error {
+	for i, data := range p.users {
+		if data.ID == user.ID {
+			p.users[i].PublicMetadata = user.PublicMetadata
+			p.users[i].PrivateMetadata = user.PrivateMetadata
+			p.users[i].UnsafeMetadata = user.UnsafeMetadata
+			p.users[i].UpdatedAt = user.UpdatedAt
+		}
+	}
+	return nil
+}
+
 func (p *userPersister) Delete(user models.User) error {
 	index := -1
 	for i, data := range p.users {
@@ -53,7 +81,7 @@ func (p *userPersister) Delete(user models.User) error {
 	return nil
 }
 
-func (p *userPersister) List(page int, perPage int, userId uuid.UUID, email string, sortDirection string) ([]models.User, error) {
+func (p *userPersister) List(page int, perPage int, userId uuid.UUID, email string, blocked *bool, role string, sortDirection string) ([]models.User, error) {
 	if len(p.users) == 0 {
 		return p.users, nil
 	}
@@ -81,6 +109,16 @@ func (p *userPersister) List(page int, perPage int, userId uuid.UUID, email stri
 	return result[page-1], nil
 }
 
-func (p *userPersister) Count(userId uuid.UUID, email string) (int, error) {
+func (p *userPersister) ListDeletedBefore(before time.Time, limit int) ([]models.User, error) {
+	var result []models.User
+	for _, user := range p.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(before) && len(result) < limit {
+			result = append(result, user)
+		}
+	}
+	return result, nil
+}
+
+func (p *userPersister) Count(userId uuid.UUID, email string, blocked *bool, role string) (int, error) {
 	return len(p.users), nil
 }
@@ -0,0 +1,30 @@
+package test
+
+import (
+	"github.com/gofrs/uuid"
+	"github.com/teamhanko/hanko/backend/persistence"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+)
+
+func NewWebauthnPrfSaltPersister(init []models.WebauthnPrfSalt) persistence.WebauthnPrfSaltPersister {
+	return &webauthnPrfSaltPersister{append([]models.WebauthnPrfSalt{}, init...)}
+}
+
+type webauthnPrfSaltPersister struct {
+	salts []models.WebauthnPrfSalt
+}
+
+func (p *webauthnPrfSaltPersister) GetByUserId(userId uuid.UUID) (*models.WebauthnPrfSalt, error) {
+	for _, salt := range p.salts {
+		if salt.UserID == userId {
+			s := salt
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *webauthnPrfSaltPersister) Create(salt models.WebauthnPrfSalt) error {
+	p.salts = append(p.salts, salt)
+	return nil
+}
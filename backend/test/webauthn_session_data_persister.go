@@ -4,6 +4,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"time"
 )
 
 func NewWebauthnSessionDataPersister(init []models.WebauthnSessionData) persistence.WebauthnSessionDataPersister {
@@ -63,3 +64,16 @@ func (p *webauthnSessionDataPersister) Delete(sessionData models.WebauthnSession
 
 	return nil
 }
+
+func (p *webauthnSessionDataPersister) DeleteExpired(before time.Time) (int, error) {
+	var kept []models.WebauthnSessionData
+	for _, data := range p.sessionData {
+		if (data.ExpiresAt.Valid && data.ExpiresAt.Time.Before(before)) || (!data.ExpiresAt.Valid && data.CreatedAt.Before(before)) {
+			continue
+		}
+		kept = append(kept, data)
+	}
+	count := len(p.sessionData) - len(kept)
+	p.sessionData = kept
+	return count, nil
+}
@@ -74,6 +74,14 @@ func ErrorMaxNumberOfAddresses(desc string) *ThirdPartyError {
 	return &ThirdPartyError{Code: ErrorCodeMaxNumberOfAddresses, Description: desc}
 }
 
+func ErrorUserBlocked(desc string) *ThirdPartyError {
+	return &ThirdPartyError{Code: ErrorCodeUserBlocked, Description: desc}
+}
+
+func ErrorEmailDomainNotAllowed(desc string) *ThirdPartyError {
+	return &ThirdPartyError{Code: ErrorCodeEmailDomainNotAllowed, Description: desc}
+}
+
 const (
 	ErrorCodeInvalidRequest          = "invalid_request"
 	ErrorCodeServerError             = "server_error"
@@ -81,4 +89,6 @@ const (
 	ErrorCodeMultipleAccounts        = "multiple_accounts"
 	ErrorCodeUnverifiedProviderEmail = "unverified_email"
 	ErrorCodeMaxNumberOfAddresses    = "email_maxnum"
+	ErrorCodeUserBlocked             = "user_blocked"
+	ErrorCodeEmailDomainNotAllowed   = "email_domain_not_allowed"
 )
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"github.com/gobuffalo/pop/v6"
 	"github.com/teamhanko/hanko/backend/config"
+	"github.com/teamhanko/hanko/backend/emaildomain"
 	"github.com/teamhanko/hanko/backend/persistence"
 	"github.com/teamhanko/hanko/backend/persistence/models"
+	"github.com/teamhanko/hanko/backend/rbac"
+	"time"
 )
 
 type AccountLinkingResult struct {
@@ -13,7 +16,7 @@ type AccountLinkingResult struct {
 	User *models.User
 }
 
-func LinkAccount(tx *pop.Connection, cfg *config.Config, p persistence.Persister, userData *UserData, providerName string) (*AccountLinkingResult, error) {
+func LinkAccount(tx *pop.Connection, cfg *config.Config, p persistence.Persister, emailDomainChecker *emaildomain.Checker, userData *UserData, providerName string) (*AccountLinkingResult, error) {
 	identity, err := p.GetIdentityPersister().Get(userData.Metadata.Subject, providerName)
 	if err != nil {
 		return nil, ErrorServer("could not get identity").WithCause(err)
@@ -24,7 +27,7 @@ func LinkAccount(tx *pop.Connection, cfg *config.Config, p persistence.Persister
 	}
 
 	if identity == nil {
-		return signUp(tx, p, userData, providerName)
+		return signUp(tx, cfg, p, emailDomainChecker, userData, providerName)
 	} else {
 		return signIn(tx, cfg, p, userData, identity)
 	}
@@ -102,6 +105,24 @@ func signIn(tx *pop.Connection, cfg *config.Config, p persistence.Persister, use
 		return nil, ErrorServer("could not get user").WithCause(terr)
 	}
 
+	if user.IsDeleted() {
+		return nil, ErrorUserConflict("the user linked to the third party account has been deleted")
+	}
+
+	if user.IsBlocked(time.Now().UTC()) {
+		return nil, ErrorUserBlocked("user is blocked")
+	}
+
+	if cfg.ThirdParty.ProfileRefresh == config.ThirdPartyProfileRefreshMissing || cfg.ThirdParty.ProfileRefresh == config.ThirdPartyProfileRefreshAlways {
+		overwrite := cfg.ThirdParty.ProfileRefresh == config.ThirdPartyProfileRefreshAlways
+		if applyProfile(user, userData.Metadata, overwrite) {
+			terr = userPersister.Update(*user)
+			if terr != nil {
+				return nil, ErrorServer("could not update user profile").WithCause(terr)
+			}
+		}
+	}
+
 	linkingResult = &AccountLinkingResult{
 		Type: models.AuditLogThirdPartySignInSucceeded,
 		User: user,
@@ -110,9 +131,13 @@ func signIn(tx *pop.Connection, cfg *config.Config, p persistence.Persister, use
 	return linkingResult, nil
 }
 
-func signUp(tx *pop.Connection, p persistence.Persister, userData *UserData, providerName string) (*AccountLinkingResult, error) {
+func signUp(tx *pop.Connection, cfg *config.Config, p persistence.Persister, emailDomainChecker *emaildomain.Checker, userData *UserData, providerName string) (*AccountLinkingResult, error) {
 	var linkingResult *AccountLinkingResult
 
+	if !emailDomainChecker.Allowed(userData.Metadata.Email) {
+		return nil, ErrorEmailDomainNotAllowed("third party provider email domain is not allowed")
+	}
+
 	userPersister := p.GetUserPersisterWithConnection(tx)
 	emailPersister := p.GetEmailPersisterWithConnection(tx)
 	primaryEmailPersister := p.GetPrimaryEmailPersisterWithConnection(tx)
@@ -128,11 +153,17 @@ func signUp(tx *pop.Connection, p persistence.Persister, userData *UserData, pro
 	}
 
 	user := models.NewUser()
+	applyProfile(&user, userData.Metadata, true)
 	terr = userPersister.Create(user)
 	if terr != nil {
 		return nil, ErrorServer("could not create user").WithCause(terr)
 	}
 
+	terr = rbac.GrantDefaultRole(cfg.Rbac, p.GetRolePersisterWithConnection(tx), user.ID)
+	if terr != nil {
+		return nil, ErrorServer("could not grant default role").WithCause(terr)
+	}
+
 	if email != nil && email.UserID == nil {
 		// There exists an email with the same address as the primary provider address, but it is not assigned
 		// to any user yet, hence we assign the new user ID to this email.
@@ -0,0 +1,93 @@
+package thirdparty
+
+import (
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"golang.org/x/text/language"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxNameLength      = 100
+	maxAvatarURLLength = 2048
+	maxLocaleLength    = 35
+)
+
+// applyProfile sets the profile fields of the user to the values of the provider claims. If overwrite is false, only
+// fields without a value are set. Fields for which the provider has no value are never changed. It reports whether
+// the user has been changed. Provider values are subject to the same limits as values set by the user: names are
+// truncated, avatar URLs and locales which are not valid are ignored.
+func applyProfile(user *models.User, claims *Claims, overwrite bool) bool {
+	if claims == nil {
+		return false
+	}
+
+	displayName := claims.Name
+	if displayName == "" {
+		displayName = strings.TrimSpace(claims.GivenName + " " + claims.FamilyName)
+	}
+
+	givenName := truncateName(claims.GivenName)
+	familyName := truncateName(claims.FamilyName)
+	displayName = truncateName(displayName)
+
+	avatarURL := claims.Picture
+	if !isValidAvatarURL(avatarURL) {
+		avatarURL = ""
+	}
+
+	locale := claims.Locale
+	if !isValidLocale(locale) {
+		locale = ""
+	}
+
+	changed := false
+	for _, field := range []struct {
+		target **string
+		value  string
+	}{
+		{&user.DisplayName, displayName},
+		{&user.GivenName, givenName},
+		{&user.FamilyName, familyName},
+		{&user.AvatarURL, avatarURL},
+		{&user.Locale, locale},
+	} {
+		if field.value == "" || (*field.target != nil && (!overwrite || **field.target == field.value)) {
+			continue
+		}
+		value := field.value
+		*field.target = &value
+		changed = true
+	}
+
+	return changed
+}
+
+// truncateName shortens the name to the maximum length of a profile name without splitting a character.
+func truncateName(name string) string {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) <= maxNameLength {
+		return name
+	}
+	return strings.TrimSpace(string([]rune(name)[:maxNameLength]))
+}
+
+func isValidAvatarURL(value string) bool {
+	if value == "" || len(value) > maxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isValidLocale(value string) bool {
+	if value == "" || len(value) > maxLocaleLength {
+		return false
+	}
+	_, err := language.Parse(value)
+	return err == nil
+}
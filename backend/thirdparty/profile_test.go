@@ -0,0 +1,97 @@
+package thirdparty
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/persistence/models"
+	"strings"
+	"testing"
+)
+
+func TestApplyProfile(t *testing.T) {
+	existingName := "Jane"
+	existingLocale := "en"
+
+	tests := []struct {
+		name            string
+		claims          *Claims
+		overwrite       bool
+		expectedChanged bool
+		expectedName    string
+		expectedLocale  string
+		expectedAvatar  string
+	}{
+		{
+			name:            "sets missing fields only",
+			claims:          &Claims{Name: "John Doe", Locale: "de", Picture: "https://example.com/john.png"},
+			expectedChanged: true,
+			expectedName:    "Jane",
+			expectedLocale:  "en",
+			expectedAvatar:  "https://example.com/john.png",
+		},
+		{
+			name:            "overwrites existing fields",
+			claims:          &Claims{GivenName: "John", FamilyName: "Doe", Locale: "de"},
+			overwrite:       true,
+			expectedChanged: true,
+			expectedName:    "John Doe",
+			expectedLocale:  "de",
+		},
+		{
+			name:            "keeps fields without provider value",
+			claims:          &Claims{},
+			overwrite:       true,
+			expectedChanged: false,
+			expectedName:    "Jane",
+			expectedLocale:  "en",
+		},
+		{
+			name:            "reports no change for equal values",
+			claims:          &Claims{Name: "Jane", Locale: "en"},
+			overwrite:       true,
+			expectedChanged: false,
+			expectedName:    "Jane",
+			expectedLocale:  "en",
+		},
+		{
+			name:            "ignores invalid avatar url and locale",
+			claims:          &Claims{Picture: "javascript:alert(1)", Locale: "not a locale"},
+			overwrite:       true,
+			expectedChanged: false,
+			expectedName:    "Jane",
+			expectedLocale:  "en",
+		},
+		{
+			name:            "ignores too long avatar url",
+			claims:          &Claims{Picture: "https://example.com/" + strings.Repeat("a", 2048)},
+			expectedChanged: false,
+			expectedName:    "Jane",
+			expectedLocale:  "en",
+		},
+		{
+			name:            "truncates too long names",
+			claims:          &Claims{Name: strings.Repeat("ä", 120)},
+			overwrite:       true,
+			expectedChanged: true,
+			expectedName:    strings.Repeat("ä", 100),
+			expectedLocale:  "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, locale := existingName, existingLocale
+			user := models.User{DisplayName: &name, Locale: &locale}
+
+			changed := applyProfile(&user, tt.claims, tt.overwrite)
+
+			assert.Equal(t, tt.expectedChanged, changed)
+			assert.Equal(t, tt.expectedName, *user.DisplayName)
+			assert.Equal(t, tt.expectedLocale, *user.Locale)
+			if tt.expectedAvatar == "" {
+				assert.Nil(t, user.AvatarURL)
+			} else {
+				assert.Equal(t, tt.expectedAvatar, *user.AvatarURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package username
+
+import (
+	"fmt"
+	"github.com/teamhanko/hanko/backend/config"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is a machine-readable code describing a rule the username does not fulfil.
+type Violation string
+
+const (
+	ViolationTooShort          Violation = "username_too_short"
+	ViolationTooLong           Violation = "username_too_long"
+	ViolationInvalidCharacters Violation = "username_invalid_characters"
+	ViolationInvalidStart      Violation = "username_invalid_start"
+)
+
+// Normalize returns the form in which usernames are stored and looked up, so that usernames which only differ in
+// case or surrounding whitespace are considered the same.
+func Normalize(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+type Policy struct {
+	cfg config.Username
+}
+
+func NewPolicy(cfg config.Username) *Policy {
+	return &Policy{cfg: cfg}
+}
+
+// Check returns all violations of the username. The username must be normalized.
+func (p *Policy) Check(username string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(username)
+	if length < p.cfg.MinLength {
+		violations = append(violations, ViolationTooShort)
+	}
+	if length > p.cfg.MaxLength {
+		violations = append(violations, ViolationTooLong)
+	}
+
+	for _, r := range username {
+		if !isAlphanumeric(r) && !strings.ContainsRune(p.cfg.AllowedCharacters, r) {
+			violations = append(violations, ViolationInvalidCharacters)
+			break
+		}
+	}
+
+	if first, _ := utf8.DecodeRuneInString(username); length > 0 && !isAlphanumeric(first) {
+		violations = append(violations, ViolationInvalidStart)
+	}
+
+	return violations
+}
+
+// Describe returns a human-readable description of the violation.
+func (p *Policy) Describe(violation Violation) string {
+	switch violation {
+	case ViolationTooShort:
+		return fmt.Sprintf("username must be at least %d characters long", p.cfg.MinLength)
+	case ViolationTooLong:
+		return fmt.Sprintf("username must not be longer than %d characters", p.cfg.MaxLength)
+	case ViolationInvalidCharacters:
+		if p.cfg.AllowedCharacters == "" {
+			return "username must only contain letters a-z and digits"
+		}
+		return fmt.Sprintf("username must only contain letters a-z, digits and the characters %s", p.cfg.AllowedCharacters)
+	case ViolationInvalidStart:
+		return "username must start with a letter or a digit"
+	default:
+		return string(violation)
+	}
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,69 @@
+package username
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/teamhanko/hanko/backend/config"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "jane.doe", Normalize("  Jane.Doe "))
+}
+
+func TestPolicy_Check(t *testing.T) {
+	cfg := config.DefaultConfig().Username
+
+	tests := []struct {
+		name     string
+		allowed  *string
+		username string
+		expected []Violation
+	}{
+		{
+			name:     "valid username",
+			username: "jane.doe_42",
+			expected: nil,
+		},
+		{
+			name:     "too short",
+			username: "jd",
+			expected: []Violation{ViolationTooShort},
+		},
+		{
+			name:     "too long",
+			username: "jane.doe.with.a.very.long.username",
+			expected: []Violation{ViolationTooLong},
+		},
+		{
+			name:     "email address",
+			username: "jane@example.com",
+			expected: []Violation{ViolationInvalidCharacters},
+		},
+		{
+			name:     "non ascii letters",
+			username: "jäne",
+			expected: []Violation{ViolationInvalidCharacters},
+		},
+		{
+			name:     "starts with allowed character",
+			username: "_jane",
+			expected: []Violation{ViolationInvalidStart},
+		},
+		{
+			name:     "character not allowed by config",
+			allowed:  func() *string { s := ""; return &s }(),
+			username: "jane.doe",
+			expected: []Violation{ViolationInvalidCharacters},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			if tt.allowed != nil {
+				c.AllowedCharacters = *tt.allowed
+			}
+			assert.Equal(t, tt.expected, NewPolicy(c).Check(tt.username))
+		})
+	}
+}